@@ -1,25 +1,63 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
 	"github.com/yeremiaaryo/gotu-assignment/internal/handler/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/handler/carts"
 	"github.com/yeremiaaryo/gotu-assignment/internal/handler/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/handler/payments"
+	"github.com/yeremiaaryo/gotu-assignment/internal/handler/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/internal/handler/returns"
 	"github.com/yeremiaaryo/gotu-assignment/internal/handler/users"
+	"github.com/yeremiaaryo/gotu-assignment/internal/handler/webhooks"
 	auth "github.com/yeremiaaryo/gotu-assignment/internal/middleware"
+	usersModel "github.com/yeremiaaryo/gotu-assignment/internal/model/users"
 	booksRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/books"
+	cartsRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/carts"
+	idempotencyRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/idempotency"
 	ordersRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/orders"
+	outboxRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/outbox"
+	paymentsRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/payments"
+	promotionsRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/promotions"
+	returnsRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/returns"
+	sessionsRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/sessions"
 	usersRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/users"
+	webhooksRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/webhooks"
 	booksUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/books"
+	cartsUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/carts"
+	notificationsUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/notifications"
 	ordersUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/orders"
+	outboxUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/outbox"
+	paymentsUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/payments"
+	promotionsUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/promotions"
+	returnsUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/returns"
 	usersUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/users"
+	webhooksUsecase "github.com/yeremiaaryo/gotu-assignment/internal/usecase/webhooks"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/eventsink"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/mailer"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/payment"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/payment/fake"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/redis"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/tax"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/webhook"
 	"log"
+	"os"
+	"time"
 )
 
+// defaultWebhookSinkTimeout bounds a delivery to a webhook sink without a configured timeout
+const defaultWebhookSinkTimeout = 5 * time.Second
+
+// defaultMailDir is where the file transport writes the emails without a configured dir
+const defaultMailDir = "./tmp/mail"
+
 type CustomValidator struct {
 	validator *validator.Validate
 }
@@ -46,23 +84,70 @@ func InitApps(cfg *configs.Config) error {
 		return err
 	}
 
+	paymentProvider, err := initPaymentProvider(&cfg.Payment)
+	if err != nil {
+		log.Fatalf("init payment provider failed: %v", err)
+	}
+
+	taxCalculator, err := initTaxCalculator(&cfg.Tax)
+	if err != nil {
+		log.Fatalf("init tax calculator failed: %v", err)
+	}
+
+	mailTransport, err := initMailTransport(&cfg.Mail)
+	if err != nil {
+		log.Fatalf("init mail transport failed: %v", err)
+	}
+
+	sinks, err := initOutboxSinks(&cfg.Outbox, redisAgent)
+	if err != nil {
+		log.Fatalf("init outbox sinks failed: %v", err)
+	}
+
 	// Init all repo here
 	usersRepo := usersRepository.New(masterDB, slaveDB)
 	booksRepo := booksRepository.New(masterDB, slaveDB, redisAgent)
 	ordersRepo := ordersRepository.New(masterDB, slaveDB)
+	idempotencyRepo := idempotencyRepository.New(redisAgent)
+	cartsRepo := cartsRepository.New(redisAgent)
+	sessionsRepo := sessionsRepository.New(redisAgent)
+	paymentsRepo := paymentsRepository.New(masterDB)
+	returnsRepo := returnsRepository.New(masterDB, slaveDB)
+	promotionsRepo := promotionsRepository.New(masterDB, slaveDB)
+	outboxRepo := outboxRepository.New(masterDB)
+	webhooksRepo := webhooksRepository.New(masterDB, slaveDB)
 
 	// Init all usecase here
-	usersUsecase := usersUsecase.New(usersRepo, redisAgent, cfg)
+	notificationsUsecase := notificationsUsecase.New(mailTransport, usersRepo, ordersRepo, cfg)
+	usersUsecase := usersUsecase.New(usersRepo, sessionsRepo, redisAgent, notificationsUsecase, cfg)
 	booksUsecase := booksUsecase.New(booksRepo, cfg)
-	ordersUsecase := ordersUsecase.New(ordersRepo, booksRepo, cfg)
+	paymentsUsecase := paymentsUsecase.New(paymentsRepo, ordersRepo, paymentProvider)
+	promotionsUsecase := promotionsUsecase.New(promotionsRepo)
+	ordersUsecase := ordersUsecase.New(ordersRepo, booksRepo, idempotencyRepo, usersRepo, paymentsUsecase, promotionsUsecase,
+		taxCalculator, cfg)
+	cartsUsecase := cartsUsecase.New(cartsRepo, booksRepo, idempotencyRepo, ordersUsecase)
+	returnsUsecase := returnsUsecase.New(returnsRepo, ordersRepo, paymentsUsecase, booksRepo)
+	webhooksUsecase := webhooksUsecase.New(webhooksRepo, webhook.New(cfg.Webhooks.Timeout), cfg)
+	outboxUsecase := outboxUsecase.New(outboxRepo, append(sinks, webhooksUsecase.Sink(), notificationsUsecase.Sink()), cfg)
+
+	// the outbox hands the events over to the webhook subscriptions, which are sent on their own
+	go outboxUsecase.Run(context.Background())
+	go webhooksUsecase.Run(context.Background())
+	go notificationsUsecase.Run(context.Background())
 
 	// Init all handler here
 	usersHandler := users.New(usersUsecase)
 	booksHandler := books.New(booksUsecase)
 	ordersHandler := orders.New(ordersUsecase)
+	cartsHandler := carts.New(cartsUsecase)
+	paymentsHandler := payments.New(paymentsUsecase)
+	returnsHandler := returns.New(returnsUsecase)
+	promotionsHandler := promotions.New(promotionsUsecase)
+	webhooksHandler := webhooks.New(webhooksUsecase)
 
 	// init auth
 	authHandler := auth.New(redisAgent)
+	adminOnly := authHandler.RequireRole(usersModel.RoleAdmin)
 
 	// Echo instance
 	e := echo.New()
@@ -76,19 +161,158 @@ func InitApps(cfg *configs.Config) error {
 	// User handler
 	e.POST("/register", usersHandler.CreateUser)
 	e.POST("/login", usersHandler.Login)
+	e.GET("/email/verify", usersHandler.VerifyEmail)
+	e.POST("/email/verification", usersHandler.SendEmailVerification, authHandler.AuthMiddleware)
+	e.POST("/password/forgot", usersHandler.ForgotPassword)
+	e.POST("/password/reset", usersHandler.ResetPassword)
+	e.PUT("/me/password", usersHandler.ChangePassword, authHandler.AuthMiddleware)
+	e.POST("/token/refresh", usersHandler.RefreshToken)
+	e.POST("/logout", usersHandler.Logout, authHandler.AuthMiddleware)
+	e.GET("/me/sessions", usersHandler.GetSessions, authHandler.AuthMiddleware)
+	e.DELETE("/me/sessions", usersHandler.RevokeOtherSessions, authHandler.AuthMiddleware)
+	e.DELETE("/me/sessions/:id", usersHandler.RevokeSession, authHandler.AuthMiddleware)
 
 	// Book handler
 	e.GET("/books", booksHandler.GetBooks)
+	e.GET("/books/:id", booksHandler.GetBook)
+	e.POST("/books", booksHandler.CreateBook, authHandler.AuthMiddleware, adminOnly)
+	e.PUT("/books/:id", booksHandler.UpdateBook, authHandler.AuthMiddleware, adminOnly)
+	e.PATCH("/books/:id", booksHandler.PatchBook, authHandler.AuthMiddleware, adminOnly)
+	e.DELETE("/books/:id", booksHandler.DeleteBook, authHandler.AuthMiddleware, adminOnly)
 
 	// Order handler
 	e.POST("/order", ordersHandler.CreateOrder, authHandler.AuthMiddleware)
+	e.POST("/order/quote", ordersHandler.QuoteOrder, authHandler.AuthMiddleware)
 	e.GET("/order", ordersHandler.GetOrderHistory, authHandler.AuthMiddleware)
+	e.GET("/order/:id", ordersHandler.GetOrderDetail, authHandler.AuthMiddleware)
+	e.POST("/order/:id/cancel", ordersHandler.CancelOrder, authHandler.AuthMiddleware)
+	e.PATCH("/order/:id/status", ordersHandler.UpdateOrderStatus, authHandler.AuthMiddleware, adminOnly)
+	e.POST("/order/:id/pay", paymentsHandler.Pay, authHandler.AuthMiddleware)
+	e.POST("/order/:id/returns", returnsHandler.CreateReturn, authHandler.AuthMiddleware)
+	e.GET("/order/:id/returns", returnsHandler.GetReturns, authHandler.AuthMiddleware)
+	e.PATCH("/returns/:id/status", returnsHandler.UpdateReturnStatus, authHandler.AuthMiddleware, adminOnly)
+
+	// Payment handler, the webhook is authenticated by the signature of the provider
+	e.POST("/payments/webhook", paymentsHandler.Webhook)
+
+	// Cart handler
+	e.GET("/cart", cartsHandler.GetCart, authHandler.AuthMiddleware)
+	e.POST("/cart", cartsHandler.AddItem, authHandler.AuthMiddleware)
+	e.PATCH("/cart", cartsHandler.UpdateItem, authHandler.AuthMiddleware)
+	e.DELETE("/cart", cartsHandler.ClearCart, authHandler.AuthMiddleware)
+	e.POST("/cart/checkout", cartsHandler.Checkout, authHandler.AuthMiddleware)
+
+	// Promotion handler
+	e.GET("/promotions", promotionsHandler.GetPromotions, authHandler.AuthMiddleware, adminOnly)
+	e.GET("/promotions/:id", promotionsHandler.GetPromotion, authHandler.AuthMiddleware, adminOnly)
+	e.POST("/promotions", promotionsHandler.CreatePromotion, authHandler.AuthMiddleware, adminOnly)
+	e.PUT("/promotions/:id", promotionsHandler.UpdatePromotion, authHandler.AuthMiddleware, adminOnly)
+	e.DELETE("/promotions/:id", promotionsHandler.DeletePromotion, authHandler.AuthMiddleware, adminOnly)
+
+	// Webhook handler
+	e.GET("/webhooks", webhooksHandler.GetSubscriptions, authHandler.AuthMiddleware, adminOnly)
+	e.GET("/webhooks/:id", webhooksHandler.GetSubscription, authHandler.AuthMiddleware, adminOnly)
+	e.POST("/webhooks", webhooksHandler.CreateSubscription, authHandler.AuthMiddleware, adminOnly)
+	e.PUT("/webhooks/:id", webhooksHandler.UpdateSubscription, authHandler.AuthMiddleware, adminOnly)
+	e.DELETE("/webhooks/:id", webhooksHandler.DeleteSubscription, authHandler.AuthMiddleware, adminOnly)
+	e.GET("/webhooks/:id/deliveries", webhooksHandler.GetDeliveries, authHandler.AuthMiddleware, adminOnly)
 
 	// Start server
 	e.Logger.Fatal(e.Start(cfg.Service.Port))
 	return nil
 }
 
+// initPaymentProvider picks the payment gateway, the fake one runs in-process and is the default for local runs
+func initPaymentProvider(config *configs.PaymentConfig) (payment.Provider, error) {
+	switch config.Provider {
+	case "", "fake":
+		return fake.New(config.WebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", config.Provider)
+	}
+}
+
+// initMailTransport picks how the emails are sent, the file transport keeps them on disk for local development
+func initMailTransport(config *configs.MailConfig) (mailer.Transport, error) {
+	switch config.Transport {
+	case "", "file":
+		dir := config.Dir
+		if dir == "" {
+			dir = defaultMailDir
+		}
+		return mailer.NewFile(dir), nil
+	case "stdout":
+		return mailer.NewWriter(os.Stdout), nil
+	case "smtp":
+		if config.SMTP.Address == "" {
+			return nil, fmt.Errorf("smtp mail transport has no address")
+		}
+		return mailer.NewSMTP(config.SMTP.Address, config.SMTP.Username, config.SMTP.Password, config.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport: %s", config.Transport)
+	}
+}
+
+// initTaxCalculator reads the tax rates from the config, a config without rates charges no tax
+func initTaxCalculator(config *configs.TaxConfig) (*tax.Calculator, error) {
+	rates := make([]tax.Rate, 0, len(config.Rates))
+	for _, rateConfig := range config.Rates {
+		rate, err := percent.Parse(rateConfig.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tax rate of %s: %v", rateConfig.Country, err)
+		}
+		taxRate := tax.Rate{Country: rateConfig.Country, Rate: rate, Inclusive: rateConfig.Inclusive}
+		for _, reducedConfig := range rateConfig.ReducedRates {
+			reduced, err := percent.Parse(reducedConfig.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid reduced tax rate of %s: %v", rateConfig.Country, err)
+			}
+			taxRate.Reduced = append(taxRate.Reduced, tax.ReducedRate{Rate: reduced, BookIDs: reducedConfig.BookIDs})
+		}
+		rates = append(rates, taxRate)
+	}
+	return tax.New(rates, config.DefaultCountry)
+}
+
+// initOutboxSinks builds the sinks the outbox events are delivered to, a sink is named after its type by default
+func initOutboxSinks(config *configs.OutboxConfig, redisAgent *redis.Redis) ([]eventsink.Sink, error) {
+	sinks := make([]eventsink.Sink, 0, len(config.Sinks))
+	// the webhook subscriptions and the order emails are always sinks of the outbox
+	names := map[string]bool{webhooksUsecase.SinkName: true, notificationsUsecase.SinkName: true}
+	for _, sinkConfig := range config.Sinks {
+		name := sinkConfig.Name
+		if name == "" {
+			name = sinkConfig.Type
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate outbox sink name: %s", name)
+		}
+		names[name] = true
+
+		switch sinkConfig.Type {
+		case "log":
+			sinks = append(sinks, eventsink.NewLog(name))
+		case "webhook":
+			if sinkConfig.URL == "" {
+				return nil, fmt.Errorf("outbox sink %s has no url", name)
+			}
+			timeout := sinkConfig.Timeout
+			if timeout <= 0 {
+				timeout = defaultWebhookSinkTimeout
+			}
+			sinks = append(sinks, eventsink.NewWebhook(name, sinkConfig.URL, sinkConfig.Secret, timeout))
+		case "redis":
+			if sinkConfig.Stream == "" {
+				return nil, fmt.Errorf("outbox sink %s has no stream", name)
+			}
+			sinks = append(sinks, eventsink.NewRedisStream(name, redisAgent, sinkConfig.Stream, sinkConfig.MaxLen))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink type: %s", sinkConfig.Type)
+		}
+	}
+	return sinks, nil
+}
+
 func initRedis(config *configs.RedisConfig) (*redis.Redis, error) {
 	// init redis MS configs.
 	rdsConfig := redis.RedisConfig{
@@ -1,15 +1,25 @@
 package configs
 
+import "time"
+
 type (
 	Config struct {
 		Service  Service
 		Database DatabaseConfig
 		Redis    RedisConfig
+		Payment  PaymentConfig
+		Tax      TaxConfig
+		Outbox   OutboxConfig
+		Webhooks WebhooksConfig
+		Mail     MailConfig
+		Auth     AuthConfig
 	}
 
+	// Service.BaseURL is the public address of the service, the links in the emails point to it
 	Service struct {
 		Port      string
 		SecretKey string
+		BaseURL   string
 	}
 
 	DatabaseConfig struct {
@@ -21,6 +31,54 @@ type (
 		Address string
 	}
 
+	PaymentConfig struct {
+		Provider      string
+		WebhookSecret string
+	}
+
+	// TaxConfig holds the tax rate of every country sold into, rates are percentages such as "11" or "7.5"
+	TaxConfig struct {
+		DefaultCountry string
+		Rates          []TaxRateConfig
+	}
+
+	TaxRateConfig struct {
+		Country      string
+		Rate         string
+		Inclusive    bool
+		ReducedRates []TaxReducedRateConfig
+	}
+
+	TaxReducedRateConfig struct {
+		Rate    string
+		BookIDs []int64
+	}
+
+	// OutboxConfig tunes the dispatcher of the outbox events, an event failing on MaxAttempts attempts is dead-lettered.
+	// The wait before a retry starts at RetryBackoff and doubles on every failed attempt up to MaxRetryBackoff.
+	OutboxConfig struct {
+		PollInterval    time.Duration
+		BatchSize       int
+		MaxAttempts     int
+		RetryBackoff    time.Duration
+		MaxRetryBackoff time.Duration
+		// Lease keeps a claimed event from other dispatchers while it is delivered
+		Lease time.Duration
+		Sinks []OutboxSinkConfig
+	}
+
+	// OutboxSinkConfig is a destination of the events, Type is log, webhook or redis. Name identifies the sink
+	// and defaults to the type, it has to stay the same once events were delivered to the sink.
+	OutboxSinkConfig struct {
+		Type    string
+		Name    string
+		URL     string
+		Secret  string
+		Timeout time.Duration
+		Stream  string
+		MaxLen  int64
+	}
+
 	RedisConfig struct {
 		Address             string
 		Password            string
@@ -30,4 +88,47 @@ type (
 		Wait                bool
 		DB                  int
 	}
+
+	// WebhooksConfig tunes the sending of the deliveries to the webhook subscriptions, a delivery failing on MaxAttempts
+	// attempts is marked failed. Timeout bounds a single request to a subscriber.
+	WebhooksConfig struct {
+		PollInterval    time.Duration
+		BatchSize       int
+		MaxAttempts     int
+		RetryBackoff    time.Duration
+		MaxRetryBackoff time.Duration
+		Lease           time.Duration
+		Timeout         time.Duration
+	}
+
+	// MailConfig picks the transport of the emails: smtp, file (an .eml file per email written to Dir) or stdout.
+	// Emails are queued in memory and sent by Workers, a failed send is retried up to MaxAttempts times. The order emails
+	// are sent by the outbox instead, which retries them itself. Timeout bounds a single send.
+	MailConfig struct {
+		Transport    string
+		From         string
+		Dir          string
+		SMTP         SMTPConfig
+		Timeout      time.Duration
+		QueueSize    int
+		Workers      int
+		MaxAttempts  int
+		RetryBackoff time.Duration
+	}
+
+	SMTPConfig struct {
+		Address  string
+		Username string
+		Password string
+	}
+
+	// AuthConfig holds how long the tokens sent by email and the login tokens are valid. A session expires when its
+	// refresh token isn't used for RefreshTokenTTL, a user has at most MaxSessions sessions at a time
+	AuthConfig struct {
+		EmailVerificationTTL time.Duration
+		PasswordResetTTL     time.Duration
+		AccessTokenTTL       time.Duration
+		RefreshTokenTTL      time.Duration
+		MaxSessions          int
+	}
 )
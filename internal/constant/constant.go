@@ -1,6 +1,19 @@
 package constant
 
 const (
-	RedisKeyToken = "token:%d"
-	RedisKeyBooks = "books:%s:%d:%d"
+	RedisKeyBooks        = "books:%s:%d:%d"
+	RedisKeyBooksAfter   = "books:%s:after:%s:%d:%d"
+	RedisKeyBook         = "book:%d"
+	RedisKeyBooksPattern = "books:*"
+
+	RedisKeyOrderIdempotency    = "idempotency:order:%d:%s"
+	RedisKeyCheckoutIdempotency = "idempotency:checkout:%d:%s"
+
+	RedisKeyCart = "cart:%d"
+
+	RedisKeyEmailVerification = "email_verification:%s"
+	RedisKeyPasswordReset     = "password_reset:%s"
+	RedisKeyPasswordResetUser = "password_reset:user:%d"
+
+	RedisKeySessions = "sessions:%d"
 )
@@ -4,13 +4,19 @@ import (
 	"context"
 	"github.com/labstack/echo/v4"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
 	"net/http"
 	"strconv"
 )
 
 //go:generate mockgen -package=books -source=books_handler.go -destination=books_handler_mock_test.go
 type booksUsecase interface {
-	GetBooks(ctx context.Context, search string, pageSize, pageIndex int) ([]books.Model, error)
+	GetBooks(ctx context.Context, filter books.Filter, cursor string, pageSize, pageIndex int) ([]books.Model, response.Pagination, error)
+	GetBookByID(ctx context.Context, id int64) (*books.Model, error)
+	CreateBook(ctx context.Context, req books.CreateBookRequest) (*books.Model, error)
+	UpdateBook(ctx context.Context, req books.UpdateBookRequest) (*books.Model, error)
+	PatchBook(ctx context.Context, req books.PatchBookRequest) (*books.Model, error)
+	DeleteBook(ctx context.Context, id int64) error
 }
 type Handler struct {
 	booksUsecase booksUsecase
@@ -22,7 +28,11 @@ func New(booksUsecase booksUsecase) *Handler {
 
 func (h *Handler) GetBooks(c echo.Context) error {
 	response := books.GetBookListResponse{}
-	search := c.QueryParam("search")
+	filter, err := parseBookFilter(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
 
 	pageIndex, err := strconv.Atoi(c.QueryParam("page_index"))
 	if err != nil {
@@ -33,13 +43,143 @@ func (h *Handler) GetBooks(c echo.Context) error {
 		pageSize = 10 // default page size is 10 if error
 	}
 
-	bookList, err := h.booksUsecase.GetBooks(c.Request().Context(), search, pageSize, pageIndex)
+	bookList, pagination, err := h.booksUsecase.GetBooks(c.Request().Context(), filter, c.QueryParam("cursor"), pageSize, pageIndex)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
+		statusCode := BookCustomErrorHTTPCode(err)
 		response.Error = err.Error()
 		return c.JSON(statusCode, response)
 	}
 	response.Result = true
 	response.Books = bookList
+	response.Pagination = pagination
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetBook(c echo.Context) error {
+	response := books.BookResponse{}
+	bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid book id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	book, err := h.booksUsecase.GetBookByID(c.Request().Context(), bookID)
+	if err != nil {
+		statusCode := BookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Book = book
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateBook(c echo.Context) error {
+	response := books.BookResponse{}
+	var request books.CreateBookRequest
+	err := c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	book, err := h.booksUsecase.CreateBook(c.Request().Context(), request)
+	if err != nil {
+		statusCode := BookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Book = book
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (h *Handler) UpdateBook(c echo.Context) error {
+	response := books.BookResponse{}
+	bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid book id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request books.UpdateBookRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.ID = bookID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	book, err := h.booksUsecase.UpdateBook(c.Request().Context(), request)
+	if err != nil {
+		statusCode := BookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Book = book
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) PatchBook(c echo.Context) error {
+	response := books.BookResponse{}
+	bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid book id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request books.PatchBookRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.ID = bookID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	book, err := h.booksUsecase.PatchBook(c.Request().Context(), request)
+	if err != nil {
+		statusCode := BookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Book = book
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) DeleteBook(c echo.Context) error {
+	response := books.BookResponse{}
+	bookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid book id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.booksUsecase.DeleteBook(c.Request().Context(), bookID)
+	if err != nil {
+		statusCode := BookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
 	return c.JSON(http.StatusOK, response)
 }
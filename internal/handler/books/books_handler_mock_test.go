@@ -10,6 +10,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	books "github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	response "github.com/yeremiaaryo/gotu-assignment/internal/response"
 )
 
 // MockbooksUsecase is a mock of booksUsecase interface.
@@ -35,17 +36,92 @@ func (m *MockbooksUsecase) EXPECT() *MockbooksUsecaseMockRecorder {
 	return m.recorder
 }
 
+// CreateBook mocks base method.
+func (m *MockbooksUsecase) CreateBook(ctx context.Context, req books.CreateBookRequest) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBook", ctx, req)
+	ret0, _ := ret[0].(*books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBook indicates an expected call of CreateBook.
+func (mr *MockbooksUsecaseMockRecorder) CreateBook(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBook", reflect.TypeOf((*MockbooksUsecase)(nil).CreateBook), ctx, req)
+}
+
+// DeleteBook mocks base method.
+func (m *MockbooksUsecase) DeleteBook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBook indicates an expected call of DeleteBook.
+func (mr *MockbooksUsecaseMockRecorder) DeleteBook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockbooksUsecase)(nil).DeleteBook), ctx, id)
+}
+
+// GetBookByID mocks base method.
+func (m *MockbooksUsecase) GetBookByID(ctx context.Context, id int64) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByID", ctx, id)
+	ret0, _ := ret[0].(*books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByID indicates an expected call of GetBookByID.
+func (mr *MockbooksUsecaseMockRecorder) GetBookByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByID", reflect.TypeOf((*MockbooksUsecase)(nil).GetBookByID), ctx, id)
+}
+
 // GetBooks mocks base method.
-func (m *MockbooksUsecase) GetBooks(ctx context.Context, search string, pageSize, pageIndex int) ([]books.Model, error) {
+func (m *MockbooksUsecase) GetBooks(ctx context.Context, filter books.Filter, cursor string, pageSize, pageIndex int) ([]books.Model, response.Pagination, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBooks", ctx, search, pageSize, pageIndex)
+	ret := m.ctrl.Call(m, "GetBooks", ctx, filter, cursor, pageSize, pageIndex)
 	ret0, _ := ret[0].([]books.Model)
+	ret1, _ := ret[1].(response.Pagination)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBooks indicates an expected call of GetBooks.
+func (mr *MockbooksUsecaseMockRecorder) GetBooks(ctx, filter, cursor, pageSize, pageIndex interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockbooksUsecase)(nil).GetBooks), ctx, filter, cursor, pageSize, pageIndex)
+}
+
+// PatchBook mocks base method.
+func (m *MockbooksUsecase) PatchBook(ctx context.Context, req books.PatchBookRequest) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchBook", ctx, req)
+	ret0, _ := ret[0].(*books.Model)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetBooks indicates an expected call of GetBooks.
-func (mr *MockbooksUsecaseMockRecorder) GetBooks(ctx, search, pageSize, pageIndex interface{}) *gomock.Call {
+// PatchBook indicates an expected call of PatchBook.
+func (mr *MockbooksUsecaseMockRecorder) PatchBook(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchBook", reflect.TypeOf((*MockbooksUsecase)(nil).PatchBook), ctx, req)
+}
+
+// UpdateBook mocks base method.
+func (m *MockbooksUsecase) UpdateBook(ctx context.Context, req books.UpdateBookRequest) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, req)
+	ret0, _ := ret[0].(*books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockbooksUsecaseMockRecorder) UpdateBook(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockbooksUsecase)(nil).GetBooks), ctx, search, pageSize, pageIndex)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockbooksUsecase)(nil).UpdateBook), ctx, req)
 }
@@ -3,14 +3,18 @@ package books
 import (
 	"encoding/json"
 	"errors"
+	"github.com/go-playground/validator/v10"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
 	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandler_GetBooks(t *testing.T) {
@@ -55,9 +59,9 @@ func TestHandler_GetBooks(t *testing.T) {
 				},
 			},
 			mockFn: func(args args) {
-				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), args.search, 10, 1).Return([]books.Model{
+				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), books.Filter{Search: args.search}, "", 10, 1).Return([]books.Model{
 					{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
-				}, nil)
+				}, response.Pagination{TotalItems: 1, TotalPages: 1, PageIndex: 1, PageSize: 10}, nil)
 			},
 		},
 		{
@@ -78,9 +82,9 @@ func TestHandler_GetBooks(t *testing.T) {
 				},
 			},
 			mockFn: func(args args) {
-				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), args.search, 10, 1).Return([]books.Model{
+				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), books.Filter{Search: args.search}, "", 10, 1).Return([]books.Model{
 					{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
-				}, nil)
+				}, response.Pagination{TotalItems: 1, TotalPages: 1, PageIndex: 1, PageSize: 10}, nil)
 			},
 		},
 		{
@@ -101,9 +105,9 @@ func TestHandler_GetBooks(t *testing.T) {
 				},
 			},
 			mockFn: func(args args) {
-				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), args.search, 10, 1).Return([]books.Model{
+				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), books.Filter{Search: args.search}, "", 10, 1).Return([]books.Model{
 					{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
-				}, nil)
+				}, response.Pagination{TotalItems: 1, TotalPages: 1, PageIndex: 1, PageSize: 10}, nil)
 			},
 		},
 		{
@@ -121,7 +125,7 @@ func TestHandler_GetBooks(t *testing.T) {
 				},
 			},
 			mockFn: func(args args) {
-				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), args.search, 10, 1).Return(nil, errors.New("mock error from usecase"))
+				mockBooksUC.EXPECT().GetBooks(c.Request().Context(), books.Filter{Search: args.search}, "", 10, 1).Return(nil, response.Pagination{}, errors.New("mock error from usecase"))
 			},
 		},
 	}
@@ -155,3 +159,362 @@ func TestHandler_GetBooks(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetBooksFilter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksUC := NewMockbooksUsecase(mockCtrl)
+	minPrice, maxPrice := money.MustParse("5"), money.MustParse("10.5")
+	publishedFrom := time.Date(1940, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error invalid min price",
+			query:          "min_price=abc",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid min_price, must be a positive number","books":null,"pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error invalid published date",
+			query:          "published_to=31-12-1950",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid published_to, format must be YYYY-MM-DD","books":null,"pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error invalid sort from usecase",
+			query:          "sort=cheapest",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid sort: cheapest","books":null,"pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().GetBooks(gomock.Any(), books.Filter{Sort: "cheapest"}, "", 10, 1).Return(nil, response.Pagination{}, errors.New("invalid sort: cheapest"))
+			},
+		},
+		{
+			name:           "error invalid cursor from usecase",
+			query:          "cursor=tampered",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid cursor","books":null,"pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().GetBooks(gomock.Any(), books.Filter{}, "tampered", 10, 1).Return(nil, response.Pagination{}, errors.New("invalid cursor"))
+			},
+		},
+		{
+			name:           "success by cursor",
+			query:          "cursor=next&page_size=1",
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"books":[],"pagination":{"total_items":3,"total_pages":3,"page_index":0,"page_size":1,"has_next":true,"next_cursor":"after-next"}}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().GetBooks(gomock.Any(), books.Filter{}, "next", 1, 1).Return([]books.Model{}, response.Pagination{TotalItems: 3, TotalPages: 3, PageSize: 1, HasNext: true, NextCursor: "after-next"}, nil)
+			},
+		},
+		{
+			name:           "success with every filter",
+			query:          "search=orwell&author=George+Orwell&min_price=5&max_price=10.5&published_from=1940-01-01&sort=price_asc",
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"books":[],"pagination":{"total_items":0,"total_pages":0,"page_index":1,"page_size":10,"has_next":false}}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().GetBooks(gomock.Any(), books.Filter{
+					Search:        "orwell",
+					Author:        "George Orwell",
+					MinPrice:      &minPrice,
+					MaxPrice:      &maxPrice,
+					PublishedFrom: &publishedFrom,
+					Sort:          books.SortByPriceAsc,
+				}, "", 10, 1).Return([]books.Model{}, response.Pagination{PageIndex: 1, PageSize: 10}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				booksUsecase: mockBooksUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/books?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.GetBooks(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+type CustomValidator struct {
+	validator *validator.Validate
+}
+
+func (cv *CustomValidator) Validate(i interface{}) error {
+	return cv.validator.Struct(i)
+}
+
+func TestHandler_CreateBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksUC := NewMockbooksUsecase(mockCtrl)
+
+	type args struct {
+		payload string
+	}
+	tests := []struct {
+		name           string
+		args           args
+		expectedStatus int
+		want           string
+		mockFn         func(args args)
+	}{
+		{
+			name: "error validate",
+			args: args{
+				payload: `{"title":"1984","author":"George Orwell","isbn":"123","published_date":"1949-06-08","price":-1}`,
+			},
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'CreateBookRequest.ISBN' Error:Field validation for 'ISBN' failed on the 'isbn' tag\nKey: 'CreateBookRequest.Price' Error:Field validation for 'Price' failed on the 'gt' tag","book":null}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name: "error isbn already exists",
+			args: args{
+				payload: `{"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"1949-06-08","price":9.99}`,
+			},
+			expectedStatus: http.StatusConflict,
+			want:           `{"result":false,"error":"isbn already exists","book":null}`,
+			mockFn: func(args args) {
+				mockBooksUC.EXPECT().CreateBook(gomock.Any(), gomock.Any()).Return(nil, errors.New("isbn already exists"))
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				payload: `{"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"1949-06-08","price":9.99}`,
+			},
+			expectedStatus: http.StatusCreated,
+			want:           `{"result":true,"book":{"id":1,"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"0001-01-01T00:00:00Z","price":9.99,"stock":0,"available":false}}`,
+			mockFn: func(args args) {
+				mockBooksUC.EXPECT().CreateBook(gomock.Any(), books.CreateBookRequest{
+					Title:         "1984",
+					Author:        "George Orwell",
+					ISBN:          "9780451524935",
+					PublishedDate: "1949-06-08",
+					Price:         money.MustParse("9.99"),
+				}).Return(&books.Model{ID: 1, Title: "1984", Author: "George Orwell", ISBN: "9780451524935", Price: money.MustParse("9.99")}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			h := &Handler{
+				booksUsecase: mockBooksUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(tt.args.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.CreateBook(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_PatchBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksUC := NewMockbooksUsecase(mockCtrl)
+
+	type args struct {
+		id      string
+		payload string
+	}
+	tests := []struct {
+		name           string
+		args           args
+		expectedStatus int
+		want           string
+		mockFn         func(args args)
+	}{
+		{
+			name:           "error invalid id",
+			args:           args{id: "abc", payload: `{}`},
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid book id","book":null}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error empty title",
+			args:           args{id: "1", payload: `{"title":""}`},
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'PatchBookRequest.Title' Error:Field validation for 'Title' failed on the 'min' tag","book":null}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error book not found",
+			args:           args{id: "99", payload: `{"price":12.5}`},
+			expectedStatus: http.StatusNotFound,
+			want:           `{"result":false,"error":"book with id: 99 is not found","book":null}`,
+			mockFn: func(args args) {
+				mockBooksUC.EXPECT().PatchBook(gomock.Any(), gomock.Any()).Return(nil, errors.New("book with id: 99 is not found"))
+			},
+		},
+		{
+			name:           "success",
+			args:           args{id: "1", payload: `{"price":12.5}`},
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"book":{"id":1,"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"0001-01-01T00:00:00Z","price":12.5,"stock":0,"available":false}}`,
+			mockFn: func(args args) {
+				price := money.MustParse("12.5")
+				mockBooksUC.EXPECT().PatchBook(gomock.Any(), books.PatchBookRequest{ID: 1, Price: &price}).
+					Return(&books.Model{ID: 1, Title: "1984", Author: "George Orwell", ISBN: "9780451524935", Price: money.MustParse("12.5")}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			h := &Handler{
+				booksUsecase: mockBooksUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPatch, "/books/"+tt.args.id, strings.NewReader(tt.args.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.args.id)
+			if assert.NoError(t, h.PatchBook(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksUC := NewMockbooksUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error referenced by orders",
+			id:             "1",
+			expectedStatus: http.StatusConflict,
+			want:           `{"result":false,"error":"book with id: 1 is referenced by existing orders","book":null}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().DeleteBook(gomock.Any(), int64(1)).Return(errors.New("book with id: 1 is referenced by existing orders"))
+			},
+		},
+		{
+			name:           "success",
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"book":null}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().DeleteBook(gomock.Any(), int64(1)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				booksUsecase: mockBooksUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/books/"+tt.id, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			if assert.NoError(t, h.DeleteBook(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksUC := NewMockbooksUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error invalid id",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid book id","book":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error not found",
+			id:             "99",
+			expectedStatus: http.StatusNotFound,
+			want:           `{"result":false,"error":"book with id: 99 is not found","book":null}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().GetBookByID(gomock.Any(), int64(99)).Return(nil, errors.New("book with id: 99 is not found"))
+			},
+		},
+		{
+			name:           "success",
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"book":{"id":1,"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"0001-01-01T00:00:00Z","price":9.99,"stock":0,"available":false}}`,
+			mockFn: func() {
+				mockBooksUC.EXPECT().GetBookByID(gomock.Any(), int64(1)).
+					Return(&books.Model{ID: 1, Title: "1984", Author: "George Orwell", ISBN: "9780451524935", Price: money.MustParse("9.99")}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				booksUsecase: mockBooksUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/books/"+tt.id, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			if assert.NoError(t, h.GetBook(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package books
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func BookCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "is not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "isbn already exists"), strings.Contains(err.Error(), "is referenced by existing orders"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "invalid published date"), strings.Contains(err.Error(), "invalid price range"),
+		strings.Contains(err.Error(), "invalid sort"), strings.Contains(err.Error(), "invalid cursor"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// parseBookFilter reads the filters of the book list from the query params
+func parseBookFilter(c echo.Context) (books.Filter, error) {
+	filter := books.Filter{
+		Search: c.QueryParam("search"),
+		Author: c.QueryParam("author"),
+		Sort:   books.SortBy(c.QueryParam("sort")),
+	}
+
+	var err error
+	filter.MinPrice, err = parseAmountParam(c, "min_price")
+	if err != nil {
+		return filter, err
+	}
+	filter.MaxPrice, err = parseAmountParam(c, "max_price")
+	if err != nil {
+		return filter, err
+	}
+	filter.PublishedFrom, err = parseDateParam(c, "published_from")
+	if err != nil {
+		return filter, err
+	}
+	filter.PublishedTo, err = parseDateParam(c, "published_to")
+	if err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseAmountParam(c echo.Context, name string) (*money.Amount, error) {
+	param := c.QueryParam(name)
+	if param == "" {
+		return nil, nil
+	}
+	value, err := money.Parse(param)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid %s, must be a positive number", name)
+	}
+	return &value, nil
+}
+
+func parseDateParam(c echo.Context, name string) (*time.Time, error) {
+	param := c.QueryParam(name)
+	if param == "" {
+		return nil, nil
+	}
+	value, err := time.Parse(time.DateOnly, param)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, format must be YYYY-MM-DD", name)
+	}
+	return &value, nil
+}
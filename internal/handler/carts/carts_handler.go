@@ -0,0 +1,184 @@
+package carts
+
+import (
+	"context"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/carts"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"net/http"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+//go:generate mockgen -package=carts -source=carts_handler.go -destination=carts_handler_mock_test.go
+type cartsUsecase interface {
+	GetCart(ctx context.Context, userID int64) (*carts.Cart, error)
+	AddItem(ctx context.Context, req carts.AddItemRequest) (*carts.Cart, error)
+	UpdateItem(ctx context.Context, req carts.UpdateItemRequest) (*carts.Cart, error)
+	ClearCart(ctx context.Context, userID int64) error
+	Checkout(ctx context.Context, req carts.CheckoutRequest) (*orders.CreateOrderResponse, error)
+}
+type Handler struct {
+	cartsUsecase cartsUsecase
+}
+
+func New(cartsUsecase cartsUsecase) *Handler {
+	return &Handler{cartsUsecase: cartsUsecase}
+}
+
+func (h *Handler) GetCart(c echo.Context) error {
+	response := carts.CartResponse{}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	cart, err := h.cartsUsecase.GetCart(c.Request().Context(), userID)
+	if err != nil {
+		statusCode := CartCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Cart = cart
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) AddItem(c echo.Context) error {
+	response := carts.CartResponse{}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request carts.AddItemRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.UserID = userID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	cart, err := h.cartsUsecase.AddItem(c.Request().Context(), request)
+	if err != nil {
+		statusCode := CartCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Cart = cart
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) UpdateItem(c echo.Context) error {
+	response := carts.CartResponse{}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request carts.UpdateItemRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.UserID = userID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	cart, err := h.cartsUsecase.UpdateItem(c.Request().Context(), request)
+	if err != nil {
+		statusCode := CartCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Cart = cart
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) ClearCart(c echo.Context) error {
+	response := carts.CartResponse{}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.cartsUsecase.ClearCart(c.Request().Context(), userID)
+	if err != nil {
+		statusCode := CartCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Cart = &carts.Cart{Items: []carts.Item{}}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) Checkout(c echo.Context) error {
+	response := orders.CreateOrderResponse{}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	idempotencyKey := c.Request().Header.Get(headerIdempotencyKey)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		response.Error = fmt.Sprintf("invalid %s header, must be at most %d characters", headerIdempotencyKey, maxIdempotencyKeyLength)
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request carts.CheckoutRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.UserID = userID
+	request.IdempotencyKey = idempotencyKey
+	order, err := h.cartsUsecase.Checkout(c.Request().Context(), request)
+	if err != nil {
+		statusCode := CheckoutCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	if order.Replayed {
+		c.Response().Header().Set(headerIdempotentReplayed, "true")
+	}
+	order.Result = true
+	return c.JSON(http.StatusCreated, order)
+}
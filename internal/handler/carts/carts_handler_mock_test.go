@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: carts_handler.go
+
+// Package carts is a generated GoMock package.
+package carts
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	carts "github.com/yeremiaaryo/gotu-assignment/internal/model/carts"
+	orders "github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+)
+
+// MockcartsUsecase is a mock of cartsUsecase interface.
+type MockcartsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockcartsUsecaseMockRecorder
+}
+
+// MockcartsUsecaseMockRecorder is the mock recorder for MockcartsUsecase.
+type MockcartsUsecaseMockRecorder struct {
+	mock *MockcartsUsecase
+}
+
+// NewMockcartsUsecase creates a new mock instance.
+func NewMockcartsUsecase(ctrl *gomock.Controller) *MockcartsUsecase {
+	mock := &MockcartsUsecase{ctrl: ctrl}
+	mock.recorder = &MockcartsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcartsUsecase) EXPECT() *MockcartsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// AddItem mocks base method.
+func (m *MockcartsUsecase) AddItem(ctx context.Context, req carts.AddItemRequest) (*carts.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddItem", ctx, req)
+	ret0, _ := ret[0].(*carts.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddItem indicates an expected call of AddItem.
+func (mr *MockcartsUsecaseMockRecorder) AddItem(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddItem", reflect.TypeOf((*MockcartsUsecase)(nil).AddItem), ctx, req)
+}
+
+// Checkout mocks base method.
+func (m *MockcartsUsecase) Checkout(ctx context.Context, req carts.CheckoutRequest) (*orders.CreateOrderResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkout", ctx, req)
+	ret0, _ := ret[0].(*orders.CreateOrderResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkout indicates an expected call of Checkout.
+func (mr *MockcartsUsecaseMockRecorder) Checkout(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkout", reflect.TypeOf((*MockcartsUsecase)(nil).Checkout), ctx, req)
+}
+
+// ClearCart mocks base method.
+func (m *MockcartsUsecase) ClearCart(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearCart", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearCart indicates an expected call of ClearCart.
+func (mr *MockcartsUsecaseMockRecorder) ClearCart(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearCart", reflect.TypeOf((*MockcartsUsecase)(nil).ClearCart), ctx, userID)
+}
+
+// GetCart mocks base method.
+func (m *MockcartsUsecase) GetCart(ctx context.Context, userID int64) (*carts.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCart", ctx, userID)
+	ret0, _ := ret[0].(*carts.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCart indicates an expected call of GetCart.
+func (mr *MockcartsUsecaseMockRecorder) GetCart(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockcartsUsecase)(nil).GetCart), ctx, userID)
+}
+
+// UpdateItem mocks base method.
+func (m *MockcartsUsecase) UpdateItem(ctx context.Context, req carts.UpdateItemRequest) (*carts.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateItem", ctx, req)
+	ret0, _ := ret[0].(*carts.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateItem indicates an expected call of UpdateItem.
+func (mr *MockcartsUsecaseMockRecorder) UpdateItem(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItem", reflect.TypeOf((*MockcartsUsecase)(nil).UpdateItem), ctx, req)
+}
@@ -0,0 +1,184 @@
+package carts
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/carts"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CustomValidator struct {
+	validator *validator.Validate
+}
+
+func (cv *CustomValidator) Validate(i interface{}) error {
+	return cv.validator.Struct(i)
+}
+
+func TestHandler_AddItem(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCartsUC := NewMockcartsUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		wantStatusCode int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate",
+			payload:        `{"book_id":101,"quantity":0}`,
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"result":false, "error":"Key: 'AddItemRequest.Quantity' Error:Field validation for 'Quantity' failed on the 'required' tag", "cart":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error not enough stock",
+			payload:        `{"book_id":101,"quantity":9}`,
+			wantStatusCode: http.StatusConflict,
+			want:           `{"result":false, "error":"only 5 left in stock for book with id: 101", "cart":null}`,
+			mockFn: func() {
+				mockCartsUC.EXPECT().AddItem(gomock.Any(), carts.AddItemRequest{UserID: 1, BookID: 101, Quantity: 9}).
+					Return(nil, errors.New("only 5 left in stock for book with id: 101"))
+			},
+		},
+		{
+			name:           "success",
+			payload:        `{"book_id":101,"quantity":2}`,
+			wantStatusCode: http.StatusOK,
+			want: `{"result":true, "cart":{"total_amount":21.98,"items":[{"book_id":101,"title":"1984","author":"George Orwell",
+				"price":10.99,"quantity":2,"subtotal":21.98,"available":true}]}}`,
+			mockFn: func() {
+				mockCartsUC.EXPECT().AddItem(gomock.Any(), carts.AddItemRequest{UserID: 1, BookID: 101, Quantity: 2}).Return(&carts.Cart{
+					Items: []carts.Item{
+						{BookID: 101, Title: "1984", Author: "George Orwell", Price: money.MustParse("10.99"), Quantity: 2, Subtotal: money.MustParse("21.98"), Available: true},
+					},
+					TotalAmount: money.MustParse("21.98"),
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				cartsUsecase: mockCartsUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/cart", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("userID", int64(1))
+			if assert.NoError(t, h.AddItem(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Checkout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCartsUC := NewMockcartsUsecase(mockCtrl)
+
+	request := carts.CheckoutRequest{UserID: 1, IdempotencyKey: "checkout-1"}
+	promoRequest := carts.CheckoutRequest{UserID: 1, IdempotencyKey: "checkout-1", PromoCode: "SAVE10"}
+
+	tests := []struct {
+		name           string
+		body           string
+		wantStatusCode int
+		want           string
+		wantReplayed   string
+		mockFn         func()
+	}{
+		{
+			name:           "error empty cart",
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"result":false, "error":"cart is empty", "order_id":0, "status":""}`,
+			mockFn: func() {
+				mockCartsUC.EXPECT().Checkout(gomock.Any(), request).Return(nil, errors.New("cart is empty"))
+			},
+		},
+		{
+			name:           "error price changed",
+			wantStatusCode: http.StatusConflict,
+			want:           `{"result":false, "error":"book with id: 101 has different price", "order_id":0, "status":""}`,
+			mockFn: func() {
+				mockCartsUC.EXPECT().Checkout(gomock.Any(), request).Return(nil, errors.New("book with id: 101 has different price"))
+			},
+		},
+		{
+			name:           "success",
+			wantStatusCode: http.StatusCreated,
+			want:           `{"result":true, "order_id":7, "status":"NEW"}`,
+			mockFn: func() {
+				mockCartsUC.EXPECT().Checkout(gomock.Any(), request).Return(&orders.CreateOrderResponse{OrderID: 7, Status: "NEW"}, nil)
+			},
+		},
+		{
+			name:           "success replays a retried checkout",
+			wantStatusCode: http.StatusCreated,
+			want:           `{"result":true, "order_id":7, "status":"NEW"}`,
+			wantReplayed:   "true",
+			mockFn: func() {
+				mockCartsUC.EXPECT().Checkout(gomock.Any(), request).
+					Return(&orders.CreateOrderResponse{OrderID: 7, Status: "NEW", Replayed: true}, nil)
+			},
+		},
+		{
+			name:           "error promo code usage limit",
+			body:           `{"promo_code":"SAVE10"}`,
+			wantStatusCode: http.StatusConflict,
+			want:           `{"result":false, "error":"promo code SAVE10 has reached its usage limit", "order_id":0, "status":""}`,
+			mockFn: func() {
+				mockCartsUC.EXPECT().Checkout(gomock.Any(), promoRequest).Return(nil, errors.New("promo code SAVE10 has reached its usage limit"))
+			},
+		},
+		{
+			name:           "success with promo code",
+			body:           `{"promo_code":"SAVE10"}`,
+			wantStatusCode: http.StatusCreated,
+			want:           `{"result":true, "order_id":8, "status":"NEW"}`,
+			mockFn: func() {
+				mockCartsUC.EXPECT().Checkout(gomock.Any(), promoRequest).Return(&orders.CreateOrderResponse{OrderID: 8, Status: "NEW"}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				cartsUsecase: mockCartsUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/cart/checkout", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Idempotency-Key", "checkout-1")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("userID", int64(1))
+			if assert.NoError(t, h.Checkout(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+				assert.Equal(t, tt.wantReplayed, rec.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package carts
+
+import (
+	"net/http"
+	"strings"
+)
+
+func CartCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "is not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "left in stock"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func CheckoutCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "usage limit"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "cart is empty"), strings.Contains(err.Error(), "promo code"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "used with a different request"), strings.Contains(err.Error(), "billing country"):
+		return http.StatusUnprocessableEntity
+	case strings.Contains(err.Error(), "email is not verified"):
+		return http.StatusForbidden
+	// the price or stock changed between pricing the cart and placing the order, the client has to reload the cart
+	case strings.Contains(err.Error(), "out of stock"), strings.Contains(err.Error(), "has different price"),
+		strings.Contains(err.Error(), "total amount is different"), strings.Contains(err.Error(), "still in progress"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -6,8 +6,75 @@ import (
 )
 
 func CreateOrderCustomErrorHTTPCode(err error) int {
-	if strings.Contains(err.Error(), "book with id") || strings.Contains(err.Error(), "total amount is different") {
+	if strings.Contains(err.Error(), "used with a different request") {
+		return http.StatusUnprocessableEntity
+	}
+	if strings.Contains(err.Error(), "still in progress") {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "out of stock") || strings.Contains(err.Error(), "usage limit") {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "email is not verified") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "billing country") {
+		return http.StatusUnprocessableEntity
+	}
+	if strings.Contains(err.Error(), "book with id") || strings.Contains(err.Error(), "total amount is different") ||
+		strings.Contains(err.Error(), "quote") || strings.Contains(err.Error(), "promo code") {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func QuoteOrderCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "usage limit") {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "billing country") {
+		return http.StatusUnprocessableEntity
+	}
+	if strings.Contains(err.Error(), "is not found") || strings.Contains(err.Error(), "promo code") {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func OrderHistoryCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "invalid cursor") {
 		return http.StatusBadRequest
 	}
 	return http.StatusInternalServerError
 }
+
+func UpdateOrderStatusCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	if strings.Contains(err.Error(), "updated by another request") {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "invalid status") {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func CancelOrderCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	if strings.Contains(err.Error(), "already cancelled") || strings.Contains(err.Error(), "can't be cancelled") ||
+		strings.Contains(err.Error(), "updated by another request") {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func OrderDetailCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -2,17 +2,29 @@ package orders
 
 import (
 	"context"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
 	"net/http"
 	"strconv"
 )
 
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
 //go:generate mockgen -package=orders -source=orders_handler.go -destination=orders_handler_mock_test.go
 type ordersUsecase interface {
 	InsertOrder(ctx context.Context, order orders.CreateOrderRequest) (*orders.CreateOrderResponse, error)
-	GetOrdersByUserID(ctx context.Context, userID int64, pageIndex, pageSize int) ([]orders.History, error)
+	QuoteOrder(ctx context.Context, req orders.QuoteRequest) (*orders.Quote, error)
+	GetOrdersByUserID(ctx context.Context, userID int64, cursor string, pageIndex, pageSize int) ([]orders.History, response.Pagination, error)
+	GetOrderDetail(ctx context.Context, orderID, userID int64, role string) (*orders.Detail, error)
+	UpdateOrderStatus(ctx context.Context, req orders.UpdateOrderStatusRequest) (*orders.UpdateOrderStatusResponse, error)
+	CancelOrder(ctx context.Context, req orders.CancelOrderRequest) (*orders.UpdateOrderStatusResponse, error)
 }
 type Handler struct {
 	ordersUsecase ordersUsecase
@@ -39,6 +51,11 @@ func (h *Handler) CreateOrder(c echo.Context) error {
 	}
 
 	request.UserID = userID
+	request.IdempotencyKey = c.Request().Header.Get(headerIdempotencyKey)
+	if len(request.IdempotencyKey) > maxIdempotencyKeyLength {
+		response.Error = fmt.Sprintf("invalid %s header, must be at most %d characters", headerIdempotencyKey, maxIdempotencyKeyLength)
+		return c.JSON(http.StatusBadRequest, response)
+	}
 	err = c.Validate(request)
 	if err != nil {
 		response.Error = err.Error()
@@ -50,10 +67,47 @@ func (h *Handler) CreateOrder(c echo.Context) error {
 		response.Error = err.Error()
 		return c.JSON(statusCode, response)
 	}
+	if order.Replayed {
+		c.Response().Header().Set(headerIdempotentReplayed, "true")
+	}
 	order.Result = true
 	return c.JSON(http.StatusCreated, order)
 }
 
+func (h *Handler) QuoteOrder(c echo.Context) error {
+	var response orders.QuoteResponse
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request orders.QuoteRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.UserID = userID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	quote, err := h.ordersUsecase.QuoteOrder(c.Request().Context(), request)
+	if err != nil {
+		statusCode := QuoteOrderCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Quote = quote
+	return c.JSON(http.StatusOK, response)
+}
+
 func (h *Handler) GetOrderHistory(c echo.Context) error {
 	var response orders.OrderHistoryResponse
 
@@ -72,13 +126,133 @@ func (h *Handler) GetOrderHistory(c echo.Context) error {
 		pageSize = 10 // default page size is 10 if error
 	}
 
-	orderHistory, err := h.ordersUsecase.GetOrdersByUserID(c.Request().Context(), userID, pageIndex, pageSize)
+	orderHistory, pagination, err := h.ordersUsecase.GetOrdersByUserID(c.Request().Context(), userID, c.QueryParam("cursor"), pageIndex, pageSize)
 	if err != nil {
+		statusCode := OrderHistoryCustomErrorHTTPCode(err)
 		response.Error = err.Error()
-		return c.JSON(http.StatusInternalServerError, response)
+		return c.JSON(statusCode, response)
 	}
 
 	response.Histories = orderHistory
+	response.Pagination = pagination
+	response.Result = true
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetOrderDetail(c echo.Context) error {
+	var response orders.OrderDetailResponse
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid order id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	role, err := util.GetRole(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	order, err := h.ordersUsecase.GetOrderDetail(c.Request().Context(), orderID, userID, role)
+	if err != nil {
+		statusCode := OrderDetailCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+
+	response.Order = order
 	response.Result = true
 	return c.JSON(http.StatusOK, response)
 }
+
+func (h *Handler) UpdateOrderStatus(c echo.Context) error {
+	response := orders.UpdateOrderStatusResponse{}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid order id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	actorID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	actorRole, err := util.GetRole(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request orders.UpdateOrderStatusRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.OrderID = orderID
+	request.ActorID = actorID
+	request.ActorRole = actorRole
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	updated, err := h.ordersUsecase.UpdateOrderStatus(c.Request().Context(), request)
+	if err != nil {
+		statusCode := UpdateOrderStatusCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	updated.Result = true
+	return c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) CancelOrder(c echo.Context) error {
+	response := orders.UpdateOrderStatusResponse{}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid order id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request orders.CancelOrderRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.OrderID = orderID
+	request.UserID = userID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	cancelled, err := h.ordersUsecase.CancelOrder(c.Request().Context(), request)
+	if err != nil {
+		statusCode := CancelOrderCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	cancelled.Result = true
+	return c.JSON(http.StatusOK, cancelled)
+}
@@ -10,6 +10,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	orders "github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	response "github.com/yeremiaaryo/gotu-assignment/internal/response"
 )
 
 // MockordersUsecase is a mock of ordersUsecase interface.
@@ -35,19 +36,50 @@ func (m *MockordersUsecase) EXPECT() *MockordersUsecaseMockRecorder {
 	return m.recorder
 }
 
-// GetOrdersByUserID mocks base method.
-func (m *MockordersUsecase) GetOrdersByUserID(ctx context.Context, userID int64, pageIndex, pageSize int) ([]orders.History, error) {
+// CancelOrder mocks base method.
+func (m *MockordersUsecase) CancelOrder(ctx context.Context, req orders.CancelOrderRequest) (*orders.UpdateOrderStatusResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrdersByUserID", ctx, userID, pageIndex, pageSize)
-	ret0, _ := ret[0].([]orders.History)
+	ret := m.ctrl.Call(m, "CancelOrder", ctx, req)
+	ret0, _ := ret[0].(*orders.UpdateOrderStatusResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// CancelOrder indicates an expected call of CancelOrder.
+func (mr *MockordersUsecaseMockRecorder) CancelOrder(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockordersUsecase)(nil).CancelOrder), ctx, req)
+}
+
+// GetOrderDetail mocks base method.
+func (m *MockordersUsecase) GetOrderDetail(ctx context.Context, orderID, userID int64, role string) (*orders.Detail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderDetail", ctx, orderID, userID, role)
+	ret0, _ := ret[0].(*orders.Detail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderDetail indicates an expected call of GetOrderDetail.
+func (mr *MockordersUsecaseMockRecorder) GetOrderDetail(ctx, orderID, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderDetail", reflect.TypeOf((*MockordersUsecase)(nil).GetOrderDetail), ctx, orderID, userID, role)
+}
+
+// GetOrdersByUserID mocks base method.
+func (m *MockordersUsecase) GetOrdersByUserID(ctx context.Context, userID int64, cursor string, pageIndex, pageSize int) ([]orders.History, response.Pagination, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByUserID", ctx, userID, cursor, pageIndex, pageSize)
+	ret0, _ := ret[0].([]orders.History)
+	ret1, _ := ret[1].(response.Pagination)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // GetOrdersByUserID indicates an expected call of GetOrdersByUserID.
-func (mr *MockordersUsecaseMockRecorder) GetOrdersByUserID(ctx, userID, pageIndex, pageSize interface{}) *gomock.Call {
+func (mr *MockordersUsecaseMockRecorder) GetOrdersByUserID(ctx, userID, cursor, pageIndex, pageSize interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockordersUsecase)(nil).GetOrdersByUserID), ctx, userID, pageIndex, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockordersUsecase)(nil).GetOrdersByUserID), ctx, userID, cursor, pageIndex, pageSize)
 }
 
 // InsertOrder mocks base method.
@@ -64,3 +96,33 @@ func (mr *MockordersUsecaseMockRecorder) InsertOrder(ctx, order interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrder", reflect.TypeOf((*MockordersUsecase)(nil).InsertOrder), ctx, order)
 }
+
+// QuoteOrder mocks base method.
+func (m *MockordersUsecase) QuoteOrder(ctx context.Context, req orders.QuoteRequest) (*orders.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteOrder", ctx, req)
+	ret0, _ := ret[0].(*orders.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteOrder indicates an expected call of QuoteOrder.
+func (mr *MockordersUsecaseMockRecorder) QuoteOrder(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteOrder", reflect.TypeOf((*MockordersUsecase)(nil).QuoteOrder), ctx, req)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockordersUsecase) UpdateOrderStatus(ctx context.Context, req orders.UpdateOrderStatusRequest) (*orders.UpdateOrderStatusResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, req)
+	ret0, _ := ret[0].(*orders.UpdateOrderStatusResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockordersUsecaseMockRecorder) UpdateOrderStatus(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockordersUsecase)(nil).UpdateOrderStatus), ctx, req)
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -28,14 +31,16 @@ func TestHandler_CreateOrder(t *testing.T) {
 	mockOrdersUC := NewMockordersUsecase(mockCtrl)
 
 	type args struct {
-		payload string
-		userID  int64
+		payload        string
+		userID         int64
+		idempotencyKey string
 	}
 	tests := []struct {
-		name   string
-		args   args
-		want   string
-		mockFn func(args args)
+		name         string
+		args         args
+		want         string
+		wantReplayed string
+		mockFn       func(args args)
 	}{
 		{
 			name: "error invalid user id",
@@ -64,11 +69,33 @@ func TestHandler_CreateOrder(t *testing.T) {
 				payload: `{}`,
 				userID:  1,
 			},
-			want: `{"result":false,"error":"Key: 'CreateOrderRequest.TotalAmount' Error:Field validation for 'TotalAmount' failed on the 'required' tag\nKey: 'CreateOrderRequest.Items' Error:Field validation for 'Items' failed on the 'required' tag", "order_id":0, "result":false, "status":""}`,
+			want: `{"result":false,"error":"Key: 'CreateOrderRequest.Items' Error:Field validation for 'Items' failed on the 'required_without' tag", "order_id":0, "result":false, "status":""}`,
 			mockFn: func(args args) {
 
 			},
 		},
+		{
+			name: "error validate item quantity",
+			args: args{
+				payload: `{"items":[{"book_id":1,"quantity":-2,"price":50.0}],"total_amount":100.0}`,
+				userID:  1,
+			},
+			want: `{"error":"Key: 'CreateOrderRequest.Items[0].Quantity' Error:Field validation for 'Quantity' failed on the 'gt' tag", "order_id":0, "result":false, "status":""}`,
+			mockFn: func(args args) {
+
+			},
+		},
+		{
+			name: "error out of stock",
+			args: args{
+				payload: `{"items":[{"book_id":1,"quantity":2,"price":50.0}],"total_amount":100.0}`,
+				userID:  1,
+			},
+			want: `{"error":"out of stock for book_ids: 1", "order_id":0, "result":false, "status":""}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("out of stock for book_ids: 1"))
+			},
+		},
 		{
 			name: "error InsertOrder",
 			args: args{
@@ -80,6 +107,52 @@ func TestHandler_CreateOrder(t *testing.T) {
 				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("failed to insert order"))
 			},
 		},
+		{
+			name: "error idempotency key too long",
+			args: args{
+				payload:        `{"items":[{"book_id":1,"quantity":2,"price":50.0}],"total_amount":100.0}`,
+				userID:         1,
+				idempotencyKey: strings.Repeat("k", 256),
+			},
+			want: `{"error":"invalid Idempotency-Key header, must be at most 255 characters", "order_id":0, "result":false, "status":""}`,
+			mockFn: func(args args) {
+
+			},
+		},
+		{
+			name: "error idempotency key reused with a different body",
+			args: args{
+				payload:        `{"items":[{"book_id":1,"quantity":2,"price":50.0}],"total_amount":100.0}`,
+				userID:         1,
+				idempotencyKey: "retry-me",
+			},
+			want: `{"error":"idempotency key was already used with a different request", "order_id":0, "result":false, "status":""}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("idempotency key was already used with a different request"))
+			},
+		},
+		{
+			name: "success replayed",
+			args: args{
+				payload:        `{"items":[{"book_id":1,"quantity":2,"price":50.0}],"total_amount":100.0}`,
+				userID:         1,
+				idempotencyKey: "retry-me",
+			},
+			want:         `{"order_id":1, "result":true, "status":"NEW"}`,
+			wantReplayed: "true",
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), orders.CreateOrderRequest{
+					UserID:         1,
+					IdempotencyKey: "retry-me",
+					TotalAmount:    money.MustParse("100"),
+					Items:          []orders.CreateOrderItem{{BookID: 1, Quantity: 2, Price: money.MustParse("50")}},
+				}).Return(&orders.CreateOrderResponse{
+					OrderID:  1,
+					Status:   orders.OrderStatusNew.String(),
+					Replayed: true,
+				}, nil)
+			},
+		},
 		{
 			name: "success",
 			args: args{
@@ -105,6 +178,9 @@ func TestHandler_CreateOrder(t *testing.T) {
 			e.Validator = &CustomValidator{validator: validator.New()}
 			req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(tt.args.payload))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.args.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", tt.args.idempotencyKey)
+			}
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
 			if tt.args.userID != 0 {
@@ -112,6 +188,7 @@ func TestHandler_CreateOrder(t *testing.T) {
 			}
 			if assert.NoError(t, h.CreateOrder(c)) {
 				assert.JSONEq(t, tt.want, rec.Body.String())
+				assert.Equal(t, tt.wantReplayed, rec.Header().Get("Idempotent-Replayed"))
 			}
 		})
 	}
@@ -125,6 +202,7 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 
 	type args struct {
 		userID    int64
+		cursor    string
 		pageIndex string
 		pageSize  string
 	}
@@ -142,7 +220,7 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 				pageIndex: "1",
 				pageSize:  "10",
 			},
-			want:   `{"data":null, "error":"userID not found", "result":false}`,
+			want:   `{"data":null, "error":"userID not found", "result":false, "pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
 			mockFn: func(userID int64, pageIndex, pageSize string) {},
 		},
 		{
@@ -152,9 +230,22 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 				pageIndex: "invalid, use default",
 				pageSize:  "invalid, use default",
 			},
-			want: `{"data":null, "error":"failed to retrieve order history", "result":false}`,
+			want: `{"data":null, "error":"failed to retrieve order history", "result":false, "pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
+			mockFn: func(userID int64, pageIndex, pageSize string) {
+				mockOrdersUC.EXPECT().GetOrdersByUserID(gomock.Any(), userID, "", 1, 10).Return(nil, response.Pagination{}, errors.New("failed to retrieve order history"))
+			},
+		},
+		{
+			name: "error invalid cursor",
+			args: args{
+				userID:    1,
+				cursor:    "tampered",
+				pageIndex: "1",
+				pageSize:  "10",
+			},
+			want: `{"data":null, "error":"invalid cursor", "result":false, "pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
 			mockFn: func(userID int64, pageIndex, pageSize string) {
-				mockOrdersUC.EXPECT().GetOrdersByUserID(gomock.Any(), userID, 1, 10).Return(nil, errors.New("failed to retrieve order history"))
+				mockOrdersUC.EXPECT().GetOrdersByUserID(gomock.Any(), userID, "tampered", 1, 10).Return(nil, response.Pagination{}, errors.New("invalid cursor"))
 			},
 		},
 		{
@@ -164,25 +255,29 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 				pageIndex: "1",
 				pageSize:  "10",
 			},
-			want: `{"data":[{"order_id":1,"total_amount":100.0,"status":"NEW","created_at":1623800000,"updated_at":1623800000,"items":[{"item_id":1,"book_id":1,"quantity":2,"price":50.0}]}], "result":true}`,
+			want: `{"data":[{"order_id":1,"subtotal":100.0,"discount":0,"tax":9.91,"tax_inclusive":true,"total_amount":100.0,"status":"NEW","created_at":1623800000,"updated_at":1623800000,"items":[{"item_id":1,"book_id":1,"quantity":2,"price":50.0,"discount":0,"tax":9.91,"returned_quantity":0,"refunded_amount":0}]}], "result":true, "pagination":{"total_items":11,"total_pages":2,"page_index":1,"page_size":10,"has_next":true,"next_cursor":"next"}}`,
 			mockFn: func(userID int64, pageIndex, pageSize string) {
-				mockOrdersUC.EXPECT().GetOrdersByUserID(gomock.Any(), userID, 1, 10).Return([]orders.History{
+				mockOrdersUC.EXPECT().GetOrdersByUserID(gomock.Any(), userID, "", 1, 10).Return([]orders.History{
 					{
-						ID:          1,
-						TotalAmount: 100.0,
-						Status:      "NEW",
-						CreatedAt:   1623800000,
-						UpdatedAt:   1623800000,
+						ID:           1,
+						Subtotal:     money.MustParse("100"),
+						Tax:          money.MustParse("9.91"),
+						TaxInclusive: true,
+						TotalAmount:  money.MustParse("100"),
+						Status:       "NEW",
+						CreatedAt:    1623800000,
+						UpdatedAt:    1623800000,
 						Items: []orders.ItemHistory{
 							{
 								ID:       1,
 								BookID:   1,
 								Quantity: 2,
-								Price:    50.0,
+								Price:    money.MustParse("50"),
+								Tax:      money.MustParse("9.91"),
 							},
 						},
 					},
-				}, nil)
+				}, response.Pagination{TotalItems: 11, TotalPages: 2, PageIndex: 1, PageSize: 10, HasNext: true, NextCursor: "next"}, nil)
 			},
 		},
 	}
@@ -203,6 +298,9 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 			if tt.args.userID != 0 {
 				c.Set("userID", tt.args.userID)
 			}
+			if tt.args.cursor != "" {
+				c.QueryParams().Add("cursor", tt.args.cursor)
+			}
 			c.QueryParams().Add("page_index", tt.args.pageIndex)
 			c.QueryParams().Add("page_size", tt.args.pageSize)
 
@@ -212,3 +310,356 @@ func TestHandler_GetOrderHistory(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_UpdateOrderStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockOrdersUC := NewMockordersUsecase(mockCtrl)
+
+	type args struct {
+		orderID string
+		payload string
+		userID  int64
+	}
+	tests := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+		want           string
+		mockFn         func(args args)
+	}{
+		{
+			name: "error invalid order id",
+			args: args{
+				orderID: "abc",
+				payload: `{"status":"PAID","updated_at":1000}`,
+				userID:  9,
+			},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"invalid order id", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name: "error validate",
+			args: args{
+				orderID: "1",
+				payload: `{"status":"PAID"}`,
+				userID:  9,
+			},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"Key: 'UpdateOrderStatusRequest.UpdatedAt' Error:Field validation for 'UpdatedAt' failed on the 'required' tag", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name: "error order not found",
+			args: args{
+				orderID: "1",
+				payload: `{"status":"PAID","updated_at":1000}`,
+				userID:  9,
+			},
+			wantStatusCode: http.StatusNotFound,
+			want:           `{"error":"order with id: 1 is not found", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(nil, errors.New("order with id: 1 is not found"))
+			},
+		},
+		{
+			name: "error invalid transition",
+			args: args{
+				orderID: "1",
+				payload: `{"status":"DELIVERED","updated_at":1000}`,
+				userID:  9,
+			},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"invalid status transition from NEW to DELIVERED", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(nil, errors.New("invalid status transition from NEW to DELIVERED"))
+			},
+		},
+		{
+			name: "error order was updated concurrently",
+			args: args{
+				orderID: "1",
+				payload: `{"status":"PAID","updated_at":1000}`,
+				userID:  9,
+			},
+			wantStatusCode: http.StatusConflict,
+			want:           `{"error":"order was updated by another request, please reload it", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(nil, errors.New("order was updated by another request, please reload it"))
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				orderID: "1",
+				payload: `{"status":"SHIPPED","updated_at":1000,"note":"JNE 123"}`,
+				userID:  9,
+			},
+			wantStatusCode: http.StatusOK,
+			want:           `{"order_id":1, "result":true, "status":"SHIPPED", "updated_at":2000}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().UpdateOrderStatus(gomock.Any(), orders.UpdateOrderStatusRequest{
+					OrderID:   1,
+					ActorID:   9,
+					ActorRole: "admin",
+					Status:    "SHIPPED",
+					UpdatedAt: 1000,
+					Note:      "JNE 123",
+				}).Return(&orders.UpdateOrderStatusResponse{
+					OrderID:   1,
+					Status:    "SHIPPED",
+					UpdatedAt: 2000,
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			h := &Handler{
+				ordersUsecase: mockOrdersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPatch, "/order/"+tt.args.orderID+"/status", strings.NewReader(tt.args.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.args.orderID)
+			c.Set("userID", tt.args.userID)
+			c.Set("role", "admin")
+			if assert.NoError(t, h.UpdateOrderStatus(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_CancelOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockOrdersUC := NewMockordersUsecase(mockCtrl)
+
+	type args struct {
+		orderID string
+		payload string
+		userID  int64
+	}
+	tests := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+		want           string
+		mockFn         func(args args)
+	}{
+		{
+			name:           "error invalid order id",
+			args:           args{orderID: "abc", userID: 2},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"invalid order id", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error invalid user id",
+			args:           args{orderID: "1"},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"userID not found", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error already cancelled",
+			args:           args{orderID: "1", userID: 2},
+			wantStatusCode: http.StatusConflict,
+			want:           `{"error":"order with id: 1 is already cancelled", "order_id":0, "result":false, "status":"", "updated_at":0}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().CancelOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("order with id: 1 is already cancelled"))
+			},
+		},
+		{
+			name:           "success",
+			args:           args{orderID: "1", payload: `{"reason":"changed my mind"}`, userID: 2},
+			wantStatusCode: http.StatusOK,
+			want:           `{"order_id":1, "result":true, "status":"CANCELLED", "updated_at":2000}`,
+			mockFn: func(args args) {
+				mockOrdersUC.EXPECT().CancelOrder(gomock.Any(), orders.CancelOrderRequest{OrderID: 1, UserID: 2, Reason: "changed my mind"}).
+					Return(&orders.UpdateOrderStatusResponse{OrderID: 1, Status: "CANCELLED", UpdatedAt: 2000}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			h := &Handler{
+				ordersUsecase: mockOrdersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/order/"+tt.args.orderID+"/cancel", strings.NewReader(tt.args.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.args.orderID)
+			if tt.args.userID != 0 {
+				c.Set("userID", tt.args.userID)
+			}
+			if assert.NoError(t, h.CancelOrder(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetOrderDetail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockOrdersUC := NewMockordersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		orderID        string
+		wantStatusCode int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error invalid order id",
+			orderID:        "abc",
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"invalid order id", "order":null, "result":false}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "success",
+			orderID:        "1",
+			wantStatusCode: http.StatusOK,
+			want: `{"result":true, "order":{"order_id":1,"user_id":2,"subtotal":19.98,"discount":0,"billing_country":"CA","tax":1.00,"tax_inclusive":false,"total_amount":20.98,
+				"status":"NEW","created_at":1000,"updated_at":1000,
+				"items":[{"item_id":5,"book_id":3,"title":"1984","author":"George Orwell","isbn":"9780451524935","quantity":2,"price":9.99,"discount":0,"tax_rate":5,"tax":1.00,"returned_quantity":0,"refunded_amount":0}],
+				"status_history":[{"to_status":"NEW","actor_id":2,"actor_role":"customer","created_at":1000}]}}`,
+			mockFn: func() {
+				mockOrdersUC.EXPECT().GetOrderDetail(gomock.Any(), int64(1), int64(2), "customer").Return(&orders.Detail{
+					ID:             1,
+					UserID:         2,
+					Subtotal:       money.MustParse("19.98"),
+					BillingCountry: "CA",
+					Tax:            money.MustParse("1"),
+					TotalAmount:    money.MustParse("20.98"),
+					Status:         "NEW",
+					CreatedAt:      1000,
+					UpdatedAt:      1000,
+					Items: []orders.ItemDetail{
+						{ID: 5, BookID: 3, Title: "1984", Author: "George Orwell", ISBN: "9780451524935", Quantity: 2, Price: money.MustParse("9.99"),
+							TaxRate: percent.MustParse("5"), Tax: money.MustParse("1")},
+					},
+					StatusHistory: []orders.StatusHistory{
+						{ID: 1, OrderID: 1, To: "NEW", ActorID: 2, ActorRole: "customer", CreatedAt: 1000},
+					},
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				ordersUsecase: mockOrdersUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/order/"+tt.orderID, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.orderID)
+			c.Set("userID", int64(2))
+			c.Set("role", "customer")
+			if assert.NoError(t, h.GetOrderDetail(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_QuoteOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockOrdersUC := NewMockordersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		wantStatusCode int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate",
+			payload:        `{}`,
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"result":false, "error":"Key: 'QuoteRequest.Items' Error:Field validation for 'Items' failed on the 'required' tag", "quote":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error unknown book",
+			payload:        `{"items":[{"book_id":999,"quantity":1,"price":9.99}]}`,
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"result":false, "error":"book with id: 999 is not found", "quote":null}`,
+			mockFn: func() {
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("book with id: 999 is not found"))
+			},
+		},
+		{
+			name:           "success",
+			payload:        `{"items":[{"book_id":3,"quantity":2,"price":9.49}]}`,
+			wantStatusCode: http.StatusOK,
+			want: `{"result":true, "quote":{"quote_id":"signed","expires_at":1000,"subtotal":19.98,"discount":0.00,"billing_country":"ID","tax":1.98,"tax_inclusive":true,"grand_total":19.98,
+				"items":[{"book_id":3,"title":"1984","quantity":2,"price":9.99,"expected_price":9.49,"price_changed":true,"line_total":19.98,"discount":0,"tax_rate":11,"tax":1.98,"available":true}]}}`,
+			mockFn: func() {
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), orders.QuoteRequest{
+					UserID: 1,
+					Items:  []orders.CreateOrderItem{{BookID: 3, Quantity: 2, Price: money.MustParse("9.49")}},
+				}).Return(&orders.Quote{
+					QuoteID:   "signed",
+					ExpiresAt: 1000,
+					Items: []orders.QuoteItem{
+						{BookID: 3, Title: "1984", Quantity: 2, Price: money.MustParse("9.99"), ExpectedPrice: money.MustParse("9.49"), PriceChanged: true, LineTotal: money.MustParse("19.98"), Available: true,
+							TaxRate: percent.MustParse("11"), Tax: money.MustParse("1.98")},
+					},
+					Subtotal:       money.MustParse("19.98"),
+					BillingCountry: "ID",
+					Tax:            money.MustParse("1.98"),
+					TaxInclusive:   true,
+					GrandTotal:     money.MustParse("19.98"),
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				ordersUsecase: mockOrdersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/order/quote", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("userID", int64(1))
+			if assert.NoError(t, h.QuoteOrder(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package payments
+
+import (
+	"net/http"
+	"strings"
+)
+
+func PayCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	if strings.Contains(err.Error(), "was declined") {
+		return http.StatusPaymentRequired
+	}
+	if strings.Contains(err.Error(), "can't be paid") || strings.Contains(err.Error(), "payment in progress") ||
+		strings.Contains(err.Error(), "updated by another request") {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func WebhookCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "invalid webhook signature") {
+		return http.StatusUnauthorized
+	}
+	if strings.Contains(err.Error(), "invalid webhook payload") {
+		return http.StatusBadRequest
+	}
+	// retrying doesn't change the amount, the provider should stop sending it
+	if strings.Contains(err.Error(), "doesn't match the total") {
+		return http.StatusUnprocessableEntity
+	}
+	if strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
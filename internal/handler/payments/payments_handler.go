@@ -0,0 +1,90 @@
+package payments
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// headerSignature carries the signature of the webhook payload
+const headerSignature = "X-Signature"
+
+//go:generate mockgen -package=payments -source=payments_handler.go -destination=payments_handler_mock_test.go
+type paymentsUsecase interface {
+	Pay(ctx context.Context, req payments.PayRequest) (*payments.Model, error)
+	HandleWebhook(ctx context.Context, payload []byte, signature string) error
+}
+
+type Handler struct {
+	paymentsUsecase paymentsUsecase
+}
+
+func New(paymentsUsecase paymentsUsecase) *Handler {
+	return &Handler{paymentsUsecase: paymentsUsecase}
+}
+
+func (h *Handler) Pay(c echo.Context) error {
+	response := payments.PaymentResponse{}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid order id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request payments.PayRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.OrderID = orderID
+	request.UserID = userID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	payment, err := h.paymentsUsecase.Pay(c.Request().Context(), request)
+	if err != nil {
+		statusCode := PayCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Payment = payment
+	return c.JSON(http.StatusOK, response)
+}
+
+// Webhook receives the callbacks of the payment provider, the raw body is needed as it is what the provider signed
+func (h *Handler) Webhook(c echo.Context) error {
+	res := response.BaseResponse{}
+
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		res.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, res)
+	}
+
+	err = h.paymentsUsecase.HandleWebhook(c.Request().Context(), payload, c.Request().Header.Get(headerSignature))
+	if err != nil {
+		statusCode := WebhookCustomErrorHTTPCode(err)
+		res.Error = err.Error()
+		return c.JSON(statusCode, res)
+	}
+	res.Result = true
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: payments_handler.go
+
+// Package payments is a generated GoMock package.
+package payments
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	payments "github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+)
+
+// MockpaymentsUsecase is a mock of paymentsUsecase interface.
+type MockpaymentsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockpaymentsUsecaseMockRecorder
+}
+
+// MockpaymentsUsecaseMockRecorder is the mock recorder for MockpaymentsUsecase.
+type MockpaymentsUsecaseMockRecorder struct {
+	mock *MockpaymentsUsecase
+}
+
+// NewMockpaymentsUsecase creates a new mock instance.
+func NewMockpaymentsUsecase(ctrl *gomock.Controller) *MockpaymentsUsecase {
+	mock := &MockpaymentsUsecase{ctrl: ctrl}
+	mock.recorder = &MockpaymentsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpaymentsUsecase) EXPECT() *MockpaymentsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// HandleWebhook mocks base method.
+func (m *MockpaymentsUsecase) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleWebhook", ctx, payload, signature)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleWebhook indicates an expected call of HandleWebhook.
+func (mr *MockpaymentsUsecaseMockRecorder) HandleWebhook(ctx, payload, signature interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleWebhook", reflect.TypeOf((*MockpaymentsUsecase)(nil).HandleWebhook), ctx, payload, signature)
+}
+
+// Pay mocks base method.
+func (m *MockpaymentsUsecase) Pay(ctx context.Context, req payments.PayRequest) (*payments.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pay", ctx, req)
+	ret0, _ := ret[0].(*payments.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pay indicates an expected call of Pay.
+func (mr *MockpaymentsUsecaseMockRecorder) Pay(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pay", reflect.TypeOf((*MockpaymentsUsecase)(nil).Pay), ctx, req)
+}
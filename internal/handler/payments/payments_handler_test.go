@@ -0,0 +1,169 @@
+package payments
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CustomValidator struct {
+	validator *validator.Validate
+}
+
+func (cv *CustomValidator) Validate(i interface{}) error {
+	return cv.validator.Struct(i)
+}
+
+func TestHandler_Pay(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPaymentsUC := NewMockpaymentsUsecase(mockCtrl)
+
+	type args struct {
+		orderID string
+		payload string
+		userID  int64
+	}
+	tests := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+		want           string
+		mockFn         func(args args)
+	}{
+		{
+			name:           "error invalid order id",
+			args:           args{orderID: "abc", payload: `{"payment_token":"tok_visa"}`, userID: 2},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"invalid order id", "payment":null, "result":false}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error missing payment token",
+			args:           args{orderID: "1", payload: `{}`, userID: 2},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"Key: 'PayRequest.PaymentToken' Error:Field validation for 'PaymentToken' failed on the 'required' tag", "payment":null, "result":false}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error declined",
+			args:           args{orderID: "1", payload: `{"payment_token":"tok_declined"}`, userID: 2},
+			wantStatusCode: http.StatusPaymentRequired,
+			want:           `{"error":"payment was declined: card declined", "payment":null, "result":false}`,
+			mockFn: func(args args) {
+				mockPaymentsUC.EXPECT().Pay(gomock.Any(), gomock.Any()).Return(nil, errors.New("payment was declined: card declined"))
+			},
+		},
+		{
+			name:           "error order already paid",
+			args:           args{orderID: "1", payload: `{"payment_token":"tok_visa"}`, userID: 2},
+			wantStatusCode: http.StatusConflict,
+			want:           `{"error":"order with id: 1 can't be paid, its status is PAID", "payment":null, "result":false}`,
+			mockFn: func(args args) {
+				mockPaymentsUC.EXPECT().Pay(gomock.Any(), gomock.Any()).Return(nil, errors.New("order with id: 1 can't be paid, its status is PAID"))
+			},
+		},
+		{
+			name:           "success",
+			args:           args{orderID: "1", payload: `{"payment_token":"tok_visa"}`, userID: 2},
+			wantStatusCode: http.StatusOK,
+			want: `{"result":true, "payment":{"payment_id":7, "order_id":1, "provider":"fake", "reference":"fake_1_1",
+				"amount":19.98, "refunded_amount":0, "status":"CAPTURED", "created_at":1000, "updated_at":1001}}`,
+			mockFn: func(args args) {
+				mockPaymentsUC.EXPECT().Pay(gomock.Any(), payments.PayRequest{OrderID: 1, UserID: 2, PaymentToken: "tok_visa"}).
+					Return(&payments.Model{ID: 7, OrderID: 1, Provider: "fake", Reference: "fake_1_1", Amount: money.MustParse("19.98"),
+						Status: "CAPTURED", CreatedAt: 1000, UpdatedAt: 1001}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			h := &Handler{
+				paymentsUsecase: mockPaymentsUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/order/"+tt.args.orderID+"/pay", strings.NewReader(tt.args.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.args.orderID)
+			if tt.args.userID != 0 {
+				c.Set("userID", tt.args.userID)
+			}
+			if assert.NoError(t, h.Pay(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Webhook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPaymentsUC := NewMockpaymentsUsecase(mockCtrl)
+	payload := `{"type":"payment.captured","reference":"fake_1_1","amount":19.98}`
+
+	tests := []struct {
+		name           string
+		wantStatusCode int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error invalid signature",
+			wantStatusCode: http.StatusUnauthorized,
+			want:           `{"error":"invalid webhook signature", "result":false}`,
+			mockFn: func() {
+				mockPaymentsUC.EXPECT().HandleWebhook(gomock.Any(), []byte(payload), "abc").Return(errors.New("invalid webhook signature"))
+			},
+		},
+		{
+			name:           "error payment not found",
+			wantStatusCode: http.StatusNotFound,
+			want:           `{"error":"payment with reference: fake_1_1 is not found", "result":false}`,
+			mockFn: func() {
+				mockPaymentsUC.EXPECT().HandleWebhook(gomock.Any(), []byte(payload), "abc").Return(errors.New("payment with reference: fake_1_1 is not found"))
+			},
+		},
+		{
+			name:           "success",
+			wantStatusCode: http.StatusOK,
+			want:           `{"result":true}`,
+			mockFn: func() {
+				mockPaymentsUC.EXPECT().HandleWebhook(gomock.Any(), []byte(payload), "abc").Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				paymentsUsecase: mockPaymentsUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/payments/webhook", strings.NewReader(payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("X-Signature", "abc")
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.Webhook(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package promotions
+
+import (
+	"net/http"
+	"strings"
+)
+
+func PromotionCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "is not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already exists"), strings.Contains(err.Error(), "is referenced by existing orders"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,135 @@
+package promotions
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"net/http"
+	"strconv"
+)
+
+//go:generate mockgen -package=promotions -source=promotions_handler.go -destination=promotions_handler_mock_test.go
+type promotionsUsecase interface {
+	GetPromotions(ctx context.Context) ([]promotions.Model, error)
+	GetPromotionByID(ctx context.Context, id int64) (*promotions.Model, error)
+	CreatePromotion(ctx context.Context, req promotions.CreatePromotionRequest) (*promotions.Model, error)
+	UpdatePromotion(ctx context.Context, req promotions.UpdatePromotionRequest) (*promotions.Model, error)
+	DeletePromotion(ctx context.Context, id int64) error
+}
+
+type Handler struct {
+	promotionsUsecase promotionsUsecase
+}
+
+func New(promotionsUsecase promotionsUsecase) *Handler {
+	return &Handler{promotionsUsecase: promotionsUsecase}
+}
+
+func (h *Handler) GetPromotions(c echo.Context) error {
+	response := promotions.PromotionsResponse{}
+	list, err := h.promotionsUsecase.GetPromotions(c.Request().Context())
+	if err != nil {
+		statusCode := PromotionCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Promotions = list
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetPromotion(c echo.Context) error {
+	response := promotions.PromotionResponse{}
+	promotionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid promotion id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	promotion, err := h.promotionsUsecase.GetPromotionByID(c.Request().Context(), promotionID)
+	if err != nil {
+		statusCode := PromotionCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Promotion = promotion
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreatePromotion(c echo.Context) error {
+	response := promotions.PromotionResponse{}
+	var request promotions.CreatePromotionRequest
+	err := c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	promotion, err := h.promotionsUsecase.CreatePromotion(c.Request().Context(), request)
+	if err != nil {
+		statusCode := PromotionCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Promotion = promotion
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (h *Handler) UpdatePromotion(c echo.Context) error {
+	response := promotions.PromotionResponse{}
+	promotionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid promotion id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request promotions.UpdatePromotionRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.ID = promotionID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	promotion, err := h.promotionsUsecase.UpdatePromotion(c.Request().Context(), request)
+	if err != nil {
+		statusCode := PromotionCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Promotion = promotion
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) DeletePromotion(c echo.Context) error {
+	response := promotions.PromotionResponse{}
+	promotionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid promotion id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.promotionsUsecase.DeletePromotion(c.Request().Context(), promotionID)
+	if err != nil {
+		statusCode := PromotionCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	return c.JSON(http.StatusOK, response)
+}
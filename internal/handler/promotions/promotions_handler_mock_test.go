@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: promotions_handler.go
+
+// Package promotions is a generated GoMock package.
+package promotions
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	promotions "github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+)
+
+// MockpromotionsUsecase is a mock of promotionsUsecase interface.
+type MockpromotionsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockpromotionsUsecaseMockRecorder
+}
+
+// MockpromotionsUsecaseMockRecorder is the mock recorder for MockpromotionsUsecase.
+type MockpromotionsUsecaseMockRecorder struct {
+	mock *MockpromotionsUsecase
+}
+
+// NewMockpromotionsUsecase creates a new mock instance.
+func NewMockpromotionsUsecase(ctrl *gomock.Controller) *MockpromotionsUsecase {
+	mock := &MockpromotionsUsecase{ctrl: ctrl}
+	mock.recorder = &MockpromotionsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpromotionsUsecase) EXPECT() *MockpromotionsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreatePromotion mocks base method.
+func (m *MockpromotionsUsecase) CreatePromotion(ctx context.Context, req promotions.CreatePromotionRequest) (*promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePromotion", ctx, req)
+	ret0, _ := ret[0].(*promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePromotion indicates an expected call of CreatePromotion.
+func (mr *MockpromotionsUsecaseMockRecorder) CreatePromotion(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePromotion", reflect.TypeOf((*MockpromotionsUsecase)(nil).CreatePromotion), ctx, req)
+}
+
+// DeletePromotion mocks base method.
+func (m *MockpromotionsUsecase) DeletePromotion(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePromotion", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePromotion indicates an expected call of DeletePromotion.
+func (mr *MockpromotionsUsecaseMockRecorder) DeletePromotion(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePromotion", reflect.TypeOf((*MockpromotionsUsecase)(nil).DeletePromotion), ctx, id)
+}
+
+// GetPromotionByID mocks base method.
+func (m *MockpromotionsUsecase) GetPromotionByID(ctx context.Context, id int64) (*promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotionByID", ctx, id)
+	ret0, _ := ret[0].(*promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotionByID indicates an expected call of GetPromotionByID.
+func (mr *MockpromotionsUsecaseMockRecorder) GetPromotionByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotionByID", reflect.TypeOf((*MockpromotionsUsecase)(nil).GetPromotionByID), ctx, id)
+}
+
+// GetPromotions mocks base method.
+func (m *MockpromotionsUsecase) GetPromotions(ctx context.Context) ([]promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotions", ctx)
+	ret0, _ := ret[0].([]promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotions indicates an expected call of GetPromotions.
+func (mr *MockpromotionsUsecaseMockRecorder) GetPromotions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotions", reflect.TypeOf((*MockpromotionsUsecase)(nil).GetPromotions), ctx)
+}
+
+// UpdatePromotion mocks base method.
+func (m *MockpromotionsUsecase) UpdatePromotion(ctx context.Context, req promotions.UpdatePromotionRequest) (*promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePromotion", ctx, req)
+	ret0, _ := ret[0].(*promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePromotion indicates an expected call of UpdatePromotion.
+func (mr *MockpromotionsUsecaseMockRecorder) UpdatePromotion(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePromotion", reflect.TypeOf((*MockpromotionsUsecase)(nil).UpdatePromotion), ctx, req)
+}
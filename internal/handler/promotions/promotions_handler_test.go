@@ -0,0 +1,214 @@
+package promotions
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CustomValidator struct {
+	validator *validator.Validate
+}
+
+func (cv *CustomValidator) Validate(i interface{}) error {
+	return cv.validator.Struct(i)
+}
+
+func TestHandler_CreatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPromotionsUC := NewMockpromotionsUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate",
+			payload:        `{"code":"ORWELL","discount_type":"BOGO","discount_value":10}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'CreatePromotionRequest.DiscountType' Error:Field validation for 'DiscountType' failed on the 'oneof' tag","promotion":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error invalid rule",
+			payload:        `{"code":"ORWELL","discount_type":"PERCENTAGE","discount_value":120}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid discount_value, a percentage can't be more than 100","promotion":null}`,
+			mockFn: func() {
+				mockPromotionsUC.EXPECT().CreatePromotion(gomock.Any(), gomock.Any()).Return(nil, errors.New("invalid discount_value, a percentage can't be more than 100"))
+			},
+		},
+		{
+			name:           "error code already exists",
+			payload:        `{"code":"ORWELL","discount_type":"PERCENTAGE","discount_value":10}`,
+			expectedStatus: http.StatusConflict,
+			want:           `{"result":false,"error":"promo code ORWELL already exists","promotion":null}`,
+			mockFn: func() {
+				mockPromotionsUC.EXPECT().CreatePromotion(gomock.Any(), gomock.Any()).Return(nil, errors.New("promo code ORWELL already exists"))
+			},
+		},
+		{
+			name:           "success",
+			payload:        `{"code":"ORWELL","discount_type":"PERCENTAGE","discount_value":10,"authors":["George Orwell"],"per_user_limit":1}`,
+			expectedStatus: http.StatusCreated,
+			want: `{"result":true,"promotion":{"promotion_id":4,"code":"ORWELL","description":"","discount_type":"PERCENTAGE","discount_value":10.00,
+				"max_discount":0.00,"min_spend":0.00,"book_ids":[],"authors":["George Orwell"],"starts_at":1700000000000,"ends_at":0,"usage_limit":0,
+				"per_user_limit":1,"used_count":0,"created_at":1700000000000,"updated_at":1700000000000}}`,
+			mockFn: func() {
+				mockPromotionsUC.EXPECT().CreatePromotion(gomock.Any(), promotions.CreatePromotionRequest{
+					Code:               "ORWELL",
+					DiscountType:       "PERCENTAGE",
+					DiscountPercentage: percent.MustParse("10"),
+					Authors:            []string{"George Orwell"},
+					PerUserLimit:       1,
+				}).Return(&promotions.Model{
+					ID: 4, Code: "ORWELL", DiscountType: "PERCENTAGE", DiscountPercentage: percent.MustParse("10"), BookIDs: []int64{},
+					Authors: []string{"George Orwell"}, StartsAt: 1700000000000, PerUserLimit: 1, CreatedAt: 1700000000000, UpdatedAt: 1700000000000,
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				promotionsUsecase: mockPromotionsUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/promotions", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.CreatePromotion(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_UpdatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPromotionsUC := NewMockpromotionsUsecase(mockCtrl)
+
+	type args struct {
+		id      string
+		payload string
+	}
+	tests := []struct {
+		name           string
+		args           args
+		expectedStatus int
+		want           string
+		mockFn         func(args args)
+	}{
+		{
+			name:           "error invalid id",
+			args:           args{id: "abc", payload: `{}`},
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid promotion id","promotion":null}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error promotion not found",
+			args:           args{id: "99", payload: `{"code":"FIVE","discount_type":"FIXED","discount_value":5}`},
+			expectedStatus: http.StatusNotFound,
+			want:           `{"result":false,"error":"promotion with id: 99 is not found","promotion":null}`,
+			mockFn: func(args args) {
+				mockPromotionsUC.EXPECT().UpdatePromotion(gomock.Any(), promotions.UpdatePromotionRequest{
+					ID:                     99,
+					CreatePromotionRequest: promotions.CreatePromotionRequest{Code: "FIVE", DiscountType: "FIXED", DiscountAmount: money.MustParse("5")},
+				}).Return(nil, errors.New("promotion with id: 99 is not found"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			h := &Handler{
+				promotionsUsecase: mockPromotionsUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPut, "/promotions/"+tt.args.id, strings.NewReader(tt.args.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.args.id)
+			if assert.NoError(t, h.UpdatePromotion(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_DeletePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPromotionsUC := NewMockpromotionsUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error referenced by orders",
+			id:             "4",
+			expectedStatus: http.StatusConflict,
+			want:           `{"result":false,"error":"promotion with id: 4 is referenced by existing orders","promotion":null}`,
+			mockFn: func() {
+				mockPromotionsUC.EXPECT().DeletePromotion(gomock.Any(), int64(4)).Return(errors.New("promotion with id: 4 is referenced by existing orders"))
+			},
+		},
+		{
+			name:           "success",
+			id:             "4",
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"promotion":null}`,
+			mockFn: func() {
+				mockPromotionsUC.EXPECT().DeletePromotion(gomock.Any(), int64(4)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				promotionsUsecase: mockPromotionsUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/promotions/"+tt.id, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			if assert.NoError(t, h.DeletePromotion(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package returns
+
+import (
+	"net/http"
+	"strings"
+)
+
+func CreateReturnCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "order with id") && strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	if strings.Contains(err.Error(), "can't be returned") || strings.Contains(err.Error(), "already has an open return") {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "order item with id") || strings.Contains(err.Error(), "can be returned") {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func GetReturnsCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func UpdateReturnStatusCustomErrorHTTPCode(err error) int {
+	if strings.Contains(err.Error(), "is not found") {
+		return http.StatusNotFound
+	}
+	if strings.Contains(err.Error(), "is already") || strings.Contains(err.Error(), "updated by another request") ||
+		strings.Contains(err.Error(), "can't be refunded") {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "refund of return") {
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,139 @@
+package returns
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"net/http"
+	"strconv"
+)
+
+//go:generate mockgen -package=returns -source=returns_handler.go -destination=returns_handler_mock_test.go
+type returnsUsecase interface {
+	CreateReturn(ctx context.Context, req returns.CreateReturnRequest) (*returns.Model, error)
+	GetReturns(ctx context.Context, orderID, userID int64, role string) ([]returns.Model, error)
+	UpdateReturnStatus(ctx context.Context, req returns.UpdateReturnStatusRequest) (*returns.Model, error)
+}
+
+type Handler struct {
+	returnsUsecase returnsUsecase
+}
+
+func New(returnsUsecase returnsUsecase) *Handler {
+	return &Handler{returnsUsecase: returnsUsecase}
+}
+
+func (h *Handler) CreateReturn(c echo.Context) error {
+	response := returns.ReturnResponse{}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid order id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request returns.CreateReturnRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.OrderID = orderID
+	request.UserID = userID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	created, err := h.returnsUsecase.CreateReturn(c.Request().Context(), request)
+	if err != nil {
+		statusCode := CreateReturnCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Return = created
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (h *Handler) GetReturns(c echo.Context) error {
+	var response returns.ReturnsResponse
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid order id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	role, err := util.GetRole(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	returnList, err := h.returnsUsecase.GetReturns(c.Request().Context(), orderID, userID, role)
+	if err != nil {
+		statusCode := GetReturnsCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+
+	response.Returns = returnList
+	response.Result = true
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) UpdateReturnStatus(c echo.Context) error {
+	response := returns.ReturnResponse{}
+
+	returnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid return id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	actorID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request returns.UpdateReturnStatusRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.ReturnID = returnID
+	request.ActorID = actorID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	updated, err := h.returnsUsecase.UpdateReturnStatus(c.Request().Context(), request)
+	if err != nil {
+		statusCode := UpdateReturnStatusCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Return = updated
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: returns_handler.go
+
+// Package returns is a generated GoMock package.
+package returns
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	returns "github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+)
+
+// MockreturnsUsecase is a mock of returnsUsecase interface.
+type MockreturnsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockreturnsUsecaseMockRecorder
+}
+
+// MockreturnsUsecaseMockRecorder is the mock recorder for MockreturnsUsecase.
+type MockreturnsUsecaseMockRecorder struct {
+	mock *MockreturnsUsecase
+}
+
+// NewMockreturnsUsecase creates a new mock instance.
+func NewMockreturnsUsecase(ctrl *gomock.Controller) *MockreturnsUsecase {
+	mock := &MockreturnsUsecase{ctrl: ctrl}
+	mock.recorder = &MockreturnsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockreturnsUsecase) EXPECT() *MockreturnsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreateReturn mocks base method.
+func (m *MockreturnsUsecase) CreateReturn(ctx context.Context, req returns.CreateReturnRequest) (*returns.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReturn", ctx, req)
+	ret0, _ := ret[0].(*returns.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReturn indicates an expected call of CreateReturn.
+func (mr *MockreturnsUsecaseMockRecorder) CreateReturn(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReturn", reflect.TypeOf((*MockreturnsUsecase)(nil).CreateReturn), ctx, req)
+}
+
+// GetReturns mocks base method.
+func (m *MockreturnsUsecase) GetReturns(ctx context.Context, orderID, userID int64, role string) ([]returns.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReturns", ctx, orderID, userID, role)
+	ret0, _ := ret[0].([]returns.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReturns indicates an expected call of GetReturns.
+func (mr *MockreturnsUsecaseMockRecorder) GetReturns(ctx, orderID, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReturns", reflect.TypeOf((*MockreturnsUsecase)(nil).GetReturns), ctx, orderID, userID, role)
+}
+
+// UpdateReturnStatus mocks base method.
+func (m *MockreturnsUsecase) UpdateReturnStatus(ctx context.Context, req returns.UpdateReturnStatusRequest) (*returns.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReturnStatus", ctx, req)
+	ret0, _ := ret[0].(*returns.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateReturnStatus indicates an expected call of UpdateReturnStatus.
+func (mr *MockreturnsUsecaseMockRecorder) UpdateReturnStatus(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReturnStatus", reflect.TypeOf((*MockreturnsUsecase)(nil).UpdateReturnStatus), ctx, req)
+}
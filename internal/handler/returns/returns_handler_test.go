@@ -0,0 +1,190 @@
+package returns
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CustomValidator struct {
+	validator *validator.Validate
+}
+
+func (cv *CustomValidator) Validate(i interface{}) error {
+	return cv.validator.Struct(i)
+}
+
+func TestHandler_CreateReturn(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockReturnsUC := NewMockreturnsUsecase(mockCtrl)
+
+	type args struct {
+		orderID string
+		payload string
+		userID  int64
+	}
+	tests := []struct {
+		name           string
+		args           args
+		wantStatusCode int
+		want           string
+		mockFn         func(args args)
+	}{
+		{
+			name:           "error invalid order id",
+			args:           args{orderID: "abc", userID: 2},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"invalid order id", "result":false, "return":null}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error missing reason",
+			args:           args{orderID: "1", payload: `{"items":[{"order_item_id":5,"quantity":1}]}`, userID: 2},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"Key: 'CreateReturnRequest.Items[0].Reason' Error:Field validation for 'Reason' failed on the 'required' tag", "result":false, "return":null}`,
+			mockFn:         func(args args) {},
+		},
+		{
+			name:           "error order not delivered",
+			args:           args{orderID: "1", payload: `{"items":[{"order_item_id":5,"quantity":1,"reason":"damaged"}]}`, userID: 2},
+			wantStatusCode: http.StatusConflict,
+			want:           `{"error":"order with id: 1 can't be returned, its status is SHIPPED", "result":false, "return":null}`,
+			mockFn: func(args args) {
+				mockReturnsUC.EXPECT().CreateReturn(gomock.Any(), gomock.Any()).Return(nil, errors.New("order with id: 1 can't be returned, its status is SHIPPED"))
+			},
+		},
+		{
+			name:           "error too many returned",
+			args:           args{orderID: "1", payload: `{"items":[{"order_item_id":5,"quantity":3,"reason":"damaged"}]}`, userID: 2},
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"only 2 of order item with id: 5 can be returned", "result":false, "return":null}`,
+			mockFn: func(args args) {
+				mockReturnsUC.EXPECT().CreateReturn(gomock.Any(), gomock.Any()).Return(nil, errors.New("only 2 of order item with id: 5 can be returned"))
+			},
+		},
+		{
+			name:           "success",
+			args:           args{orderID: "1", payload: `{"items":[{"order_item_id":5,"quantity":1,"reason":"damaged"}]}`, userID: 2},
+			wantStatusCode: http.StatusCreated,
+			want: `{"result":true, "return":{"return_id":4, "order_id":1, "user_id":2, "status":"REQUESTED", "refund_amount":9.99,
+				"created_at":1000, "updated_at":1000, "items":[{"return_item_id":8, "order_item_id":5, "book_id":3, "quantity":1,
+				"reason":"damaged", "refund_amount":9.99}]}}`,
+			mockFn: func(args args) {
+				mockReturnsUC.EXPECT().CreateReturn(gomock.Any(), returns.CreateReturnRequest{
+					OrderID: 1,
+					UserID:  2,
+					Items:   []returns.CreateReturnItem{{OrderItemID: 5, Quantity: 1, Reason: "damaged"}},
+				}).Return(&returns.Model{
+					ID: 4, OrderID: 1, UserID: 2, Status: "REQUESTED", RefundAmount: money.MustParse("9.99"), CreatedAt: 1000, UpdatedAt: 1000,
+					Items: []returns.Item{{ID: 8, ReturnID: 4, OrderItemID: 5, BookID: 3, Quantity: 1, Reason: "damaged", RefundAmount: money.MustParse("9.99")}},
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			h := &Handler{
+				returnsUsecase: mockReturnsUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/order/"+tt.args.orderID+"/returns", strings.NewReader(tt.args.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.args.orderID)
+			if tt.args.userID != 0 {
+				c.Set("userID", tt.args.userID)
+			}
+			if assert.NoError(t, h.CreateReturn(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_UpdateReturnStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockReturnsUC := NewMockreturnsUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		wantStatusCode int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error unknown status",
+			payload:        `{"status":"REFUNDED"}`,
+			wantStatusCode: http.StatusBadRequest,
+			want:           `{"error":"Key: 'UpdateReturnStatusRequest.Status' Error:Field validation for 'Status' failed on the 'oneof' tag", "result":false, "return":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error already decided",
+			payload:        `{"status":"APPROVED"}`,
+			wantStatusCode: http.StatusConflict,
+			want:           `{"error":"return with id: 4 is already REJECTED", "result":false, "return":null}`,
+			mockFn: func() {
+				mockReturnsUC.EXPECT().UpdateReturnStatus(gomock.Any(), gomock.Any()).Return(nil, errors.New("return with id: 4 is already REJECTED"))
+			},
+		},
+		{
+			name:           "error refund failed",
+			payload:        `{"status":"APPROVED"}`,
+			wantStatusCode: http.StatusBadGateway,
+			want:           `{"error":"refund of return with id: 4 failed: provider is down", "result":false, "return":null}`,
+			mockFn: func() {
+				mockReturnsUC.EXPECT().UpdateReturnStatus(gomock.Any(), gomock.Any()).Return(nil, errors.New("refund of return with id: 4 failed: provider is down"))
+			},
+		},
+		{
+			name:           "success",
+			payload:        `{"status":"APPROVED","note":"ok"}`,
+			wantStatusCode: http.StatusOK,
+			want: `{"result":true, "return":{"return_id":4, "order_id":1, "user_id":2, "status":"REFUNDED", "refund_amount":9.99,
+				"note":"ok", "created_at":1000, "updated_at":2000, "items":null}}`,
+			mockFn: func() {
+				mockReturnsUC.EXPECT().UpdateReturnStatus(gomock.Any(), returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED", Note: "ok"}).
+					Return(&returns.Model{ID: 4, OrderID: 1, UserID: 2, Status: "REFUNDED", RefundAmount: money.MustParse("9.99"), Note: "ok", CreatedAt: 1000, UpdatedAt: 2000}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				returnsUsecase: mockReturnsUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPatch, "/returns/4/status", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("4")
+			c.Set("userID", int64(9))
+			if assert.NoError(t, h.UpdateReturnStatus(c)) {
+				assert.Equal(t, tt.wantStatusCode, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
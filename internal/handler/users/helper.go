@@ -0,0 +1,55 @@
+package users
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"net/http"
+	"strings"
+)
+
+func EmailVerificationCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "is not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already verified"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func PasswordCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "is not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid current password"):
+		return http.StatusUnauthorized
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func SessionCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "is not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "refresh token"):
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+// getSession returns the user and the session set by the auth middleware
+func getSession(c echo.Context) (int64, string, error) {
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		return 0, "", err
+	}
+	sessionID, err := util.GetSessionID(c)
+	if err != nil {
+		return 0, "", err
+	}
+	return userID, sessionID, nil
+}
@@ -7,12 +7,23 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
 )
 
 //go:generate mockgen -package=users -source=users_handler.go -destination=users_handler_mock_test.go
 type usersUsecase interface {
 	CreateUser(ctx context.Context, req users.CreateUserRequest) (*users.Model, error)
-	Login(ctx context.Context, req users.LoginRequest) (string, error)
+	Login(ctx context.Context, req users.LoginRequest) (*users.Tokens, error)
+	RefreshToken(ctx context.Context, req users.RefreshTokenRequest) (*users.Tokens, error)
+	Logout(ctx context.Context, userID int64, sessionID string) error
+	GetSessions(ctx context.Context, userID int64, currentSessionID string) ([]users.SessionInfo, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) error
+	SendEmailVerification(ctx context.Context, userID int64) error
+	VerifyEmail(ctx context.Context, token string) (*users.Model, error)
+	ForgotPassword(ctx context.Context, req users.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req users.ResetPasswordRequest) error
+	ChangePassword(ctx context.Context, req users.ChangePasswordRequest) (*users.Tokens, error)
 }
 type Handler struct {
 	usersUsecase usersUsecase
@@ -31,6 +42,7 @@ func (h *Handler) CreateUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, response)
 	}
 
+	request.Email = users.NormalizeEmail(request.Email)
 	err = c.Validate(request)
 	if err != nil {
 		response.Error = err.Error()
@@ -42,6 +54,9 @@ func (h *Handler) CreateUser(c echo.Context) error {
 		if strings.EqualFold(err.Error(), "email already exists") {
 			statusCode = http.StatusFound
 		}
+		if strings.Contains(err.Error(), "is not supported") {
+			statusCode = http.StatusBadRequest
+		}
 		response.Error = err.Error()
 		return c.JSON(statusCode, response)
 	}
@@ -64,8 +79,10 @@ func (h *Handler) Login(c echo.Context) error {
 		response.Error = err.Error()
 		return c.JSON(http.StatusBadRequest, response)
 	}
+	request.UserAgent = c.Request().UserAgent()
+	request.IPAddress = c.RealIP()
 
-	token, err := h.usersUsecase.Login(c.Request().Context(), request)
+	tokens, err := h.usersUsecase.Login(c.Request().Context(), request)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "invalid email") {
@@ -75,6 +92,243 @@ func (h *Handler) Login(c echo.Context) error {
 		return c.JSON(statusCode, response)
 	}
 	response.Result = true
-	response.Token = token
+	response.Tokens = tokens
+	return c.JSON(http.StatusOK, response)
+}
+
+// SendEmailVerification emails a new verification link to the logged-in user
+func (h *Handler) SendEmailVerification(c echo.Context) error {
+	response := users.UserResponse{}
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.usersUsecase.SendEmailVerification(c.Request().Context(), userID)
+	if err != nil {
+		statusCode := EmailVerificationCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// VerifyEmail is the link of the verification email, so it is a GET with the token in the query
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	response := users.UserResponse{}
+	var request users.VerifyEmailRequest
+	err := c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	user, err := h.usersUsecase.VerifyEmail(c.Request().Context(), request.Token)
+	if err != nil {
+		statusCode := EmailVerificationCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.User = user
+	return c.JSON(http.StatusOK, response)
+}
+
+// ForgotPassword always responds accepted, so it doesn't tell whether an account exists for the email
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	response := users.PasswordResponse{}
+	var request users.ForgotPasswordRequest
+	err := c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.Email = users.NormalizeEmail(request.Email)
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	err = h.usersUsecase.ForgotPassword(c.Request().Context(), request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusInternalServerError, response)
+	}
+	response.Result = true
+	return c.JSON(http.StatusAccepted, response)
+}
+
+func (h *Handler) ResetPassword(c echo.Context) error {
+	response := users.PasswordResponse{}
+	var request users.ResetPasswordRequest
+	err := c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	err = h.usersUsecase.ResetPassword(c.Request().Context(), request)
+	if err != nil {
+		statusCode := PasswordCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	return c.JSON(http.StatusOK, response)
+}
+
+// ChangePassword responds with the tokens of a new session, the session of the request is logged out along with every other session
+func (h *Handler) ChangePassword(c echo.Context) error {
+	response := users.LoginResponse{}
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request users.ChangePasswordRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.UserID = userID
+	request.UserAgent = c.Request().UserAgent()
+	request.IPAddress = c.RealIP()
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	tokens, err := h.usersUsecase.ChangePassword(c.Request().Context(), request)
+	if err != nil {
+		statusCode := PasswordCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Tokens = tokens
+	return c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken responds with a new access token and a new refresh token, the refresh token of the request can't be used again
+func (h *Handler) RefreshToken(c echo.Context) error {
+	response := users.LoginResponse{}
+	var request users.RefreshTokenRequest
+	err := c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+	request.UserAgent = c.Request().UserAgent()
+	request.IPAddress = c.RealIP()
+
+	tokens, err := h.usersUsecase.RefreshToken(c.Request().Context(), request)
+	if err != nil {
+		statusCode := SessionCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+
+	response.Result = true
+	response.Tokens = tokens
+	return c.JSON(http.StatusOK, response)
+}
+
+// Logout logs out the session of the request, the other sessions of the user stay logged in
+func (h *Handler) Logout(c echo.Context) error {
+	response := users.SessionsResponse{}
+	userID, sessionID, err := getSession(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.usersUsecase.Logout(c.Request().Context(), userID, sessionID)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusInternalServerError, response)
+	}
+
+	response.Result = true
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetSessions lists the devices the user is logged in from, the session of the request is marked as current
+func (h *Handler) GetSessions(c echo.Context) error {
+	response := users.SessionsResponse{}
+	userID, sessionID, err := getSession(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	sessions, err := h.usersUsecase.GetSessions(c.Request().Context(), userID, sessionID)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusInternalServerError, response)
+	}
+
+	response.Result = true
+	response.Sessions = sessions
+	return c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession logs out one of the devices of the user
+func (h *Handler) RevokeSession(c echo.Context) error {
+	response := users.SessionsResponse{}
+	userID, err := util.GetUserID(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.usersUsecase.RevokeSession(c.Request().Context(), userID, c.Param("id"))
+	if err != nil {
+		statusCode := SessionCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+
+	response.Result = true
+	return c.JSON(http.StatusOK, response)
+}
+
+// RevokeOtherSessions logs out every device of the user except the one of the request
+func (h *Handler) RevokeOtherSessions(c echo.Context) error {
+	response := users.SessionsResponse{}
+	userID, sessionID, err := getSession(c)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.usersUsecase.RevokeOtherSessions(c.Request().Context(), userID, sessionID)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusInternalServerError, response)
+	}
+
+	response.Result = true
 	return c.JSON(http.StatusOK, response)
 }
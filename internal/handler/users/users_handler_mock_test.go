@@ -35,6 +35,21 @@ func (m *MockusersUsecase) EXPECT() *MockusersUsecaseMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockusersUsecase) ChangePassword(ctx context.Context, req users.ChangePasswordRequest) (*users.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, req)
+	ret0, _ := ret[0].(*users.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockusersUsecaseMockRecorder) ChangePassword(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockusersUsecase)(nil).ChangePassword), ctx, req)
+}
+
 // CreateUser mocks base method.
 func (m *MockusersUsecase) CreateUser(ctx context.Context, req users.CreateUserRequest) (*users.Model, error) {
 	m.ctrl.T.Helper()
@@ -50,11 +65,40 @@ func (mr *MockusersUsecaseMockRecorder) CreateUser(ctx, req interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockusersUsecase)(nil).CreateUser), ctx, req)
 }
 
+// ForgotPassword mocks base method.
+func (m *MockusersUsecase) ForgotPassword(ctx context.Context, req users.ForgotPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockusersUsecaseMockRecorder) ForgotPassword(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockusersUsecase)(nil).ForgotPassword), ctx, req)
+}
+
+// GetSessions mocks base method.
+func (m *MockusersUsecase) GetSessions(ctx context.Context, userID int64, currentSessionID string) ([]users.SessionInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, userID, currentSessionID)
+	ret0, _ := ret[0].([]users.SessionInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockusersUsecaseMockRecorder) GetSessions(ctx, userID, currentSessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockusersUsecase)(nil).GetSessions), ctx, userID, currentSessionID)
+}
+
 // Login mocks base method.
-func (m *MockusersUsecase) Login(ctx context.Context, req users.LoginRequest) (string, error) {
+func (m *MockusersUsecase) Login(ctx context.Context, req users.LoginRequest) (*users.Tokens, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Login", ctx, req)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(*users.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -64,3 +108,103 @@ func (mr *MockusersUsecaseMockRecorder) Login(ctx, req interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockusersUsecase)(nil).Login), ctx, req)
 }
+
+// Logout mocks base method.
+func (m *MockusersUsecase) Logout(ctx context.Context, userID int64, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockusersUsecaseMockRecorder) Logout(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockusersUsecase)(nil).Logout), ctx, userID, sessionID)
+}
+
+// RefreshToken mocks base method.
+func (m *MockusersUsecase) RefreshToken(ctx context.Context, req users.RefreshTokenRequest) (*users.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, req)
+	ret0, _ := ret[0].(*users.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockusersUsecaseMockRecorder) RefreshToken(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockusersUsecase)(nil).RefreshToken), ctx, req)
+}
+
+// ResetPassword mocks base method.
+func (m *MockusersUsecase) ResetPassword(ctx context.Context, req users.ResetPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockusersUsecaseMockRecorder) ResetPassword(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockusersUsecase)(nil).ResetPassword), ctx, req)
+}
+
+// RevokeOtherSessions mocks base method.
+func (m *MockusersUsecase) RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", ctx, userID, currentSessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockusersUsecaseMockRecorder) RevokeOtherSessions(ctx, userID, currentSessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockusersUsecase)(nil).RevokeOtherSessions), ctx, userID, currentSessionID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockusersUsecase) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockusersUsecaseMockRecorder) RevokeSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockusersUsecase)(nil).RevokeSession), ctx, userID, sessionID)
+}
+
+// SendEmailVerification mocks base method.
+func (m *MockusersUsecase) SendEmailVerification(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendEmailVerification", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendEmailVerification indicates an expected call of SendEmailVerification.
+func (mr *MockusersUsecaseMockRecorder) SendEmailVerification(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendEmailVerification", reflect.TypeOf((*MockusersUsecase)(nil).SendEmailVerification), ctx, userID)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockusersUsecase) VerifyEmail(ctx context.Context, token string) (*users.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(*users.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockusersUsecaseMockRecorder) VerifyEmail(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockusersUsecase)(nil).VerifyEmail), ctx, token)
+}
@@ -67,18 +67,47 @@ func TestHandler_CreateUser(t *testing.T) {
 				mockUsersUC.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil, errors.New("email already exists"))
 			},
 		},
+		{
+			name: "error invalid email",
+			args: args{
+				payload: `{"email":"not an email","password":"password"}`,
+			},
+			want:   `{"result":false,"error":"Key: 'CreateUserRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag","user":null}`,
+			mockFn: func(args args) {},
+		},
+		{
+			name: "error invalid billing country",
+			args: args{
+				payload: `{"email":"email@email.com","password":"password","billing_country":"IDN"}`,
+			},
+			want:   `{"result":false,"error":"Key: 'CreateUserRequest.BillingCountry' Error:Field validation for 'BillingCountry' failed on the 'iso3166_1_alpha2' tag","user":null}`,
+			mockFn: func(args args) {},
+		},
+		{
+			name: "error billing country without tax rate",
+			args: args{
+				payload: `{"email":"email@email.com","password":"password","billing_country":"FR"}`,
+			},
+			want: `{"result":false,"error":"billing country FR is not supported","user":null}`,
+			mockFn: func(args args) {
+				mockUsersUC.EXPECT().CreateUser(gomock.Any(), users.CreateUserRequest{Email: "email@email.com", Password: "password", BillingCountry: "FR"}).
+					Return(nil, errors.New("billing country FR is not supported"))
+			},
+		},
 		{
 			name: "success",
 			args: args{
-				payload: `{"email":"email@email.com","password":"password"}`,
+				payload: `{"email":" Email@Email.com ","password":"password","billing_country":"SG"}`,
 			},
-			want: `{"result":true,"user":{"id":1,"email":"email@email.com","created_at":1714580787000,"updated_at":1714580787000}}`,
+			want: `{"result":true,"user":{"id":1,"email":"email@email.com","role":"customer","billing_country":"SG","email_verified_at":0,"created_at":1714580787000,"updated_at":1714580787000}}`,
 			mockFn: func(args args) {
-				mockUsersUC.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(&users.Model{
-					ID:        1,
-					Email:     "email@email.com",
-					CreatedAt: 1714580787000,
-					UpdatedAt: 1714580787000,
+				mockUsersUC.EXPECT().CreateUser(gomock.Any(), users.CreateUserRequest{Email: "email@email.com", Password: "password", BillingCountry: "SG"}).Return(&users.Model{
+					ID:             1,
+					Email:          "email@email.com",
+					Role:           users.RoleCustomer.String(),
+					BillingCountry: "SG",
+					CreatedAt:      1714580787000,
+					UpdatedAt:      1714580787000,
 				}, nil)
 			},
 		},
@@ -122,7 +151,7 @@ func TestHandler_Login(t *testing.T) {
 			args: args{
 				payload: `failed`,
 			},
-			want: `{"result":false,"error":"code=400, message=Syntax error: offset=3, error=invalid character 'i' in literal false (expecting 'l'), internal=invalid character 'i' in literal false (expecting 'l')","token":""}`,
+			want: `{"result":false,"error":"code=400, message=Syntax error: offset=3, error=invalid character 'i' in literal false (expecting 'l'), internal=invalid character 'i' in literal false (expecting 'l')"}`,
 			mockFn: func(args args) {
 
 			},
@@ -132,7 +161,7 @@ func TestHandler_Login(t *testing.T) {
 			args: args{
 				payload: `{}`,
 			},
-			want: `{"result":false,"error":"Key: 'LoginRequest.Email' Error:Field validation for 'Email' failed on the 'required' tag\nKey: 'LoginRequest.Password' Error:Field validation for 'Password' failed on the 'required' tag","token":""}`,
+			want: `{"result":false,"error":"Key: 'LoginRequest.Email' Error:Field validation for 'Email' failed on the 'required' tag\nKey: 'LoginRequest.Password' Error:Field validation for 'Password' failed on the 'required' tag"}`,
 			mockFn: func(args args) {
 
 			},
@@ -142,9 +171,9 @@ func TestHandler_Login(t *testing.T) {
 			args: args{
 				payload: `{"email":"email@email.com","password":"12345"}`,
 			},
-			want: `{"result":false,"error":"invalid email or password","token":""}`,
+			want: `{"result":false,"error":"invalid email or password"}`,
 			mockFn: func(args args) {
-				mockUsersUC.EXPECT().Login(gomock.Any(), gomock.Any()).Return("", errors.New("invalid email or password"))
+				mockUsersUC.EXPECT().Login(gomock.Any(), gomock.Any()).Return(nil, errors.New("invalid email or password"))
 			},
 		},
 		{
@@ -152,9 +181,10 @@ func TestHandler_Login(t *testing.T) {
 			args: args{
 				payload: `{"email":"email@email.com","password":"12345"}`,
 			},
-			want: `{"result":true,"token":"token"}`,
+			want: `{"result":true,"token":"token","refresh_token":"1.a1.secret","expires_in":900}`,
 			mockFn: func(args args) {
-				mockUsersUC.EXPECT().Login(gomock.Any(), gomock.Any()).Return("token", nil)
+				mockUsersUC.EXPECT().Login(gomock.Any(), users.LoginRequest{Email: "email@email.com", Password: "12345", UserAgent: "Firefox",
+					IPAddress: "192.0.2.1"}).Return(&users.Tokens{Token: "token", RefreshToken: "1.a1.secret", ExpiresIn: 900}, nil)
 			},
 		},
 	}
@@ -168,6 +198,7 @@ func TestHandler_Login(t *testing.T) {
 			e.Validator = &CustomValidator{validator: validator.New()}
 			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.args.payload))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("User-Agent", "Firefox")
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
 			if assert.NoError(t, h.Login(c)) {
@@ -177,3 +208,483 @@ func TestHandler_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_SendEmailVerification(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error already verified",
+			expectedStatus: http.StatusConflict,
+			want:           `{"result":false,"error":"email is already verified","user":null}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().SendEmailVerification(gomock.Any(), int64(1)).Return(errors.New("email is already verified"))
+			},
+		},
+		{
+			name:           "success",
+			expectedStatus: http.StatusAccepted,
+			want:           `{"result":true,"user":null}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().SendEmailVerification(gomock.Any(), int64(1)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/email/verification", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("userID", int64(1))
+			if assert.NoError(t, h.SendEmailVerification(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_VerifyEmail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'VerifyEmailRequest.Token' Error:Field validation for 'Token' failed on the 'required' tag","user":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error expired token",
+			query:          "?token=abc",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid or expired verification token","user":null}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().VerifyEmail(gomock.Any(), "abc").Return(nil, errors.New("invalid or expired verification token"))
+			},
+		},
+		{
+			name:           "success",
+			query:          "?token=abc",
+			expectedStatus: http.StatusOK,
+			want: `{"result":true,"user":{"id":1,"email":"email@email.com","role":"customer","billing_country":"","email_verified_at":1714580788000,
+				"created_at":1714580787000,"updated_at":1714580788000}}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().VerifyEmail(gomock.Any(), "abc").Return(&users.Model{ID: 1, Email: "email@email.com", Role: "customer",
+					EmailVerifiedAt: 1714580788000, CreatedAt: 1714580787000, UpdatedAt: 1714580788000}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodGet, "/email/verify"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.VerifyEmail(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ForgotPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate",
+			payload:        `{"email":"not an email"}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'ForgotPasswordRequest.Email' Error:Field validation for 'Email' failed on the 'email' tag"}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "success",
+			payload:        `{"email":" Email@Email.com"}`,
+			expectedStatus: http.StatusAccepted,
+			want:           `{"result":true}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().ForgotPassword(gomock.Any(), users.ForgotPasswordRequest{Email: "email@email.com"}).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/password/forgot", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.ForgotPassword(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate short password",
+			payload:        `{"token":"abc","password":"short"}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'ResetPasswordRequest.Password' Error:Field validation for 'Password' failed on the 'min' tag"}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error expired token",
+			payload:        `{"token":"abc","password":"new-password"}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid or expired password reset token"}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().ResetPassword(gomock.Any(), users.ResetPasswordRequest{Token: "abc", Password: "new-password"}).
+					Return(errors.New("invalid or expired password reset token"))
+			},
+		},
+		{
+			name:           "success",
+			payload:        `{"token":"abc","password":"new-password"}`,
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().ResetPassword(gomock.Any(), users.ResetPasswordRequest{Token: "abc", Password: "new-password"}).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/password/reset", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.ResetPassword(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ChangePassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error wrong current password",
+			payload:        `{"current_password":"54321","new_password":"new-password"}`,
+			expectedStatus: http.StatusUnauthorized,
+			want:           `{"result":false,"error":"invalid current password"}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().ChangePassword(gomock.Any(), users.ChangePasswordRequest{UserID: 1, CurrentPassword: "54321",
+					NewPassword: "new-password", IPAddress: "192.0.2.1"}).Return(nil, errors.New("invalid current password"))
+			},
+		},
+		{
+			name:           "success",
+			payload:        `{"current_password":"12345","new_password":"new-password"}`,
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"token":"token","refresh_token":"1.b2.secret","expires_in":900}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().ChangePassword(gomock.Any(), users.ChangePasswordRequest{UserID: 1, CurrentPassword: "12345",
+					NewPassword: "new-password", IPAddress: "192.0.2.1"}).Return(&users.Tokens{Token: "token", RefreshToken: "1.b2.secret", ExpiresIn: 900}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPut, "/me/password", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("userID", int64(1))
+			if assert.NoError(t, h.ChangePassword(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RefreshToken(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate",
+			payload:        `{}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'RefreshTokenRequest.RefreshToken' Error:Field validation for 'RefreshToken' failed on the 'required' tag"}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error reused refresh token",
+			payload:        `{"refresh_token":"1.a1.old"}`,
+			expectedStatus: http.StatusUnauthorized,
+			want:           `{"result":false,"error":"refresh token is already used, the session is logged out, please re-login"}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().RefreshToken(gomock.Any(), users.RefreshTokenRequest{RefreshToken: "1.a1.old", IPAddress: "192.0.2.1"}).
+					Return(nil, errors.New("refresh token is already used, the session is logged out, please re-login"))
+			},
+		},
+		{
+			name:           "success",
+			payload:        `{"refresh_token":"1.a1.secret"}`,
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true,"token":"token","refresh_token":"1.a1.next","expires_in":900}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().RefreshToken(gomock.Any(), users.RefreshTokenRequest{RefreshToken: "1.a1.secret", IPAddress: "192.0.2.1"}).
+					Return(&users.Tokens{Token: "token", RefreshToken: "1.a1.next", ExpiresIn: 900}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/token/refresh", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.RefreshToken(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Logout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+	mockUsersUC.EXPECT().Logout(gomock.Any(), int64(1), "a1").Return(nil)
+
+	h := &Handler{
+		usersUsecase: mockUsersUC,
+	}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", int64(1))
+	c.Set("sessionID", "a1")
+	if assert.NoError(t, h.Logout(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"result":true}`, rec.Body.String())
+	}
+}
+
+func TestHandler_GetSessions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		sessionID      interface{}
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error no session",
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"sessionID not found"}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "success",
+			sessionID:      "a1",
+			expectedStatus: http.StatusOK,
+			want: `{"result":true,"sessions":[{"id":"a1","user_agent":"Firefox","ip_address":"10.0.0.1","created_at":1000,"last_used_at":2000,
+				"expires_at":9000,"current":true}]}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().GetSessions(gomock.Any(), int64(1), "a1").Return([]users.SessionInfo{{ID: "a1", UserAgent: "Firefox",
+					IPAddress: "10.0.0.1", CreatedAt: 1000, LastUsedAt: 2000, ExpiresAt: 9000, Current: true}}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/me/sessions", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("userID", int64(1))
+			if tt.sessionID != nil {
+				c.Set("sessionID", tt.sessionID)
+			}
+			if assert.NoError(t, h.GetSessions(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RevokeSession(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error session not found",
+			expectedStatus: http.StatusNotFound,
+			want:           `{"result":false,"error":"session with id: b2 is not found"}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().RevokeSession(gomock.Any(), int64(1), "b2").Return(errors.New("session with id: b2 is not found"))
+			},
+		},
+		{
+			name:           "success",
+			expectedStatus: http.StatusOK,
+			want:           `{"result":true}`,
+			mockFn: func() {
+				mockUsersUC.EXPECT().RevokeSession(gomock.Any(), int64(1), "b2").Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				usersUsecase: mockUsersUC,
+			}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/me/sessions/b2", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("b2")
+			c.Set("userID", int64(1))
+			if assert.NoError(t, h.RevokeSession(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RevokeOtherSessions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersUC := NewMockusersUsecase(mockCtrl)
+	mockUsersUC.EXPECT().RevokeOtherSessions(gomock.Any(), int64(1), "a1").Return(nil)
+
+	h := &Handler{
+		usersUsecase: mockUsersUC,
+	}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/me/sessions", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set("userID", int64(1))
+	c.Set("sessionID", "a1")
+	if assert.NoError(t, h.RevokeOtherSessions(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"result":true}`, rec.Body.String())
+	}
+}
@@ -0,0 +1,16 @@
+package webhooks
+
+import (
+	"net/http"
+	"strings"
+)
+
+func WebhookCustomErrorHTTPCode(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "is not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
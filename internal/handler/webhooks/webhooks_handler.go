@@ -0,0 +1,178 @@
+package webhooks
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"net/http"
+	"strconv"
+)
+
+//go:generate mockgen -package=webhooks -source=webhooks_handler.go -destination=webhooks_handler_mock_test.go
+type webhooksUsecase interface {
+	GetSubscriptions(ctx context.Context) ([]webhooks.Subscription, error)
+	GetSubscriptionByID(ctx context.Context, id int64) (*webhooks.Subscription, error)
+	CreateSubscription(ctx context.Context, req webhooks.CreateSubscriptionRequest) (*webhooks.Subscription, error)
+	UpdateSubscription(ctx context.Context, req webhooks.UpdateSubscriptionRequest) (*webhooks.Subscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetDeliveries(ctx context.Context, req webhooks.DeliveriesRequest) ([]webhooks.Delivery, response.Pagination, error)
+}
+
+type Handler struct {
+	webhooksUsecase webhooksUsecase
+}
+
+func New(webhooksUsecase webhooksUsecase) *Handler {
+	return &Handler{webhooksUsecase: webhooksUsecase}
+}
+
+func (h *Handler) GetSubscriptions(c echo.Context) error {
+	response := webhooks.SubscriptionsResponse{}
+	list, err := h.webhooksUsecase.GetSubscriptions(c.Request().Context())
+	if err != nil {
+		statusCode := WebhookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Subscriptions = list
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetSubscription(c echo.Context) error {
+	response := webhooks.SubscriptionResponse{}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid webhook subscription id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	subscription, err := h.webhooksUsecase.GetSubscriptionByID(c.Request().Context(), subscriptionID)
+	if err != nil {
+		statusCode := WebhookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Subscription = subscription
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateSubscription(c echo.Context) error {
+	response := webhooks.SubscriptionResponse{}
+	var request webhooks.CreateSubscriptionRequest
+	err := c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	subscription, err := h.webhooksUsecase.CreateSubscription(c.Request().Context(), request)
+	if err != nil {
+		statusCode := WebhookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Subscription = subscription
+	return c.JSON(http.StatusCreated, response)
+}
+
+func (h *Handler) UpdateSubscription(c echo.Context) error {
+	response := webhooks.SubscriptionResponse{}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid webhook subscription id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	var request webhooks.UpdateSubscriptionRequest
+	err = c.Bind(&request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	request.ID = subscriptionID
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	subscription, err := h.webhooksUsecase.UpdateSubscription(c.Request().Context(), request)
+	if err != nil {
+		statusCode := WebhookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Subscription = subscription
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) DeleteSubscription(c echo.Context) error {
+	response := webhooks.SubscriptionResponse{}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid webhook subscription id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	err = h.webhooksUsecase.DeleteSubscription(c.Request().Context(), subscriptionID)
+	if err != nil {
+		statusCode := WebhookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetDeliveries(c echo.Context) error {
+	response := webhooks.DeliveriesResponse{}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error = "invalid webhook subscription id"
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	pageIndex, err := strconv.Atoi(c.QueryParam("page_index"))
+	if err != nil {
+		pageIndex = 1 // default page index is 1 if error
+	}
+	pageSize, err := strconv.Atoi(c.QueryParam("page_size"))
+	if err != nil {
+		pageSize = 10 // default page size is 10 if error
+	}
+
+	request := webhooks.DeliveriesRequest{
+		SubscriptionID: subscriptionID,
+		Status:         c.QueryParam("status"),
+		PageIndex:      pageIndex,
+		PageSize:       pageSize,
+	}
+	err = c.Validate(request)
+	if err != nil {
+		response.Error = err.Error()
+		return c.JSON(http.StatusBadRequest, response)
+	}
+
+	deliveries, pagination, err := h.webhooksUsecase.GetDeliveries(c.Request().Context(), request)
+	if err != nil {
+		statusCode := WebhookCustomErrorHTTPCode(err)
+		response.Error = err.Error()
+		return c.JSON(statusCode, response)
+	}
+	response.Result = true
+	response.Deliveries = deliveries
+	response.Pagination = pagination
+	return c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,127 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: webhooks_handler.go
+
+// Package webhooks is a generated GoMock package.
+package webhooks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	webhooks "github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	response "github.com/yeremiaaryo/gotu-assignment/internal/response"
+)
+
+// MockwebhooksUsecase is a mock of webhooksUsecase interface.
+type MockwebhooksUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockwebhooksUsecaseMockRecorder
+}
+
+// MockwebhooksUsecaseMockRecorder is the mock recorder for MockwebhooksUsecase.
+type MockwebhooksUsecaseMockRecorder struct {
+	mock *MockwebhooksUsecase
+}
+
+// NewMockwebhooksUsecase creates a new mock instance.
+func NewMockwebhooksUsecase(ctrl *gomock.Controller) *MockwebhooksUsecase {
+	mock := &MockwebhooksUsecase{ctrl: ctrl}
+	mock.recorder = &MockwebhooksUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockwebhooksUsecase) EXPECT() *MockwebhooksUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CreateSubscription mocks base method.
+func (m *MockwebhooksUsecase) CreateSubscription(ctx context.Context, req webhooks.CreateSubscriptionRequest) (*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", ctx, req)
+	ret0, _ := ret[0].(*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockwebhooksUsecaseMockRecorder) CreateSubscription(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockwebhooksUsecase)(nil).CreateSubscription), ctx, req)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockwebhooksUsecase) DeleteSubscription(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockwebhooksUsecaseMockRecorder) DeleteSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockwebhooksUsecase)(nil).DeleteSubscription), ctx, id)
+}
+
+// GetDeliveries mocks base method.
+func (m *MockwebhooksUsecase) GetDeliveries(ctx context.Context, req webhooks.DeliveriesRequest) ([]webhooks.Delivery, response.Pagination, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveries", ctx, req)
+	ret0, _ := ret[0].([]webhooks.Delivery)
+	ret1, _ := ret[1].(response.Pagination)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDeliveries indicates an expected call of GetDeliveries.
+func (mr *MockwebhooksUsecaseMockRecorder) GetDeliveries(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveries", reflect.TypeOf((*MockwebhooksUsecase)(nil).GetDeliveries), ctx, req)
+}
+
+// GetSubscriptionByID mocks base method.
+func (m *MockwebhooksUsecase) GetSubscriptionByID(ctx context.Context, id int64) (*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionByID", ctx, id)
+	ret0, _ := ret[0].(*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionByID indicates an expected call of GetSubscriptionByID.
+func (mr *MockwebhooksUsecaseMockRecorder) GetSubscriptionByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionByID", reflect.TypeOf((*MockwebhooksUsecase)(nil).GetSubscriptionByID), ctx, id)
+}
+
+// GetSubscriptions mocks base method.
+func (m *MockwebhooksUsecase) GetSubscriptions(ctx context.Context) ([]webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions", ctx)
+	ret0, _ := ret[0].([]webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockwebhooksUsecaseMockRecorder) GetSubscriptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockwebhooksUsecase)(nil).GetSubscriptions), ctx)
+}
+
+// UpdateSubscription mocks base method.
+func (m *MockwebhooksUsecase) UpdateSubscription(ctx context.Context, req webhooks.UpdateSubscriptionRequest) (*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscription", ctx, req)
+	ret0, _ := ret[0].(*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSubscription indicates an expected call of UpdateSubscription.
+func (mr *MockwebhooksUsecaseMockRecorder) UpdateSubscription(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscription", reflect.TypeOf((*MockwebhooksUsecase)(nil).UpdateSubscription), ctx, req)
+}
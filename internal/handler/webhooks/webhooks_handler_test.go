@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CustomValidator struct {
+	validator *validator.Validate
+}
+
+func (cv *CustomValidator) Validate(i interface{}) error {
+	return cv.validator.Struct(i)
+}
+
+func TestHandler_CreateSubscription(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksUC := NewMockwebhooksUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		payload        string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate unknown event type",
+			payload:        `{"url":"https://partner.example.com/hooks","event_types":["OrderShipped"]}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"Key: 'CreateSubscriptionRequest.EventTypes[0]' Error:Field validation for 'EventTypes[0]' failed on the 'oneof' tag","subscription":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error invalid url",
+			payload:        `{"url":"ftp://partner.example.com/hooks","event_types":["OrderCreated"]}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid url, must be an http or https url","subscription":null}`,
+			mockFn: func() {
+				mockWebhooksUC.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(nil, errors.New("invalid url, must be an http or https url"))
+			},
+		},
+		{
+			name:           "success shows the secret",
+			payload:        `{"url":"https://partner.example.com/hooks","event_types":["OrderCreated"],"description":"fulfilment"}`,
+			expectedStatus: http.StatusCreated,
+			want: `{"result":true,"subscription":{"subscription_id":2,"url":"https://partner.example.com/hooks","secret":"whsec_0123456789abcdef",
+				"event_types":["OrderCreated"],"description":"fulfilment","active":true,"created_at":1000,"updated_at":1000}}`,
+			mockFn: func() {
+				mockWebhooksUC.EXPECT().CreateSubscription(gomock.Any(), webhooks.CreateSubscriptionRequest{
+					URL:         "https://partner.example.com/hooks",
+					EventTypes:  []string{"OrderCreated"},
+					Description: "fulfilment",
+				}).Return(&webhooks.Subscription{ID: 2, URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef",
+					EventTypes: []string{"OrderCreated"}, Description: "fulfilment", Active: true, CreatedAt: 1000, UpdatedAt: 1000}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				webhooksUsecase: mockWebhooksUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if assert.NoError(t, h.CreateSubscription(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_UpdateSubscription(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksUC := NewMockwebhooksUsecase(mockCtrl)
+	inactive := false
+
+	tests := []struct {
+		name           string
+		id             string
+		payload        string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error invalid id",
+			id:             "abc",
+			payload:        `{}`,
+			expectedStatus: http.StatusBadRequest,
+			want:           `{"result":false,"error":"invalid webhook subscription id","subscription":null}`,
+			mockFn:         func() {},
+		},
+		{
+			name:           "error not found",
+			id:             "9",
+			payload:        `{"url":"https://partner.example.com/hooks","event_types":["OrderCreated"],"active":false}`,
+			expectedStatus: http.StatusNotFound,
+			want:           `{"result":false,"error":"webhook subscription with id: 9 is not found","subscription":null}`,
+			mockFn: func() {
+				mockWebhooksUC.EXPECT().UpdateSubscription(gomock.Any(), webhooks.UpdateSubscriptionRequest{
+					ID: 9,
+					CreateSubscriptionRequest: webhooks.CreateSubscriptionRequest{URL: "https://partner.example.com/hooks",
+						EventTypes: []string{"OrderCreated"}, Active: &inactive},
+				}).Return(nil, errors.New("webhook subscription with id: 9 is not found"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				webhooksUsecase: mockWebhooksUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodPut, "/webhooks/"+tt.id, strings.NewReader(tt.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			if assert.NoError(t, h.UpdateSubscription(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetDeliveries(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksUC := NewMockwebhooksUsecase(mockCtrl)
+
+	tests := []struct {
+		name           string
+		id             string
+		query          string
+		expectedStatus int
+		want           string
+		mockFn         func()
+	}{
+		{
+			name:           "error validate status",
+			id:             "2",
+			query:          "?status=LOST",
+			expectedStatus: http.StatusBadRequest,
+			want: `{"result":false,"error":"Key: 'DeliveriesRequest.Status' Error:Field validation for 'Status' failed on the 'oneof' tag",
+				"deliveries":null,"pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
+			mockFn: func() {},
+		},
+		{
+			name:           "error subscription not found",
+			id:             "9",
+			expectedStatus: http.StatusNotFound,
+			want: `{"result":false,"error":"webhook subscription with id: 9 is not found","deliveries":null,
+				"pagination":{"total_items":0,"total_pages":0,"page_index":0,"page_size":0,"has_next":false}}`,
+			mockFn: func() {
+				mockWebhooksUC.EXPECT().GetDeliveries(gomock.Any(), webhooks.DeliveriesRequest{SubscriptionID: 9, PageIndex: 1, PageSize: 10}).
+					Return(nil, response.Pagination{}, errors.New("webhook subscription with id: 9 is not found"))
+			},
+		},
+		{
+			name:           "success",
+			id:             "2",
+			query:          "?status=FAILED&page_index=1&page_size=20",
+			expectedStatus: http.StatusOK,
+			want: `{"result":true,"deliveries":[{"delivery_id":6,"subscription_id":2,"event_id":7,"event_type":"OrderCreated",
+				"payload":{"id":7},"status":"FAILED","attempts":8,"response_status":502,"last_error":"subscriber responded with status 502",
+				"next_attempt_at":5000,"created_at":900,"delivered_at":0}],
+				"pagination":{"total_items":1,"total_pages":1,"page_index":1,"page_size":20,"has_next":false}}`,
+			mockFn: func() {
+				mockWebhooksUC.EXPECT().GetDeliveries(gomock.Any(), webhooks.DeliveriesRequest{SubscriptionID: 2, Status: "FAILED", PageIndex: 1, PageSize: 20}).
+					Return([]webhooks.Delivery{{ID: 6, SubscriptionID: 2, EventID: 7, EventType: "OrderCreated", Payload: json.RawMessage(`{"id":7}`),
+						Status: "FAILED", Attempts: 8, ResponseStatus: 502, LastError: "subscriber responded with status 502", NextAttemptAt: 5000,
+						CreatedAt: 900, URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef"}},
+						response.Pagination{TotalItems: 1, TotalPages: 1, PageIndex: 1, PageSize: 20}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{
+				webhooksUsecase: mockWebhooksUC,
+			}
+			e := echo.New()
+			e.Validator = &CustomValidator{validator: validator.New()}
+			req := httptest.NewRequest(http.MethodGet, "/webhooks/"+tt.id+"/deliveries"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			if assert.NoError(t, h.GetDeliveries(c)) {
+				assert.Equal(t, tt.expectedStatus, rec.Code)
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,19 +1,25 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/yeremiaaryo/gotu-assignment/internal/constant"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/jwt"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
 
 	"github.com/yeremiaaryo/gotu-assignment/internal/response"
 
 	"github.com/labstack/echo/v4"
 )
 
+//go:generate mockgen -package=middleware -source=middleware.go -destination=middleware_mock_test.go
 type redis interface {
 	Get(key string, field ...interface{}) (string, error)
 }
@@ -41,28 +47,65 @@ func (h *Handler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 		tokenString := header[len("Bearer "):]
-		userID, err := jwt.VerifyToken(tokenString, secretKey)
-		if err != nil {
-			// Token is invalid
+		claims, err := jwt.VerifyToken(tokenString, secretKey)
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			// the client should get a new token with its refresh token
 			return c.JSON(http.StatusForbidden, response.BaseResponse{
 				Result: false,
-				Error:  "invalid token",
+				Error:  "token is expired, please refresh it",
 			})
 		}
-		latestToken, err := h.redis.Get(fmt.Sprintf(constant.RedisKeyToken, userID))
-		if err != nil {
+		if err != nil || claims.SessionID == "" {
+			// Token is invalid, tokens issued before sessions existed don't carry one
 			return c.JSON(http.StatusForbidden, response.BaseResponse{
 				Result: false,
-				Error:  "invalid to retrieve token",
+				Error:  "invalid token",
 			})
 		}
-		if latestToken != tokenString {
+		// the session is gone once it is logged out, revoked or expired, its access token stops working right away
+		value, err := h.redis.Get(fmt.Sprintf(constant.RedisKeySessions, claims.ID), claims.SessionID)
+		var session users.Session
+		if err == nil {
+			err = jsoniter.UnmarshalFromString(value, &session)
+		}
+		if err != nil || session.IsExpired(time.Now().UnixMilli()) {
 			return c.JSON(http.StatusForbidden, response.BaseResponse{
 				Result: false,
-				Error:  "token is invalid, please re-login",
+				Error:  "session is logged out or expired, please re-login",
 			})
 		}
-		c.Set("userID", userID)
+		role := claims.Role
+		if role == "" {
+			role = users.RoleCustomer.String()
+		}
+		c.Set("userID", claims.ID)
+		c.Set("role", role)
+		c.Set("sessionID", claims.SessionID)
 		return next(c)
 	}
 }
+
+// RequireRole only lets the request through when the logged-in user has one of the given roles,
+// it reads the role set by AuthMiddleware so it must be attached after it
+func (h *Handler) RequireRole(roles ...users.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, err := util.GetRole(c)
+			if err != nil {
+				return c.JSON(http.StatusForbidden, response.BaseResponse{
+					Result: false,
+					Error:  err.Error(),
+				})
+			}
+			for _, allowed := range roles {
+				if role == allowed.String() {
+					return next(c)
+				}
+			}
+			return c.JSON(http.StatusForbidden, response.BaseResponse{
+				Result: false,
+				Error:  "you don't have permission to access this resource",
+			})
+		}
+	}
+}
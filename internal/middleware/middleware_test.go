@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/jwt"
+)
+
+func TestHandler_AuthMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+	configs.Get().Service.SecretKey = "secret"
+
+	adminToken, _ := jwt.CreateToken(jwt.Claims{ID: 1, Email: "admin@email.com", Role: users.RoleAdmin.String(), SessionID: "a1"}, "secret", time.Minute)
+	noRoleToken, _ := jwt.CreateToken(jwt.Claims{ID: 2, Email: "user@email.com", SessionID: "b2"}, "secret", time.Minute)
+	expiredToken, _ := jwt.CreateToken(jwt.Claims{ID: 1, Email: "admin@email.com", SessionID: "a1"}, "secret", -time.Minute)
+	legacyToken, _ := jwt.CreateToken(jwt.Claims{ID: 1, Email: "admin@email.com"}, "secret", time.Minute)
+	activeSession := `{"id":"a1","user_id":1,"expires_at":` + strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10) + `}`
+
+	tests := []struct {
+		name          string
+		header        string
+		wantStatus    int
+		wantError     string
+		wantRole      string
+		wantSessionID string
+		mockFn        func()
+	}{
+		{
+			name:       "error no token",
+			header:     "",
+			wantStatus: http.StatusForbidden,
+			wantError:  "no token provided",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error invalid token",
+			header:     "Bearer invalid",
+			wantStatus: http.StatusForbidden,
+			wantError:  "invalid token",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error expired token",
+			header:     "Bearer " + expiredToken,
+			wantStatus: http.StatusForbidden,
+			wantError:  "token is expired, please refresh it",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error token without session",
+			header:     "Bearer " + legacyToken,
+			wantStatus: http.StatusForbidden,
+			wantError:  "invalid token",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error session is logged out",
+			header:     "Bearer " + adminToken,
+			wantStatus: http.StatusForbidden,
+			wantError:  "session is logged out or expired, please re-login",
+			mockFn: func() {
+				mockRedis.EXPECT().Get("sessions:1", "a1").Return("", errors.New("redigo: nil returned"))
+			},
+		},
+		{
+			name:       "error session is expired",
+			header:     "Bearer " + adminToken,
+			wantStatus: http.StatusForbidden,
+			wantError:  "session is logged out or expired, please re-login",
+			mockFn: func() {
+				mockRedis.EXPECT().Get("sessions:1", "a1").Return(`{"id":"a1","user_id":1,"expires_at":1000}`, nil)
+			},
+		},
+		{
+			name:          "success with role from token",
+			header:        "Bearer " + adminToken,
+			wantStatus:    http.StatusOK,
+			wantRole:      "admin",
+			wantSessionID: "a1",
+			mockFn: func() {
+				mockRedis.EXPECT().Get("sessions:1", "a1").Return(activeSession, nil)
+			},
+		},
+		{
+			name:          "success token without role defaults to customer",
+			header:        "Bearer " + noRoleToken,
+			wantStatus:    http.StatusOK,
+			wantRole:      "customer",
+			wantSessionID: "b2",
+			mockFn: func() {
+				mockRedis.EXPECT().Get("sessions:2", "b2").Return(activeSession, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			h := &Handler{redis: mockRedis}
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", tt.header)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			var gotRole, gotSessionID interface{}
+			next := func(c echo.Context) error {
+				gotRole = c.Get("role")
+				gotSessionID = c.Get("sessionID")
+				return c.NoContent(http.StatusOK)
+			}
+			if assert.NoError(t, h.AuthMiddleware(next)(c)) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+				if tt.wantError != "" {
+					assert.Contains(t, rec.Body.String(), tt.wantError)
+				}
+				if tt.wantRole != "" {
+					assert.Equal(t, tt.wantRole, gotRole)
+					assert.Equal(t, tt.wantSessionID, gotSessionID)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_RequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       interface{}
+		allowed    []users.Role
+		wantStatus int
+	}{
+		{
+			name:       "error role not set",
+			role:       nil,
+			allowed:    []users.Role{users.RoleAdmin},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "error customer on admin route",
+			role:       "customer",
+			allowed:    []users.Role{users.RoleAdmin},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "success one of the allowed roles",
+			role:       "support",
+			allowed:    []users.Role{users.RoleAdmin, users.RoleSupport},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{}
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.role != nil {
+				c.Set("role", tt.role)
+			}
+
+			next := func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}
+			if assert.NoError(t, h.RequireRole(tt.allowed...)(next)(c)) {
+				assert.Equal(t, tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
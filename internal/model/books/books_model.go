@@ -2,25 +2,143 @@ package books
 
 import (
 	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"net/url"
 	"time"
 )
 
+type SortBy string
+
+const (
+	SortByRelevance         SortBy = "relevance"
+	SortByPriceAsc          SortBy = "price_asc"
+	SortByPriceDesc         SortBy = "price_desc"
+	SortByTitleAsc          SortBy = "title_asc"
+	SortByTitleDesc         SortBy = "title_desc"
+	SortByPublishedDateAsc  SortBy = "published_date_asc"
+	SortByPublishedDateDesc SortBy = "published_date_desc"
+	SortByNewest            SortBy = "newest"
+)
+
+func (s SortBy) String() string {
+	return string(s)
+}
+
+// IsValid checks whether the sort is one of the supported sorts, empty means the default sort
+func (s SortBy) IsValid() bool {
+	switch s {
+	case "", SortByRelevance, SortByPriceAsc, SortByPriceDesc, SortByTitleAsc, SortByTitleDesc,
+		SortByPublishedDateAsc, SortByPublishedDateDesc, SortByNewest:
+		return true
+	}
+	return false
+}
+
 type (
 	Model struct {
-		ID            int64     `json:"id" db:"id"`
-		Title         string    `json:"title" db:"title"`
-		Author        string    `json:"author" db:"author"`
-		ISBN          string    `json:"isbn" db:"isbn"`
-		PublishedDate time.Time `json:"published_date" db:"published_date"`
-		Price         float64   `json:"price" db:"price"`
-		CreatedAt     int64     `json:"-" db:"created_at"`
-		UpdatedAt     int64     `json:"-" db:"updated_at"`
+		ID            int64        `json:"id" db:"id"`
+		Title         string       `json:"title" db:"title"`
+		Author        string       `json:"author" db:"author"`
+		ISBN          string       `json:"isbn" db:"isbn"`
+		PublishedDate time.Time    `json:"published_date" db:"published_date"`
+		Price         money.Amount `json:"price" db:"price"`
+		Stock         int          `json:"stock" db:"stock"`
+		Available     bool         `json:"available" db:"available"`           // whether the book is in stock, computed by the query
+		Relevance     float64      `json:"relevance,omitempty" db:"relevance"` // only filled when searching
+		CreatedAt     int64        `json:"-" db:"created_at"`
+		UpdatedAt     int64        `json:"-" db:"updated_at"`
+	}
+
+	// Cursor is the keyset position of a book in the sorted list, Value is its sort key in text form
+	Cursor struct {
+		Value string `json:"v"`
+		ID    int64  `json:"id"`
+	}
+
+	// Page is a page of the book list, Last is the position of its last book when there is a next page
+	Page struct {
+		Books      []Model `json:"books"`
+		TotalItems int64   `json:"total_items"`
+		HasNext    bool    `json:"has_next"`
+		Last       *Cursor `json:"last,omitempty"`
 	}
 )
 
+// Filter is the structured filter of the book list, nil or empty fields are not filtered
+type Filter struct {
+	Search        string
+	Author        string
+	MinPrice      *money.Amount
+	MaxPrice      *money.Amount
+	PublishedFrom *time.Time
+	PublishedTo   *time.Time
+	Sort          SortBy
+}
+
+// Encode returns a stable representation of every filter, used to build the cache key of the book list
+func (f Filter) Encode() string {
+	values := url.Values{}
+	values.Set("search", f.Search)
+	values.Set("author", f.Author)
+	values.Set("sort", f.Sort.String())
+	if f.MinPrice != nil {
+		values.Set("min_price", f.MinPrice.String())
+	}
+	if f.MaxPrice != nil {
+		values.Set("max_price", f.MaxPrice.String())
+	}
+	if f.PublishedFrom != nil {
+		values.Set("published_from", f.PublishedFrom.Format(time.DateOnly))
+	}
+	if f.PublishedTo != nil {
+		values.Set("published_to", f.PublishedTo.Format(time.DateOnly))
+	}
+	return values.Encode() // keys are sorted so the same filter always gives the same string
+}
+
+// All request struct go below this
+type (
+	CreateBookRequest struct {
+		Title         string       `json:"title" validate:"required"`
+		Author        string       `json:"author" validate:"required"`
+		ISBN          string       `json:"isbn" validate:"required,isbn"`
+		PublishedDate string       `json:"published_date" validate:"required,datetime=2006-01-02"`
+		Price         money.Amount `json:"price" validate:"required,gt=0"`
+		Stock         int          `json:"stock" validate:"gte=0"`
+	}
+
+	UpdateBookRequest struct {
+		ID            int64        `json:"-"`
+		Title         string       `json:"title" validate:"required"`
+		Author        string       `json:"author" validate:"required"`
+		ISBN          string       `json:"isbn" validate:"required,isbn"`
+		PublishedDate string       `json:"published_date" validate:"required,datetime=2006-01-02"`
+		Price         money.Amount `json:"price" validate:"required,gt=0"`
+		Stock         int          `json:"stock" validate:"gte=0"`
+	}
+
+	// PatchBookRequest only updates the fields that are sent, nil means keep the current value
+	PatchBookRequest struct {
+		ID            int64         `json:"-"`
+		Title         *string       `json:"title" validate:"omitempty,min=1"`
+		Author        *string       `json:"author" validate:"omitempty,min=1"`
+		ISBN          *string       `json:"isbn" validate:"omitempty,isbn"`
+		PublishedDate *string       `json:"published_date" validate:"omitempty,datetime=2006-01-02"`
+		Price         *money.Amount `json:"price" validate:"omitempty,gt=0"`
+		Stock         *int          `json:"stock" validate:"omitempty,gte=0"`
+	}
+)
+
+// All response struct go below this
 type (
 	GetBookListResponse struct {
 		response.BaseResponse
-		Books []Model `json:"books"`
+		Books      []Model             `json:"books"`
+		Pagination response.Pagination `json:"pagination"`
+	}
+
+	BookResponse struct {
+		response.BaseResponse
+		Book *Model `json:"book"`
 	}
 )
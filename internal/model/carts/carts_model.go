@@ -0,0 +1,57 @@
+package carts
+
+import (
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+type (
+	// Cart is the cart of a user priced with the current price of its books
+	Cart struct {
+		Items       []Item       `json:"items"`
+		TotalAmount money.Amount `json:"total_amount"`
+	}
+
+	// Item is a book in the cart, Available is false when there isn't enough stock for the quantity anymore
+	Item struct {
+		BookID    int64        `json:"book_id"`
+		Title     string       `json:"title"`
+		Author    string       `json:"author"`
+		Price     money.Amount `json:"price"`
+		Quantity  int          `json:"quantity"`
+		Subtotal  money.Amount `json:"subtotal"`
+		Available bool         `json:"available"`
+	}
+)
+
+// All request struct go below this
+type (
+	// AddItemRequest adds the quantity to what is already in the cart for the book
+	AddItemRequest struct {
+		UserID   int64 `json:"-"`
+		BookID   int64 `json:"book_id" validate:"required"`
+		Quantity int   `json:"quantity" validate:"required,gt=0"`
+	}
+
+	// UpdateItemRequest replaces the quantity of the book in the cart, zero removes the book
+	UpdateItemRequest struct {
+		UserID   int64 `json:"-"`
+		BookID   int64 `json:"book_id" validate:"required"`
+		Quantity int   `json:"quantity" validate:"gte=0"`
+	}
+
+	// CheckoutRequest places the order of the cart, the body with the promo code is optional
+	CheckoutRequest struct {
+		UserID         int64  `json:"-"`
+		IdempotencyKey string `json:"-"`
+		PromoCode      string `json:"promo_code" validate:"max=50"`
+	}
+)
+
+// All response struct go below this
+type (
+	CartResponse struct {
+		response.BaseResponse
+		Cart *Cart `json:"cart"`
+	}
+)
@@ -0,0 +1,43 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+// Record is what is kept under an idempotency key. Fingerprint identifies the request the key was first used with,
+// Response stays empty while that request is still being processed.
+type Record struct {
+	Fingerprint string          `json:"fingerprint"`
+	Response    json.RawMessage `json:"response,omitempty"`
+}
+
+// InProgress tells whether the first request with the key hasn't finished yet
+func (r Record) InProgress() bool {
+	return len(r.Response) == 0
+}
+
+// Replay decodes the response of the first request with the key into response, it fails when the key was first used
+// with another request or that request hasn't finished yet
+func (r Record) Replay(fingerprint string, response interface{}) error {
+	if r.Fingerprint != fingerprint {
+		return errors.New("idempotency key was already used with a different request")
+	}
+	if r.InProgress() {
+		return errors.New("a request with the same idempotency key is still in progress")
+	}
+	return json.Unmarshal(r.Response, response)
+}
+
+// Fingerprint is a digest of the request the client sent, a key reused with a different request is a client bug and
+// not a retry
+func Fingerprint(request interface{}) (string, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
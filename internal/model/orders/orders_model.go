@@ -1,77 +1,358 @@
 package orders
 
-import "github.com/yeremiaaryo/gotu-assignment/internal/response"
+import (
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+)
 
 type OrderStatus string
 
 const (
-	OrderStatusNew OrderStatus = "NEW"
+	OrderStatusNew             OrderStatus = "NEW"
+	OrderStatusAwaitingPayment OrderStatus = "AWAITING_PAYMENT"
+	OrderStatusPaid            OrderStatus = "PAID"
+	OrderStatusShipped         OrderStatus = "SHIPPED"
+	OrderStatusDelivered       OrderStatus = "DELIVERED"
+	OrderStatusCancelled       OrderStatus = "CANCELLED"
+	OrderStatusRefunded        OrderStatus = "REFUNDED"
+	// OrderStatusPartiallyRefunded is a delivered order of which some of the items were returned and refunded
+	OrderStatusPartiallyRefunded OrderStatus = "PARTIALLY_REFUNDED"
 )
 
+// ActorRoleSystem is the actor role of transitions the service makes on its own, e.g. when a payment is captured
+const ActorRoleSystem = "system"
+
+// orderStatusTransitions is the order lifecycle, every status maps to the statuses it can move to.
+// CANCELLED and REFUNDED are final, an order with nothing to pay goes from NEW to PAID right away.
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusNew:               {OrderStatusAwaitingPayment, OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusAwaitingPayment:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:              {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:           {OrderStatusDelivered},
+	OrderStatusDelivered:         {OrderStatusPartiallyRefunded, OrderStatusRefunded},
+	OrderStatusPartiallyRefunded: {OrderStatusRefunded},
+	OrderStatusCancelled:         {},
+	OrderStatusRefunded:          {},
+}
+
+// manualStatusTransitions are the transitions staff make by hand through the status API, the payment and the refund
+// flows make the others since they have to move the money along with the status
+var manualStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusNew:             {OrderStatusCancelled},
+	OrderStatusAwaitingPayment: {OrderStatusCancelled},
+	OrderStatusPaid:            {OrderStatusShipped},
+	OrderStatusShipped:         {OrderStatusDelivered},
+}
+
 func (os OrderStatus) String() string {
 	return string(os)
 }
 
+// IsValid checks whether the status is part of the order lifecycle
+func (os OrderStatus) IsValid() bool {
+	_, ok := orderStatusTransitions[os]
+	return ok
+}
+
+// CanTransitionTo checks whether the order lifecycle allows moving from this status to the next one
+func (os OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, status := range orderStatusTransitions[os] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSetManually checks whether staff may move the order from this status to the next one through the status API
+func (os OrderStatus) CanSetManually(next OrderStatus) bool {
+	for _, status := range manualStatusTransitions[os] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
 type (
 	Model struct {
-		ID          int64   `db:"id"`
-		UserID      int64   `db:"user_id"`
-		TotalAmount float64 `db:"total_amount"`
-		Status      string  `db:"status"`
-		CreatedAt   int64   `db:"created_at"`
-		UpdatedAt   int64   `db:"updated_at"`
+		ID          int64        `db:"id"`
+		UserID      int64        `db:"user_id"`
+		TotalAmount money.Amount `db:"total_amount"`
+		Status      string       `db:"status"`
+		CreatedAt   int64        `db:"created_at"`
+		UpdatedAt   int64        `db:"updated_at"`
 	}
 
 	OrderItem struct {
-		ID        int64   `db:"id"`
-		OrderID   int64   `db:"order_id"`
-		BookID    int64   `db:"book_id"`
-		Quantity  int     `db:"quantity"`
-		Price     float64 `db:"price"`
-		CreatedAt int64   `db:"created_at"`
-		UpdatedAt int64   `db:"updated_at"`
+		ID        int64        `db:"id"`
+		OrderID   int64        `db:"order_id"`
+		BookID    int64        `db:"book_id"`
+		Quantity  int          `db:"quantity"`
+		Price     money.Amount `db:"price"`
+		CreatedAt int64        `db:"created_at"`
+		UpdatedAt int64        `db:"updated_at"`
 	}
 
+	// StatusTransition moves the order from one status to the next, it is only applied when the order
+	// is still at ExpectedUpdatedAt so concurrent updates can't overwrite each other
+	StatusTransition struct {
+		OrderID           int64
+		From              OrderStatus
+		To                OrderStatus
+		ExpectedUpdatedAt int64
+		UpdatedAt         int64
+		ActorID           int64
+		ActorRole         string
+		Note              string
+	}
+
+	// StatusHistory is a transition of the order status, From is empty for the creation of the order
+	StatusHistory struct {
+		ID        int64  `json:"-" db:"id"`
+		OrderID   int64  `json:"-" db:"order_id"`
+		From      string `json:"from_status,omitempty" db:"from_status"`
+		To        string `json:"to_status" db:"to_status"`
+		ActorID   int64  `json:"actor_id,omitempty" db:"actor_id"`
+		ActorRole string `json:"actor_role" db:"actor_role"`
+		Note      string `json:"note,omitempty" db:"note"`
+		CreatedAt int64  `json:"created_at" db:"created_at"`
+	}
+
+	// History is an order of the user, TotalAmount is what was paid: Subtotal with Discount taken off and Tax added
+	// unless the prices included it
 	History struct {
-		ID          int64         `json:"order_id"`
-		TotalAmount float64       `json:"total_amount"`
-		Status      string        `json:"status"`
-		CreatedAt   int64         `json:"created_at"`
-		UpdatedAt   int64         `json:"updated_at"`
-		Items       []ItemHistory `json:"items"`
+		ID           int64         `json:"order_id"`
+		Subtotal     money.Amount  `json:"subtotal"`
+		Discount     money.Amount  `json:"discount"`
+		Tax          money.Amount  `json:"tax"`
+		TaxInclusive bool          `json:"tax_inclusive"`
+		TotalAmount  money.Amount  `json:"total_amount"`
+		Status       string        `json:"status"`
+		CreatedAt    int64         `json:"created_at"`
+		UpdatedAt    int64         `json:"updated_at"`
+		Items        []ItemHistory `json:"items"`
+	}
+
+	// HistoryPage is a page of the order history of a user
+	HistoryPage struct {
+		Histories  []History
+		TotalItems int64
+		HasNext    bool
+	}
+
+	// Cursor is the keyset position of an order in the history, which is sorted by newest first
+	Cursor struct {
+		CreatedAt int64 `json:"c"`
+		ID        int64 `json:"id"`
+	}
+
+	// Quote is the server-side pricing of the items, QuoteID can be sent to create the order at these prices until ExpiresAt.
+	// Tax is added to the GrandTotal unless TaxInclusive, in which case it is the part of the prices that is tax.
+	Quote struct {
+		QuoteID        string       `json:"quote_id"`
+		ExpiresAt      int64        `json:"expires_at"`
+		Items          []QuoteItem  `json:"items"`
+		Subtotal       money.Amount `json:"subtotal"`
+		PromoCode      string       `json:"promo_code,omitempty"`
+		Discount       money.Amount `json:"discount"`
+		BillingCountry string       `json:"billing_country"`
+		TaxInclusive   bool         `json:"tax_inclusive"`
+		Tax            money.Amount `json:"tax"`
+		GrandTotal     money.Amount `json:"grand_total"`
+	}
+
+	// QuoteItem is a priced line of the quote, PriceChanged tells that the current price differs from the one the client sent
+	QuoteItem struct {
+		BookID        int64        `json:"book_id"`
+		Title         string       `json:"title"`
+		Quantity      int          `json:"quantity"`
+		Price         money.Amount `json:"price"`
+		ExpectedPrice money.Amount `json:"expected_price"`
+		PriceChanged  bool         `json:"price_changed"`
+		LineTotal     money.Amount `json:"line_total"`
+		Discount      money.Amount `json:"discount"`
+		TaxRate       percent.Rate `json:"tax_rate"`
+		Tax           money.Amount `json:"tax"`
+		Available     bool         `json:"available"`
+	}
+
+	// Detail is a single order with the books of its items and its status history
+	Detail struct {
+		ID             int64           `json:"order_id" db:"id"`
+		UserID         int64           `json:"user_id" db:"user_id"`
+		Subtotal       money.Amount    `json:"subtotal" db:"subtotal_amount"`
+		Discount       money.Amount    `json:"discount" db:"discount_amount"`
+		PromoCode      string          `json:"promo_code,omitempty" db:"promo_code"`
+		BillingCountry string          `json:"billing_country" db:"billing_country"`
+		Tax            money.Amount    `json:"tax" db:"tax_amount"`
+		TaxInclusive   bool            `json:"tax_inclusive" db:"tax_inclusive"`
+		TotalAmount    money.Amount    `json:"total_amount" db:"total_amount"`
+		Status         string          `json:"status" db:"status"`
+		CreatedAt      int64           `json:"created_at" db:"created_at"`
+		UpdatedAt      int64           `json:"updated_at" db:"updated_at"`
+		Items          []ItemDetail    `json:"items" db:"-"`
+		StatusHistory  []StatusHistory `json:"status_history" db:"-"`
+	}
+
+	// ItemDetail is an order item with the book it was ordered for, the price is the one at the time of the order,
+	// Discount is the share of the order discount taken off the item and Tax is the tax of what was left to pay
+	ItemDetail struct {
+		ID       int64        `json:"item_id" db:"id"`
+		BookID   int64        `json:"book_id" db:"book_id"`
+		Title    string       `json:"title" db:"title"`
+		Author   string       `json:"author" db:"author"`
+		ISBN     string       `json:"isbn" db:"isbn"`
+		Quantity int          `json:"quantity" db:"quantity"`
+		Price    money.Amount `json:"price" db:"price"`
+		Discount money.Amount `json:"discount" db:"discount_amount"`
+		TaxRate  percent.Rate `json:"tax_rate" db:"tax_rate"`
+		Tax      money.Amount `json:"tax" db:"tax_amount"`
+		// ReturnedQuantity and RefundedAmount add up the refunded returns of the item
+		ReturnedQuantity int          `json:"returned_quantity" db:"returned_quantity"`
+		RefundedAmount   money.Amount `json:"refunded_amount" db:"refunded_amount"`
 	}
 
 	ItemHistory struct {
-		ID       int64   `json:"item_id"`
-		BookID   int64   `json:"book_id"`
-		Quantity int     `json:"quantity"`
-		Price    float64 `json:"price"`
+		ID               int64        `json:"item_id"`
+		BookID           int64        `json:"book_id"`
+		Quantity         int          `json:"quantity"`
+		Price            money.Amount `json:"price"`
+		Discount         money.Amount `json:"discount"`
+		Tax              money.Amount `json:"tax"`
+		ReturnedQuantity int          `json:"returned_quantity"`
+		RefundedAmount   money.Amount `json:"refunded_amount"`
 	}
 )
 
 type (
+	// CreateOrderRequest is the order placed by the user, either from the items and their total or from a quote.
+	// Retries sent with the same IdempotencyKey create the order only once. TotalAmount is what is paid once
+	// the discount of PromoCode is taken off and the tax is added, the fields that aren't sent are filled in
+	// when the order is priced.
 	CreateOrderRequest struct {
-		UserID      int64             `json:"-"`
-		TotalAmount float64           `json:"total_amount" validate:"required"`
-		Items       []CreateOrderItem `json:"items" validate:"required"`
+		UserID         int64             `json:"-"`
+		IdempotencyKey string            `json:"-"`
+		QuoteID        string            `json:"quote_id,omitempty"`
+		PromoCode      string            `json:"promo_code,omitempty" validate:"max=50"`
+		TotalAmount    money.Amount      `json:"total_amount" validate:"gte=0"`
+		Items          []CreateOrderItem `json:"items" validate:"required_without=QuoteID,dive"`
+		PromotionID    int64             `json:"-"`
+		Subtotal       money.Amount      `json:"-"`
+		Discount       money.Amount      `json:"-"`
+		BillingCountry string            `json:"-"`
+		Tax            money.Amount      `json:"-"`
+		TaxInclusive   bool              `json:"-"`
+	}
+
+	// QuoteRequest prices the items, the price of every item is the price the client shows so changes can be reported
+	QuoteRequest struct {
+		UserID    int64             `json:"-"`
+		PromoCode string            `json:"promo_code,omitempty" validate:"max=50"`
+		Items     []CreateOrderItem `json:"items" validate:"required,dive"`
+	}
+
+	// UpdateOrderStatusRequest moves the order to the next status, UpdatedAt is the last updated_at the client saw,
+	// the update is rejected when the order changed since then
+	UpdateOrderStatusRequest struct {
+		OrderID   int64  `json:"-"`
+		ActorID   int64  `json:"-"`
+		ActorRole string `json:"-"`
+		Status    string `json:"status" validate:"required"`
+		UpdatedAt int64  `json:"updated_at" validate:"required"`
+		Note      string `json:"note" validate:"max=500"`
+	}
+
+	// CancelOrderRequest is sent by the customer, the reason is kept in the status history
+	CancelOrderRequest struct {
+		OrderID int64  `json:"-"`
+		UserID  int64  `json:"-"`
+		Reason  string `json:"reason" validate:"max=500"`
 	}
 
 	CreateOrderItem struct {
-		BookID   int64   `json:"book_id" validate:"required"`
-		Quantity int     `json:"quantity" validate:"required"`
-		Price    float64 `json:"price" validate:"required"`
+		BookID   int64        `json:"book_id" validate:"required"`
+		Quantity int          `json:"quantity" validate:"required,gt=0"`
+		Price    money.Amount `json:"price" validate:"required,gt=0"`
+		Discount money.Amount `json:"-"` // share of the order discount, filled in when the promo code is applied
+		TaxRate  percent.Rate `json:"-"`
+		Tax      money.Amount `json:"-"`
 	}
 )
 
 type (
 	CreateOrderResponse struct {
 		response.BaseResponse
-		OrderID int64  `json:"order_id"`
-		Status  string `json:"status"`
+		OrderID  int64  `json:"order_id"`
+		Status   string `json:"status"`
+		Replayed bool   `json:"-"` // the response of an earlier request with the same idempotency key
+	}
+
+	UpdateOrderStatusResponse struct {
+		response.BaseResponse
+		OrderID   int64  `json:"order_id"`
+		Status    string `json:"status"`
+		UpdatedAt int64  `json:"updated_at"`
+	}
+
+	QuoteResponse struct {
+		response.BaseResponse
+		Quote *Quote `json:"quote"`
+	}
+
+	OrderDetailResponse struct {
+		response.BaseResponse
+		Order *Detail `json:"order"`
 	}
 
 	OrderHistoryResponse struct {
 		response.BaseResponse
-		Histories []History `json:"data"`
+		Histories  []History           `json:"data"`
+		Pagination response.Pagination `json:"pagination"`
+	}
+)
+
+// Events of an order written to the outbox, the aggregate id of both is the order id
+const (
+	AggregateType           = "order"
+	EventOrderCreated       = "OrderCreated"
+	EventOrderStatusChanged = "OrderStatusChanged"
+)
+
+type (
+	// CreatedEvent is the payload of OrderCreated, amounts are what was ordered and paid when the order was placed
+	CreatedEvent struct {
+		OrderID        int64              `json:"order_id"`
+		UserID         int64              `json:"user_id"`
+		Subtotal       money.Amount       `json:"subtotal"`
+		PromotionID    int64              `json:"promotion_id,omitempty"`
+		Discount       money.Amount       `json:"discount"`
+		BillingCountry string             `json:"billing_country"`
+		Tax            money.Amount       `json:"tax"`
+		TaxInclusive   bool               `json:"tax_inclusive"`
+		TotalAmount    money.Amount       `json:"total_amount"`
+		Status         string             `json:"status"`
+		Items          []CreatedEventItem `json:"items"`
+		CreatedAt      int64              `json:"created_at"`
+	}
+
+	CreatedEventItem struct {
+		BookID   int64        `json:"book_id"`
+		Quantity int          `json:"quantity"`
+		Price    money.Amount `json:"price"`
+		Discount money.Amount `json:"discount"`
+		Tax      money.Amount `json:"tax"`
+	}
+
+	// StatusChangedEvent is the payload of OrderStatusChanged
+	StatusChangedEvent struct {
+		OrderID    int64  `json:"order_id"`
+		FromStatus string `json:"from_status"`
+		ToStatus   string `json:"to_status"`
+		ActorID    int64  `json:"actor_id"`
+		ActorRole  string `json:"actor_role"`
+		Note       string `json:"note,omitempty"`
+		ChangedAt  int64  `json:"changed_at"`
 	}
 )
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"encoding/json"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "PENDING"
+	StatusDelivered Status = "DELIVERED"
+	// StatusDead is an event that failed on every attempt, it is kept for inspection and isn't delivered anymore
+	StatusDead Status = "DEAD"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// Event is a change of an aggregate written to the outbox within the transaction of the change,
+// DeliveredTo names the sinks that already received it
+type Event struct {
+	ID            int64           `db:"id"`
+	AggregateType string          `db:"aggregate_type"`
+	AggregateID   int64           `db:"aggregate_id"`
+	EventType     string          `db:"event_type"`
+	Payload       json.RawMessage `db:"-"`
+	Status        string          `db:"status"`
+	Attempts      int             `db:"attempts"`
+	DeliveredTo   []string        `db:"-"`
+	LastError     string          `db:"last_error"`
+	NextAttemptAt int64           `db:"next_attempt_at"`
+	CreatedAt     int64           `db:"created_at"`
+	DeliveredAt   int64           `db:"delivered_at"`
+}
+
+// NewEvent encodes the payload of a pending event that is due right away
+func NewEvent(aggregateType string, aggregateID int64, eventType string, payload interface{}, createdAt int64) (Event, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       encoded,
+		Status:        StatusPending.String(),
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	}, nil
+}
+
+// DeliveredToSink tells whether the sink already received the event on an earlier attempt
+func (e Event) DeliveredToSink(name string) bool {
+	for _, delivered := range e.DeliveredTo {
+		if delivered == name {
+			return true
+		}
+	}
+	return false
+}
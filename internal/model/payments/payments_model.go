@@ -0,0 +1,62 @@
+package payments
+
+import (
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+type Status string
+
+const (
+	// StatusAuthorized holds the amount on the payment method, a capture that is still pending stays authorized
+	// until the webhook of the provider confirms it
+	StatusAuthorized Status = "AUTHORIZED"
+	StatusCaptured   Status = "CAPTURED"
+	StatusFailed     Status = "FAILED"
+	StatusVoided     Status = "VOIDED"
+	StatusRefunded   Status = "REFUNDED"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// IsActive tells whether the payment holds or took the money of the customer
+func (s Status) IsActive() bool {
+	return s == StatusAuthorized || s == StatusCaptured
+}
+
+type (
+	// Model is a payment attempt of an order at a provider
+	Model struct {
+		ID        int64        `json:"payment_id" db:"id"`
+		OrderID   int64        `json:"order_id" db:"order_id"`
+		Provider  string       `json:"provider" db:"provider"`
+		Reference string       `json:"reference" db:"reference"`
+		Amount    money.Amount `json:"amount" db:"amount"`
+		// RefundedAmount adds up the refunds of the capture, the payment is only REFUNDED once all of it is refunded
+		RefundedAmount money.Amount `json:"refunded_amount" db:"refunded_amount"`
+		Status         string       `json:"status" db:"status"`
+		FailureReason  string       `json:"failure_reason,omitempty" db:"failure_reason"`
+		CreatedAt      int64        `json:"created_at" db:"created_at"`
+		UpdatedAt      int64        `json:"updated_at" db:"updated_at"`
+	}
+)
+
+// All request struct go below this
+type (
+	// PayRequest pays the order with the payment method the client tokenized at the provider
+	PayRequest struct {
+		OrderID      int64  `json:"-"`
+		UserID       int64  `json:"-"`
+		PaymentToken string `json:"payment_token" validate:"required"`
+	}
+)
+
+// All response struct go below this
+type (
+	PaymentResponse struct {
+		response.BaseResponse
+		Payment *Model `json:"payment"`
+	}
+)
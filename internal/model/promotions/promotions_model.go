@@ -0,0 +1,197 @@
+package promotions
+
+import (
+	"encoding/json"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+	"strings"
+)
+
+type DiscountType string
+
+const (
+	// DiscountTypePercentage takes a percentage off the eligible books, DiscountPercentage 12.5 means 12.5%
+	DiscountTypePercentage DiscountType = "PERCENTAGE"
+	// DiscountTypeFixed takes a fixed amount off the eligible books, never more than what they cost
+	DiscountTypeFixed DiscountType = "FIXED"
+)
+
+func (t DiscountType) String() string {
+	return string(t)
+}
+
+type (
+	// Model is a promo code and its discount rules, zero values of MaxDiscount, EndsAt, UsageLimit and PerUserLimit
+	// mean there is no such limit. DiscountPercentage is the discount of a PERCENTAGE promotion and DiscountAmount the
+	// one of a FIXED promotion, whichever it is is written as discount_value
+	Model struct {
+		ID                 int64        `json:"promotion_id" db:"id"`
+		Code               string       `json:"code" db:"code"`
+		Description        string       `json:"description" db:"description"`
+		DiscountType       string       `json:"discount_type" db:"discount_type"`
+		DiscountPercentage percent.Rate `json:"-" db:"-"`
+		DiscountAmount     money.Amount `json:"-" db:"-"`
+		MaxDiscount        money.Amount `json:"max_discount" db:"max_discount"`
+		MinSpend           money.Amount `json:"min_spend" db:"min_spend"`
+		BookIDs            []int64      `json:"book_ids" db:"-"`
+		Authors            []string     `json:"authors" db:"-"`
+		StartsAt           int64        `json:"starts_at" db:"starts_at"`
+		EndsAt             int64        `json:"ends_at" db:"ends_at"`
+		UsageLimit         int          `json:"usage_limit" db:"usage_limit"`
+		PerUserLimit       int          `json:"per_user_limit" db:"per_user_limit"`
+		UsedCount          int          `json:"used_count" db:"used_count"`
+		CreatedAt          int64        `json:"created_at" db:"created_at"`
+		UpdatedAt          int64        `json:"updated_at" db:"updated_at"`
+	}
+
+	// Line is an order line the promotion is applied to, priced at the price the order is placed at
+	Line struct {
+		BookID   int64
+		Author   string
+		Price    money.Amount
+		Quantity int
+	}
+
+	// Applied is the discount a promotion gives to the lines of an order, LineDiscounts follows the order of the lines
+	Applied struct {
+		PromotionID   int64
+		Code          string
+		Discount      money.Amount
+		LineDiscounts []money.Amount
+	}
+)
+
+// IsEligible checks whether the promotion applies to the line, a promotion without books or authors applies to every line
+func (m Model) IsEligible(line Line) bool {
+	if len(m.BookIDs) == 0 && len(m.Authors) == 0 {
+		return true
+	}
+	for _, id := range m.BookIDs {
+		if id == line.BookID {
+			return true
+		}
+	}
+	for _, author := range m.Authors {
+		if strings.EqualFold(author, line.Author) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountOn is the discount the promotion gives on the eligible subtotal, a percentage is rounded to the nearest cent
+// and never goes over MaxDiscount, a fixed amount never goes over the subtotal
+func (m Model) DiscountOn(subtotal money.Amount) money.Amount {
+	if DiscountType(m.DiscountType) == DiscountTypePercentage {
+		discount := m.DiscountPercentage.Of(subtotal)
+		if m.MaxDiscount > 0 && discount > m.MaxDiscount {
+			discount = m.MaxDiscount
+		}
+		return discount
+	}
+	if m.DiscountAmount > subtotal {
+		return subtotal
+	}
+	return m.DiscountAmount
+}
+
+// DiscountValue is the discount as written to discount_value, the percentage or the amount depending on the type
+func (m Model) DiscountValue() interface{} {
+	if DiscountType(m.DiscountType) == DiscountTypePercentage {
+		return m.DiscountPercentage
+	}
+	return m.DiscountAmount
+}
+
+// SetDiscountValue reads discount_value, a JSON number or a DECIMAL column, as the percentage or the amount the type says
+func (m *Model) SetDiscountValue(data []byte) error {
+	return decodeDiscountValue(m.DiscountType, data, &m.DiscountPercentage, &m.DiscountAmount)
+}
+
+// MarshalJSON writes the percentage or the amount as discount_value
+func (m Model) MarshalJSON() ([]byte, error) {
+	type model Model
+	return json.Marshal(struct {
+		model
+		DiscountValue interface{} `json:"discount_value"`
+	}{model: model(m), DiscountValue: m.DiscountValue()})
+}
+
+// UnmarshalJSON reads discount_value as the percentage or the amount the discount_type says
+func (m *Model) UnmarshalJSON(data []byte) error {
+	type model Model
+	var value struct {
+		*model
+		DiscountValue json.RawMessage `json:"discount_value"`
+	}
+	value.model = (*model)(m)
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return m.SetDiscountValue(value.DiscountValue)
+}
+
+// decodeDiscountValue decodes the value into the percentage of a PERCENTAGE promotion or the amount of any other,
+// an unknown type is left to the validation of the type
+func decodeDiscountValue(discountType string, data []byte, percentage *percent.Rate, amount *money.Amount) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if DiscountType(discountType) == DiscountTypePercentage {
+		return percentage.UnmarshalJSON(data)
+	}
+	return amount.UnmarshalJSON(data)
+}
+
+// All request struct go below this
+type (
+	CreatePromotionRequest struct {
+		Code               string       `json:"code" validate:"required,max=50"`
+		Description        string       `json:"description" validate:"max=500"`
+		DiscountType       string       `json:"discount_type" validate:"required,oneof=PERCENTAGE FIXED"`
+		DiscountPercentage percent.Rate `json:"-" validate:"required_if=DiscountType PERCENTAGE,gte=0"`
+		DiscountAmount     money.Amount `json:"-" validate:"required_if=DiscountType FIXED,gte=0"`
+		MaxDiscount        money.Amount `json:"max_discount" validate:"gte=0"`
+		MinSpend           money.Amount `json:"min_spend" validate:"gte=0"`
+		BookIDs            []int64      `json:"book_ids" validate:"dive,gt=0"`
+		Authors            []string     `json:"authors" validate:"dive,required"`
+		StartsAt           int64        `json:"starts_at" validate:"gte=0"`
+		EndsAt             int64        `json:"ends_at" validate:"gte=0"`
+		UsageLimit         int          `json:"usage_limit" validate:"gte=0"`
+		PerUserLimit       int          `json:"per_user_limit" validate:"gte=0"`
+	}
+
+	// UpdatePromotionRequest replaces every rule of the promotion, its usage so far is kept
+	UpdatePromotionRequest struct {
+		ID int64 `json:"-"`
+		CreatePromotionRequest
+	}
+)
+
+// UnmarshalJSON reads discount_value as the percentage or the amount the discount_type says
+func (r *CreatePromotionRequest) UnmarshalJSON(data []byte) error {
+	type request CreatePromotionRequest
+	var value struct {
+		*request
+		DiscountValue json.RawMessage `json:"discount_value"`
+	}
+	value.request = (*request)(r)
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return decodeDiscountValue(r.DiscountType, value.DiscountValue, &r.DiscountPercentage, &r.DiscountAmount)
+}
+
+// All response struct go below this
+type (
+	PromotionResponse struct {
+		response.BaseResponse
+		Promotion *Model `json:"promotion"`
+	}
+
+	PromotionsResponse struct {
+		response.BaseResponse
+		Promotions []Model `json:"promotions"`
+	}
+)
@@ -0,0 +1,115 @@
+package returns
+
+import (
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+type Status string
+
+const (
+	StatusRequested Status = "REQUESTED"
+	// StatusApproved is an approved return whose refund is being made at the provider, it goes back to REQUESTED when
+	// the provider refuses the refund
+	StatusApproved Status = "APPROVED"
+	// StatusRefunding is a return the provider refunded, it becomes REFUNDED once the refund is recorded on the order.
+	// Approving it again only records the refund.
+	StatusRefunding Status = "REFUNDING"
+	StatusRefunded  Status = "REFUNDED"
+	StatusRejected  Status = "REJECTED"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// IsOpen tells whether the return is still being handled, an order has at most one open return
+func (s Status) IsOpen() bool {
+	return s == StatusRequested || s == StatusApproved || s == StatusRefunding
+}
+
+type (
+	// Model is a return request of some of the items of a delivered order, RefundAmount is what the items were paid for
+	Model struct {
+		ID           int64        `json:"return_id" db:"id"`
+		OrderID      int64        `json:"order_id" db:"order_id"`
+		UserID       int64        `json:"user_id" db:"user_id"`
+		Status       string       `json:"status" db:"status"`
+		RefundAmount money.Amount `json:"refund_amount" db:"refund_amount"`
+		Note         string       `json:"note,omitempty" db:"note"`
+		CreatedAt    int64        `json:"created_at" db:"created_at"`
+		UpdatedAt    int64        `json:"updated_at" db:"updated_at"`
+		Items        []Item       `json:"items" db:"-"`
+	}
+
+	Item struct {
+		ID           int64        `json:"return_item_id" db:"id"`
+		ReturnID     int64        `json:"-" db:"return_id"`
+		OrderItemID  int64        `json:"order_item_id" db:"order_item_id"`
+		BookID       int64        `json:"book_id" db:"book_id"`
+		Quantity     int          `json:"quantity" db:"quantity"`
+		Reason       string       `json:"reason" db:"reason"`
+		RefundAmount money.Amount `json:"refund_amount" db:"refund_amount"`
+	}
+
+	// OrderItem is an order item along with its share of the order discount, its tax and how much of it was already returned
+	OrderItem struct {
+		ID               int64        `db:"id"`
+		BookID           int64        `db:"book_id"`
+		Quantity         int          `db:"quantity"`
+		Price            money.Amount `db:"price"`
+		Discount         money.Amount `db:"discount_amount"`
+		Tax              money.Amount `db:"tax_amount"`
+		TaxInclusive     bool         `db:"tax_inclusive"`
+		ReturnedQuantity int          `db:"returned_quantity"`
+	}
+)
+
+// RefundFor is what the next quantity units of the item were paid for. The discount of the item and the tax added on top
+// of its price are spread over its units, the refunds are worked out from the running total so returning every unit
+// refunds exactly what the item was paid for.
+func (i OrderItem) RefundFor(quantity int) money.Amount {
+	paid := i.Price.Mul(i.Quantity).Sub(i.Discount)
+	if !i.TaxInclusive {
+		paid = paid.Add(i.Tax)
+	}
+	before := paid.Cents() * int64(i.ReturnedQuantity) / int64(i.Quantity)
+	after := paid.Cents() * int64(i.ReturnedQuantity+quantity) / int64(i.Quantity)
+	return money.FromCents(after - before)
+}
+
+// All request struct go below this
+type (
+	CreateReturnRequest struct {
+		OrderID int64              `json:"-"`
+		UserID  int64              `json:"-"`
+		Items   []CreateReturnItem `json:"items" validate:"required,dive"`
+	}
+
+	CreateReturnItem struct {
+		OrderItemID int64  `json:"order_item_id" validate:"required"`
+		Quantity    int    `json:"quantity" validate:"required,gt=0"`
+		Reason      string `json:"reason" validate:"required,max=500"`
+	}
+
+	// UpdateReturnStatusRequest is the decision of an admin on a requested return
+	UpdateReturnStatusRequest struct {
+		ReturnID int64  `json:"-"`
+		ActorID  int64  `json:"-"`
+		Status   string `json:"status" validate:"required,oneof=APPROVED REJECTED"`
+		Note     string `json:"note" validate:"max=500"`
+	}
+)
+
+// All response struct go below this
+type (
+	ReturnResponse struct {
+		response.BaseResponse
+		Return *Model `json:"return"`
+	}
+
+	ReturnsResponse struct {
+		response.BaseResponse
+		Returns []Model `json:"returns"`
+	}
+)
@@ -2,29 +2,145 @@ package users
 
 import (
 	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"strings"
 )
 
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleAdmin    Role = "admin"
+	RoleSupport  Role = "support"
+)
+
+func (r Role) String() string {
+	return string(r)
+}
+
+// IsStaff tells whether the role works on behalf of the store and may see the data of every customer
+func (r Role) IsStaff() bool {
+	return r == RoleAdmin || r == RoleSupport
+}
+
+// NormalizeEmail trims and lower-cases the email, every email is stored and looked up normalized
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 type (
-	// Model is the user model that is retrieved from DB
+	// Model is the user model that is retrieved from DB, the orders of the user are taxed with the rate of BillingCountry.
+	// EmailVerifiedAt is 0 until the user verified the email
 	Model struct {
-		ID        int64  `db:"id" json:"id"`
-		Email     string `db:"email" json:"email"`
-		Password  string `db:"password" json:"-"`
-		CreatedAt int64  `db:"created_at" json:"created_at"`
-		UpdatedAt int64  `db:"updated_at" json:"updated_at"`
+		ID              int64  `db:"id" json:"id"`
+		Email           string `db:"email" json:"email"`
+		Password        string `db:"password" json:"-"`
+		Role            string `db:"role" json:"role"`
+		BillingCountry  string `db:"billing_country" json:"billing_country"`
+		EmailVerifiedAt int64  `db:"email_verified_at" json:"email_verified_at"`
+		CreatedAt       int64  `db:"created_at" json:"created_at"`
+		UpdatedAt       int64  `db:"updated_at" json:"updated_at"`
 	}
 )
 
+// IsEmailVerified tells whether the user verified the email, only verified users may place orders
+func (m Model) IsEmailVerified() bool {
+	return m.EmailVerifiedAt > 0
+}
+
+type (
+	// Session is a device the user logged in from, it is stored in redis until it is logged out, revoked or expired.
+	// Only the hash of the current refresh token of the session is stored
+	Session struct {
+		ID          string `json:"id"`
+		UserID      int64  `json:"user_id"`
+		UserAgent   string `json:"user_agent"`
+		IPAddress   string `json:"ip_address"`
+		RefreshHash string `json:"refresh_hash"`
+		CreatedAt   int64  `json:"created_at"`
+		LastUsedAt  int64  `json:"last_used_at"`
+		ExpiresAt   int64  `json:"expires_at"`
+	}
+
+	// SessionInfo is the session as shown to the user, Current marks the session of the request
+	SessionInfo struct {
+		ID         string `json:"id"`
+		UserAgent  string `json:"user_agent"`
+		IPAddress  string `json:"ip_address"`
+		CreatedAt  int64  `json:"created_at"`
+		LastUsedAt int64  `json:"last_used_at"`
+		ExpiresAt  int64  `json:"expires_at"`
+		Current    bool   `json:"current"`
+	}
+
+	// Tokens are issued on login and on every refresh, the access token expires in ExpiresIn seconds and the
+	// refresh token can only be used once
+	Tokens struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+)
+
+// IsExpired tells whether the refresh token of the session expired at the given time in milliseconds
+func (s Session) IsExpired(now int64) bool {
+	return s.ExpiresAt <= now
+}
+
+// Info returns the session as shown to the user
+func (s Session) Info(currentID string) SessionInfo {
+	return SessionInfo{
+		ID:         s.ID,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    s.ID == currentID,
+	}
+}
+
 // All request struct go below this
 type (
 	CreateUserRequest struct {
-		Email    string `json:"email" validate:"required"`
-		Password string `json:"password" validate:"required"`
+		Email          string `json:"email" validate:"required,email,max=254"`
+		Password       string `json:"password" validate:"required"`
+		BillingCountry string `json:"billing_country" validate:"omitempty,iso3166_1_alpha2"`
 	}
 
+	// LoginRequest carries the device of the request, it is shown in the sessions of the user
 	LoginRequest struct {
-		Email    string `json:"email" validate:"required"`
-		Password string `json:"password" validate:"required"`
+		Email     string `json:"email" validate:"required"`
+		Password  string `json:"password" validate:"required"`
+		UserAgent string `json:"-"`
+		IPAddress string `json:"-"`
+	}
+
+	RefreshTokenRequest struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+		UserAgent    string `json:"-"`
+		IPAddress    string `json:"-"`
+	}
+
+	VerifyEmailRequest struct {
+		Token string `query:"token" validate:"required"`
+	}
+
+	ForgotPasswordRequest struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	// ResetPasswordRequest carries the token of the password reset email, bcrypt only uses the first 72 bytes of a password
+	ResetPasswordRequest struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required,min=8,max=72"`
+	}
+
+	ChangePasswordRequest struct {
+		UserID          int64  `json:"-"`
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+		UserAgent       string `json:"-"`
+		IPAddress       string `json:"-"`
 	}
 )
 
@@ -37,6 +153,15 @@ type (
 
 	LoginResponse struct {
 		response.BaseResponse
-		Token string `json:"token"`
+		*Tokens
+	}
+
+	PasswordResponse struct {
+		response.BaseResponse
+	}
+
+	SessionsResponse struct {
+		response.BaseResponse
+		Sessions []SessionInfo `json:"sessions,omitempty"`
 	}
 )
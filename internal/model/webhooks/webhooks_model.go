@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "PENDING"
+	DeliveryStatusDelivered DeliveryStatus = "DELIVERED"
+	// DeliveryStatusFailed is a delivery that failed on every attempt, it isn't retried anymore
+	DeliveryStatusFailed DeliveryStatus = "FAILED"
+)
+
+func (s DeliveryStatus) String() string {
+	return string(s)
+}
+
+type (
+	// Subscription is a partner endpoint that gets the events of EventTypes, the secret is only shown when it is created
+	Subscription struct {
+		ID          int64    `json:"subscription_id" db:"id"`
+		URL         string   `json:"url" db:"url"`
+		Secret      string   `json:"secret,omitempty" db:"secret"`
+		EventTypes  []string `json:"event_types" db:"-"`
+		Description string   `json:"description" db:"description"`
+		Active      bool     `json:"active" db:"active"`
+		CreatedAt   int64    `json:"created_at" db:"created_at"`
+		UpdatedAt   int64    `json:"updated_at" db:"updated_at"`
+	}
+
+	// Delivery is an event sent to a subscription, URL and Secret are the ones of the subscription when it is claimed to be sent
+	Delivery struct {
+		ID             int64           `json:"delivery_id" db:"id"`
+		SubscriptionID int64           `json:"subscription_id" db:"subscription_id"`
+		EventID        int64           `json:"event_id" db:"event_id"`
+		EventType      string          `json:"event_type" db:"event_type"`
+		Payload        json.RawMessage `json:"payload" db:"-"`
+		Status         string          `json:"status" db:"status"`
+		Attempts       int             `json:"attempts" db:"attempts"`
+		ResponseStatus int             `json:"response_status" db:"response_status"`
+		LastError      string          `json:"last_error" db:"last_error"`
+		NextAttemptAt  int64           `json:"next_attempt_at" db:"next_attempt_at"`
+		CreatedAt      int64           `json:"created_at" db:"created_at"`
+		DeliveredAt    int64           `json:"delivered_at" db:"delivered_at"`
+		URL            string          `json:"-" db:"url"`
+		Secret         string          `json:"-" db:"secret"`
+	}
+)
+
+// All request struct go below this
+type (
+	// CreateSubscriptionRequest subscribes the url to the event types, a secret is generated when none is given
+	CreateSubscriptionRequest struct {
+		URL         string   `json:"url" validate:"required,url,max=2048"`
+		Secret      string   `json:"secret" validate:"omitempty,min=16,max=255"`
+		EventTypes  []string `json:"event_types" validate:"required,min=1,dive,oneof=OrderCreated OrderStatusChanged"`
+		Description string   `json:"description" validate:"max=500"`
+		Active      *bool    `json:"active"`
+	}
+
+	// UpdateSubscriptionRequest replaces the subscription, the secret and active are kept when they are left out
+	UpdateSubscriptionRequest struct {
+		ID int64 `json:"-"`
+		CreateSubscriptionRequest
+	}
+
+	// DeliveriesRequest is a page of the delivery log of a subscription, newest first, optionally of one status
+	DeliveriesRequest struct {
+		SubscriptionID int64
+		Status         string `validate:"omitempty,oneof=PENDING DELIVERED FAILED"`
+		PageIndex      int
+		PageSize       int
+	}
+)
+
+// All response struct go below this
+type (
+	SubscriptionResponse struct {
+		response.BaseResponse
+		Subscription *Subscription `json:"subscription"`
+	}
+
+	SubscriptionsResponse struct {
+		response.BaseResponse
+		Subscriptions []Subscription `json:"subscriptions"`
+	}
+
+	DeliveriesResponse struct {
+		response.BaseResponse
+		Deliveries []Delivery          `json:"deliveries"`
+		Pagination response.Pagination `json:"pagination"`
+	}
+)
@@ -2,22 +2,34 @@ package books
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/lib/pq"
 	"github.com/yeremiaaryo/gotu-assignment/internal/constant"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"log"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 //go:generate mockgen -package=books -source=books_repository.go -destination=books_repository_mock_test.go
 type redis interface {
 	Get(key string, field ...interface{}) (string, error)
 	Set(key string, value string, ttl int64, field ...interface{}) (interface{}, error)
+	Del(key string, field ...interface{}) (bool, error)
+	DelByPattern(pattern string) (int64, error)
 }
 
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
 type repository struct {
 	masterDB internalsql.MasterDB
 	slaveDB  internalsql.SlaveDB
@@ -34,28 +46,93 @@ func New(masterDB internalsql.MasterDB, slaveDB internalsql.SlaveDB, redis redis
 	return &r
 }
 
-func (r *repository) GetBooks(ctx context.Context, search string, limit, offset int) ([]books.Model, error) {
-	var bookList []books.Model
+// bookListRow is a row of the book list along with the total of books matching the filter
+type bookListRow struct {
+	books.Model
+	TotalItems int64 `db:"total_items"`
+}
+
+// GetBooks returns a page of the book list, either by offset or after the given keyset position.
+// The whole page along with its total is cached in redis.
+func (r *repository) GetBooks(ctx context.Context, filter books.Filter, after *books.Cursor, limit, offset int) (books.Page, error) {
+	var page books.Page
 
-	redisKey := fmt.Sprintf(constant.RedisKeyBooks, search, limit, offset)
+	redisKey := fmt.Sprintf(constant.RedisKeyBooks, filter.Encode(), limit, offset)
+	if after != nil {
+		redisKey = fmt.Sprintf(constant.RedisKeyBooksAfter, filter.Encode(), after.Value, after.ID, limit)
+		offset = 0
+	}
 	resStr, err := r.redis.Get(redisKey)
 	if err == nil && resStr != "" {
-		err = jsoniter.Unmarshal([]byte(resStr), &bookList)
+		err = jsoniter.Unmarshal([]byte(resStr), &page)
 		if err == nil {
-			return bookList, nil
+			return page, nil
 		}
 	}
 
 	var queryBuilder strings.Builder
-	queryBuilder.WriteString(queryGetBooks)
+	var (
+		args       []interface{}
+		conditions []string
+	)
 
-	var args []interface{}
+	countQuery := queryCountBooks
+	if filter.Search != "" {
+		queryBuilder.WriteString(querySearchBooks)
+		countQuery = queryCountSearchBooks
+		args = append(args, normalizeSearch(filter.Search))
+	} else {
+		queryBuilder.WriteString(queryListBooks)
+	}
+
+	if filter.Author != "" {
+		conditions = append(conditions, `lower(author) = lower(?)`)
+		args = append(args, strings.TrimSpace(filter.Author))
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, `price >= ?`)
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, `price <= ?`)
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.PublishedFrom != nil {
+		conditions = append(conditions, `published_date >= ?`)
+		args = append(args, *filter.PublishedFrom)
+	}
+	if filter.PublishedTo != nil {
+		conditions = append(conditions, `published_date <= ?`)
+		args = append(args, *filter.PublishedTo)
+	}
 
-	if search != "" {
-		queryBuilder.WriteString(` WHERE lower(title) ILIKE lower(?) OR lower(author) ILIKE lower(?)`)
-		searchPattern := "%" + search + "%"
-		args = append(args, searchPattern, searchPattern)
+	var whereClause string
+	if len(conditions) > 0 {
+		// the search query already has its own WHERE clause
+		if filter.Search != "" {
+			whereClause = ` AND `
+		} else {
+			whereClause = ` WHERE `
+		}
+		whereClause += strings.Join(conditions, ` AND `)
 	}
+	queryBuilder.WriteString(whereClause)
+	filterArgs := args
+
+	// the keyset condition is not part of the filter, so the total still counts every matching book
+	if after != nil {
+		if filter.Search != "" || whereClause != "" {
+			queryBuilder.WriteString(` AND `)
+		} else {
+			queryBuilder.WriteString(` WHERE `)
+		}
+		keyset, keysetArgs := keysetCondition(filter, *after)
+		queryBuilder.WriteString(keyset)
+		args = append(args, keysetArgs...)
+	}
+
+	queryBuilder.WriteString(` ORDER BY `)
+	queryBuilder.WriteString(orderByClause(filter))
 
 	queryBuilder.WriteString(` LIMIT ? OFFSET ?`)
 	args = append(args, limit, offset)
@@ -65,22 +142,60 @@ func (r *repository) GetBooks(ctx context.Context, search string, limit, offset
 
 	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
 	if err != nil {
-		return nil, err
+		return books.Page{}, err
 	}
 	defer stmt.Close()
 
-	err = stmt.SelectContext(ctx, &bookList, args...)
+	var rows []bookListRow
+	err = stmt.SelectContext(ctx, &rows, args...)
 	if err != nil {
-		return nil, err
+		return books.Page{}, err
+	}
+
+	page = books.Page{Books: make([]books.Model, 0, len(rows))}
+	var matchingItems int64 // books matching the query, only the ones after the cursor in keyset mode
+	for _, row := range rows {
+		page.Books = append(page.Books, row.Model)
+		matchingItems = row.TotalItems
 	}
+	page.HasNext = int64(offset+len(rows)) < matchingItems
+	page.TotalItems = matchingItems
 
-	val, err := jsoniter.MarshalToString(bookList)
+	// a page past the last one has no row to carry the total, and a keyset page only counts the rest of the list
+	if (len(rows) == 0 && offset > 0) || after != nil {
+		page.TotalItems, err = r.countBooks(ctx, countQuery+whereClause, filterArgs)
+		if err != nil {
+			return books.Page{}, err
+		}
+	}
+	if page.HasNext {
+		last := rows[len(rows)-1].Model
+		page.Last = &books.Cursor{Value: sortKey(filter, last), ID: last.ID}
+	}
+
+	val, err := jsoniter.MarshalToString(page)
 	if err != nil {
-		return bookList, nil // still return no error, just error on set redis shouldn't block user journey
+		return page, nil // still return no error, just error on set redis shouldn't block user journey
 	}
-	fmt.Println(val)
 	_, _ = r.redis.Set(redisKey, val, int64((30 * time.Second).Seconds()))
-	return bookList, nil
+	return page, nil
+}
+
+func (r *repository) countBooks(ctx context.Context, query string, args []interface{}) (int64, error) {
+	rebindQuery := r.slaveDB.Rebind(query)
+
+	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var total int64
+	err = stmt.GetContext(ctx, &total, args...)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
 }
 
 func (r *repository) GetBookByIDs(ctx context.Context, ids []int64) (map[int64]books.Model, error) {
@@ -113,3 +228,257 @@ func (r *repository) GetBookByIDs(ctx context.Context, ids []int64) (map[int64]b
 	}
 	return result, nil
 }
+
+func (r *repository) GetBookByID(ctx context.Context, id int64) (*books.Model, error) {
+	var book books.Model
+
+	redisKey := fmt.Sprintf(constant.RedisKeyBook, id)
+	resStr, err := r.redis.Get(redisKey)
+	if err == nil && resStr != "" {
+		err = jsoniter.Unmarshal([]byte(resStr), &book)
+		if err == nil {
+			return &book, nil
+		}
+	}
+
+	query := queryGetBooks + ` WHERE id = ?`
+	rebindQuery := r.slaveDB.Rebind(query)
+
+	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.GetContext(ctx, &book, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	val, err := jsoniter.MarshalToString(book)
+	if err != nil {
+		return &book, nil // still return no error, just error on set redis shouldn't block user journey
+	}
+	_, _ = r.redis.Set(redisKey, val, int64((5 * time.Minute).Seconds()))
+	return &book, nil
+}
+
+func (r *repository) GetBookByISBN(ctx context.Context, isbn string) (*books.Model, error) {
+	query := queryGetBooks + ` WHERE isbn = ?`
+	rebindQuery := r.slaveDB.Rebind(query)
+
+	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var book books.Model
+	err = stmt.GetContext(ctx, &book, isbn)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &book, nil
+}
+
+func (r *repository) InsertBook(ctx context.Context, model books.Model) (*books.Model, error) {
+	rebindQuery := r.masterDB.Rebind(insertBookQuery)
+
+	stmt, err := r.masterDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, model.Title, model.Author, model.ISBN, model.PublishedDate, model.Price, model.Stock, model.CreatedAt, model.UpdatedAt).Scan(&model.ID)
+	if err != nil {
+		if isPQError(err, pqUniqueViolation) {
+			return nil, errors.New("isbn already exists")
+		}
+		return nil, err
+	}
+
+	r.InvalidateBookCache()
+	model.Available = model.Stock > 0
+	return &model, nil
+}
+
+// UpdateBook updates every field of the book, the stock is left untouched when nil so an update
+// that doesn't manage the stock can't overwrite what orders decremented in the meantime
+func (r *repository) UpdateBook(ctx context.Context, model books.Model, stock *int) (*books.Model, error) {
+	rebindQuery := r.masterDB.Rebind(updateBookQuery)
+
+	stmt, err := r.masterDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, model.Title, model.Author, model.ISBN, model.PublishedDate, model.Price, stock, model.UpdatedAt, model.ID)
+	if err != nil {
+		if isPQError(err, pqUniqueViolation) {
+			return nil, errors.New("isbn already exists")
+		}
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, fmt.Errorf("book with id: %d is not found", model.ID)
+	}
+
+	r.InvalidateBookCache(model.ID)
+	if stock != nil {
+		model.Stock = *stock
+	}
+	model.Available = model.Stock > 0
+	return &model, nil
+}
+
+func (r *repository) DeleteBook(ctx context.Context, id int64) error {
+	rebindQuery := r.masterDB.Rebind(deleteBookQuery)
+
+	stmt, err := r.masterDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		if isPQError(err, pqForeignKeyViolation) {
+			return fmt.Errorf("book with id: %d is referenced by existing orders", id)
+		}
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("book with id: %d is not found", id)
+	}
+
+	r.InvalidateBookCache(id)
+	return nil
+}
+
+// InvalidateBookCache drops every cached book listing and the detail cache of the given books,
+// failing to do so shouldn't fail the write since the cache will expire on its own
+func (r *repository) InvalidateBookCache(ids ...int64) {
+	_, err := r.redis.DelByPattern(constant.RedisKeyBooksPattern)
+	if err != nil {
+		log.Printf("[InvalidateBookCache] error when deleting book list cache: %v", err)
+	}
+	for _, id := range ids {
+		_, err = r.redis.Del(fmt.Sprintf(constant.RedisKeyBook, id))
+		if err != nil {
+			log.Printf("[InvalidateBookCache] error when deleting cache of book %d: %v", id, err)
+		}
+	}
+}
+
+// orderByClause maps the sort to its ORDER BY clause, id is always the last column so the order is deterministic
+func orderByClause(filter books.Filter) string {
+	switch filter.Sort {
+	case books.SortByPriceAsc:
+		return `price ASC, id ASC`
+	case books.SortByPriceDesc:
+		return `price DESC, id DESC`
+	case books.SortByTitleAsc:
+		return `title ASC, id ASC`
+	case books.SortByTitleDesc:
+		return `title DESC, id DESC`
+	case books.SortByPublishedDateAsc:
+		return `published_date ASC, id ASC`
+	case books.SortByPublishedDateDesc:
+		return `published_date DESC, id DESC`
+	case books.SortByNewest:
+		return `created_at DESC, id DESC`
+	}
+
+	// relevance only exists when searching, it is also the default sort of a search
+	if filter.Search != "" {
+		return `relevance DESC, id ASC`
+	}
+	return `id ASC`
+}
+
+// keysetCondition returns the condition of the books positioned after the cursor in the order of orderByClause,
+// the sort key is passed as text and casted by postgres to the type of its column
+func keysetCondition(filter books.Filter, after books.Cursor) (string, []interface{}) {
+	switch filter.Sort {
+	case books.SortByPriceAsc:
+		return `(price, id) > (?, ?)`, []interface{}{after.Value, after.ID}
+	case books.SortByPriceDesc:
+		return `(price, id) < (?, ?)`, []interface{}{after.Value, after.ID}
+	case books.SortByTitleAsc:
+		return `(title, id) > (?, ?)`, []interface{}{after.Value, after.ID}
+	case books.SortByTitleDesc:
+		return `(title, id) < (?, ?)`, []interface{}{after.Value, after.ID}
+	case books.SortByPublishedDateAsc:
+		return `(published_date, id) > (?, ?)`, []interface{}{after.Value, after.ID}
+	case books.SortByPublishedDateDesc:
+		return `(published_date, id) < (?, ?)`, []interface{}{after.Value, after.ID}
+	case books.SortByNewest:
+		return `(created_at, id) < (?, ?)`, []interface{}{after.Value, after.ID}
+	}
+
+	if filter.Search != "" {
+		// the rank is descending while the id is ascending so a row comparison can't be used,
+		// the rank is compared as real since that is what ts_rank returns
+		return `(ts_rank(search_vector, query) < ?::real OR (ts_rank(search_vector, query) = ?::real AND id > ?))`,
+			[]interface{}{after.Value, after.Value, after.ID}
+	}
+	return `id > ?`, []interface{}{after.ID}
+}
+
+// sortKey returns the value of the sort column of the book in text form, see keysetCondition
+func sortKey(filter books.Filter, book books.Model) string {
+	switch filter.Sort {
+	case books.SortByPriceAsc, books.SortByPriceDesc:
+		return book.Price.String()
+	case books.SortByTitleAsc, books.SortByTitleDesc:
+		return book.Title
+	case books.SortByPublishedDateAsc, books.SortByPublishedDateDesc:
+		return book.PublishedDate.Format(time.DateOnly)
+	case books.SortByNewest:
+		return strconv.FormatInt(book.CreatedAt, 10)
+	}
+
+	if filter.Search != "" {
+		return strconv.FormatFloat(book.Relevance, 'g', -1, 32)
+	}
+	return ""
+}
+
+// normalizeSearch strips the hyphens of an ISBN-looking search since ISBNs are stored without them
+func normalizeSearch(search string) string {
+	search = strings.TrimSpace(search)
+	isISBN := search != ""
+	for _, c := range search {
+		if !unicode.IsDigit(c) && c != '-' && c != ' ' && c != 'X' && c != 'x' {
+			isISBN = false
+			break
+		}
+	}
+	if isISBN {
+		return strings.NewReplacer("-", "", " ", "").Replace(search)
+	}
+	return search
+}
+
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -33,6 +33,41 @@ func (m *Mockredis) EXPECT() *MockredisMockRecorder {
 	return m.recorder
 }
 
+// Del mocks base method.
+func (m *Mockredis) Del(key string, field ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{key}
+	for _, a := range field {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Del", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockredisMockRecorder) Del(key interface{}, field ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{key}, field...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*Mockredis)(nil).Del), varargs...)
+}
+
+// DelByPattern mocks base method.
+func (m *Mockredis) DelByPattern(pattern string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelByPattern", pattern)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DelByPattern indicates an expected call of DelByPattern.
+func (mr *MockredisMockRecorder) DelByPattern(pattern interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelByPattern", reflect.TypeOf((*Mockredis)(nil).DelByPattern), pattern)
+}
+
 // Get mocks base method.
 func (m *Mockredis) Get(key string, field ...interface{}) (string, error) {
 	m.ctrl.T.Helper()
@@ -8,8 +8,10 @@ import (
 	"github.com/lib/pq"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_repository_GetBooks(t *testing.T) {
@@ -25,16 +27,22 @@ func Test_repository_GetBooks(t *testing.T) {
 
 	mockRedis := NewMockredis(mockCtrl)
 
+	minPrice, maxPrice := money.MustParse("5"), money.MustParse("10.5")
+	publishedFrom := time.Date(1940, time.January, 1, 0, 0, 0, 0, time.UTC)
+	publishedTo := time.Date(1950, time.December, 31, 0, 0, 0, 0, time.UTC)
+	searchQuery := `SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, ts_rank(search_vector, query) AS relevance, COUNT(*) OVER() AS total_items FROM books, websearch_to_tsquery('simple', ?) query WHERE search_vector @@ query ORDER BY relevance DESC, id ASC LIMIT ? OFFSET ?`
+
 	type args struct {
 		ctx    context.Context
-		search string
+		filter books.Filter
+		after  *books.Cursor
 		limit  int
 		offset int
 	}
 	tests := []struct {
 		name    string
 		args    args
-		want    []books.Model
+		want    books.Page
 		wantErr bool
 		mockFn  func(args args)
 	}{
@@ -42,15 +50,15 @@ func Test_repository_GetBooks(t *testing.T) {
 			name: "error when preparing statement",
 			args: args{
 				ctx:    context.Background(),
-				search: "Orwell",
+				filter: books.Filter{Search: "Orwell"},
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    books.Page{},
 			wantErr: true,
 			mockFn: func(args args) {
-				mockRedis.EXPECT().Get("books:Orwell:10:0").Return("", errors.New("failed"))
-				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price FROM books WHERE lower(title) ILIKE lower(?) OR lower(author) ILIKE lower(?) LIMIT ? OFFSET ?`).
+				mockRedis.EXPECT().Get("books:author=&search=Orwell&sort=:10:0").Return("", errors.New("failed"))
+				mock.ExpectPrepare(searchQuery).
 					WillReturnError(errors.New("failed"))
 			},
 		},
@@ -58,17 +66,17 @@ func Test_repository_GetBooks(t *testing.T) {
 			name: "error when querying statement",
 			args: args{
 				ctx:    context.Background(),
-				search: "Orwell",
+				filter: books.Filter{Search: "Orwell"},
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    books.Page{},
 			wantErr: true,
 			mockFn: func(args args) {
-				mockRedis.EXPECT().Get("books:Orwell:10:0").Return("", errors.New("failed"))
-				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price FROM books WHERE lower(title) ILIKE lower(?) OR lower(author) ILIKE lower(?) LIMIT ? OFFSET ?`).
+				mockRedis.EXPECT().Get("books:author=&search=Orwell&sort=:10:0").Return("", errors.New("failed"))
+				mock.ExpectPrepare(searchQuery).
 					ExpectQuery().
-					WithArgs("%Orwell%", "%Orwell%", 10, 0).
+					WithArgs("Orwell", 10, 0).
 					WillReturnError(errors.New("failed"))
 			},
 		},
@@ -76,93 +84,270 @@ func Test_repository_GetBooks(t *testing.T) {
 			name: "search by title success",
 			args: args{
 				ctx:    context.Background(),
-				search: "Orwell",
+				filter: books.Filter{Search: "Orwell"},
 				limit:  10,
 				offset: 0,
 			},
-			want: []books.Model{
-				{
-					ID:     1,
-					Title:  "1984",
-					Author: "George Orwell",
-					ISBN:   "9780451524935",
-					Price:  9.99,
+			want: books.Page{
+				Books: []books.Model{
+					{
+						ID:        1,
+						Title:     "1984",
+						Author:    "George Orwell",
+						ISBN:      "9780451524935",
+						Price:     money.MustParse("9.99"),
+						Relevance: 0.6,
+					},
 				},
+				TotalItems: 1,
 			},
 			wantErr: false,
 			mockFn: func(args args) {
-				mockRedis.EXPECT().Get("books:Orwell:10:0").Return("", errors.New("failed"))
-				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price FROM books WHERE lower(title) ILIKE lower(?) OR lower(author) ILIKE lower(?) LIMIT ? OFFSET ?`).
+				mockRedis.EXPECT().Get("books:author=&search=Orwell&sort=:10:0").Return("", errors.New("failed"))
+				mock.ExpectPrepare(searchQuery).
 					ExpectQuery().
-					WithArgs("%Orwell%", "%Orwell%", 10, 0).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price"}).
-						AddRow(1, "1984", "George Orwell", "9780451524935", 9.99))
-				mockRedis.EXPECT().Set("books:Orwell:10:0", gomock.Any(), gomock.Any()).Return(nil, nil)
+					WithArgs("Orwell", 10, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price", "relevance", "total_items"}).
+						AddRow(1, "1984", "George Orwell", "9780451524935", 9.99, 0.6, 1))
+				mockRedis.EXPECT().Set("books:author=&search=Orwell&sort=:10:0", gomock.Any(), gomock.Any()).Return(nil, nil)
 			},
 		},
 		{
-			name: "no search term",
+			name: "search by hyphenated isbn",
 			args: args{
 				ctx:    context.Background(),
-				search: "",
+				filter: books.Filter{Search: "978-0-451-52493-5"},
 				limit:  10,
 				offset: 0,
 			},
-			want: []books.Model{
-				{
-					ID:     1,
-					Title:  "1984",
-					Author: "George Orwell",
-					ISBN:   "9780451524935",
-					Price:  9.99,
+			want: books.Page{
+				Books: []books.Model{
+					{
+						ID:        1,
+						Title:     "1984",
+						Author:    "George Orwell",
+						ISBN:      "9780451524935",
+						Price:     money.MustParse("9.99"),
+						Relevance: 0.2,
+					},
 				},
-				{
-					ID:     2,
-					Title:  "Animal Farm",
+				TotalItems: 1,
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockRedis.EXPECT().Get("books:author=&search=978-0-451-52493-5&sort=:10:0").Return("", errors.New("failed"))
+				mock.ExpectPrepare(searchQuery).
+					ExpectQuery().
+					WithArgs("9780451524935", 10, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price", "relevance", "total_items"}).
+						AddRow(1, "1984", "George Orwell", "9780451524935", 9.99, 0.2, 1))
+				mockRedis.EXPECT().Set("books:author=&search=978-0-451-52493-5&sort=:10:0", gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "search with every filter and sort by price",
+			args: args{
+				ctx: context.Background(),
+				filter: books.Filter{
+					Search:        "Orwell",
+					Author:        "george orwell",
+					MinPrice:      &minPrice,
+					MaxPrice:      &maxPrice,
+					PublishedFrom: &publishedFrom,
+					PublishedTo:   &publishedTo,
+					Sort:          books.SortByPriceDesc,
+				},
+				limit:  10,
+				offset: 10,
+			},
+			want:    books.Page{Books: []books.Model{}, TotalItems: 4},
+			wantErr: false,
+			mockFn: func(args args) {
+				redisKey := "books:author=george+orwell&max_price=10.50&min_price=5.00&published_from=1940-01-01&published_to=1950-12-31&search=Orwell&sort=price_desc:10:10"
+				mockRedis.EXPECT().Get(redisKey).Return("", errors.New("failed"))
+				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, ts_rank(search_vector, query) AS relevance, COUNT(*) OVER() AS total_items FROM books, websearch_to_tsquery('simple', ?) query WHERE search_vector @@ query AND lower(author) = lower(?) AND price >= ? AND price <= ? AND published_date >= ? AND published_date <= ? ORDER BY price DESC, id DESC LIMIT ? OFFSET ?`).
+					ExpectQuery().
+					WithArgs("Orwell", "george orwell", minPrice, maxPrice, publishedFrom, publishedTo, 10, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price", "relevance", "total_items"}))
+				mock.ExpectPrepare(`SELECT COUNT(*) FROM books, websearch_to_tsquery('simple', ?) query WHERE search_vector @@ query AND lower(author) = lower(?) AND price >= ? AND price <= ? AND published_date >= ? AND published_date <= ?`).
+					ExpectQuery().
+					WithArgs("Orwell", "george orwell", minPrice, maxPrice, publishedFrom, publishedTo).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+				mockRedis.EXPECT().Set(redisKey, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "filter without search, sort by newest",
+			args: args{
+				ctx: context.Background(),
+				filter: books.Filter{
 					Author: "George Orwell",
-					ISBN:   "9780451526342",
-					Price:  8.99,
+					Sort:   books.SortByNewest,
 				},
+				limit:  10,
+				offset: 0,
 			},
+			want:    books.Page{Books: []books.Model{}},
 			wantErr: false,
 			mockFn: func(args args) {
-				mockRedis.EXPECT().Get("books::10:0").Return("", errors.New("failed"))
-				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price FROM books LIMIT ? OFFSET ?`).
+				redisKey := "books:author=George+Orwell&search=&sort=newest:10:0"
+				mockRedis.EXPECT().Get(redisKey).Return("", errors.New("failed"))
+				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, COUNT(*) OVER() AS total_items FROM books WHERE lower(author) = lower(?) ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`).
+					ExpectQuery().
+					WithArgs("George Orwell", 10, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price", "total_items"}))
+				mockRedis.EXPECT().Set(redisKey, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "search after cursor, sorted by relevance",
+			args: args{
+				ctx:    context.Background(),
+				filter: books.Filter{Search: "Orwell"},
+				after:  &books.Cursor{Value: "0.6", ID: 1},
+				limit:  10,
+				offset: 10,
+			},
+			want: books.Page{
+				Books: []books.Model{
+					{
+						ID:        2,
+						Title:     "Animal Farm",
+						Author:    "George Orwell",
+						ISBN:      "9780451526342",
+						Price:     money.MustParse("8.99"),
+						Relevance: 0.6,
+					},
+				},
+				TotalItems: 2,
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				redisKey := "books:author=&search=Orwell&sort=:after:0.6:1:10"
+				mockRedis.EXPECT().Get(redisKey).Return("", errors.New("failed"))
+				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, ts_rank(search_vector, query) AS relevance, COUNT(*) OVER() AS total_items FROM books, websearch_to_tsquery('simple', ?) query WHERE search_vector @@ query AND (ts_rank(search_vector, query) < ?::real OR (ts_rank(search_vector, query) = ?::real AND id > ?)) ORDER BY relevance DESC, id ASC LIMIT ? OFFSET ?`).
+					ExpectQuery().
+					WithArgs("Orwell", "0.6", "0.6", int64(1), 10, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price", "relevance", "total_items"}).
+						AddRow(2, "Animal Farm", "George Orwell", "9780451526342", 8.99, 0.6, 1))
+				mock.ExpectPrepare(`SELECT COUNT(*) FROM books, websearch_to_tsquery('simple', ?) query WHERE search_vector @@ query`).
+					ExpectQuery().
+					WithArgs("Orwell").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mockRedis.EXPECT().Set(redisKey, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "filter after cursor, sorted by price with a next page",
+			args: args{
+				ctx:    context.Background(),
+				filter: books.Filter{MaxPrice: &maxPrice, Sort: books.SortByPriceAsc},
+				after:  &books.Cursor{Value: "6.99", ID: 4},
+				limit:  1,
+			},
+			want: books.Page{
+				Books: []books.Model{
+					{
+						ID:     2,
+						Title:  "To Kill a Mockingbird",
+						Author: "Harper Lee",
+						ISBN:   "9780061120084",
+						Price:  money.MustParse("7.99"),
+					},
+				},
+				TotalItems: 6,
+				HasNext:    true,
+				Last:       &books.Cursor{Value: "7.99", ID: 2},
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				redisKey := "books:author=&max_price=10.50&search=&sort=price_asc:after:6.99:4:1"
+				mockRedis.EXPECT().Get(redisKey).Return("", errors.New("failed"))
+				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, COUNT(*) OVER() AS total_items FROM books WHERE price <= ? AND (price, id) > (?, ?) ORDER BY price ASC, id ASC LIMIT ? OFFSET ?`).
+					ExpectQuery().
+					WithArgs(maxPrice, "6.99", int64(4), 1, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price", "total_items"}).
+						AddRow(2, "To Kill a Mockingbird", "Harper Lee", "9780061120084", 7.99, 5))
+				mock.ExpectPrepare(`SELECT COUNT(*) FROM books WHERE price <= ?`).
+					ExpectQuery().
+					WithArgs(maxPrice).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+				mockRedis.EXPECT().Set(redisKey, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "no search term",
+			args: args{
+				ctx:    context.Background(),
+				filter: books.Filter{},
+				limit:  10,
+				offset: 0,
+			},
+			want: books.Page{
+				Books: []books.Model{
+					{
+						ID:     1,
+						Title:  "1984",
+						Author: "George Orwell",
+						ISBN:   "9780451524935",
+						Price:  money.MustParse("9.99"),
+					},
+					{
+						ID:     2,
+						Title:  "Animal Farm",
+						Author: "George Orwell",
+						ISBN:   "9780451526342",
+						Price:  money.MustParse("8.99"),
+					},
+				},
+				TotalItems: 12,
+				HasNext:    true,
+				Last:       &books.Cursor{ID: 2},
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockRedis.EXPECT().Get("books:author=&search=&sort=:10:0").Return("", errors.New("failed"))
+				mock.ExpectPrepare(`SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, COUNT(*) OVER() AS total_items FROM books ORDER BY id ASC LIMIT ? OFFSET ?`).
 					ExpectQuery().
 					WithArgs(10, 0).
-					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price"}).
-						AddRow(1, "1984", "George Orwell", "9780451524935", 9.99).
-						AddRow(2, "Animal Farm", "George Orwell", "9780451526342", 8.99))
-				mockRedis.EXPECT().Set("books::10:0", gomock.Any(), gomock.Any()).Return(nil, nil)
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price", "total_items"}).
+						AddRow(1, "1984", "George Orwell", "9780451524935", 9.99, 12).
+						AddRow(2, "Animal Farm", "George Orwell", "9780451526342", 8.99, 12))
+				mockRedis.EXPECT().Set("books:author=&search=&sort=:10:0", gomock.Any(), gomock.Any()).Return(nil, nil)
 			},
 		},
 		{
 			name: "no search term, get from redis",
 			args: args{
 				ctx:    context.Background(),
-				search: "",
+				filter: books.Filter{},
 				limit:  10,
 				offset: 0,
 			},
-			want: []books.Model{
-				{
-					ID:     1,
-					Title:  "1984",
-					Author: "George Orwell",
-					ISBN:   "9780451524935",
-					Price:  9.99,
-				},
-				{
-					ID:     2,
-					Title:  "Animal Farm",
-					Author: "George Orwell",
-					ISBN:   "9780451526342",
-					Price:  8.99,
+			want: books.Page{
+				Books: []books.Model{
+					{
+						ID:     1,
+						Title:  "1984",
+						Author: "George Orwell",
+						ISBN:   "9780451524935",
+						Price:  money.MustParse("9.99"),
+					},
+					{
+						ID:     2,
+						Title:  "Animal Farm",
+						Author: "George Orwell",
+						ISBN:   "9780451526342",
+						Price:  money.MustParse("8.99"),
+					},
 				},
+				TotalItems: 12,
+				HasNext:    true,
+				Last:       &books.Cursor{ID: 2},
 			},
 			wantErr: false,
 			mockFn: func(args args) {
-				mockRedis.EXPECT().Get("books::10:0").Return(`[{"id":1,"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"0001-01-01T00:00:00Z","price":9.99},{"id":2,"title":"Animal Farm","author":"George Orwell","isbn":"9780451526342","published_date":"0001-01-01T00:00:00Z","price":8.99}]`, nil)
+				mockRedis.EXPECT().Get("books:author=&search=&sort=:10:0").Return(`{"books":[{"id":1,"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"0001-01-01T00:00:00Z","price":9.99},{"id":2,"title":"Animal Farm","author":"George Orwell","isbn":"9780451526342","published_date":"0001-01-01T00:00:00Z","price":8.99}],"total_items":12,"has_next":true,"last":{"v":"","id":2}}`, nil)
 			},
 		},
 	}
@@ -175,7 +360,7 @@ func Test_repository_GetBooks(t *testing.T) {
 				slaveDB:  slaveDB,
 				redis:    mockRedis,
 			}
-			got, err := r.GetBooks(tt.args.ctx, tt.args.search, tt.args.limit, tt.args.offset)
+			got, err := r.GetBooks(tt.args.ctx, tt.args.filter, tt.args.after, tt.args.limit, tt.args.offset)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetBooks() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -202,7 +387,7 @@ func Test_repository_GetBookByIDs(t *testing.T) {
 		ctx context.Context
 		ids []int64
 	}
-	selectQuery := masterDB.Rebind(`SELECT id, title, author, isbn, published_date, price FROM books WHERE id = ANY(?)`)
+	selectQuery := masterDB.Rebind(`SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available FROM books WHERE id = ANY(?)`)
 	tests := []struct {
 		name    string
 		args    args
@@ -250,14 +435,14 @@ func Test_repository_GetBookByIDs(t *testing.T) {
 					Title:  "1984",
 					Author: "George Orwell",
 					ISBN:   "9780451524935",
-					Price:  9.99,
+					Price:  money.MustParse("9.99"),
 				},
 				2: {
 					ID:     2,
 					Title:  "Animal Farm",
 					Author: "George Orwell",
 					ISBN:   "9780451526342",
-					Price:  8.99,
+					Price:  money.MustParse("8.99"),
 				},
 			},
 			wantErr: false,
@@ -290,3 +475,410 @@ func Test_repository_GetBookByIDs(t *testing.T) {
 		})
 	}
 }
+
+func Test_repository_InsertBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	insertQuery := masterDB.Rebind(`INSERT INTO books
+							(title, author, isbn, published_date, price, stock, created_at, updated_at)
+							VALUES(?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;`)
+	publishedDate := time.Date(1949, time.June, 8, 0, 0, 0, 0, time.UTC)
+
+	type args struct {
+		ctx   context.Context
+		model books.Model
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *books.Model
+		wantErr bool
+		mockFn  func(args args)
+	}{
+		{
+			name: "error when preparing statement",
+			args: args{
+				ctx:   context.Background(),
+				model: books.Model{Title: "1984"},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mock.ExpectPrepare(insertQuery).WillReturnError(errors.New("failed"))
+			},
+		},
+		{
+			name: "error duplicate isbn",
+			args: args{
+				ctx:   context.Background(),
+				model: books.Model{Title: "1984", ISBN: "9780451524935"},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mock.ExpectPrepare(insertQuery).ExpectQuery().WillReturnError(&pq.Error{Code: pqUniqueViolation})
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				model: books.Model{
+					Title:         "1984",
+					Author:        "George Orwell",
+					ISBN:          "9780451524935",
+					PublishedDate: publishedDate,
+					Price:         money.MustParse("9.99"),
+					Stock:         20,
+					CreatedAt:     1714641784000,
+					UpdatedAt:     1714641784000,
+				},
+			},
+			want: &books.Model{
+				ID:            1,
+				Title:         "1984",
+				Author:        "George Orwell",
+				ISBN:          "9780451524935",
+				PublishedDate: publishedDate,
+				Price:         money.MustParse("9.99"),
+				Stock:         20,
+				Available:     true,
+				CreatedAt:     1714641784000,
+				UpdatedAt:     1714641784000,
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mock.ExpectPrepare(insertQuery).ExpectQuery().
+					WithArgs("1984", "George Orwell", "9780451524935", publishedDate, money.MustParse("9.99"), 20, int64(1714641784000), int64(1714641784000)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mockRedis.EXPECT().DelByPattern("books:*").Return(int64(2), nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			r := &repository{
+				masterDB: masterDB,
+				slaveDB:  slaveDB,
+				redis:    mockRedis,
+			}
+			got, err := r.InsertBook(tt.args.ctx, tt.args.model)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InsertBook() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InsertBook() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdateBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	updateQuery := masterDB.Rebind(`UPDATE books
+							SET title = ?, author = ?, isbn = ?, published_date = ?, price = ?, stock = COALESCE(?, stock), updated_at = ?
+							WHERE id = ?;`)
+	model := books.Model{
+		ID:            1,
+		Title:         "1984",
+		Author:        "George Orwell",
+		ISBN:          "9780451524935",
+		PublishedDate: time.Date(1949, time.June, 8, 0, 0, 0, 0, time.UTC),
+		Price:         money.MustParse("12.5"),
+		Stock:         3,
+		UpdatedAt:     1714641784000,
+	}
+	stock := 10
+
+	type args struct {
+		ctx   context.Context
+		model books.Model
+		stock *int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *books.Model
+		wantErr bool
+		mockFn  func(args args)
+	}{
+		{
+			name:    "error when executing statement",
+			args:    args{ctx: context.Background(), model: model},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mock.ExpectPrepare(updateQuery).ExpectExec().WillReturnError(errors.New("failed"))
+			},
+		},
+		{
+			name:    "error book not found",
+			args:    args{ctx: context.Background(), model: model},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mock.ExpectPrepare(updateQuery).ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "success with stock",
+			args: args{ctx: context.Background(), model: model, stock: &stock},
+			want: &books.Model{
+				ID:            1,
+				Title:         "1984",
+				Author:        "George Orwell",
+				ISBN:          "9780451524935",
+				PublishedDate: model.PublishedDate,
+				Price:         money.MustParse("12.5"),
+				Stock:         10,
+				Available:     true,
+				UpdatedAt:     1714641784000,
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mock.ExpectPrepare(updateQuery).ExpectExec().
+					WithArgs(model.Title, model.Author, model.ISBN, model.PublishedDate, model.Price, &stock, model.UpdatedAt, model.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mockRedis.EXPECT().DelByPattern("books:*").Return(int64(0), errors.New("redis down"))
+				mockRedis.EXPECT().Del("book:1").Return(true, nil)
+			},
+		},
+		{
+			name: "success without stock keeps the current stock",
+			args: args{ctx: context.Background(), model: model},
+			want: &books.Model{
+				ID:            1,
+				Title:         "1984",
+				Author:        "George Orwell",
+				ISBN:          "9780451524935",
+				PublishedDate: model.PublishedDate,
+				Price:         money.MustParse("12.5"),
+				Stock:         3,
+				Available:     true,
+				UpdatedAt:     1714641784000,
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mock.ExpectPrepare(updateQuery).ExpectExec().
+					WithArgs(model.Title, model.Author, model.ISBN, model.PublishedDate, model.Price, nil, model.UpdatedAt, model.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mockRedis.EXPECT().DelByPattern("books:*").Return(int64(1), nil)
+				mockRedis.EXPECT().Del("book:1").Return(true, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			r := &repository{
+				masterDB: masterDB,
+				slaveDB:  slaveDB,
+				redis:    mockRedis,
+			}
+			got, err := r.UpdateBook(tt.args.ctx, tt.args.model, tt.args.stock)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateBook() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateBook() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_DeleteBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	deleteQuery := masterDB.Rebind(`DELETE FROM books WHERE id = ?;`)
+
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr string
+		mockFn  func(args args)
+	}{
+		{
+			name:    "error book referenced by orders",
+			args:    args{ctx: context.Background(), id: 1},
+			wantErr: "book with id: 1 is referenced by existing orders",
+			mockFn: func(args args) {
+				mock.ExpectPrepare(deleteQuery).ExpectExec().WithArgs(args.id).WillReturnError(&pq.Error{Code: pqForeignKeyViolation})
+			},
+		},
+		{
+			name:    "error book not found",
+			args:    args{ctx: context.Background(), id: 1},
+			wantErr: "book with id: 1 is not found",
+			mockFn: func(args args) {
+				mock.ExpectPrepare(deleteQuery).ExpectExec().WithArgs(args.id).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "success",
+			args: args{ctx: context.Background(), id: 1},
+			mockFn: func(args args) {
+				mock.ExpectPrepare(deleteQuery).ExpectExec().WithArgs(args.id).WillReturnResult(sqlmock.NewResult(0, 1))
+				mockRedis.EXPECT().DelByPattern("books:*").Return(int64(1), nil)
+				mockRedis.EXPECT().Del("book:1").Return(true, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			r := &repository{
+				masterDB: masterDB,
+				slaveDB:  slaveDB,
+				redis:    mockRedis,
+			}
+			err := r.DeleteBook(tt.args.ctx, tt.args.id)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("DeleteBook() unexpected error = %v", err)
+				return
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("DeleteBook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_repository_GetBookByID(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	selectQuery := slaveDB.Rebind(`SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available FROM books WHERE id = ?`)
+
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *books.Model
+		wantErr bool
+		mockFn  func(args args)
+	}{
+		{
+			name:    "error querying statement",
+			args:    args{ctx: context.Background(), id: 1},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockRedis.EXPECT().Get("book:1").Return("", errors.New("failed"))
+				mock.ExpectPrepare(selectQuery).ExpectQuery().WithArgs(args.id).WillReturnError(errors.New("failed"))
+			},
+		},
+		{
+			name:    "not found",
+			args:    args{ctx: context.Background(), id: 1},
+			want:    nil,
+			wantErr: false,
+			mockFn: func(args args) {
+				mockRedis.EXPECT().Get("book:1").Return("", errors.New("failed"))
+				mock.ExpectPrepare(selectQuery).ExpectQuery().WithArgs(args.id).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price"}))
+			},
+		},
+		{
+			name: "success from db",
+			args: args{ctx: context.Background(), id: 1},
+			want: &books.Model{
+				ID:     1,
+				Title:  "1984",
+				Author: "George Orwell",
+				ISBN:   "9780451524935",
+				Price:  money.MustParse("9.99"),
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockRedis.EXPECT().Get("book:1").Return("", errors.New("failed"))
+				mock.ExpectPrepare(selectQuery).ExpectQuery().WithArgs(args.id).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "isbn", "price"}).
+						AddRow(1, "1984", "George Orwell", "9780451524935", 9.99))
+				mockRedis.EXPECT().Set("book:1", gomock.Any(), int64(300)).Return(nil, nil)
+			},
+		},
+		{
+			name: "success from redis",
+			args: args{ctx: context.Background(), id: 1},
+			want: &books.Model{
+				ID:     1,
+				Title:  "1984",
+				Author: "George Orwell",
+				ISBN:   "9780451524935",
+				Price:  money.MustParse("9.99"),
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockRedis.EXPECT().Get("book:1").Return(`{"id":1,"title":"1984","author":"George Orwell","isbn":"9780451524935","published_date":"0001-01-01T00:00:00Z","price":9.99}`, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			r := &repository{
+				masterDB: masterDB,
+				slaveDB:  slaveDB,
+				redis:    mockRedis,
+			}
+			got, err := r.GetBookByID(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetBookByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetBookByID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
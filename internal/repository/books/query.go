@@ -1,6 +1,32 @@
 package books
 
 var (
-	queryGetBooks = `SELECT id, title, author, isbn, published_date, price
+	queryGetBooks = `SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available
         FROM books`
+
+	// queryListBooks also returns the total of books matching the filter in every row, so the page and the count are a single query
+	queryListBooks = `SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, COUNT(*) OVER() AS total_items
+        FROM books`
+
+	queryCountBooks = `SELECT COUNT(*) FROM books`
+
+	// querySearchBooks ranks the books against a full-text query, the query is passed once and joined as "query"
+	querySearchBooks = `SELECT id, title, author, isbn, published_date, price, stock, stock > 0 AS available, created_at, ts_rank(search_vector, query) AS relevance,
+            COUNT(*) OVER() AS total_items
+        FROM books, websearch_to_tsquery('simple', ?) query
+        WHERE search_vector @@ query`
+
+	queryCountSearchBooks = `SELECT COUNT(*)
+        FROM books, websearch_to_tsquery('simple', ?) query
+        WHERE search_vector @@ query`
+
+	insertBookQuery = `INSERT INTO books
+							(title, author, isbn, published_date, price, stock, created_at, updated_at)
+							VALUES(?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;`
+
+	updateBookQuery = `UPDATE books
+							SET title = ?, author = ?, isbn = ?, published_date = ?, price = ?, stock = COALESCE(?, stock), updated_at = ?
+							WHERE id = ?;`
+
+	deleteBookQuery = `DELETE FROM books WHERE id = ?;`
 )
@@ -0,0 +1,68 @@
+package carts
+
+import (
+	"context"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/constant"
+	"strconv"
+	"time"
+)
+
+// ttl keeps an abandoned cart around for a month, every change to the cart starts it again
+var ttl = int64((30 * 24 * time.Hour).Seconds())
+
+//go:generate mockgen -package=carts -source=carts_repository.go -destination=carts_repository_mock_test.go
+type redis interface {
+	GetAll(key string) (map[string]string, error)
+	Set(key string, value string, ttl int64, field ...interface{}) (interface{}, error)
+	Del(key string, field ...interface{}) (bool, error)
+}
+
+// repository keeps the cart of every user in a redis hash of book id to quantity
+type repository struct {
+	redis redis
+}
+
+func New(redis redis) *repository {
+	return &repository{redis: redis}
+}
+
+// GetCart returns the quantity of every book in the cart of the user
+func (r *repository) GetCart(ctx context.Context, userID int64) (map[int64]int, error) {
+	fields, err := r.redis.GetAll(fmt.Sprintf(constant.RedisKeyCart, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int64]int, len(fields))
+	for field, value := range fields {
+		bookID, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		quantity, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		quantities[bookID] = quantity
+	}
+	return quantities, nil
+}
+
+// SetItem sets the quantity of the book in the cart of the user
+func (r *repository) SetItem(ctx context.Context, userID, bookID int64, quantity int) error {
+	_, err := r.redis.Set(fmt.Sprintf(constant.RedisKeyCart, userID), strconv.Itoa(quantity), ttl, bookID)
+	return err
+}
+
+// DeleteItem removes the book from the cart of the user
+func (r *repository) DeleteItem(ctx context.Context, userID, bookID int64) error {
+	_, err := r.redis.Del(fmt.Sprintf(constant.RedisKeyCart, userID), bookID)
+	return err
+}
+
+// DeleteCart empties the cart of the user
+func (r *repository) DeleteCart(ctx context.Context, userID int64) error {
+	_, err := r.redis.Del(fmt.Sprintf(constant.RedisKeyCart, userID))
+	return err
+}
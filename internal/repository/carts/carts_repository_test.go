@@ -0,0 +1,84 @@
+package carts
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"reflect"
+	"testing"
+)
+
+func Test_repository_GetCart(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	tests := []struct {
+		name    string
+		want    map[int64]int
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on redis",
+			wantErr: true,
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("cart:1").Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name:    "error invalid quantity",
+			wantErr: true,
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("cart:1").Return(map[string]string{"101": "two"}, nil)
+			},
+		},
+		{
+			name: "empty cart",
+			want: map[int64]int{},
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("cart:1").Return(map[string]string{}, nil)
+			},
+		},
+		{
+			name: "success",
+			want: map[int64]int{101: 2, 103: 1},
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("cart:1").Return(map[string]string{"101": "2", "103": "1"}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				redis: mockRedis,
+			}
+			got, err := r.GetCart(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCart() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCart() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_SetItem(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+	mockRedis.EXPECT().Set("cart:1", "3", ttl, int64(101)).Return(int64(1), nil)
+
+	r := &repository{
+		redis: mockRedis,
+	}
+	err := r.SetItem(context.Background(), 1, 101, 3)
+	if err != nil {
+		t.Errorf("SetItem() error = %v", err)
+	}
+}
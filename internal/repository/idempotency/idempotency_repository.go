@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
+	"time"
+)
+
+// ttl is how long a key is remembered, retries after that are treated as new requests
+var ttl = int64((24 * time.Hour).Seconds())
+
+//go:generate mockgen -package=idempotency -source=idempotency_repository.go -destination=idempotency_repository_mock_test.go
+type redis interface {
+	Get(key string, field ...interface{}) (string, error)
+	Set(key string, value string, ttl int64, field ...interface{}) (interface{}, error)
+	SetNX(key string, value string, ttl int64) (bool, error)
+	Del(key string, field ...interface{}) (bool, error)
+}
+
+type repository struct {
+	redis redis
+}
+
+func New(redis redis) *repository {
+	return &repository{redis: redis}
+}
+
+// Reserve claims the key for the request with the given fingerprint. It returns nil when the key was free and is now
+// claimed, otherwise it returns the record of the request that claimed it first.
+func (r *repository) Reserve(ctx context.Context, key, fingerprint string) (*idempotency.Record, error) {
+	val, err := jsoniter.MarshalToString(idempotency.Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := r.redis.SetNX(key, val, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	resStr, err := r.redis.Get(key)
+	if err != nil {
+		return nil, errors.New("failed to read idempotency key, please retry")
+	}
+	var record idempotency.Record
+	err = jsoniter.UnmarshalFromString(resStr, &record)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Save stores the response of the request that reserved the key so retries can replay it
+func (r *repository) Save(ctx context.Context, key, fingerprint string, response interface{}) error {
+	res, err := jsoniter.Marshal(response)
+	if err != nil {
+		return err
+	}
+	val, err := jsoniter.MarshalToString(idempotency.Record{Fingerprint: fingerprint, Response: res})
+	if err != nil {
+		return err
+	}
+	_, err = r.redis.Set(key, val, ttl)
+	return err
+}
+
+// Release frees the key after the request failed, so the client can retry it
+func (r *repository) Release(ctx context.Context, key string) error {
+	_, err := r.redis.Del(key)
+	return err
+}
@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
+	"reflect"
+	"testing"
+)
+
+func Test_repository_Reserve(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	tests := []struct {
+		name    string
+		want    *idempotency.Record
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on setnx",
+			wantErr: true,
+			mockFn: func() {
+				mockRedis.EXPECT().SetNX("key", `{"fingerprint":"abc"}`, ttl).Return(false, errors.New("failed"))
+			},
+		},
+		{
+			name: "reserved",
+			want: nil,
+			mockFn: func() {
+				mockRedis.EXPECT().SetNX("key", `{"fingerprint":"abc"}`, ttl).Return(true, nil)
+			},
+		},
+		{
+			name:    "error on reading the existing record",
+			wantErr: true,
+			mockFn: func() {
+				mockRedis.EXPECT().SetNX("key", `{"fingerprint":"abc"}`, ttl).Return(false, nil)
+				mockRedis.EXPECT().Get("key").Return("", errors.New("failed"))
+			},
+		},
+		{
+			name: "already reserved and in progress",
+			want: &idempotency.Record{Fingerprint: "abc"},
+			mockFn: func() {
+				mockRedis.EXPECT().SetNX("key", `{"fingerprint":"abc"}`, ttl).Return(false, nil)
+				mockRedis.EXPECT().Get("key").Return(`{"fingerprint":"abc"}`, nil)
+			},
+		},
+		{
+			name: "already reserved and done",
+			want: &idempotency.Record{Fingerprint: "abc", Response: json.RawMessage(`{"order_id":1}`)},
+			mockFn: func() {
+				mockRedis.EXPECT().SetNX("key", `{"fingerprint":"abc"}`, ttl).Return(false, nil)
+				mockRedis.EXPECT().Get("key").Return(`{"fingerprint":"abc","response":{"order_id":1}}`, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				redis: mockRedis,
+			}
+			got, err := r.Reserve(context.Background(), "key", "abc")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Reserve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Reserve() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_Save(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+	mockRedis.EXPECT().Set("key", `{"fingerprint":"abc","response":{"order_id":1}}`, ttl).Return("OK", nil)
+
+	r := &repository{
+		redis: mockRedis,
+	}
+	err := r.Save(context.Background(), "key", "abc", map[string]int{"order_id": 1})
+	if err != nil {
+		t.Errorf("Save() error = %v", err)
+	}
+}
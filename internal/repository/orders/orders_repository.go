@@ -2,9 +2,19 @@ package orders
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/outbox"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -32,6 +42,20 @@ func (r *repository) InsertOrder(ctx context.Context, order orders.CreateOrderRe
 	createdAt := time.Now().UnixMilli()
 	updatedAt := createdAt
 
+	// reserve the stock first so an order can't be placed for books we don't have
+	err = r.decrementStock(ctx, tx, order.Items, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// the promotion is redeemed before the order is inserted so the order doesn't count against the limit of the user
+	if order.PromotionID != 0 {
+		err = r.redeemPromotion(ctx, tx, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	stmtOrder, err := tx.PreparexContext(ctx, tx.Rebind(insertOrderQuery))
 	if err != nil {
 		return nil, err
@@ -39,7 +63,9 @@ func (r *repository) InsertOrder(ctx context.Context, order orders.CreateOrderRe
 	defer stmtOrder.Close()
 
 	var orderID int64
-	err = stmtOrder.QueryRowContext(ctx, order.UserID, order.TotalAmount, orders.OrderStatusNew, createdAt, updatedAt).Scan(&orderID)
+	err = stmtOrder.QueryRowContext(ctx, order.UserID, order.Subtotal, sql.NullInt64{Int64: order.PromotionID, Valid: order.PromotionID != 0},
+		order.Discount, order.BillingCountry, order.Tax, order.TaxInclusive, order.TotalAmount, orders.OrderStatusNew, createdAt,
+		updatedAt).Scan(&orderID)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +77,42 @@ func (r *repository) InsertOrder(ctx context.Context, order orders.CreateOrderRe
 	defer stmtOrderItem.Close()
 
 	for _, item := range order.Items {
-		_, err = stmtOrderItem.ExecContext(ctx, orderID, item.BookID, item.Quantity, item.Price, createdAt, updatedAt)
+		_, err = stmtOrderItem.ExecContext(ctx, orderID, item.BookID, item.Quantity, item.Price, item.Discount, item.TaxRate, item.Tax,
+			createdAt, updatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = InsertStatusHistory(ctx, tx, orders.StatusHistory{
+		OrderID:   orderID,
+		To:        orders.OrderStatusNew.String(),
+		ActorID:   order.UserID,
+		ActorRole: users.RoleCustomer.String(),
+		CreatedAt: createdAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertOutboxEvent(ctx, tx, orderID, orders.EventOrderCreated, createdEvent(orderID, order, createdAt), createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	status := orders.OrderStatusNew
+	// a promotion may cover the whole order, then there is nothing to pay and the provider isn't involved
+	if order.TotalAmount == 0 {
+		status = orders.OrderStatusPaid
+		err = UpdateStatus(ctx, tx, orders.StatusTransition{
+			OrderID:           orderID,
+			From:              orders.OrderStatusNew,
+			To:                orders.OrderStatusPaid,
+			ExpectedUpdatedAt: updatedAt,
+			UpdatedAt:         util.NextUpdatedAt(updatedAt),
+			ActorRole:         orders.ActorRoleSystem,
+			Note:              "nothing to pay",
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -59,38 +120,426 @@ func (r *repository) InsertOrder(ctx context.Context, order orders.CreateOrderRe
 
 	response := &orders.CreateOrderResponse{
 		OrderID: orderID,
-		Status:  orders.OrderStatusNew.String(),
+		Status:  status.String(),
 	}
 
 	return response, tx.Commit()
 }
 
-func (r *repository) GetOrdersByUserID(ctx context.Context, userID int64, limit, offset int) ([]orders.History, error) {
-	rebindQuery := r.slaveDB.Rebind(getOrderHistoryByUserID)
-	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
+// GetOrderByID reads the order from the master so a status update never starts from a stale replica
+func (r *repository) GetOrderByID(ctx context.Context, id int64) (*orders.Model, error) {
+	rebindQuery := r.masterDB.Rebind(getOrderByIDQuery)
+	stmt, err := r.masterDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var order orders.Model
+	err = stmt.QueryRowxContext(ctx, id).StructScan(&order)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrderDetail returns the order with the books of its items and its status history, nil when it doesn't exist
+func (r *repository) GetOrderDetail(ctx context.Context, id int64) (*orders.Detail, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(getOrderDetailQuery))
 	if err != nil {
 		return nil, err
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryxContext(ctx, userID, limit, offset)
+	var order orders.Detail
+	err = stmt.GetContext(ctx, &order, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	stmtItem, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(getOrderDetailItemsQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmtItem.Close()
+
+	order.Items = make([]orders.ItemDetail, 0)
+	err = stmtItem.SelectContext(ctx, &order.Items, id)
+	if err != nil {
+		return nil, err
+	}
+
+	stmtHistory, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(getOrderStatusHistoryQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmtHistory.Close()
+
+	order.StatusHistory = make([]orders.StatusHistory, 0)
+	err = stmtHistory.SelectContext(ctx, &order.StatusHistory, id)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// UpdateOrderStatus applies the transition and records it in the status history within one transaction
+func (r *repository) UpdateOrderStatus(ctx context.Context, transition orders.StatusTransition) error {
+	tx, err := r.masterDB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = UpdateStatus(ctx, tx, transition)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CancelOrder cancels the order and puts the ordered quantity back into the stock within one transaction,
+// it returns the ids of the restocked books
+func (r *repository) CancelOrder(ctx context.Context, transition orders.StatusTransition) ([]int64, error) {
+	tx, err := r.masterDB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = UpdateStatus(ctx, tx, transition)
+	if err != nil {
+		return nil, err
+	}
+
+	bookIDs, err := r.restock(ctx, tx, transition.OrderID, transition.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.releasePromotion(ctx, tx, transition.OrderID)
 	if err != nil {
 		return nil, err
 	}
+
+	return bookIDs, tx.Commit()
+}
+
+// UpdateStatus moves the order to the next status and records the transition within the transaction of the caller,
+// it fails when the order was updated since it was read. Every change of the status goes through it, refunds of
+// returns included, so there is one concurrency check and one status history.
+func UpdateStatus(ctx context.Context, tx *sqlx.Tx, transition orders.StatusTransition) error {
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(updateOrderStatusQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, transition.To, transition.UpdatedAt, transition.OrderID, transition.ExpectedUpdatedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("order was updated by another request, please reload it")
+	}
+
+	err = InsertStatusHistory(ctx, tx, orders.StatusHistory{
+		OrderID:   transition.OrderID,
+		From:      transition.From.String(),
+		To:        transition.To.String(),
+		ActorID:   transition.ActorID,
+		ActorRole: transition.ActorRole,
+		Note:      transition.Note,
+		CreatedAt: transition.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return insertOutboxEvent(ctx, tx, transition.OrderID, orders.EventOrderStatusChanged, orders.StatusChangedEvent{
+		OrderID:    transition.OrderID,
+		FromStatus: transition.From.String(),
+		ToStatus:   transition.To.String(),
+		ActorID:    transition.ActorID,
+		ActorRole:  transition.ActorRole,
+		Note:       transition.Note,
+		ChangedAt:  transition.UpdatedAt,
+	}, transition.UpdatedAt)
+}
+
+// insertOutboxEvent writes the event of the order within the transaction of the change, so the event is only
+// delivered once the change is committed and a committed change always has its event
+func insertOutboxEvent(ctx context.Context, tx *sqlx.Tx, orderID int64, eventType string, payload interface{}, createdAt int64) error {
+	event, err := outbox.NewEvent(orders.AggregateType, orderID, eventType, payload, createdAt)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(insertOutboxEventQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, event.AggregateType, event.AggregateID, event.EventType, string(event.Payload), event.Status,
+		event.NextAttemptAt, event.CreatedAt)
+	return err
+}
+
+func createdEvent(orderID int64, order orders.CreateOrderRequest, createdAt int64) orders.CreatedEvent {
+	event := orders.CreatedEvent{
+		OrderID:        orderID,
+		UserID:         order.UserID,
+		Subtotal:       order.Subtotal,
+		PromotionID:    order.PromotionID,
+		Discount:       order.Discount,
+		BillingCountry: order.BillingCountry,
+		Tax:            order.Tax,
+		TaxInclusive:   order.TaxInclusive,
+		TotalAmount:    order.TotalAmount,
+		Status:         orders.OrderStatusNew.String(),
+		Items:          make([]orders.CreatedEventItem, 0, len(order.Items)),
+		CreatedAt:      createdAt,
+	}
+	for _, item := range order.Items {
+		event.Items = append(event.Items, orders.CreatedEventItem{
+			BookID:   item.BookID,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+			Discount: item.Discount,
+			Tax:      item.Tax,
+		})
+	}
+	return event
+}
+
+// InsertStatusHistory records a step of the order within the transaction of the caller, a step that doesn't change
+// the status has the same from and to
+func InsertStatusHistory(ctx context.Context, tx *sqlx.Tx, history orders.StatusHistory) error {
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(insertOrderStatusHistoryQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var from, actorID interface{}
+	if history.From != "" {
+		from = history.From
+	}
+	if history.ActorID != 0 {
+		actorID = history.ActorID
+	}
+	_, err = stmt.ExecContext(ctx, history.OrderID, from, history.To, actorID, history.ActorRole, history.Note, history.CreatedAt)
+	return err
+}
+
+// decrementStock takes the ordered quantity out of the stock of every book within the order transaction.
+// Books are updated in ascending id order so concurrent orders lock them in the same order and can't deadlock.
+func (r *repository) decrementStock(ctx context.Context, tx *sqlx.Tx, items []orders.CreateOrderItem, updatedAt int64) error {
+	quantities := make(map[int64]int)
+	bookIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		if _, ok := quantities[item.BookID]; !ok {
+			bookIDs = append(bookIDs, item.BookID)
+		}
+		quantities[item.BookID] += item.Quantity
+	}
+	sort.Slice(bookIDs, func(i, j int) bool {
+		return bookIDs[i] < bookIDs[j]
+	})
+
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(decrementStockQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var outOfStock []string
+	for _, bookID := range bookIDs {
+		res, err := stmt.ExecContext(ctx, quantities[bookID], updatedAt, bookID, quantities[bookID])
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			outOfStock = append(outOfStock, strconv.FormatInt(bookID, 10))
+		}
+	}
+	if len(outOfStock) > 0 {
+		return fmt.Errorf("out of stock for book_ids: %s", strings.Join(outOfStock, ", "))
+	}
+	return nil
+}
+
+// redeemPromotion takes a redemption of the promotion and one of the user, the rows of both stay locked until the
+// order is placed so concurrent orders can't redeem past either limit
+func (r *repository) redeemPromotion(ctx context.Context, tx *sqlx.Tx, order orders.CreateOrderRequest) error {
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(redeemPromotionQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var perUserLimit int
+	err = stmt.GetContext(ctx, &perUserLimit, order.PromotionID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("promo code %s has reached its usage limit", order.PromoCode)
+	}
+	if err != nil {
+		return err
+	}
+
+	stmtUser, err := tx.PreparexContext(ctx, tx.Rebind(redeemUserPromotionQuery))
+	if err != nil {
+		return err
+	}
+	defer stmtUser.Close()
+
+	res, err := stmtUser.ExecContext(ctx, order.PromotionID, order.UserID, perUserLimit, perUserLimit)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("promo code %s has reached its usage limit for this user", order.PromoCode)
+	}
+	return nil
+}
+
+func (r *repository) releasePromotion(ctx context.Context, tx *sqlx.Tx, orderID int64) error {
+	for _, query := range []string{releasePromotionQuery, releaseUserPromotionQuery} {
+		stmt, err := tx.PreparexContext(ctx, tx.Rebind(query))
+		if err != nil {
+			return err
+		}
+		_, err = stmt.ExecContext(ctx, orderID)
+		stmt.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restock puts the quantity of every book of the order back into its stock
+func (r *repository) restock(ctx context.Context, tx *sqlx.Tx, orderID int64, updatedAt int64) ([]int64, error) {
+	stmtQuantity, err := tx.PreparexContext(ctx, tx.Rebind(getOrderQuantitiesQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmtQuantity.Close()
+
+	var quantities []struct {
+		BookID   int64 `db:"book_id"`
+		Quantity int   `db:"quantity"`
+	}
+	err = stmtQuantity.SelectContext(ctx, &quantities, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	bookQuantities := make(map[int64]int, len(quantities))
+	for _, quantity := range quantities {
+		bookQuantities[quantity.BookID] += quantity.Quantity
+	}
+	return IncrementStock(ctx, tx, bookQuantities, updatedAt)
+}
+
+// IncrementStock puts the quantities back into the stock of the books within the transaction of the caller and returns
+// the ids of the books. Books are updated in ascending id order like decrementStock so the updates can't deadlock.
+func IncrementStock(ctx context.Context, tx *sqlx.Tx, quantities map[int64]int, updatedAt int64) ([]int64, error) {
+	bookIDs := make([]int64, 0, len(quantities))
+	for bookID := range quantities {
+		bookIDs = append(bookIDs, bookID)
+	}
+	sort.Slice(bookIDs, func(i, j int) bool {
+		return bookIDs[i] < bookIDs[j]
+	})
+
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(incrementStockQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, bookID := range bookIDs {
+		_, err = stmt.ExecContext(ctx, quantities[bookID], updatedAt, bookID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bookIDs, nil
+}
+
+// GetOrdersByUserID returns a page of the order history of the user, either by offset or after the given keyset position
+func (r *repository) GetOrdersByUserID(ctx context.Context, userID int64, after *orders.Cursor, limit, offset int) (orders.HistoryPage, error) {
+	query := getOrderHistoryByUserID
+	args := []interface{}{userID, limit, offset}
+	if after != nil {
+		query = getOrderHistoryByUserIDAfter
+		args = []interface{}{userID, after.CreatedAt, after.ID, limit}
+		offset = 0
+	}
+
+	rebindQuery := r.slaveDB.Rebind(query)
+	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return orders.HistoryPage{}, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryxContext(ctx, args...)
+	if err != nil {
+		return orders.HistoryPage{}, err
+	}
 	defer rows.Close()
 
-	var ordersList []orders.History
+	var (
+		ordersList    []orders.History
+		matchingItems int64 // orders of the user, only the ones after the cursor in keyset mode
+	)
 	for rows.Next() {
 		var order orders.History
-		err = rows.Scan(&order.ID, &order.TotalAmount, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+		err = rows.Scan(&order.ID, &order.Subtotal, &order.Discount, &order.Tax, &order.TaxInclusive, &order.TotalAmount, &order.Status,
+			&order.CreatedAt, &order.UpdatedAt, &matchingItems)
 		if err != nil {
-			return nil, err
+			return orders.HistoryPage{}, err
 		}
 		ordersList = append(ordersList, order)
 	}
 
+	page := orders.HistoryPage{
+		TotalItems: matchingItems,
+		HasNext:    int64(offset+len(ordersList)) < matchingItems,
+	}
+
+	// a page past the last one has no row to carry the total, and a keyset page only counts the rest of the history
+	if (len(ordersList) == 0 && offset > 0) || after != nil {
+		page.TotalItems, err = r.countOrdersByUserID(ctx, userID)
+		if err != nil {
+			return orders.HistoryPage{}, err
+		}
+	}
 	if len(ordersList) == 0 {
-		return nil, nil
+		return page, nil
 	}
 
 	orderIDs := make([]int64, len(ordersList))
@@ -101,13 +550,13 @@ func (r *repository) GetOrdersByUserID(ctx context.Context, userID int64, limit,
 	rebindItemQuery := r.slaveDB.Rebind(getItemsQuery)
 	stmtItem, err := r.slaveDB.PreparexContext(ctx, rebindItemQuery)
 	if err != nil {
-		return nil, err
+		return orders.HistoryPage{}, err
 	}
 	defer stmtItem.Close()
 
 	itemsRows, err := stmtItem.QueryxContext(ctx, pq.Array(orderIDs))
 	if err != nil {
-		return nil, err
+		return orders.HistoryPage{}, err
 	}
 	defer itemsRows.Close()
 
@@ -119,9 +568,10 @@ func (r *repository) GetOrdersByUserID(ctx context.Context, userID int64, limit,
 			orderID int64
 		)
 
-		err = itemsRows.Scan(&item.ID, &orderID, &item.BookID, &item.Quantity, &item.Price)
+		err = itemsRows.Scan(&item.ID, &orderID, &item.BookID, &item.Quantity, &item.Price, &item.Discount, &item.Tax, &item.ReturnedQuantity,
+			&item.RefundedAmount)
 		if err != nil {
-			return nil, err
+			return orders.HistoryPage{}, err
 		}
 		itemsMap[orderID] = append(itemsMap[orderID], item)
 	}
@@ -131,5 +581,22 @@ func (r *repository) GetOrdersByUserID(ctx context.Context, userID int64, limit,
 		ordersList[i].Items = itemsMap[order.ID]
 	}
 
-	return ordersList, nil
+	page.Histories = ordersList
+	return page, nil
+}
+
+func (r *repository) countOrdersByUserID(ctx context.Context, userID int64) (int64, error) {
+	rebindQuery := r.slaveDB.Rebind(countOrdersByUserID)
+	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var total int64
+	err = stmt.GetContext(ctx, &total, userID)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
 }
@@ -8,6 +8,8 @@ import (
 	"github.com/lib/pq"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
 	"reflect"
 	"testing"
 )
@@ -23,14 +25,52 @@ func Test_repository_InsertOrder(t *testing.T) {
 	}()
 
 	insertOrderQueryTest := masterDB.Rebind(`
-        INSERT INTO orders (user_id, total_amount, status, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?)
+        INSERT INTO orders (user_id, subtotal_amount, promotion_id, discount_amount, billing_country, tax_amount,
+            tax_inclusive, total_amount, status, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         RETURNING id;
     `)
 
 	insertOrderItemQueryTest := masterDB.Rebind(`
-        INSERT INTO order_items (order_id, book_id, quantity, price, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?, ?);
+        INSERT INTO order_items (order_id, book_id, quantity, price, discount_amount, tax_rate, tax_amount, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	redeemPromotionQueryTest := masterDB.Rebind(`
+        UPDATE promotions
+        SET used_count = used_count + 1
+        WHERE id = ? AND (usage_limit = 0 OR used_count < usage_limit)
+        RETURNING per_user_limit;
+    `)
+
+	redeemUserPromotionQueryTest := masterDB.Rebind(`
+        INSERT INTO promotion_redemptions (promotion_id, user_id, count)
+        VALUES (?, ?, 1)
+        ON CONFLICT (promotion_id, user_id) DO UPDATE
+        SET count = promotion_redemptions.count + 1
+        WHERE ? = 0 OR promotion_redemptions.count < ?;
+    `)
+
+	decrementStockQueryTest := masterDB.Rebind(`
+        UPDATE books
+        SET stock = stock - ?, updated_at = ?
+        WHERE id = ? AND stock >= ?;
+    `)
+
+	insertOrderStatusHistoryQueryTest := masterDB.Rebind(`
+        INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, note, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	insertOutboxEventQueryTest := masterDB.Rebind(`
+        INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, status, next_attempt_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	updateOrderStatusQueryTest := masterDB.Rebind(`
+        UPDATE orders
+        SET status = ?, updated_at = ?
+        WHERE id = ? AND updated_at = ?;
     `)
 
 	type args struct {
@@ -38,11 +78,12 @@ func Test_repository_InsertOrder(t *testing.T) {
 		order orders.CreateOrderRequest
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    *orders.CreateOrderResponse
-		wantErr bool
-		mockFn  func(args args)
+		name       string
+		args       args
+		want       *orders.CreateOrderResponse
+		wantErr    bool
+		wantErrMsg string
+		mockFn     func(args args)
 	}{
 		{
 			name: "error on begin transaction",
@@ -50,12 +91,12 @@ func Test_repository_InsertOrder(t *testing.T) {
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -66,18 +107,70 @@ func Test_repository_InsertOrder(t *testing.T) {
 				mock.ExpectBegin().WillReturnError(errors.New("failed to begin transaction"))
 			},
 		},
+		{
+			name: "error on prepare decrement stock query",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   101,
+							Quantity: 2,
+							Price:    money.MustParse("50"),
+						},
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).WillReturnError(errors.New("failed to prepare decrement stock query"))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "error out of stock names every book without enough stock",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 103, Quantity: 1, Price: money.MustParse("10")},
+						{BookID: 101, Quantity: 2, Price: money.MustParse("20")},
+						{BookID: 102, Quantity: 1, Price: money.MustParse("10")},
+						{BookID: 101, Quantity: 1, Price: money.MustParse("20")},
+					},
+				},
+			},
+			want:       nil,
+			wantErr:    true,
+			wantErrMsg: "out of stock for book_ids: 101, 103",
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				// books are locked in ascending id order and the quantity of the same book is summed
+				stmt := mock.ExpectPrepare(decrementStockQueryTest)
+				stmt.ExpectExec().WithArgs(3, sqlmock.AnyArg(), 101, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+				stmt.ExpectExec().WithArgs(1, sqlmock.AnyArg(), 102, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				stmt.ExpectExec().WithArgs(1, sqlmock.AnyArg(), 103, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
 		{
 			name: "error on prepare insert order query",
 			args: args{
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -86,6 +179,9 @@ func Test_repository_InsertOrder(t *testing.T) {
 			wantErr: true,
 			mockFn: func(args args) {
 				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectPrepare(insertOrderQueryTest).WillReturnError(errors.New("failed to prepare order query"))
 				mock.ExpectRollback()
 			},
@@ -96,12 +192,12 @@ func Test_repository_InsertOrder(t *testing.T) {
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -110,6 +206,9 @@ func Test_repository_InsertOrder(t *testing.T) {
 			wantErr: true,
 			mockFn: func(args args) {
 				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().WillReturnError(errors.New("failed to insert order"))
 				mock.ExpectRollback()
 			},
@@ -120,12 +219,12 @@ func Test_repository_InsertOrder(t *testing.T) {
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -134,6 +233,9 @@ func Test_repository_InsertOrder(t *testing.T) {
 			wantErr: true,
 			mockFn: func(args args) {
 				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 				mock.ExpectPrepare(insertOrderItemQueryTest).WillReturnError(errors.New("failed to prepare order item query"))
@@ -146,12 +248,12 @@ func Test_repository_InsertOrder(t *testing.T) {
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -160,24 +262,89 @@ func Test_repository_InsertOrder(t *testing.T) {
 			wantErr: true,
 			mockFn: func(args args) {
 				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 				mock.ExpectPrepare(insertOrderItemQueryTest).ExpectExec().WillReturnError(errors.New("failed to insert order item"))
 				mock.ExpectRollback()
 			},
 		},
+		{
+			name: "error on insert status history",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   101,
+							Quantity: 2,
+							Price:    money.MustParse("50"),
+						},
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectPrepare(insertOrderItemQueryTest).ExpectExec().
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WillReturnError(errors.New("failed to insert status history"))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "error on insert outbox event rolls the order back",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("50")},
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectPrepare(insertOrderItemQueryTest).ExpectExec().
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WillReturnError(errors.New("failed to insert outbox event"))
+				mock.ExpectRollback()
+			},
+		},
 		{
 			name: "success",
 			args: args{
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -189,10 +356,178 @@ func Test_repository_InsertOrder(t *testing.T) {
 			wantErr: false,
 			mockFn: func(args args) {
 				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 				mock.ExpectPrepare(insertOrderItemQueryTest).ExpectExec().
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, nil, "NEW", 1, "customer", "", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WithArgs("order", 1, "OrderCreated", sqlmock.AnyArg(), "PENDING", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "success order with nothing to pay is paid right away",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					PromoCode:   "FREE",
+					PromotionID: 4,
+					Subtotal:    money.MustParse("100"),
+					Discount:    money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("50"), Discount: money.MustParse("100")},
+					},
+				},
+			},
+			want: &orders.CreateOrderResponse{
+				OrderID: 1,
+				Status:  orders.OrderStatusPaid.String(),
+			},
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(redeemPromotionQueryTest).ExpectQuery().
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows([]string{"per_user_limit"}).AddRow(1))
+				mock.ExpectPrepare(redeemUserPromotionQueryTest).ExpectExec().
+					WithArgs(4, 1, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectPrepare(insertOrderItemQueryTest).ExpectExec().
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, nil, "NEW", 1, "customer", "", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WithArgs("order", 1, "OrderCreated", sqlmock.AnyArg(), "PENDING", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("PAID", sqlmock.AnyArg(), 1, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, "NEW", "PAID", nil, "system", "nothing to pay", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WithArgs("order", 1, "OrderStatusChanged", sqlmock.AnyArg(), "PENDING", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "error promo code has no redemption left",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					PromoCode:   "SAVE10",
+					PromotionID: 4,
+					Discount:    money.MustParse("10"),
+					TotalAmount: money.MustParse("90"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("50"), Discount: money.MustParse("10")},
+					},
+				},
+			},
+			wantErr:    true,
+			wantErrMsg: "promo code SAVE10 has reached its usage limit",
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(redeemPromotionQueryTest).ExpectQuery().
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows([]string{"per_user_limit"}))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "error user has no redemption of the promo code left",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					PromoCode:   "SAVE10",
+					PromotionID: 4,
+					Discount:    money.MustParse("10"),
+					TotalAmount: money.MustParse("90"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("50"), Discount: money.MustParse("10")},
+					},
+				},
+			},
+			wantErr:    true,
+			wantErrMsg: "promo code SAVE10 has reached its usage limit for this user",
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(redeemPromotionQueryTest).ExpectQuery().
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows([]string{"per_user_limit"}).AddRow(1))
+				mock.ExpectPrepare(redeemUserPromotionQueryTest).ExpectExec().
+					WithArgs(4, 1, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "success with promo code records the discounts",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:         1,
+					PromoCode:      "SAVE10",
+					PromotionID:    4,
+					Subtotal:       money.MustParse("100"),
+					Discount:       money.MustParse("10"),
+					BillingCountry: "CA",
+					Tax:            money.MustParse("4.50"),
+					TotalAmount:    money.MustParse("94.50"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("50"), Discount: money.MustParse("10"), TaxRate: percent.MustParse("5"), Tax: money.MustParse("4.50")},
+					},
+				},
+			},
+			want: &orders.CreateOrderResponse{
+				OrderID: 1,
+				Status:  orders.OrderStatusNew.String(),
+			},
+			mockFn: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(decrementStockQueryTest).ExpectExec().
+					WithArgs(2, sqlmock.AnyArg(), 101, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(redeemPromotionQueryTest).ExpectQuery().
+					WithArgs(4).
+					WillReturnRows(sqlmock.NewRows([]string{"per_user_limit"}).AddRow(1))
+				mock.ExpectPrepare(redeemUserPromotionQueryTest).ExpectExec().
+					WithArgs(4, 1, 1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderQueryTest).ExpectQuery().
+					WithArgs(1, "100.00", 4, "10.00", "CA", "4.50", false, "94.50", "NEW", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectPrepare(insertOrderItemQueryTest).ExpectExec().
+					WithArgs(1, 101, 2, "50.00", "10.00", "5.00", "4.50", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, nil, "NEW", 1, "customer", "", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WithArgs("order", 1, "OrderCreated", sqlmock.AnyArg(), "PENDING", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 			},
 		},
@@ -208,6 +543,9 @@ func Test_repository_InsertOrder(t *testing.T) {
 				t.Errorf("InsertOrder() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErrMsg != "" && err.Error() != tt.wantErrMsg {
+				t.Errorf("InsertOrder() error = %v, want %v", err, tt.wantErrMsg)
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("InsertOrder() got = %v, want %v", got, tt.want)
 			}
@@ -228,19 +566,32 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 	type args struct {
 		ctx    context.Context
 		userID int64
+		after  *orders.Cursor
 		limit  int
 		offset int
 	}
 	getOrderQueryTest := slaveDB.Rebind(`
-					SELECT id, total_amount, status, created_at, updated_at
+					SELECT id, subtotal_amount, discount_amount, tax_amount, tax_inclusive, total_amount, status, created_at, updated_at,
+						COUNT(*) OVER() AS total_items
 					FROM orders
 					WHERE user_id = ?
-					ORDER BY created_at DESC
+					ORDER BY created_at DESC, id DESC
 					LIMIT ? OFFSET ?
 				`)
 
+	getOrderAfterQueryTest := slaveDB.Rebind(`
+					SELECT id, subtotal_amount, discount_amount, tax_amount, tax_inclusive, total_amount, status, created_at, updated_at,
+						COUNT(*) OVER() AS total_items
+					FROM orders
+					WHERE user_id = ? AND (created_at, id) < (?, ?)
+					ORDER BY created_at DESC, id DESC
+					LIMIT ?
+				`)
+
+	countOrderQueryTest := slaveDB.Rebind(`SELECT COUNT(*) FROM orders WHERE user_id = ?`)
+
 	getOrderItemQueryTest := slaveDB.Rebind(`
-					SELECT id, order_id, book_id, quantity, price
+					SELECT id, order_id, book_id, quantity, price, discount_amount, tax_amount, returned_quantity, refunded_amount
 					FROM order_items
 					WHERE order_id = ANY(?)
 				`)
@@ -248,7 +599,7 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		want    []orders.History
+		want    orders.HistoryPage
 		wantErr bool
 		mockFn  func(args args)
 	}{
@@ -260,7 +611,7 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    orders.HistoryPage{},
 			wantErr: true,
 			mockFn: func(args args) {
 				mock.ExpectPrepare(getOrderQueryTest).WillReturnError(errors.New("failed"))
@@ -274,7 +625,7 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    orders.HistoryPage{},
 			wantErr: true,
 			mockFn: func(args args) {
 				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
@@ -290,11 +641,11 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    orders.HistoryPage{},
 			wantErr: true,
 			mockFn: func(args args) {
-				rows := sqlmock.NewRows([]string{"id", "total_amount", "status", "created_at", "updated_at"}).
-					AddRow("invalid_id", 100, "NEW", 1623550814, 1623550814)
+				rows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"}).
+					AddRow("invalid_id", 100, 0, 0, true, 100, "NEW", 1623550814, 1623550814, 1)
 				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
 					WithArgs(1, 10, 0).
 					WillReturnRows(rows)
@@ -308,15 +659,35 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    orders.HistoryPage{},
 			wantErr: false,
 			mockFn: func(args args) {
-				rows := sqlmock.NewRows([]string{"id", "total_amount", "status", "created_at", "updated_at"})
+				rows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"})
 				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
 					WithArgs(1, 10, 0).
 					WillReturnRows(rows)
 			},
 		},
+		{
+			name: "page past the last one counts the orders",
+			args: args{
+				ctx:    context.Background(),
+				userID: 1,
+				limit:  10,
+				offset: 30,
+			},
+			want:    orders.HistoryPage{TotalItems: 25},
+			wantErr: false,
+			mockFn: func(args args) {
+				rows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"})
+				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
+					WithArgs(1, 10, 30).
+					WillReturnRows(rows)
+				mock.ExpectPrepare(countOrderQueryTest).ExpectQuery().
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(25))
+			},
+		},
 		{
 			name: "error when preparing items statement",
 			args: args{
@@ -325,11 +696,11 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    orders.HistoryPage{},
 			wantErr: true,
 			mockFn: func(args args) {
-				rows := sqlmock.NewRows([]string{"id", "total_amount", "status", "created_at", "updated_at"}).
-					AddRow(1, 100, "NEW", 1623550814, 1623550814)
+				rows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"}).
+					AddRow(1, 100, 0, 0, true, 100, "NEW", 1623550814, 1623550814, 3)
 				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
 					WithArgs(1, 10, 0).
 					WillReturnRows(rows)
@@ -344,11 +715,11 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    orders.HistoryPage{},
 			wantErr: true,
 			mockFn: func(args args) {
-				rows := sqlmock.NewRows([]string{"id", "total_amount", "status", "created_at", "updated_at"}).
-					AddRow(1, 100, "NEW", 1623550814, 1623550814)
+				rows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"}).
+					AddRow(1, 100, 0, 0, true, 100, "NEW", 1623550814, 1623550814, 3)
 				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
 					WithArgs(1, 10, 0).
 					WillReturnRows(rows)
@@ -365,17 +736,17 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want:    nil,
+			want:    orders.HistoryPage{},
 			wantErr: true,
 			mockFn: func(args args) {
-				orderRows := sqlmock.NewRows([]string{"id", "total_amount", "status", "created_at", "updated_at"}).
-					AddRow(1, 100, "NEW", 1623550814, 1623550814)
+				orderRows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"}).
+					AddRow(1, 100, 0, 0, true, 100, "NEW", 1623550814, 1623550814, 3)
 				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
 					WithArgs(1, 10, 0).
 					WillReturnRows(orderRows)
 
-				itemsRows := sqlmock.NewRows([]string{"id", "order_id", "book_id", "quantity", "price"}).
-					AddRow("invalid_id", 1, 1, 2, 50)
+				itemsRows := sqlmock.NewRows([]string{"id", "order_id", "book_id", "quantity", "price", "discount_amount", "tax_amount", "returned_quantity", "refunded_amount"}).
+					AddRow("invalid_id", 1, 1, 2, 50, 0, 0, 0, 0)
 				mock.ExpectPrepare(getOrderItemQueryTest).ExpectQuery().
 					WithArgs(pq.Array([]int64{1})).
 					WillReturnRows(itemsRows)
@@ -389,33 +760,90 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 				limit:  10,
 				offset: 0,
 			},
-			want: []orders.History{
-				{
-					ID:          1,
-					TotalAmount: 100,
-					Status:      "NEW",
-					CreatedAt:   1623550814,
-					UpdatedAt:   1623550814,
-					Items: []orders.ItemHistory{
-						{
-							ID:       1,
-							BookID:   1,
-							Quantity: 2,
-							Price:    50,
+			want: orders.HistoryPage{
+				Histories: []orders.History{
+					{
+						ID:           1,
+						Subtotal:     money.MustParse("100"),
+						TaxInclusive: true,
+						TotalAmount:  money.MustParse("100"),
+						Status:       "NEW",
+						CreatedAt:    1623550814,
+						UpdatedAt:    1623550814,
+						Items: []orders.ItemHistory{
+							{
+								ID:               1,
+								BookID:           1,
+								Quantity:         2,
+								Price:            money.MustParse("50"),
+								ReturnedQuantity: 1,
+								RefundedAmount:   money.MustParse("50"),
+							},
 						},
 					},
 				},
+				TotalItems: 3,
+				HasNext:    true,
 			},
 			wantErr: false,
 			mockFn: func(args args) {
-				orderRows := sqlmock.NewRows([]string{"id", "total_amount", "status", "created_at", "updated_at"}).
-					AddRow(1, 100, "NEW", 1623550814, 1623550814)
+				orderRows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"}).
+					AddRow(1, 100, 0, 0, true, 100, "NEW", 1623550814, 1623550814, 3)
 				mock.ExpectPrepare(getOrderQueryTest).ExpectQuery().
 					WithArgs(1, 10, 0).
 					WillReturnRows(orderRows)
 
-				itemsRows := sqlmock.NewRows([]string{"id", "order_id", "book_id", "quantity", "price"}).
-					AddRow(1, 1, 1, 2, 50)
+				itemsRows := sqlmock.NewRows([]string{"id", "order_id", "book_id", "quantity", "price", "discount_amount", "tax_amount", "returned_quantity", "refunded_amount"}).
+					AddRow(1, 1, 1, 2, 50, 0, 0, 1, 50)
+				mock.ExpectPrepare(getOrderItemQueryTest).ExpectQuery().
+					WithArgs(pq.Array([]int64{1})).
+					WillReturnRows(itemsRows)
+			},
+		},
+		{
+			name: "next page after cursor counts every order of the user",
+			args: args{
+				ctx:    context.Background(),
+				userID: 1,
+				after:  &orders.Cursor{CreatedAt: 1623550900, ID: 2},
+				limit:  10,
+			},
+			want: orders.HistoryPage{
+				Histories: []orders.History{
+					{
+						ID:           1,
+						Subtotal:     money.MustParse("100"),
+						TaxInclusive: true,
+						TotalAmount:  money.MustParse("100"),
+						Status:       "NEW",
+						CreatedAt:    1623550814,
+						UpdatedAt:    1623550814,
+						Items: []orders.ItemHistory{
+							{
+								ID:       1,
+								BookID:   1,
+								Quantity: 2,
+								Price:    money.MustParse("50"),
+							},
+						},
+					},
+				},
+				TotalItems: 2,
+				HasNext:    false,
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				orderRows := sqlmock.NewRows([]string{"id", "subtotal_amount", "discount_amount", "tax_amount", "tax_inclusive", "total_amount", "status", "created_at", "updated_at", "total_items"}).
+					AddRow(1, 100, 0, 0, true, 100, "NEW", 1623550814, 1623550814, 1)
+				mock.ExpectPrepare(getOrderAfterQueryTest).ExpectQuery().
+					WithArgs(1, 1623550900, 2, 10).
+					WillReturnRows(orderRows)
+				mock.ExpectPrepare(countOrderQueryTest).ExpectQuery().
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+				itemsRows := sqlmock.NewRows([]string{"id", "order_id", "book_id", "quantity", "price", "discount_amount", "tax_amount", "returned_quantity", "refunded_amount"}).
+					AddRow(1, 1, 1, 2, 50, 0, 0, 0, 0)
 				mock.ExpectPrepare(getOrderItemQueryTest).ExpectQuery().
 					WithArgs(pq.Array([]int64{1})).
 					WillReturnRows(itemsRows)
@@ -428,7 +856,7 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 			r := &repository{
 				slaveDB: slaveDB,
 			}
-			got, err := r.GetOrdersByUserID(tt.args.ctx, tt.args.userID, tt.args.limit, tt.args.offset)
+			got, err := r.GetOrdersByUserID(tt.args.ctx, tt.args.userID, tt.args.after, tt.args.limit, tt.args.offset)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetOrdersByUserID() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -439,3 +867,458 @@ func Test_repository_GetOrdersByUserID(t *testing.T) {
 		})
 	}
 }
+
+func Test_repository_GetOrderByID(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	getOrderByIDQueryTest := masterDB.Rebind(`
+		SELECT id, user_id, total_amount, status, created_at, updated_at
+		FROM orders
+		WHERE id = ?
+	`)
+
+	tests := []struct {
+		name    string
+		id      int64
+		want    *orders.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on prepare",
+			id:      1,
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(getOrderByIDQueryTest).WillReturnError(errors.New("failed to prepare"))
+			},
+		},
+		{
+			name: "not found",
+			id:   1,
+			want: nil,
+			mockFn: func() {
+				mock.ExpectPrepare(getOrderByIDQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_amount", "status", "created_at", "updated_at"}))
+			},
+		},
+		{
+			name: "success",
+			id:   1,
+			want: &orders.Model{ID: 1, UserID: 2, TotalAmount: money.MustParse("19.98"), Status: "PAID", CreatedAt: 1000, UpdatedAt: 2000},
+			mockFn: func() {
+				mock.ExpectPrepare(getOrderByIDQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_amount", "status", "created_at", "updated_at"}).
+						AddRow(1, 2, "19.98", "PAID", 1000, 2000))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.GetOrderByID(context.Background(), tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetOrderByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetOrderByID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdateOrderStatus(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updateOrderStatusQueryTest := masterDB.Rebind(`
+        UPDATE orders
+        SET status = ?, updated_at = ?
+        WHERE id = ? AND updated_at = ?;
+    `)
+
+	insertOrderStatusHistoryQueryTest := masterDB.Rebind(`
+        INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, note, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	insertOutboxEventQueryTest := masterDB.Rebind(`
+        INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, status, next_attempt_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	transition := orders.StatusTransition{
+		OrderID:           1,
+		From:              orders.OrderStatusPaid,
+		To:                orders.OrderStatusShipped,
+		ExpectedUpdatedAt: 1000,
+		UpdatedAt:         2000,
+		ActorID:           9,
+		ActorRole:         "admin",
+		Note:              "JNE 123",
+	}
+
+	tests := []struct {
+		name       string
+		wantErr    bool
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:    "error on begin transaction",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectBegin().WillReturnError(errors.New("failed to begin"))
+			},
+		},
+		{
+			name:       "error order was updated concurrently",
+			wantErr:    true,
+			wantErrMsg: "order was updated by another request, please reload it",
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("SHIPPED", 2000, 1, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "error on insert status history",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("SHIPPED", 2000, 1, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WillReturnError(errors.New("failed to insert status history"))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "error on insert outbox event",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("SHIPPED", 2000, 1, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WillReturnError(errors.New("failed to insert outbox event"))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("SHIPPED", 2000, 1, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, "PAID", "SHIPPED", 9, "admin", "JNE 123", 2000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WithArgs("order", 1, "OrderStatusChanged",
+						`{"order_id":1,"from_status":"PAID","to_status":"SHIPPED","actor_id":9,"actor_role":"admin","note":"JNE 123","changed_at":2000}`,
+						"PENDING", 2000, 2000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			err := r.UpdateOrderStatus(context.Background(), transition)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateOrderStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrMsg != "" && err.Error() != tt.wantErrMsg {
+				t.Errorf("UpdateOrderStatus() error = %v, want %v", err, tt.wantErrMsg)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_CancelOrder(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updateOrderStatusQueryTest := masterDB.Rebind(`
+        UPDATE orders
+        SET status = ?, updated_at = ?
+        WHERE id = ? AND updated_at = ?;
+    `)
+
+	insertOrderStatusHistoryQueryTest := masterDB.Rebind(`
+        INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, note, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	insertOutboxEventQueryTest := masterDB.Rebind(`
+        INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, status, next_attempt_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	getOrderQuantitiesQueryTest := masterDB.Rebind(`
+		SELECT book_id, SUM(quantity) AS quantity
+		FROM order_items
+		WHERE order_id = ?
+		GROUP BY book_id
+		ORDER BY book_id
+	`)
+
+	incrementStockQueryTest := masterDB.Rebind(`
+        UPDATE books
+        SET stock = stock + ?, updated_at = ?
+        WHERE id = ?;
+    `)
+
+	releasePromotionQueryTest := masterDB.Rebind(`
+        UPDATE promotions
+        SET used_count = used_count - 1
+        WHERE id = (SELECT promotion_id FROM orders WHERE id = ?) AND used_count > 0;
+    `)
+
+	releaseUserPromotionQueryTest := masterDB.Rebind(`
+        UPDATE promotion_redemptions
+        SET count = count - 1
+        WHERE (promotion_id, user_id) = (SELECT promotion_id, user_id FROM orders WHERE id = ?) AND count > 0;
+    `)
+
+	transition := orders.StatusTransition{
+		OrderID:           1,
+		From:              orders.OrderStatusNew,
+		To:                orders.OrderStatusCancelled,
+		ExpectedUpdatedAt: 1000,
+		UpdatedAt:         2000,
+		ActorID:           2,
+		ActorRole:         "customer",
+	}
+
+	tests := []struct {
+		name       string
+		want       []int64
+		wantErr    bool
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error order was updated concurrently",
+			wantErr:    true,
+			wantErrMsg: "order was updated by another request, please reload it",
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("CANCELLED", 2000, 1, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "error on restock",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("CANCELLED", 2000, 1, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(getOrderQuantitiesQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"book_id", "quantity"}).AddRow(101, 2))
+				mock.ExpectPrepare(incrementStockQueryTest).ExpectExec().
+					WillReturnError(errors.New("failed to restock"))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "success",
+			want: []int64{101, 103},
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("CANCELLED", 2000, 1, 1000).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, "NEW", "CANCELLED", 2, "customer", "", 2000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WithArgs("order", 1, "OrderStatusChanged", sqlmock.AnyArg(), "PENDING", 2000, 2000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(getOrderQuantitiesQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"book_id", "quantity"}).AddRow(101, 2).AddRow(103, 1))
+				incrementStock := mock.ExpectPrepare(incrementStockQueryTest)
+				incrementStock.ExpectExec().WithArgs(2, 2000, 101).WillReturnResult(sqlmock.NewResult(0, 1))
+				incrementStock.ExpectExec().WithArgs(1, 2000, 103).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(releasePromotionQueryTest).ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(releaseUserPromotionQueryTest).ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.CancelOrder(context.Background(), transition)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CancelOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrMsg != "" && err.Error() != tt.wantErrMsg {
+				t.Errorf("CancelOrder() error = %v, want %v", err, tt.wantErrMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CancelOrder() got = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_GetOrderDetail(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	getOrderDetailQueryTest := slaveDB.Rebind(`
+		SELECT o.id, o.user_id, o.subtotal_amount, o.discount_amount, COALESCE(p.code, '') AS promo_code, o.billing_country,
+			o.tax_amount, o.tax_inclusive, o.total_amount, o.status, o.created_at, o.updated_at
+		FROM orders o
+		LEFT JOIN promotions p ON p.id = o.promotion_id
+		WHERE o.id = ?
+	`)
+
+	getOrderDetailItemsQueryTest := slaveDB.Rebind(`
+		SELECT oi.id, oi.book_id, b.title, b.author, b.isbn, oi.quantity, oi.price, oi.discount_amount, oi.tax_rate, oi.tax_amount,
+			oi.returned_quantity, oi.refunded_amount
+		FROM order_items oi
+		JOIN books b ON b.id = oi.book_id
+		WHERE oi.order_id = ?
+		ORDER BY oi.id
+	`)
+
+	getOrderStatusHistoryQueryTest := slaveDB.Rebind(`
+		SELECT id, order_id, COALESCE(from_status, '') AS from_status, to_status, COALESCE(actor_id, 0) AS actor_id,
+			actor_role, note, created_at
+		FROM order_status_history
+		WHERE order_id = ?
+		ORDER BY id
+	`)
+
+	orderColumns := []string{"id", "user_id", "subtotal_amount", "discount_amount", "promo_code", "billing_country", "tax_amount", "tax_inclusive",
+		"total_amount", "status", "created_at", "updated_at"}
+
+	tests := []struct {
+		name    string
+		want    *orders.Detail
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name: "not found",
+			want: nil,
+			mockFn: func() {
+				mock.ExpectPrepare(getOrderDetailQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(orderColumns))
+			},
+		},
+		{
+			name:    "error on get items",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(getOrderDetailQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(1, 2, "19.98", "0", "", "ID", "1.98", true, "19.98", "PAID", 1000, 2000))
+				mock.ExpectPrepare(getOrderDetailItemsQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnError(errors.New("failed to get items"))
+			},
+		},
+		{
+			name: "success",
+			want: &orders.Detail{
+				ID:             1,
+				UserID:         2,
+				Subtotal:       money.MustParse("19.98"),
+				Discount:       money.MustParse("2"),
+				PromoCode:      "SAVE2",
+				BillingCountry: "CA",
+				Tax:            money.MustParse("0.90"),
+				TotalAmount:    money.MustParse("18.88"),
+				Status:         "PAID",
+				CreatedAt:      1000,
+				UpdatedAt:      2000,
+				Items: []orders.ItemDetail{
+					{ID: 5, BookID: 3, Title: "1984", Author: "George Orwell", ISBN: "9780451524935", Quantity: 2, Price: money.MustParse("9.99"),
+						Discount: money.MustParse("2"), TaxRate: percent.MustParse("5"), Tax: money.MustParse("0.90")},
+				},
+				StatusHistory: []orders.StatusHistory{
+					{ID: 1, OrderID: 1, To: "NEW", ActorID: 2, ActorRole: "customer", CreatedAt: 1000},
+					{ID: 2, OrderID: 1, From: "NEW", To: "AWAITING_PAYMENT", ActorID: 9, ActorRole: "admin", CreatedAt: 1500},
+					{ID: 3, OrderID: 1, From: "AWAITING_PAYMENT", To: "PAID", ActorID: 9, ActorRole: "admin", Note: "paid by transfer", CreatedAt: 2000},
+				},
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(getOrderDetailQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(1, 2, "19.98", "2.00", "SAVE2", "CA", "0.90", false, "18.88", "PAID", 1000, 2000))
+				mock.ExpectPrepare(getOrderDetailItemsQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "book_id", "title", "author", "isbn", "quantity", "price", "discount_amount", "tax_rate", "tax_amount",
+						"returned_quantity", "refunded_amount"}).
+						AddRow(5, 3, "1984", "George Orwell", "9780451524935", 2, "9.99", "2.00", "5.00", "0.90", 0, "0"))
+				mock.ExpectPrepare(getOrderStatusHistoryQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "from_status", "to_status", "actor_id", "actor_role", "note", "created_at"}).
+						AddRow(1, 1, "", "NEW", 2, "customer", "", 1000).
+						AddRow(2, 1, "NEW", "AWAITING_PAYMENT", 9, "admin", "", 1500).
+						AddRow(3, 1, "AWAITING_PAYMENT", "PAID", 9, "admin", "paid by transfer", 2000))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				slaveDB: slaveDB,
+			}
+			got, err := r.GetOrderDetail(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetOrderDetail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetOrderDetail() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,25 +2,147 @@ package orders
 
 var (
 	insertOrderQuery = `
-        INSERT INTO orders (user_id, total_amount, status, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?)
+        INSERT INTO orders (user_id, subtotal_amount, promotion_id, discount_amount, billing_country, tax_amount,
+            tax_inclusive, total_amount, status, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         RETURNING id;
     `
 	insertOrderItemQuery = `
-        INSERT INTO order_items (order_id, book_id, quantity, price, created_at, updated_at)
-        VALUES (?, ?, ?, ?, ?, ?);
+        INSERT INTO order_items (order_id, book_id, quantity, price, discount_amount, tax_rate, tax_amount, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+    `
+
+	// redeemPromotionQuery only matches while the promotion has redemptions left overall. The update locks the promotion
+	// until the transaction ends, a concurrent order waits and checks used_count again once it is committed.
+	redeemPromotionQuery = `
+        UPDATE promotions
+        SET used_count = used_count + 1
+        WHERE id = ? AND (usage_limit = 0 OR used_count < usage_limit)
+        RETURNING per_user_limit;
+    `
+
+	// redeemUserPromotionQuery counts the redemption of the user while the user has redemptions left, a per user limit of
+	// 0 means unlimited. The upsert locks the row of the user, a concurrent order of the same user waits and checks the
+	// count again once it is committed, which a count of the orders of the user can't do since it doesn't see them.
+	redeemUserPromotionQuery = `
+        INSERT INTO promotion_redemptions (promotion_id, user_id, count)
+        VALUES (?, ?, 1)
+        ON CONFLICT (promotion_id, user_id) DO UPDATE
+        SET count = promotion_redemptions.count + 1
+        WHERE ? = 0 OR promotion_redemptions.count < ?;
+    `
+
+	// releasePromotionQuery gives the redemption of a cancelled order back, orders without a promotion match nothing
+	releasePromotionQuery = `
+        UPDATE promotions
+        SET used_count = used_count - 1
+        WHERE id = (SELECT promotion_id FROM orders WHERE id = ?) AND used_count > 0;
+    `
+
+	// releaseUserPromotionQuery gives the redemption of a cancelled order back to its user
+	releaseUserPromotionQuery = `
+        UPDATE promotion_redemptions
+        SET count = count - 1
+        WHERE (promotion_id, user_id) = (SELECT promotion_id, user_id FROM orders WHERE id = ?) AND count > 0;
+    `
+
+	insertOrderStatusHistoryQuery = `
+        INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, note, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `
+
+	insertOutboxEventQuery = `
+        INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, status, next_attempt_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `
+
+	getOrderByIDQuery = `
+		SELECT id, user_id, total_amount, status, created_at, updated_at
+		FROM orders
+		WHERE id = ?
+	`
+
+	getOrderDetailQuery = `
+		SELECT o.id, o.user_id, o.subtotal_amount, o.discount_amount, COALESCE(p.code, '') AS promo_code, o.billing_country,
+			o.tax_amount, o.tax_inclusive, o.total_amount, o.status, o.created_at, o.updated_at
+		FROM orders o
+		LEFT JOIN promotions p ON p.id = o.promotion_id
+		WHERE o.id = ?
+	`
+
+	getOrderDetailItemsQuery = `
+		SELECT oi.id, oi.book_id, b.title, b.author, b.isbn, oi.quantity, oi.price, oi.discount_amount, oi.tax_rate, oi.tax_amount,
+			oi.returned_quantity, oi.refunded_amount
+		FROM order_items oi
+		JOIN books b ON b.id = oi.book_id
+		WHERE oi.order_id = ?
+		ORDER BY oi.id
+	`
+
+	getOrderStatusHistoryQuery = `
+		SELECT id, order_id, COALESCE(from_status, '') AS from_status, to_status, COALESCE(actor_id, 0) AS actor_id,
+			actor_role, note, created_at
+		FROM order_status_history
+		WHERE order_id = ?
+		ORDER BY id
+	`
+
+	// updateOrderStatusQuery only matches when nobody updated the order since it was read
+	updateOrderStatusQuery = `
+        UPDATE orders
+        SET status = ?, updated_at = ?
+        WHERE id = ? AND updated_at = ?;
+    `
+
+	// decrementStockQuery only matches when there is enough stock, the update also locks the book until the transaction ends
+	decrementStockQuery = `
+        UPDATE books
+        SET stock = stock - ?, updated_at = ?
+        WHERE id = ? AND stock >= ?;
+    `
+
+	// getOrderQuantitiesQuery sums the quantity per book, sorted by book id so restocking locks the books in the same order as ordering
+	getOrderQuantitiesQuery = `
+		SELECT book_id, SUM(quantity) AS quantity
+		FROM order_items
+		WHERE order_id = ?
+		GROUP BY book_id
+		ORDER BY book_id
+	`
+
+	incrementStockQuery = `
+        UPDATE books
+        SET stock = stock + ?, updated_at = ?
+        WHERE id = ?;
     `
 
 	getOrderHistoryByUserID = `
-		SELECT id, total_amount, status, created_at, updated_at
+		SELECT id, subtotal_amount, discount_amount, tax_amount, tax_inclusive, total_amount, status, created_at, updated_at,
+			COUNT(*) OVER() AS total_items
 		FROM orders
 		WHERE user_id = ?
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT ? OFFSET ?
 	`
 
+	// getOrderHistoryByUserIDAfter is the keyset version of getOrderHistoryByUserID, the total only counts the orders after the cursor
+	getOrderHistoryByUserIDAfter = `
+		SELECT id, subtotal_amount, discount_amount, tax_amount, tax_inclusive, total_amount, status, created_at, updated_at,
+			COUNT(*) OVER() AS total_items
+		FROM orders
+		WHERE user_id = ? AND (created_at, id) < (?, ?)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	countOrdersByUserID = `
+		SELECT COUNT(*)
+		FROM orders
+		WHERE user_id = ?
+	`
+
 	getItemsQuery = `
-		SELECT id, order_id, book_id, quantity, price
+		SELECT id, order_id, book_id, quantity, price, discount_amount, tax_amount, returned_quantity, refunded_amount
 		FROM order_items
 		WHERE order_id = ANY(?)
 	`
@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/outbox"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"sort"
+)
+
+// repository only uses the master, the dispatcher claims events by updating them
+type repository struct {
+	masterDB internalsql.MasterDB
+}
+
+func New(masterDB internalsql.MasterDB) *repository {
+	return &repository{masterDB: masterDB}
+}
+
+// eventRow is an event as stored, the payload is JSONB and the sinks it was delivered to a postgres array
+type eventRow struct {
+	outbox.Event
+	Payload     string         `db:"payload"`
+	DeliveredTo pq.StringArray `db:"delivered_to"`
+}
+
+func (row eventRow) toModel() outbox.Event {
+	event := row.Event
+	event.Payload = json.RawMessage(row.Payload)
+	event.DeliveredTo = []string(row.DeliveredTo)
+	return event
+}
+
+// ClaimEvents returns up to limit pending events that are due at now, oldest first. The events are kept from other
+// dispatchers until leaseUntil, an event that isn't updated by then is claimed again.
+func (r *repository) ClaimEvents(ctx context.Context, limit int, now, leaseUntil int64) ([]outbox.Event, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(claimEventsQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryxContext(ctx, leaseUntil, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]outbox.Event, 0)
+	for rows.Next() {
+		var row eventRow
+		err = rows.StructScan(&row)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, row.toModel())
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	return events, nil
+}
+
+// UpdateDelivery records the outcome of an attempt to deliver the event
+func (r *repository) UpdateDelivery(ctx context.Context, event outbox.Event) error {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(updateDeliveryQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	deliveredTo := event.DeliveredTo
+	if deliveredTo == nil {
+		deliveredTo = make([]string, 0)
+	}
+	_, err = stmt.ExecContext(ctx, event.Status, event.Attempts, pq.StringArray(deliveredTo), event.LastError, event.NextAttemptAt,
+		event.DeliveredAt, event.ID)
+	return err
+}
@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/outbox"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"reflect"
+	"testing"
+)
+
+var eventColumns = []string{"id", "aggregate_type", "aggregate_id", "event_type", "payload", "status", "attempts", "delivered_to",
+	"last_error", "next_attempt_at", "created_at", "delivered_at"}
+
+func Test_repository_ClaimEvents(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	claimEventsQueryTest := masterDB.Rebind(`
+        UPDATE outbox
+        SET next_attempt_at = ?
+        WHERE id IN (
+            SELECT id FROM outbox
+            WHERE status = 'PENDING' AND next_attempt_at <= ?
+            ORDER BY next_attempt_at, id
+            LIMIT ?
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, aggregate_type, aggregate_id, event_type, payload, status, attempts, delivered_to, last_error,
+            next_attempt_at, created_at, delivered_at;
+    `)
+
+	tests := []struct {
+		name    string
+		want    []outbox.Event
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on claim",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(claimEventsQueryTest).ExpectQuery().
+					WithArgs(61000, 1000, 100).
+					WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name: "no event is due",
+			want: []outbox.Event{},
+			mockFn: func() {
+				mock.ExpectPrepare(claimEventsQueryTest).ExpectQuery().
+					WithArgs(61000, 1000, 100).
+					WillReturnRows(sqlmock.NewRows(eventColumns))
+			},
+		},
+		{
+			name: "success sorts the claimed events",
+			want: []outbox.Event{
+				{
+					ID: 3, AggregateType: "order", AggregateID: 1, EventType: "OrderCreated", Payload: json.RawMessage(`{"order_id":1}`),
+					Status: "PENDING", DeliveredTo: []string{}, NextAttemptAt: 61000, CreatedAt: 900,
+				},
+				{
+					ID: 5, AggregateType: "order", AggregateID: 1, EventType: "OrderStatusChanged", Payload: json.RawMessage(`{"order_id":1}`),
+					Status: "PENDING", Attempts: 2, DeliveredTo: []string{"log"}, LastError: "webhook: connection refused",
+					NextAttemptAt: 61000, CreatedAt: 950,
+				},
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(claimEventsQueryTest).ExpectQuery().
+					WithArgs(61000, 1000, 100).
+					WillReturnRows(sqlmock.NewRows(eventColumns).
+						AddRow(5, "order", 1, "OrderStatusChanged", `{"order_id":1}`, "PENDING", 2, "{log}", "webhook: connection refused", 61000, 950, 0).
+						AddRow(3, "order", 1, "OrderCreated", `{"order_id":1}`, "PENDING", 0, "{}", "", 61000, 900, 0))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.ClaimEvents(context.Background(), 100, 1000, 61000)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ClaimEvents() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClaimEvents() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdateDelivery(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updateDeliveryQueryTest := masterDB.Rebind(`
+        UPDATE outbox
+        SET status = ?, attempts = ?, delivered_to = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+        WHERE id = ?;
+    `)
+
+	tests := []struct {
+		name    string
+		event   outbox.Event
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on update",
+			event:   outbox.Event{ID: 3, Status: "DELIVERED", Attempts: 1, DeliveredTo: []string{"log"}, NextAttemptAt: 1000, DeliveredAt: 1500},
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(updateDeliveryQueryTest).ExpectExec().
+					WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name:  "success delivered",
+			event: outbox.Event{ID: 3, Status: "DELIVERED", Attempts: 1, DeliveredTo: []string{"log", "webhook"}, NextAttemptAt: 1000, DeliveredAt: 1500},
+			mockFn: func() {
+				mock.ExpectPrepare(updateDeliveryQueryTest).ExpectExec().
+					WithArgs("DELIVERED", 1, `{"log","webhook"}`, "", 1000, 1500, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:  "success dead letter without any delivery",
+			event: outbox.Event{ID: 5, Status: "DEAD", Attempts: 10, LastError: "webhook: timeout", NextAttemptAt: 1000},
+			mockFn: func() {
+				mock.ExpectPrepare(updateDeliveryQueryTest).ExpectExec().
+					WithArgs("DEAD", 10, "{}", "webhook: timeout", 1000, 0, 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			err := r.UpdateDelivery(context.Background(), tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpdateDelivery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
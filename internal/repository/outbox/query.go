@@ -0,0 +1,25 @@
+package outbox
+
+var (
+	// claimEventsQuery pushes the next attempt of the due events back by the lease so no other dispatcher picks
+	// them up while they are delivered, events locked by another dispatcher are skipped instead of waited for
+	claimEventsQuery = `
+        UPDATE outbox
+        SET next_attempt_at = ?
+        WHERE id IN (
+            SELECT id FROM outbox
+            WHERE status = 'PENDING' AND next_attempt_at <= ?
+            ORDER BY next_attempt_at, id
+            LIMIT ?
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, aggregate_type, aggregate_id, event_type, payload, status, attempts, delivered_to, last_error,
+            next_attempt_at, created_at, delivered_at;
+    `
+
+	updateDeliveryQuery = `
+        UPDATE outbox
+        SET status = ?, attempts = ?, delivered_to = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+        WHERE id = ?;
+    `
+)
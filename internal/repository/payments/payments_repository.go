@@ -0,0 +1,135 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+const pqUniqueViolation = "23505"
+
+// repository reads payments from the master only, a payment is always read right before it is updated
+type repository struct {
+	masterDB internalsql.MasterDB
+}
+
+func New(masterDB internalsql.MasterDB) *repository {
+	return &repository{masterDB: masterDB}
+}
+
+// InsertPayment records the payment attempt, an order can only hold one authorized or captured payment at a time
+func (r *repository) InsertPayment(ctx context.Context, model payments.Model) (*payments.Model, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(insertPaymentQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, model.OrderID, model.Provider, model.Reference, model.Amount, model.Status,
+		model.FailureReason, model.CreatedAt, model.UpdatedAt).Scan(&model.ID)
+	if err != nil {
+		if isPQError(err, pqUniqueViolation) {
+			return nil, fmt.Errorf("order with id: %d is already paid or has a payment in progress", model.OrderID)
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *repository) GetPaymentsByOrderID(ctx context.Context, orderID int64) ([]payments.Model, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(getPaymentsByOrderIDQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryxContext(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paymentList := make([]payments.Model, 0)
+	for rows.Next() {
+		var payment payments.Model
+		err = rows.StructScan(&payment)
+		if err != nil {
+			return nil, err
+		}
+		paymentList = append(paymentList, payment)
+	}
+	return paymentList, rows.Err()
+}
+
+// GetPaymentByReference returns the payment of the provider with the reference, nil when there is none
+func (r *repository) GetPaymentByReference(ctx context.Context, provider, reference string) (*payments.Model, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(getPaymentByReferenceQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var payment payments.Model
+	err = stmt.QueryRowxContext(ctx, provider, reference).StructScan(&payment)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// UpdatePaymentStatus moves the payment from one status to the next, it fails when the payment already moved on
+func (r *repository) UpdatePaymentStatus(ctx context.Context, id int64, from, to payments.Status, failureReason string, updatedAt int64) error {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(updatePaymentStatusQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, to, failureReason, updatedAt, id, from)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("payment was updated by another request")
+	}
+	return nil
+}
+
+// RefundPayment adds the amount to the refunds of a captured payment and moves it to the given status
+func (r *repository) RefundPayment(ctx context.Context, id int64, amount money.Amount, status payments.Status, updatedAt int64) error {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(refundPaymentQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, amount, status, updatedAt, id, amount)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("payment was updated by another request")
+	}
+	return nil
+}
+
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
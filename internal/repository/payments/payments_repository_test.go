@@ -0,0 +1,360 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"reflect"
+	"testing"
+)
+
+var paymentColumns = []string{"id", "order_id", "provider", "reference", "amount", "refunded_amount", "status", "failure_reason", "created_at", "updated_at"}
+
+func Test_repository_InsertPayment(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	insertPaymentQueryTest := masterDB.Rebind(`
+        INSERT INTO payments (order_id, provider, reference, amount, status, failure_reason, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING id;
+    `)
+
+	model := payments.Model{
+		OrderID:   1,
+		Provider:  "fake",
+		Reference: "fake_1_1",
+		Amount:    money.MustParse("19.98"),
+		Status:    "AUTHORIZED",
+		CreatedAt: 1000,
+		UpdatedAt: 1000,
+	}
+
+	tests := []struct {
+		name       string
+		want       *payments.Model
+		wantErr    bool
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:    "error on prepare",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(insertPaymentQueryTest).WillReturnError(errors.New("failed to prepare"))
+			},
+		},
+		{
+			name:    "error on insert",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(insertPaymentQueryTest).ExpectQuery().
+					WithArgs(1, "fake", "fake_1_1", "19.98", "AUTHORIZED", "", 1000, 1000).
+					WillReturnError(errors.New("duplicate key"))
+			},
+		},
+		{
+			name:       "error order already has an active payment",
+			wantErr:    true,
+			wantErrMsg: "order with id: 1 is already paid or has a payment in progress",
+			mockFn: func() {
+				mock.ExpectPrepare(insertPaymentQueryTest).ExpectQuery().
+					WithArgs(1, "fake", "fake_1_1", "19.98", "AUTHORIZED", "", 1000, 1000).
+					WillReturnError(&pq.Error{Code: pqUniqueViolation})
+			},
+		},
+		{
+			name: "success",
+			want: func() *payments.Model {
+				inserted := model
+				inserted.ID = 7
+				return &inserted
+			}(),
+			mockFn: func() {
+				mock.ExpectPrepare(insertPaymentQueryTest).ExpectQuery().
+					WithArgs(1, "fake", "fake_1_1", "19.98", "AUTHORIZED", "", 1000, 1000).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.InsertPayment(context.Background(), model)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InsertPayment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrMsg != "" && err.Error() != tt.wantErrMsg {
+				t.Errorf("InsertPayment() error = %v, want %v", err, tt.wantErrMsg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InsertPayment() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_GetPaymentsByOrderID(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	getPaymentsByOrderIDQueryTest := masterDB.Rebind(`
+		SELECT id, order_id, provider, reference, amount, refunded_amount, status, failure_reason, created_at, updated_at
+		FROM payments
+		WHERE order_id = ?
+		ORDER BY id
+	`)
+
+	tests := []struct {
+		name    string
+		want    []payments.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on query",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(getPaymentsByOrderIDQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnError(errors.New("failed to query"))
+			},
+		},
+		{
+			name: "no payments",
+			want: []payments.Model{},
+			mockFn: func() {
+				mock.ExpectPrepare(getPaymentsByOrderIDQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(paymentColumns))
+			},
+		},
+		{
+			name: "success",
+			want: []payments.Model{
+				{ID: 1, OrderID: 1, Provider: "fake", Reference: "fake_1_1", Amount: money.MustParse("19.98"), Status: "FAILED", FailureReason: "card declined", CreatedAt: 1000, UpdatedAt: 1000},
+				{ID: 2, OrderID: 1, Provider: "fake", Reference: "fake_1_2", Amount: money.MustParse("19.98"), RefundedAmount: money.MustParse("9.99"), Status: "CAPTURED", CreatedAt: 2000, UpdatedAt: 2001},
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(getPaymentsByOrderIDQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(paymentColumns).
+						AddRow(1, 1, "fake", "fake_1_1", "19.98", "0", "FAILED", "card declined", 1000, 1000).
+						AddRow(2, 1, "fake", "fake_1_2", "19.98", "9.99", "CAPTURED", "", 2000, 2001))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.GetPaymentsByOrderID(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPaymentsByOrderID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPaymentsByOrderID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_GetPaymentByReference(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	getPaymentByReferenceQueryTest := masterDB.Rebind(`
+		SELECT id, order_id, provider, reference, amount, refunded_amount, status, failure_reason, created_at, updated_at
+		FROM payments
+		WHERE provider = ? AND reference = ?
+	`)
+
+	tests := []struct {
+		name    string
+		want    *payments.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on prepare",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(getPaymentByReferenceQueryTest).WillReturnError(errors.New("failed to prepare"))
+			},
+		},
+		{
+			name: "not found",
+			want: nil,
+			mockFn: func() {
+				mock.ExpectPrepare(getPaymentByReferenceQueryTest).ExpectQuery().WithArgs("fake", "fake_1_1").
+					WillReturnRows(sqlmock.NewRows(paymentColumns))
+			},
+		},
+		{
+			name: "success",
+			want: &payments.Model{ID: 1, OrderID: 1, Provider: "fake", Reference: "fake_1_1", Amount: money.MustParse("19.98"), Status: "AUTHORIZED", CreatedAt: 1000, UpdatedAt: 1000},
+			mockFn: func() {
+				mock.ExpectPrepare(getPaymentByReferenceQueryTest).ExpectQuery().WithArgs("fake", "fake_1_1").
+					WillReturnRows(sqlmock.NewRows(paymentColumns).
+						AddRow(1, 1, "fake", "fake_1_1", "19.98", "0", "AUTHORIZED", "", 1000, 1000))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.GetPaymentByReference(context.Background(), "fake", "fake_1_1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPaymentByReference() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPaymentByReference() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdatePaymentStatus(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updatePaymentStatusQueryTest := masterDB.Rebind(`
+        UPDATE payments
+        SET status = ?, failure_reason = ?, updated_at = ?
+        WHERE id = ? AND status = ?;
+    `)
+
+	tests := []struct {
+		name       string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error on exec",
+			wantErrMsg: "failed to exec",
+			mockFn: func() {
+				mock.ExpectPrepare(updatePaymentStatusQueryTest).ExpectExec().WithArgs("CAPTURED", "", 2000, 1, "AUTHORIZED").
+					WillReturnError(errors.New("failed to exec"))
+			},
+		},
+		{
+			name:       "error payment already moved on",
+			wantErrMsg: "payment was updated by another request",
+			mockFn: func() {
+				mock.ExpectPrepare(updatePaymentStatusQueryTest).ExpectExec().WithArgs("CAPTURED", "", 2000, 1, "AUTHORIZED").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mock.ExpectPrepare(updatePaymentStatusQueryTest).ExpectExec().WithArgs("CAPTURED", "", 2000, 1, "AUTHORIZED").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			err := r.UpdatePaymentStatus(context.Background(), 1, payments.StatusAuthorized, payments.StatusCaptured, "", 2000)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("UpdatePaymentStatus() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("UpdatePaymentStatus() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_RefundPayment(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	refundPaymentQueryTest := masterDB.Rebind(`
+        UPDATE payments
+        SET refunded_amount = refunded_amount + ?, status = ?, updated_at = ?
+        WHERE id = ? AND status = 'CAPTURED' AND refunded_amount + ? <= amount;
+    `)
+
+	tests := []struct {
+		name       string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error on exec",
+			wantErrMsg: "failed to exec",
+			mockFn: func() {
+				mock.ExpectPrepare(refundPaymentQueryTest).ExpectExec().WithArgs("9.99", "CAPTURED", 2000, 1, "9.99").
+					WillReturnError(errors.New("failed to exec"))
+			},
+		},
+		{
+			name:       "error payment no longer captured",
+			wantErrMsg: "payment was updated by another request",
+			mockFn: func() {
+				mock.ExpectPrepare(refundPaymentQueryTest).ExpectExec().WithArgs("9.99", "CAPTURED", 2000, 1, "9.99").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mock.ExpectPrepare(refundPaymentQueryTest).ExpectExec().WithArgs("9.99", "CAPTURED", 2000, 1, "9.99").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			err := r.RefundPayment(context.Background(), 1, money.MustParse("9.99"), payments.StatusCaptured, 2000)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("RefundPayment() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("RefundPayment() unexpected error = %v", err)
+			}
+		})
+	}
+}
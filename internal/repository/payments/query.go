@@ -0,0 +1,36 @@
+package payments
+
+var (
+	insertPaymentQuery = `
+        INSERT INTO payments (order_id, provider, reference, amount, status, failure_reason, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING id;
+    `
+
+	getPaymentsByOrderIDQuery = `
+		SELECT id, order_id, provider, reference, amount, refunded_amount, status, failure_reason, created_at, updated_at
+		FROM payments
+		WHERE order_id = ?
+		ORDER BY id
+	`
+
+	getPaymentByReferenceQuery = `
+		SELECT id, order_id, provider, reference, amount, refunded_amount, status, failure_reason, created_at, updated_at
+		FROM payments
+		WHERE provider = ? AND reference = ?
+	`
+
+	// updatePaymentStatusQuery only matches while the payment is still in the status it was read in
+	updatePaymentStatusQuery = `
+        UPDATE payments
+        SET status = ?, failure_reason = ?, updated_at = ?
+        WHERE id = ? AND status = ?;
+    `
+
+	// refundPaymentQuery only matches while the payment is captured and the refunds don't exceed the captured amount
+	refundPaymentQuery = `
+        UPDATE payments
+        SET refunded_amount = refunded_amount + ?, status = ?, updated_at = ?
+        WHERE id = ? AND status = 'CAPTURED' AND refunded_amount + ? <= amount;
+    `
+)
@@ -0,0 +1,196 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+)
+
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
+type repository struct {
+	masterDB internalsql.MasterDB
+	slaveDB  internalsql.SlaveDB
+}
+
+func New(masterDB internalsql.MasterDB, slaveDB internalsql.SlaveDB) *repository {
+	return &repository{
+		masterDB: masterDB,
+		slaveDB:  slaveDB,
+	}
+}
+
+// promotionRow is a promotion as stored, the eligible books and authors are postgres arrays and discount_value is
+// read as the percentage or the amount once the type is known
+type promotionRow struct {
+	promotions.Model
+	RawDiscountValue []byte         `db:"discount_value"`
+	BookIDs          pq.Int64Array  `db:"book_ids"`
+	Authors          pq.StringArray `db:"authors"`
+}
+
+func (row promotionRow) toModel() (promotions.Model, error) {
+	model := row.Model
+	if err := model.SetDiscountValue(row.RawDiscountValue); err != nil {
+		return promotions.Model{}, err
+	}
+	model.BookIDs = []int64(row.BookIDs)
+	model.Authors = []string(row.Authors)
+	if model.BookIDs == nil {
+		model.BookIDs = make([]int64, 0)
+	}
+	if model.Authors == nil {
+		model.Authors = make([]string, 0)
+	}
+	return model, nil
+}
+
+func (r *repository) GetPromotions(ctx context.Context) ([]promotions.Model, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(queryGetPromotions+` ORDER BY id DESC`))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var rows []promotionRow
+	err = stmt.SelectContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]promotions.Model, 0, len(rows))
+	for _, row := range rows {
+		model, err := row.toModel()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, model)
+	}
+	return list, nil
+}
+
+func (r *repository) GetPromotionByID(ctx context.Context, id int64) (*promotions.Model, error) {
+	return r.getPromotion(ctx, queryGetPromotions+` WHERE id = ?`, id)
+}
+
+// GetPromotionByCode expects the code in upper case, which is how codes are stored
+func (r *repository) GetPromotionByCode(ctx context.Context, code string) (*promotions.Model, error) {
+	return r.getPromotion(ctx, queryGetPromotions+` WHERE code = ?`, code)
+}
+
+func (r *repository) getPromotion(ctx context.Context, query string, arg interface{}) (*promotions.Model, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(query))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var row promotionRow
+	err = stmt.GetContext(ctx, &row, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	model, err := row.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *repository) InsertPromotion(ctx context.Context, model promotions.Model) (*promotions.Model, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(insertPromotionQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, model.Code, model.Description, model.DiscountType, model.DiscountValue(), model.MaxDiscount,
+		model.MinSpend, pq.Array(model.BookIDs), pq.Array(model.Authors), model.StartsAt, model.EndsAt, model.UsageLimit,
+		model.PerUserLimit, model.CreatedAt, model.UpdatedAt).Scan(&model.ID)
+	if err != nil {
+		if isPQError(err, pqUniqueViolation) {
+			return nil, fmt.Errorf("promo code %s already exists", model.Code)
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *repository) UpdatePromotion(ctx context.Context, model promotions.Model) (*promotions.Model, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(updatePromotionQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, model.Code, model.Description, model.DiscountType, model.DiscountValue(), model.MaxDiscount,
+		model.MinSpend, pq.Array(model.BookIDs), pq.Array(model.Authors), model.StartsAt, model.EndsAt, model.UsageLimit,
+		model.PerUserLimit, model.UpdatedAt, model.ID).Scan(&model.UsedCount, &model.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("promotion with id: %d is not found", model.ID)
+		}
+		if isPQError(err, pqUniqueViolation) {
+			return nil, fmt.Errorf("promo code %s already exists", model.Code)
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *repository) DeletePromotion(ctx context.Context, id int64) error {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(deletePromotionQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		if isPQError(err, pqForeignKeyViolation) {
+			return fmt.Errorf("promotion with id: %d is referenced by existing orders", id)
+		}
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("promotion with id: %d is not found", id)
+	}
+	return nil
+}
+
+// CountUserRedemptions is how many times the user redeemed the promotion, cancelled orders gave their redemption back
+func (r *repository) CountUserRedemptions(ctx context.Context, promotionID, userID int64) (int, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(countUserRedemptionsQuery))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var total int
+	err = stmt.GetContext(ctx, &total, promotionID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -0,0 +1,333 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+	"reflect"
+	"testing"
+)
+
+var promotionColumns = []string{"id", "code", "description", "discount_type", "discount_value", "max_discount", "min_spend",
+	"book_ids", "authors", "starts_at", "ends_at", "usage_limit", "per_user_limit", "used_count", "created_at", "updated_at"}
+
+const getPromotionsQueryTest = `
+		SELECT id, code, description, discount_type, discount_value, max_discount, min_spend, book_ids, authors,
+			starts_at, ends_at, usage_limit, per_user_limit, used_count, created_at, updated_at
+		FROM promotions
+	`
+
+func Test_repository_GetPromotionByCode(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	query := slaveDB.Rebind(getPromotionsQueryTest + ` WHERE code = ?`)
+
+	tests := []struct {
+		name    string
+		want    *promotions.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on query",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(query).ExpectQuery().WithArgs("ORWELL").WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name: "not found",
+			mockFn: func() {
+				mock.ExpectPrepare(query).ExpectQuery().WithArgs("ORWELL").WillReturnRows(sqlmock.NewRows(promotionColumns))
+			},
+		},
+		{
+			name: "success",
+			want: &promotions.Model{
+				ID:                 4,
+				Code:               "ORWELL",
+				DiscountType:       "PERCENTAGE",
+				DiscountPercentage: percent.MustParse("12.5"),
+				MaxDiscount:        money.MustParse("10"),
+				MinSpend:           money.MustParse("20"),
+				BookIDs:            []int64{101, 102},
+				Authors:            []string{"George Orwell"},
+				StartsAt:           1000,
+				UsageLimit:         100,
+				PerUserLimit:       1,
+				UsedCount:          3,
+				CreatedAt:          1000,
+				UpdatedAt:          2000,
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(query).ExpectQuery().WithArgs("ORWELL").WillReturnRows(sqlmock.NewRows(promotionColumns).
+					AddRow(4, "ORWELL", "", "PERCENTAGE", "12.50", "10.00", "20.00", "{101,102}", `{"George Orwell"}`, 1000, 0, 100, 1, 3, 1000, 2000))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{slaveDB: slaveDB}
+			got, err := r.GetPromotionByCode(context.Background(), "ORWELL")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetPromotionByCode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPromotionByCode() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_GetPromotions(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mock.ExpectPrepare(slaveDB.Rebind(getPromotionsQueryTest + ` ORDER BY id DESC`)).ExpectQuery().
+		WillReturnRows(sqlmock.NewRows(promotionColumns).
+			AddRow(5, "WELCOME", "first order", "FIXED", "5.00", "0", "0", "{}", "{}", 1000, 3000, 0, 1, 0, 1000, 1000).
+			AddRow(4, "ORWELL", "", "PERCENTAGE", "12.50", "0", "0", "{}", `{"George Orwell"}`, 1000, 0, 0, 0, 3, 1000, 2000))
+
+	r := &repository{slaveDB: slaveDB}
+	got, err := r.GetPromotions(context.Background())
+	if err != nil {
+		t.Fatalf("GetPromotions() error = %v", err)
+	}
+	want := []promotions.Model{
+		{ID: 5, Code: "WELCOME", Description: "first order", DiscountType: "FIXED", DiscountAmount: money.MustParse("5"),
+			BookIDs: []int64{}, Authors: []string{}, StartsAt: 1000, EndsAt: 3000, PerUserLimit: 1, CreatedAt: 1000, UpdatedAt: 1000},
+		{ID: 4, Code: "ORWELL", DiscountType: "PERCENTAGE", DiscountPercentage: percent.MustParse("12.5"),
+			BookIDs: []int64{}, Authors: []string{"George Orwell"}, StartsAt: 1000, UsedCount: 3, CreatedAt: 1000, UpdatedAt: 2000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPromotions() got = %+v, want %+v", got, want)
+	}
+}
+
+func Test_repository_InsertPromotion(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	insertPromotionQueryTest := masterDB.Rebind(`
+        INSERT INTO promotions (code, description, discount_type, discount_value, max_discount, min_spend, book_ids, authors,
+            starts_at, ends_at, usage_limit, per_user_limit, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING id;
+    `)
+
+	model := promotions.Model{
+		Code:           "ORWELL",
+		DiscountType:   "FIXED",
+		DiscountAmount: money.MustParse("5"),
+		BookIDs:        []int64{101},
+		Authors:        []string{},
+		StartsAt:       1000,
+		CreatedAt:      1000,
+		UpdatedAt:      1000,
+	}
+
+	tests := []struct {
+		name       string
+		want       *promotions.Model
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error code already exists",
+			wantErrMsg: "promo code ORWELL already exists",
+			mockFn: func() {
+				mock.ExpectPrepare(insertPromotionQueryTest).ExpectQuery().WillReturnError(&pq.Error{Code: pqUniqueViolation})
+			},
+		},
+		{
+			name: "success",
+			want: func() *promotions.Model {
+				m := model
+				m.ID = 4
+				return &m
+			}(),
+			mockFn: func() {
+				mock.ExpectPrepare(insertPromotionQueryTest).ExpectQuery().
+					WithArgs("ORWELL", "", "FIXED", "5.00", "0.00", "0.00", pq.Array([]int64{101}), pq.Array([]string{}), 1000, 0, 0, 0, 1000, 1000).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{masterDB: masterDB}
+			got, err := r.InsertPromotion(context.Background(), model)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("InsertPromotion() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InsertPromotion() got = %+v, error = %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdatePromotion(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updatePromotionQueryTest := masterDB.Rebind(`
+        UPDATE promotions
+        SET code = ?, description = ?, discount_type = ?, discount_value = ?, max_discount = ?, min_spend = ?, book_ids = ?,
+            authors = ?, starts_at = ?, ends_at = ?, usage_limit = ?, per_user_limit = ?, updated_at = ?
+        WHERE id = ?
+        RETURNING used_count, created_at;
+    `)
+
+	model := promotions.Model{
+		ID:                 4,
+		Code:               "ORWELL",
+		DiscountType:       "PERCENTAGE",
+		DiscountPercentage: percent.MustParse("10"),
+		BookIDs:            []int64{},
+		Authors:            []string{"George Orwell"},
+		StartsAt:           1000,
+		UsageLimit:         50,
+		UpdatedAt:          2000,
+	}
+
+	tests := []struct {
+		name       string
+		want       *promotions.Model
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error not found",
+			wantErrMsg: "promotion with id: 4 is not found",
+			mockFn: func() {
+				mock.ExpectPrepare(updatePromotionQueryTest).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"used_count", "created_at"}))
+			},
+		},
+		{
+			name: "success keeps the usage",
+			want: func() *promotions.Model {
+				m := model
+				m.UsedCount = 12
+				m.CreatedAt = 1000
+				return &m
+			}(),
+			mockFn: func() {
+				mock.ExpectPrepare(updatePromotionQueryTest).ExpectQuery().
+					WithArgs("ORWELL", "", "PERCENTAGE", "10.00", "0.00", "0.00", pq.Array([]int64{}), pq.Array([]string{"George Orwell"}), 1000, 0, 50, 0, 2000, 4).
+					WillReturnRows(sqlmock.NewRows([]string{"used_count", "created_at"}).AddRow(12, 1000))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{masterDB: masterDB}
+			got, err := r.UpdatePromotion(context.Background(), model)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("UpdatePromotion() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdatePromotion() got = %+v, error = %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_DeletePromotion(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	deletePromotionQueryTest := masterDB.Rebind(`DELETE FROM promotions WHERE id = ?;`)
+
+	tests := []struct {
+		name       string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error redeemed promotion",
+			wantErrMsg: "promotion with id: 4 is referenced by existing orders",
+			mockFn: func() {
+				mock.ExpectPrepare(deletePromotionQueryTest).ExpectExec().WithArgs(4).WillReturnError(&pq.Error{Code: pqForeignKeyViolation})
+			},
+		},
+		{
+			name:       "error not found",
+			wantErrMsg: "promotion with id: 4 is not found",
+			mockFn: func() {
+				mock.ExpectPrepare(deletePromotionQueryTest).ExpectExec().WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mock.ExpectPrepare(deletePromotionQueryTest).ExpectExec().WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{masterDB: masterDB}
+			err := r.DeletePromotion(context.Background(), 4)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("DeletePromotion() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("DeletePromotion() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_CountUserRedemptions(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	mock.ExpectPrepare(slaveDB.Rebind(`
+		SELECT COALESCE((SELECT count FROM promotion_redemptions WHERE promotion_id = ? AND user_id = ?), 0)
+	`)).ExpectQuery().WithArgs(4, 2).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	r := &repository{slaveDB: slaveDB}
+	got, err := r.CountUserRedemptions(context.Background(), 4, 2)
+	if err != nil || got != 1 {
+		t.Errorf("CountUserRedemptions() got = %v, error = %v", got, err)
+	}
+}
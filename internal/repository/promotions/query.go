@@ -0,0 +1,32 @@
+package promotions
+
+var (
+	queryGetPromotions = `
+		SELECT id, code, description, discount_type, discount_value, max_discount, min_spend, book_ids, authors,
+			starts_at, ends_at, usage_limit, per_user_limit, used_count, created_at, updated_at
+		FROM promotions
+	`
+
+	insertPromotionQuery = `
+        INSERT INTO promotions (code, description, discount_type, discount_value, max_discount, min_spend, book_ids, authors,
+            starts_at, ends_at, usage_limit, per_user_limit, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING id;
+    `
+
+	// updatePromotionQuery replaces the rules of the promotion, the usage so far is returned as it is kept
+	updatePromotionQuery = `
+        UPDATE promotions
+        SET code = ?, description = ?, discount_type = ?, discount_value = ?, max_discount = ?, min_spend = ?, book_ids = ?,
+            authors = ?, starts_at = ?, ends_at = ?, usage_limit = ?, per_user_limit = ?, updated_at = ?
+        WHERE id = ?
+        RETURNING used_count, created_at;
+    `
+
+	deletePromotionQuery = `DELETE FROM promotions WHERE id = ?;`
+
+	// countUserRedemptionsQuery reads how many times the user redeemed the promotion, a user without a row never did
+	countUserRedemptionsQuery = `
+		SELECT COALESCE((SELECT count FROM promotion_redemptions WHERE promotion_id = ? AND user_id = ?), 0)
+	`
+)
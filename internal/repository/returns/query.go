@@ -0,0 +1,58 @@
+package returns
+
+var (
+	insertReturnQuery = `
+        INSERT INTO returns (order_id, user_id, status, refund_amount, note, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        RETURNING id;
+    `
+
+	insertReturnItemQuery = `
+        INSERT INTO return_items (return_id, order_item_id, quantity, reason, refund_amount)
+        VALUES (?, ?, ?, ?, ?)
+        RETURNING id;
+    `
+
+	getReturnByIDQuery = `
+		SELECT id, order_id, user_id, status, refund_amount, note, created_at, updated_at
+		FROM returns
+		WHERE id = ?
+	`
+
+	getReturnsByOrderIDQuery = `
+		SELECT id, order_id, user_id, status, refund_amount, note, created_at, updated_at
+		FROM returns
+		WHERE order_id = ?
+		ORDER BY id
+	`
+
+	getReturnItemsQuery = `
+		SELECT ri.id, ri.return_id, ri.order_item_id, oi.book_id, ri.quantity, ri.reason, ri.refund_amount
+		FROM return_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.return_id = ANY(?)
+		ORDER BY ri.id
+	`
+
+	getOrderItemsQuery = `
+		SELECT oi.id, oi.book_id, oi.quantity, oi.price, oi.discount_amount, oi.tax_amount, o.tax_inclusive, oi.returned_quantity
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE oi.order_id = ?
+		ORDER BY oi.id
+	`
+
+	// updateReturnStatusQuery only matches while the return is still in the status it was read in
+	updateReturnStatusQuery = `
+        UPDATE returns
+        SET status = ?, note = ?, updated_at = ?
+        WHERE id = ? AND status = ?;
+    `
+
+	// returnOrderItemQuery only matches while the returned quantity stays within the ordered quantity
+	returnOrderItemQuery = `
+        UPDATE order_items
+        SET returned_quantity = returned_quantity + ?, refunded_amount = refunded_amount + ?, updated_at = ?
+        WHERE id = ? AND returned_quantity + ? <= quantity;
+    `
+)
@@ -0,0 +1,298 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+	ordersRepository "github.com/yeremiaaryo/gotu-assignment/internal/repository/orders"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+)
+
+type repository struct {
+	masterDB internalsql.MasterDB
+	slaveDB  internalsql.SlaveDB
+}
+
+func New(masterDB internalsql.MasterDB, slaveDB internalsql.SlaveDB) *repository {
+	return &repository{
+		masterDB: masterDB,
+		slaveDB:  slaveDB,
+	}
+}
+
+// InsertReturn inserts the return with its items and records the request in the status history of the order
+func (r *repository) InsertReturn(ctx context.Context, model returns.Model, history orders.StatusHistory) (*returns.Model, error) {
+	tx, err := r.masterDB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(insertReturnQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, model.OrderID, model.UserID, model.Status, model.RefundAmount, model.Note,
+		model.CreatedAt, model.UpdatedAt).Scan(&model.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stmtItem, err := tx.PreparexContext(ctx, tx.Rebind(insertReturnItemQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmtItem.Close()
+
+	items := make([]returns.Item, len(model.Items))
+	for i, item := range model.Items {
+		item.ReturnID = model.ID
+		err = stmtItem.QueryRowxContext(ctx, item.ReturnID, item.OrderItemID, item.Quantity, item.Reason, item.RefundAmount).Scan(&item.ID)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	model.Items = items
+
+	err = ordersRepository.InsertStatusHistory(ctx, tx, history)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model, tx.Commit()
+}
+
+// GetReturnByID returns the return with its items from the master as it is read right before it is updated, nil when there is none
+func (r *repository) GetReturnByID(ctx context.Context, id int64) (*returns.Model, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(getReturnByIDQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var model returns.Model
+	err = stmt.QueryRowxContext(ctx, id).StructScan(&model)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	stmtItem, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(getReturnItemsQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmtItem.Close()
+
+	rows, err := stmtItem.QueryxContext(ctx, pq.Array([]int64{id}))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	model.Items = make([]returns.Item, 0)
+	for rows.Next() {
+		var item returns.Item
+		err = rows.StructScan(&item)
+		if err != nil {
+			return nil, err
+		}
+		model.Items = append(model.Items, item)
+	}
+	return &model, rows.Err()
+}
+
+// GetReturnsByOrderID returns every return of the order with its items, oldest first
+func (r *repository) GetReturnsByOrderID(ctx context.Context, orderID int64) ([]returns.Model, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(getReturnsByOrderIDQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	returnList := make([]returns.Model, 0)
+	err = stmt.SelectContext(ctx, &returnList, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(returnList) == 0 {
+		return returnList, nil
+	}
+
+	returnIDs := make([]int64, len(returnList))
+	for i, model := range returnList {
+		returnIDs[i] = model.ID
+	}
+
+	stmtItem, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(getReturnItemsQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmtItem.Close()
+
+	var items []returns.Item
+	err = stmtItem.SelectContext(ctx, &items, pq.Array(returnIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	itemsMap := make(map[int64][]returns.Item)
+	for _, item := range items {
+		itemsMap[item.ReturnID] = append(itemsMap[item.ReturnID], item)
+	}
+	for i, model := range returnList {
+		returnList[i].Items = itemsMap[model.ID]
+	}
+	return returnList, nil
+}
+
+// GetOrderItems returns the items of the order along with how much of them was already returned
+func (r *repository) GetOrderItems(ctx context.Context, orderID int64) ([]returns.OrderItem, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(getOrderItemsQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryxContext(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]returns.OrderItem, 0)
+	for rows.Next() {
+		var item returns.OrderItem
+		err = rows.StructScan(&item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// UpdateReturnStatus moves the return from one status to the next, it fails when the return already moved on
+func (r *repository) UpdateReturnStatus(ctx context.Context, id int64, from, to returns.Status, note string, updatedAt int64) error {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(updateReturnStatusQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return checkReturnUpdated(stmt.ExecContext(ctx, to, note, updatedAt, id, from))
+}
+
+// RejectReturn rejects the requested return and records it in the status history of the order within one transaction
+func (r *repository) RejectReturn(ctx context.Context, id int64, note string, updatedAt int64, history orders.StatusHistory) error {
+	tx, err := r.masterDB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = r.updateReturnStatus(ctx, tx, id, returns.StatusRequested, returns.StatusRejected, note, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = ordersRepository.InsertStatusHistory(ctx, tx, history)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CompleteReturn records the refund of a refunding return within one transaction: the return is REFUNDED, the returned
+// quantity and refund are added to the order items, the books are restocked and the order moves to its next status
+// through the same update as every other status change. It returns the ids of the restocked books.
+func (r *repository) CompleteReturn(ctx context.Context, model returns.Model, transition orders.StatusTransition) ([]int64, error) {
+	tx, err := r.masterDB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = r.updateReturnStatus(ctx, tx, model.ID, returns.StatusRefunding, returns.StatusRefunded, model.Note, transition.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.returnOrderItems(ctx, tx, model.Items, transition.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int64]int)
+	for _, item := range model.Items {
+		quantities[item.BookID] += item.Quantity
+	}
+	bookIDs, err := ordersRepository.IncrementStock(ctx, tx, quantities, transition.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ordersRepository.UpdateStatus(ctx, tx, transition)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookIDs, tx.Commit()
+}
+
+func (r *repository) updateReturnStatus(ctx context.Context, tx *sqlx.Tx, id int64, from, to returns.Status, note string, updatedAt int64) error {
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(updateReturnStatusQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return checkReturnUpdated(stmt.ExecContext(ctx, to, note, updatedAt, id, from))
+}
+
+func checkReturnUpdated(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("return was updated by another request, please reload it")
+	}
+	return nil
+}
+
+// returnOrderItems adds the returned quantity and refund to every order item of the return
+func (r *repository) returnOrderItems(ctx context.Context, tx *sqlx.Tx, items []returns.Item, updatedAt int64) error {
+	stmt, err := tx.PreparexContext(ctx, tx.Rebind(returnOrderItemQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		res, err := stmt.ExecContext(ctx, item.Quantity, item.RefundAmount, updatedAt, item.OrderItemID, item.Quantity)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return fmt.Errorf("order item with id: %d was already returned", item.OrderItemID)
+		}
+	}
+	return nil
+}
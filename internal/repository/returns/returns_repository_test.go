@@ -0,0 +1,520 @@
+package returns
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"reflect"
+	"testing"
+)
+
+var (
+	returnColumns     = []string{"id", "order_id", "user_id", "status", "refund_amount", "note", "created_at", "updated_at"}
+	returnItemColumns = []string{"id", "return_id", "order_item_id", "book_id", "quantity", "reason", "refund_amount"}
+)
+
+func Test_repository_InsertReturn(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	insertReturnQueryTest := masterDB.Rebind(`
+        INSERT INTO returns (order_id, user_id, status, refund_amount, note, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        RETURNING id;
+    `)
+
+	insertReturnItemQueryTest := masterDB.Rebind(`
+        INSERT INTO return_items (return_id, order_item_id, quantity, reason, refund_amount)
+        VALUES (?, ?, ?, ?, ?)
+        RETURNING id;
+    `)
+
+	insertOrderStatusHistoryQueryTest := masterDB.Rebind(`
+        INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, note, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	model := returns.Model{
+		OrderID:      1,
+		UserID:       2,
+		Status:       "REQUESTED",
+		RefundAmount: money.MustParse("9.99"),
+		CreatedAt:    1000,
+		UpdatedAt:    1000,
+		Items: []returns.Item{
+			{OrderItemID: 5, BookID: 3, Quantity: 1, Reason: "damaged", RefundAmount: money.MustParse("9.99")},
+		},
+	}
+	history := orders.StatusHistory{OrderID: 1, From: "DELIVERED", To: "DELIVERED", ActorID: 2, ActorRole: "customer", Note: "return of 9.99 requested", CreatedAt: 1000}
+
+	tests := []struct {
+		name    string
+		want    *returns.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on insert item",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(insertReturnQueryTest).ExpectQuery().
+					WithArgs(1, 2, "REQUESTED", "9.99", "", 1000, 1000).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectPrepare(insertReturnItemQueryTest).ExpectQuery().
+					WithArgs(4, 5, 1, "damaged", "9.99").
+					WillReturnError(errors.New("failed to insert item"))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "success",
+			want: &returns.Model{
+				ID:           4,
+				OrderID:      1,
+				UserID:       2,
+				Status:       "REQUESTED",
+				RefundAmount: money.MustParse("9.99"),
+				CreatedAt:    1000,
+				UpdatedAt:    1000,
+				Items: []returns.Item{
+					{ID: 8, ReturnID: 4, OrderItemID: 5, BookID: 3, Quantity: 1, Reason: "damaged", RefundAmount: money.MustParse("9.99")},
+				},
+			},
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(insertReturnQueryTest).ExpectQuery().
+					WithArgs(1, 2, "REQUESTED", "9.99", "", 1000, 1000).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectPrepare(insertReturnItemQueryTest).ExpectQuery().
+					WithArgs(4, 5, 1, "damaged", "9.99").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, "DELIVERED", "DELIVERED", 2, "customer", "return of 9.99 requested", 1000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.InsertReturn(context.Background(), model, history)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InsertReturn() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InsertReturn() got = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("InsertReturn() unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_GetReturnByID(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	getReturnByIDQueryTest := masterDB.Rebind(`
+		SELECT id, order_id, user_id, status, refund_amount, note, created_at, updated_at
+		FROM returns
+		WHERE id = ?
+	`)
+
+	getReturnItemsQueryTest := masterDB.Rebind(`
+		SELECT ri.id, ri.return_id, ri.order_item_id, oi.book_id, ri.quantity, ri.reason, ri.refund_amount
+		FROM return_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.return_id = ANY(?)
+		ORDER BY ri.id
+	`)
+
+	tests := []struct {
+		name    string
+		want    *returns.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name: "not found",
+			want: nil,
+			mockFn: func() {
+				mock.ExpectPrepare(getReturnByIDQueryTest).ExpectQuery().WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(returnColumns))
+			},
+		},
+		{
+			name:    "error on items",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(getReturnByIDQueryTest).ExpectQuery().WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(returnColumns).AddRow(4, 1, 2, "REQUESTED", "9.99", "", 1000, 1000))
+				mock.ExpectPrepare(getReturnItemsQueryTest).ExpectQuery().WithArgs(pq.Array([]int64{4})).
+					WillReturnError(errors.New("failed to get items"))
+			},
+		},
+		{
+			name: "success",
+			want: &returns.Model{
+				ID:           4,
+				OrderID:      1,
+				UserID:       2,
+				Status:       "REQUESTED",
+				RefundAmount: money.MustParse("9.99"),
+				CreatedAt:    1000,
+				UpdatedAt:    1000,
+				Items: []returns.Item{
+					{ID: 8, ReturnID: 4, OrderItemID: 5, BookID: 3, Quantity: 1, Reason: "damaged", RefundAmount: money.MustParse("9.99")},
+				},
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(getReturnByIDQueryTest).ExpectQuery().WithArgs(4).
+					WillReturnRows(sqlmock.NewRows(returnColumns).AddRow(4, 1, 2, "REQUESTED", "9.99", "", 1000, 1000))
+				mock.ExpectPrepare(getReturnItemsQueryTest).ExpectQuery().WithArgs(pq.Array([]int64{4})).
+					WillReturnRows(sqlmock.NewRows(returnItemColumns).AddRow(8, 4, 5, 3, 1, "damaged", "9.99"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.GetReturnByID(context.Background(), 4)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetReturnByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetReturnByID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_GetReturnsByOrderID(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	getReturnsByOrderIDQueryTest := slaveDB.Rebind(`
+		SELECT id, order_id, user_id, status, refund_amount, note, created_at, updated_at
+		FROM returns
+		WHERE order_id = ?
+		ORDER BY id
+	`)
+
+	getReturnItemsQueryTest := slaveDB.Rebind(`
+		SELECT ri.id, ri.return_id, ri.order_item_id, oi.book_id, ri.quantity, ri.reason, ri.refund_amount
+		FROM return_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.return_id = ANY(?)
+		ORDER BY ri.id
+	`)
+
+	tests := []struct {
+		name    string
+		want    []returns.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name: "no returns",
+			want: []returns.Model{},
+			mockFn: func() {
+				mock.ExpectPrepare(getReturnsByOrderIDQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(returnColumns))
+			},
+		},
+		{
+			name: "success",
+			want: []returns.Model{
+				{
+					ID: 3, OrderID: 1, UserID: 2, Status: "REJECTED", RefundAmount: money.MustParse("9.99"), Note: "used", CreatedAt: 900, UpdatedAt: 950,
+					Items: []returns.Item{{ID: 7, ReturnID: 3, OrderItemID: 5, BookID: 3, Quantity: 1, Reason: "changed my mind", RefundAmount: money.MustParse("9.99")}},
+				},
+				{
+					ID: 4, OrderID: 1, UserID: 2, Status: "REQUESTED", RefundAmount: money.MustParse("9.99"), CreatedAt: 1000, UpdatedAt: 1000,
+					Items: []returns.Item{{ID: 8, ReturnID: 4, OrderItemID: 5, BookID: 3, Quantity: 1, Reason: "damaged", RefundAmount: money.MustParse("9.99")}},
+				},
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(getReturnsByOrderIDQueryTest).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(returnColumns).
+						AddRow(3, 1, 2, "REJECTED", "9.99", "used", 900, 950).
+						AddRow(4, 1, 2, "REQUESTED", "9.99", "", 1000, 1000))
+				mock.ExpectPrepare(getReturnItemsQueryTest).ExpectQuery().WithArgs(pq.Array([]int64{3, 4})).
+					WillReturnRows(sqlmock.NewRows(returnItemColumns).
+						AddRow(7, 3, 5, 3, 1, "changed my mind", "9.99").
+						AddRow(8, 4, 5, 3, 1, "damaged", "9.99"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				slaveDB: slaveDB,
+			}
+			got, err := r.GetReturnsByOrderID(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetReturnsByOrderID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetReturnsByOrderID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_RejectReturn(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updateReturnStatusQueryTest := masterDB.Rebind(`
+        UPDATE returns
+        SET status = ?, note = ?, updated_at = ?
+        WHERE id = ? AND status = ?;
+    `)
+
+	insertOrderStatusHistoryQueryTest := masterDB.Rebind(`
+        INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, note, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	history := orders.StatusHistory{OrderID: 1, From: "DELIVERED", To: "DELIVERED", ActorID: 9, ActorRole: "admin", Note: "return 4 rejected: used", CreatedAt: 2000}
+
+	tests := []struct {
+		name       string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error return already decided",
+			wantErrMsg: "return was updated by another request, please reload it",
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateReturnStatusQueryTest).ExpectExec().
+					WithArgs("REJECTED", "used", 2000, 4, "REQUESTED").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateReturnStatusQueryTest).ExpectExec().
+					WithArgs("REJECTED", "used", 2000, 4, "REQUESTED").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, "DELIVERED", "DELIVERED", 9, "admin", "return 4 rejected: used", 2000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			err := r.RejectReturn(context.Background(), 4, "used", 2000, history)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("RejectReturn() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("RejectReturn() unexpected error = %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RejectReturn() unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_CompleteReturn(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updateReturnStatusQueryTest := masterDB.Rebind(`
+        UPDATE returns
+        SET status = ?, note = ?, updated_at = ?
+        WHERE id = ? AND status = ?;
+    `)
+
+	returnOrderItemQueryTest := masterDB.Rebind(`
+        UPDATE order_items
+        SET returned_quantity = returned_quantity + ?, refunded_amount = refunded_amount + ?, updated_at = ?
+        WHERE id = ? AND returned_quantity + ? <= quantity;
+    `)
+
+	incrementStockQueryTest := masterDB.Rebind(`
+        UPDATE books
+        SET stock = stock + ?, updated_at = ?
+        WHERE id = ?;
+    `)
+
+	updateOrderStatusQueryTest := masterDB.Rebind(`
+        UPDATE orders
+        SET status = ?, updated_at = ?
+        WHERE id = ? AND updated_at = ?;
+    `)
+
+	insertOrderStatusHistoryQueryTest := masterDB.Rebind(`
+        INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, note, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	insertOutboxEventQueryTest := masterDB.Rebind(`
+        INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, status, next_attempt_at, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?);
+    `)
+
+	model := returns.Model{
+		ID:      4,
+		OrderID: 1,
+		Status:  "REFUNDING",
+		Note:    "ok",
+		Items: []returns.Item{
+			{ID: 8, OrderItemID: 6, BookID: 7, Quantity: 1, RefundAmount: money.MustParse("5.00")},
+			{ID: 9, OrderItemID: 5, BookID: 3, Quantity: 2, RefundAmount: money.MustParse("19.98")},
+		},
+	}
+	transition := orders.StatusTransition{
+		OrderID:           1,
+		From:              orders.OrderStatusDelivered,
+		To:                orders.OrderStatusPartiallyRefunded,
+		ExpectedUpdatedAt: 2000,
+		UpdatedAt:         3000,
+		ActorID:           9,
+		ActorRole:         "admin",
+		Note:              "return 4 refunded 24.98",
+	}
+
+	tests := []struct {
+		name       string
+		want       []int64
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error item returned by another request",
+			wantErrMsg: "order item with id: 6 was already returned",
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateReturnStatusQueryTest).ExpectExec().
+					WithArgs("REFUNDED", "ok", 3000, 4, "REFUNDING").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(returnOrderItemQueryTest).ExpectExec().
+					WithArgs(1, "5.00", 3000, 6, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:       "error order moved on",
+			wantErrMsg: "order was updated by another request, please reload it",
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateReturnStatusQueryTest).ExpectExec().
+					WithArgs("REFUNDED", "ok", 3000, 4, "REFUNDING").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				returnItem := mock.ExpectPrepare(returnOrderItemQueryTest)
+				returnItem.ExpectExec().WithArgs(1, "5.00", 3000, 6, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				returnItem.ExpectExec().WithArgs(2, "19.98", 3000, 5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				restock := mock.ExpectPrepare(incrementStockQueryTest)
+				restock.ExpectExec().WithArgs(2, 3000, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+				restock.ExpectExec().WithArgs(1, 3000, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("PARTIALLY_REFUNDED", 3000, 1, 2000).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "success",
+			want: []int64{3, 7},
+			mockFn: func() {
+				mock.ExpectBegin()
+				mock.ExpectPrepare(updateReturnStatusQueryTest).ExpectExec().
+					WithArgs("REFUNDED", "ok", 3000, 4, "REFUNDING").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				returnItem := mock.ExpectPrepare(returnOrderItemQueryTest)
+				returnItem.ExpectExec().WithArgs(1, "5.00", 3000, 6, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				returnItem.ExpectExec().WithArgs(2, "19.98", 3000, 5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				restock := mock.ExpectPrepare(incrementStockQueryTest)
+				restock.ExpectExec().WithArgs(2, 3000, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+				restock.ExpectExec().WithArgs(1, 3000, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(updateOrderStatusQueryTest).ExpectExec().
+					WithArgs("PARTIALLY_REFUNDED", 3000, 1, 2000).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectPrepare(insertOrderStatusHistoryQueryTest).ExpectExec().
+					WithArgs(1, "DELIVERED", "PARTIALLY_REFUNDED", 9, "admin", "return 4 refunded 24.98", 3000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectPrepare(insertOutboxEventQueryTest).ExpectExec().
+					WithArgs("order", 1, "OrderStatusChanged",
+						`{"order_id":1,"from_status":"DELIVERED","to_status":"PARTIALLY_REFUNDED","actor_id":9,"actor_role":"admin","note":"return 4 refunded 24.98","changed_at":3000}`,
+						"PENDING", 3000, 3000).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.CompleteReturn(context.Background(), model, transition)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("CompleteReturn() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("CompleteReturn() unexpected error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompleteReturn() got = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("CompleteReturn() unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/yeremiaaryo/gotu-assignment/internal/constant"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"sort"
+	"time"
+)
+
+//go:generate mockgen -package=sessions -source=sessions_repository.go -destination=sessions_repository_mock_test.go
+type redis interface {
+	GetAll(key string) (map[string]string, error)
+	Set(key string, value string, ttl int64, field ...interface{}) (interface{}, error)
+	Del(key string, field ...interface{}) (bool, error)
+	Eval(script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// rotateSessionScript saves the session only while the stored one still has the refresh hash the caller read.
+// KEYS[1] is the hash of the sessions, ARGV are the session id, the refresh hash read, the new session and the ttl
+const rotateSessionScript = `
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if not current or cjson.decode(current)['refresh_hash'] ~= ARGV[2] then
+    return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+if tonumber(ARGV[4]) > 0 then
+    redis.call('EXPIRE', KEYS[1], ARGV[4])
+end
+return 1
+`
+
+// repository keeps the sessions of every user in a redis hash of session id to session. Every save starts the ttl of
+// the hash again, so it lives as long as the session saved last
+type repository struct {
+	redis redis
+}
+
+func New(redis redis) *repository {
+	return &repository{redis: redis}
+}
+
+// GetSessions returns every session of the user including the expired ones, the oldest first
+func (r *repository) GetSessions(ctx context.Context, userID int64) ([]users.Session, error) {
+	fields, err := r.redis.GetAll(fmt.Sprintf(constant.RedisKeySessions, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]users.Session, 0, len(fields))
+	for _, value := range fields {
+		var session users.Session
+		err = jsoniter.UnmarshalFromString(value, &session)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt < sessions[j].CreatedAt
+	})
+	return sessions, nil
+}
+
+// GetSession returns the session of the user, it is nil when the session doesn't exist
+func (r *repository) GetSession(ctx context.Context, userID int64, sessionID string) (*users.Session, error) {
+	sessions, err := r.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			return &session, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveSession stores the session of the user, the sessions of the user are kept for at least the ttl
+func (r *repository) SaveSession(ctx context.Context, session users.Session, ttl time.Duration) error {
+	value, err := jsoniter.MarshalToString(session)
+	if err != nil {
+		return err
+	}
+	_, err = r.redis.Set(fmt.Sprintf(constant.RedisKeySessions, session.UserID), value, int64(ttl.Seconds()), session.ID)
+	return err
+}
+
+// RotateSession saves the session in place of the one with the previous refresh hash and returns whether it did.
+// It doesn't when the session was rotated or logged out in the meantime, so only one of concurrent refreshes wins
+func (r *repository) RotateSession(ctx context.Context, session users.Session, previousRefreshHash string, ttl time.Duration) (bool, error) {
+	value, err := jsoniter.MarshalToString(session)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.redis.Eval(rotateSessionScript, []string{fmt.Sprintf(constant.RedisKeySessions, session.UserID)},
+		session.ID, previousRefreshHash, value, int64(ttl.Seconds()))
+	if err != nil {
+		return false, err
+	}
+	rotated, ok := result.(int64)
+	return ok && rotated == 1, nil
+}
+
+// DeleteSession removes the session of the user and returns whether it existed
+func (r *repository) DeleteSession(ctx context.Context, userID int64, sessionID string) (bool, error) {
+	return r.redis.Del(fmt.Sprintf(constant.RedisKeySessions, userID), sessionID)
+}
+
+// DeleteSessions removes every session of the user
+func (r *repository) DeleteSessions(ctx context.Context, userID int64) error {
+	_, err := r.redis.Del(fmt.Sprintf(constant.RedisKeySessions, userID))
+	return err
+}
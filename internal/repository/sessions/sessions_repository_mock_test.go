@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: sessions_repository.go
+
+// Package sessions is a generated GoMock package.
+package sessions
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// Mockredis is a mock of redis interface.
+type Mockredis struct {
+	ctrl     *gomock.Controller
+	recorder *MockredisMockRecorder
+}
+
+// MockredisMockRecorder is the mock recorder for Mockredis.
+type MockredisMockRecorder struct {
+	mock *Mockredis
+}
+
+// NewMockredis creates a new mock instance.
+func NewMockredis(ctrl *gomock.Controller) *Mockredis {
+	mock := &Mockredis{ctrl: ctrl}
+	mock.recorder = &MockredisMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockredis) EXPECT() *MockredisMockRecorder {
+	return m.recorder
+}
+
+// Del mocks base method.
+func (m *Mockredis) Del(key string, field ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{key}
+	for _, a := range field {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Del", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockredisMockRecorder) Del(key interface{}, field ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{key}, field...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*Mockredis)(nil).Del), varargs...)
+}
+
+// Eval mocks base method.
+func (m *Mockredis) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{script, keys}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Eval", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Eval indicates an expected call of Eval.
+func (mr *MockredisMockRecorder) Eval(script, keys interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{script, keys}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Eval", reflect.TypeOf((*Mockredis)(nil).Eval), varargs...)
+}
+
+// GetAll mocks base method.
+func (m *Mockredis) GetAll(key string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", key)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockredisMockRecorder) GetAll(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*Mockredis)(nil).GetAll), key)
+}
+
+// Set mocks base method.
+func (m *Mockredis) Set(key, value string, ttl int64, field ...interface{}) (interface{}, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{key, value, ttl}
+	for _, a := range field {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Set", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockredisMockRecorder) Set(key, value, ttl interface{}, field ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{key, value, ttl}, field...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*Mockredis)(nil).Set), varargs...)
+}
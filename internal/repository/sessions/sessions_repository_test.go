@@ -0,0 +1,205 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	laptop = `{"id":"a1","user_id":1,"user_agent":"Firefox","ip_address":"10.0.0.1","refresh_hash":"h1","created_at":1000,"last_used_at":3000,"expires_at":9000}`
+	phone  = `{"id":"b2","user_id":1,"user_agent":"iPhone","ip_address":"10.0.0.2","refresh_hash":"h2","created_at":2000,"last_used_at":2000,"expires_at":8000}`
+)
+
+func Test_repository_GetSessions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	tests := []struct {
+		name    string
+		want    []users.Session
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on redis",
+			wantErr: true,
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("sessions:1").Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name:    "error invalid session",
+			wantErr: true,
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("sessions:1").Return(map[string]string{"a1": "{"}, nil)
+			},
+		},
+		{
+			name: "no sessions",
+			want: []users.Session{},
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("sessions:1").Return(map[string]string{}, nil)
+			},
+		},
+		{
+			name: "success oldest first",
+			want: []users.Session{
+				{ID: "a1", UserID: 1, UserAgent: "Firefox", IPAddress: "10.0.0.1", RefreshHash: "h1", CreatedAt: 1000, LastUsedAt: 3000, ExpiresAt: 9000},
+				{ID: "b2", UserID: 1, UserAgent: "iPhone", IPAddress: "10.0.0.2", RefreshHash: "h2", CreatedAt: 2000, LastUsedAt: 2000, ExpiresAt: 8000},
+			},
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("sessions:1").Return(map[string]string{"b2": phone, "a1": laptop}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				redis: mockRedis,
+			}
+			got, err := r.GetSessions(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetSessions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSessions() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_GetSession(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+
+	tests := []struct {
+		name      string
+		sessionID string
+		want      *users.Session
+		wantErr   bool
+		mockFn    func()
+	}{
+		{
+			name:      "error on redis",
+			sessionID: "b2",
+			wantErr:   true,
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("sessions:1").Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name:      "session not found",
+			sessionID: "c3",
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("sessions:1").Return(map[string]string{"a1": laptop, "b2": phone}, nil)
+			},
+		},
+		{
+			name:      "success",
+			sessionID: "b2",
+			want:      &users.Session{ID: "b2", UserID: 1, UserAgent: "iPhone", IPAddress: "10.0.0.2", RefreshHash: "h2", CreatedAt: 2000, LastUsedAt: 2000, ExpiresAt: 8000},
+			mockFn: func() {
+				mockRedis.EXPECT().GetAll("sessions:1").Return(map[string]string{"a1": laptop, "b2": phone}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				redis: mockRedis,
+			}
+			got, err := r.GetSession(context.Background(), 1, tt.sessionID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetSession() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSession() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_SaveSession(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+	mockRedis.EXPECT().Set("sessions:1", laptop, int64((720*time.Hour).Seconds()), "a1").Return(int64(1), nil)
+
+	r := &repository{
+		redis: mockRedis,
+	}
+	err := r.SaveSession(context.Background(), users.Session{ID: "a1", UserID: 1, UserAgent: "Firefox", IPAddress: "10.0.0.1",
+		RefreshHash: "h1", CreatedAt: 1000, LastUsedAt: 3000, ExpiresAt: 9000}, 720*time.Hour)
+	if err != nil {
+		t.Errorf("SaveSession() error = %v", err)
+	}
+}
+
+func Test_repository_RotateSession(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+	session := users.Session{ID: "a1", UserID: 1, UserAgent: "Firefox", IPAddress: "10.0.0.1",
+		RefreshHash: "h1", CreatedAt: 1000, LastUsedAt: 3000, ExpiresAt: 9000}
+
+	tests := []struct {
+		name    string
+		result  interface{}
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{name: "success swaps the session", result: int64(1), want: true},
+		{name: "session was rotated or logged out meanwhile", result: int64(0), want: false},
+		{name: "error on eval", err: errors.New("connection refused"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRedis.EXPECT().Eval(rotateSessionScript, []string{"sessions:1"}, "a1", "h0", laptop, int64((720*time.Hour).Seconds())).
+				Return(tt.result, tt.err)
+
+			r := &repository{
+				redis: mockRedis,
+			}
+			got, err := r.RotateSession(context.Background(), session, "h0", 720*time.Hour)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RotateSession() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RotateSession() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_DeleteSession(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockRedis := NewMockredis(mockCtrl)
+	mockRedis.EXPECT().Del("sessions:1", "a1").Return(true, nil)
+
+	r := &repository{
+		redis: mockRedis,
+	}
+	deleted, err := r.DeleteSession(context.Background(), 1, "a1")
+	if err != nil || !deleted {
+		t.Errorf("DeleteSession() deleted = %v, error = %v", deleted, err)
+	}
+}
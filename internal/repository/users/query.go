@@ -2,11 +2,15 @@ package users
 
 var (
 	getUsersQuery = `SELECT 
-							id, email, password, created_at, updated_at 
+							id, email, password, role, billing_country, email_verified_at, created_at, updated_at 
 						FROM 
 						    users`
 
 	insertUserQuery = `INSERT INTO users
-							(email, password, created_at, updated_at)
-							VALUES(?, ?, ?, ?) RETURNING id;`
+							(email, password, role, billing_country, created_at, updated_at)
+							VALUES(?, ?, ?, ?, ?, ?) RETURNING id;`
+
+	verifyEmailQuery = `UPDATE users SET email_verified_at = ?, updated_at = ? WHERE id = ? AND email_verified_at = 0`
+
+	updatePasswordQuery = `UPDATE users SET password = ?, updated_at = ? WHERE id = ?`
 )
@@ -3,6 +3,7 @@ package users
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
 )
@@ -22,7 +23,14 @@ func New(masterDB internalsql.MasterDB, slaveDB internalsql.SlaveDB) *repository
 }
 
 func (r *repository) GetUser(ctx context.Context, email string) (*users.Model, error) {
-	query := getUsersQuery + ` WHERE email = ?`
+	return r.getUser(ctx, getUsersQuery+` WHERE email = ?`, email)
+}
+
+func (r *repository) GetUserByID(ctx context.Context, id int64) (*users.Model, error) {
+	return r.getUser(ctx, getUsersQuery+` WHERE id = ?`, id)
+}
+
+func (r *repository) getUser(ctx context.Context, query string, arg interface{}) (*users.Model, error) {
 	rebindQuery := r.slaveDB.Rebind(query)
 
 	stmt, err := r.slaveDB.PreparexContext(ctx, rebindQuery)
@@ -32,7 +40,7 @@ func (r *repository) GetUser(ctx context.Context, email string) (*users.Model, e
 	defer stmt.Close()
 
 	var user users.Model
-	err = stmt.GetContext(ctx, &user, email)
+	err = stmt.GetContext(ctx, &user, arg)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -51,10 +59,47 @@ func (r *repository) InsertUser(ctx context.Context, model users.Model) (*users.
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowxContext(ctx, model.Email, model.Password, model.CreatedAt, model.UpdatedAt).Scan(&model.ID)
+	err = stmt.QueryRowxContext(ctx, model.Email, model.Password, model.Role, model.BillingCountry, model.CreatedAt, model.UpdatedAt).Scan(&model.ID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &model, nil
 }
+
+// VerifyEmail marks the email of the user verified, an email verified before keeps its first verification time
+func (r *repository) VerifyEmail(ctx context.Context, id int64, verifiedAt int64) error {
+	rebindQuery := r.masterDB.Rebind(verifyEmailQuery)
+
+	stmt, err := r.masterDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, verifiedAt, verifiedAt, id)
+	return err
+}
+
+func (r *repository) UpdatePassword(ctx context.Context, id int64, password string, updatedAt int64) error {
+	rebindQuery := r.masterDB.Rebind(updatePasswordQuery)
+
+	stmt, err := r.masterDB.PreparexContext(ctx, rebindQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, password, updatedAt, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("user with id: %d is not found", id)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package users
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"reflect"
 	"testing"
@@ -28,7 +29,7 @@ func Test_repository_GetUser(t *testing.T) {
 	}()
 
 	query := `SELECT 
-				id, email, password, created_at, updated_at 
+				id, email, password, role, billing_country, email_verified_at, created_at, updated_at 
 			FROM 
 				users WHERE email = ? `
 	rebindQuery := slaveDB.Rebind(query)
@@ -71,17 +72,20 @@ func Test_repository_GetUser(t *testing.T) {
 				email: "email@email.com",
 			},
 			want: &users.Model{
-				ID:        1,
-				Email:     "email@email.com",
-				Password:  "password",
-				CreatedAt: 1714641784000,
-				UpdatedAt: 1714641784000,
+				ID:              1,
+				Email:           "email@email.com",
+				Password:        "password",
+				Role:            "customer",
+				BillingCountry:  "ID",
+				EmailVerifiedAt: 1714641785000,
+				CreatedAt:       1714641784000,
+				UpdatedAt:       1714641784000,
 			},
 			wantErr: false,
 			mockFn: func(args args) {
 				mock.ExpectPrepare(rebindQuery).ExpectQuery().
-					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password", "created_at", "updated_at"}).
-						AddRow(1, "email@email.com", "password", 1714641784000, 1714641784000))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password", "role", "billing_country", "email_verified_at", "created_at", "updated_at"}).
+						AddRow(1, "email@email.com", "password", "customer", "ID", 1714641785000, 1714641784000, 1714641784000))
 			},
 		},
 	}
@@ -104,6 +108,68 @@ func Test_repository_GetUser(t *testing.T) {
 	}
 }
 
+func Test_repository_GetUserByID(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	query := `SELECT 
+				id, email, password, role, billing_country, email_verified_at, created_at, updated_at 
+			FROM 
+				users WHERE id = ? `
+	rebindQuery := slaveDB.Rebind(query)
+
+	tests := []struct {
+		name    string
+		want    *users.Model
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error when get context",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectQuery().WithArgs(1).WillReturnError(errors.New("failed"))
+			},
+		},
+		{
+			name: "user not found",
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectQuery().WithArgs(1).WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			name: "success",
+			want: &users.Model{ID: 1, Email: "email@email.com", Role: "customer", BillingCountry: "CA"},
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectQuery().WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password", "role", "billing_country", "email_verified_at", "created_at", "updated_at"}).
+						AddRow(1, "email@email.com", "", "customer", "CA", 0, 0, 0))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+				slaveDB:  slaveDB,
+			}
+			got, err := r.GetUserByID(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetUserByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetUserByID() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_repository_InsertUser(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -116,8 +182,8 @@ func Test_repository_InsertUser(t *testing.T) {
 	}()
 
 	query := `INSERT INTO users
-							(email, password, created_at, updated_at)
-							VALUES(?, ?, ?, ?) RETURNING id;`
+							(email, password, role, billing_country, created_at, updated_at)
+							VALUES(?, ?, ?, ?, ?, ?) RETURNING id;`
 	rebindQuery := masterDB.Rebind(query)
 
 	type args struct {
@@ -199,3 +265,115 @@ func Test_repository_InsertUser(t *testing.T) {
 		})
 	}
 }
+
+func Test_repository_VerifyEmail(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	rebindQuery := masterDB.Rebind(`UPDATE users SET email_verified_at = ?, updated_at = ? WHERE id = ? AND email_verified_at = 0`)
+
+	tests := []struct {
+		name    string
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error when prepare context",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).WillReturnError(errors.New("failed"))
+			},
+		},
+		{
+			name:    "error when exec context",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectExec().WithArgs(1714641785000, 1714641785000, 1).WillReturnError(errors.New("failed"))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectExec().WithArgs(1714641785000, 1714641785000, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+				slaveDB:  slaveDB,
+			}
+			err := r.VerifyEmail(context.Background(), 1, 1714641785000)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyEmail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdatePassword(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	rebindQuery := masterDB.Rebind(`UPDATE users SET password = ?, updated_at = ? WHERE id = ?`)
+
+	tests := []struct {
+		name    string
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name:    "error when exec context",
+			wantErr: "failed",
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectExec().WithArgs("hashed", 1714641785000, 1).WillReturnError(errors.New("failed"))
+			},
+		},
+		{
+			name:    "error user not found",
+			wantErr: "user with id: 1 is not found",
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectExec().WithArgs("hashed", 1714641785000, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mock.ExpectPrepare(rebindQuery).ExpectExec().WithArgs("hashed", 1714641785000, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+				slaveDB:  slaveDB,
+			}
+			err := r.UpdatePassword(context.Background(), 1, "hashed", 1714641785000)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("UpdatePassword() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("UpdatePassword() error = %v", err)
+			}
+		})
+	}
+}
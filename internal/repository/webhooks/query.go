@@ -0,0 +1,73 @@
+package webhooks
+
+var (
+	queryGetSubscriptions = `
+		SELECT id, url, secret, event_types, description, active, created_at, updated_at
+		FROM webhook_subscriptions
+	`
+
+	insertSubscriptionQuery = `
+        INSERT INTO webhook_subscriptions (url, secret, event_types, description, active, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        RETURNING id;
+    `
+
+	updateSubscriptionQuery = `
+        UPDATE webhook_subscriptions
+        SET url = ?, secret = ?, event_types = ?, description = ?, active = ?, updated_at = ?
+        WHERE id = ?
+        RETURNING created_at;
+    `
+
+	// deleteSubscriptionQuery also deletes the delivery log of the subscription
+	deleteSubscriptionQuery = `DELETE FROM webhook_subscriptions WHERE id = ?;`
+
+	// enqueueDeliveriesQuery creates a delivery of the event for every active subscription of its type, an event that was
+	// already enqueued for a subscription is skipped
+	enqueueDeliveriesQuery = `
+        INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+        SELECT id, ?, ?, ?, ?, ?, ?
+        FROM webhook_subscriptions
+        WHERE active AND ? = ANY(event_types)
+        ON CONFLICT (subscription_id, event_id) DO NOTHING;
+    `
+
+	// claimDeliveriesQuery leases the due deliveries of the active subscriptions, see claimEventsQuery of the outbox
+	claimDeliveriesQuery = `
+        UPDATE webhook_deliveries d
+        SET next_attempt_at = ?
+        FROM webhook_subscriptions s
+        WHERE s.id = d.subscription_id AND d.id IN (
+            SELECT pending.id FROM webhook_deliveries pending
+            JOIN webhook_subscriptions subscription ON subscription.id = pending.subscription_id
+            WHERE pending.status = 'PENDING' AND pending.next_attempt_at <= ? AND subscription.active
+            ORDER BY pending.next_attempt_at, pending.id
+            LIMIT ?
+            FOR UPDATE OF pending SKIP LOCKED
+        )
+        RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+            d.last_error, d.next_attempt_at, d.created_at, d.delivered_at, s.url, s.secret;
+    `
+
+	updateDeliveryQuery = `
+        UPDATE webhook_deliveries
+        SET status = ?, attempts = ?, response_status = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+        WHERE id = ?;
+    `
+
+	// getDeliveriesQuery is the delivery log of a subscription, newest first, an empty status matches every status
+	getDeliveriesQuery = `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, response_status, last_error,
+			next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = ? AND (? = '' OR status = ?)
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	countDeliveriesQuery = `
+		SELECT COUNT(*)
+		FROM webhook_deliveries
+		WHERE subscription_id = ? AND (? = '' OR status = ?)
+	`
+)
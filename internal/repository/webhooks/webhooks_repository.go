@@ -0,0 +1,245 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"sort"
+)
+
+type repository struct {
+	masterDB internalsql.MasterDB
+	slaveDB  internalsql.SlaveDB
+}
+
+func New(masterDB internalsql.MasterDB, slaveDB internalsql.SlaveDB) *repository {
+	return &repository{
+		masterDB: masterDB,
+		slaveDB:  slaveDB,
+	}
+}
+
+// subscriptionRow is a subscription as stored, the event types are a postgres array
+type subscriptionRow struct {
+	webhooks.Subscription
+	EventTypes pq.StringArray `db:"event_types"`
+}
+
+func (row subscriptionRow) toModel() webhooks.Subscription {
+	model := row.Subscription
+	model.EventTypes = []string(row.EventTypes)
+	if model.EventTypes == nil {
+		model.EventTypes = make([]string, 0)
+	}
+	return model
+}
+
+// deliveryRow is a delivery as stored, the payload is JSONB
+type deliveryRow struct {
+	webhooks.Delivery
+	Payload string `db:"payload"`
+}
+
+func (row deliveryRow) toModel() webhooks.Delivery {
+	model := row.Delivery
+	model.Payload = json.RawMessage(row.Payload)
+	return model
+}
+
+func (r *repository) GetSubscriptions(ctx context.Context) ([]webhooks.Subscription, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(queryGetSubscriptions+` ORDER BY id DESC`))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var rows []subscriptionRow
+	err = stmt.SelectContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]webhooks.Subscription, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, row.toModel())
+	}
+	return list, nil
+}
+
+func (r *repository) GetSubscriptionByID(ctx context.Context, id int64) (*webhooks.Subscription, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(queryGetSubscriptions+` WHERE id = ?`))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var row subscriptionRow
+	err = stmt.GetContext(ctx, &row, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	model := row.toModel()
+	return &model, nil
+}
+
+func (r *repository) InsertSubscription(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(insertSubscriptionQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, model.URL, model.Secret, pq.StringArray(model.EventTypes), model.Description, model.Active,
+		model.CreatedAt, model.UpdatedAt).Scan(&model.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *repository) UpdateSubscription(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(updateSubscriptionQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, model.URL, model.Secret, pq.StringArray(model.EventTypes), model.Description, model.Active,
+		model.UpdatedAt, model.ID).Scan(&model.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook subscription with id: %d is not found", model.ID)
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *repository) DeleteSubscription(ctx context.Context, id int64) error {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(deleteSubscriptionQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("webhook subscription with id: %d is not found", id)
+	}
+	return nil
+}
+
+// EnqueueDeliveries creates a pending delivery of the event for each active subscription of its type and returns how many
+// were created, enqueueing the same event again creates none
+func (r *repository) EnqueueDeliveries(ctx context.Context, delivery webhooks.Delivery) (int64, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(enqueueDeliveriesQuery))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, delivery.EventID, delivery.EventType, string(delivery.Payload), delivery.Status,
+		delivery.NextAttemptAt, delivery.CreatedAt, delivery.EventType)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ClaimDeliveries returns up to limit pending deliveries that are due at now with the url and secret of their subscription,
+// oldest first. They are kept from other dispatchers until leaseUntil.
+func (r *repository) ClaimDeliveries(ctx context.Context, limit int, now, leaseUntil int64) ([]webhooks.Delivery, error) {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(claimDeliveriesQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryxContext(ctx, leaseUntil, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]webhooks.Delivery, 0)
+	for rows.Next() {
+		var row deliveryRow
+		err = rows.StructScan(&row)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, row.toModel())
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	return deliveries, nil
+}
+
+// UpdateDelivery records the outcome of an attempt to send the delivery
+func (r *repository) UpdateDelivery(ctx context.Context, delivery webhooks.Delivery) error {
+	stmt, err := r.masterDB.PreparexContext(ctx, r.masterDB.Rebind(updateDeliveryQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.LastError,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID)
+	return err
+}
+
+func (r *repository) GetDeliveries(ctx context.Context, subscriptionID int64, status string, limit, offset int) ([]webhooks.Delivery, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(getDeliveriesQuery))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var rows []deliveryRow
+	err = stmt.SelectContext(ctx, &rows, subscriptionID, status, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]webhooks.Delivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.toModel())
+	}
+	return deliveries, nil
+}
+
+func (r *repository) CountDeliveries(ctx context.Context, subscriptionID int64, status string) (int64, error) {
+	stmt, err := r.slaveDB.PreparexContext(ctx, r.slaveDB.Rebind(countDeliveriesQuery))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var total int64
+	err = stmt.GetContext(ctx, &total, subscriptionID, status, status)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
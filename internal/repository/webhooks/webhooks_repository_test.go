@@ -0,0 +1,362 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/internalsql"
+	"reflect"
+	"testing"
+)
+
+var (
+	subscriptionColumns = []string{"id", "url", "secret", "event_types", "description", "active", "created_at", "updated_at"}
+	deliveryColumns     = []string{"id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts",
+		"response_status", "last_error", "next_attempt_at", "created_at", "delivered_at"}
+)
+
+const getSubscriptionsQueryTest = `
+		SELECT id, url, secret, event_types, description, active, created_at, updated_at
+		FROM webhook_subscriptions
+	`
+
+func Test_repository_GetSubscriptionByID(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	query := slaveDB.Rebind(getSubscriptionsQueryTest + ` WHERE id = ?`)
+
+	tests := []struct {
+		name    string
+		want    *webhooks.Subscription
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on query",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(query).ExpectQuery().WithArgs(2).WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name: "not found",
+			mockFn: func() {
+				mock.ExpectPrepare(query).ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+			},
+		},
+		{
+			name: "success",
+			want: &webhooks.Subscription{
+				ID: 2, URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef", EventTypes: []string{"OrderCreated", "OrderStatusChanged"},
+				Description: "fulfilment", Active: true, CreatedAt: 1000, UpdatedAt: 2000,
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(query).ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows(subscriptionColumns).
+					AddRow(2, "https://partner.example.com/hooks", "whsec_0123456789abcdef", "{OrderCreated,OrderStatusChanged}",
+						"fulfilment", true, 1000, 2000))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				slaveDB: slaveDB,
+			}
+			got, err := r.GetSubscriptionByID(context.Background(), 2)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetSubscriptionByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSubscriptionByID() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_UpdateSubscription(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	updateSubscriptionQueryTest := masterDB.Rebind(`
+        UPDATE webhook_subscriptions
+        SET url = ?, secret = ?, event_types = ?, description = ?, active = ?, updated_at = ?
+        WHERE id = ?
+        RETURNING created_at;
+    `)
+	model := webhooks.Subscription{ID: 2, URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef",
+		EventTypes: []string{"OrderCreated"}, Active: false, UpdatedAt: 2000}
+
+	tests := []struct {
+		name    string
+		want    *webhooks.Subscription
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name:    "not found",
+			wantErr: "webhook subscription with id: 2 is not found",
+			mockFn: func() {
+				mock.ExpectPrepare(updateSubscriptionQueryTest).ExpectQuery().
+					WithArgs("https://partner.example.com/hooks", "whsec_0123456789abcdef", `{"OrderCreated"}`, "", false, 2000, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+			},
+		},
+		{
+			name: "success",
+			want: func() *webhooks.Subscription {
+				updated := model
+				updated.CreatedAt = 1000
+				return &updated
+			}(),
+			mockFn: func() {
+				mock.ExpectPrepare(updateSubscriptionQueryTest).ExpectQuery().
+					WithArgs("https://partner.example.com/hooks", "whsec_0123456789abcdef", `{"OrderCreated"}`, "", false, 2000, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(1000))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.UpdateSubscription(context.Background(), model)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("UpdateSubscription() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("UpdateSubscription() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateSubscription() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_EnqueueDeliveries(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	enqueueDeliveriesQueryTest := masterDB.Rebind(`
+        INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+        SELECT id, ?, ?, ?, ?, ?, ?
+        FROM webhook_subscriptions
+        WHERE active AND ? = ANY(event_types)
+        ON CONFLICT (subscription_id, event_id) DO NOTHING;
+    `)
+	delivery := webhooks.Delivery{EventID: 7, EventType: "OrderCreated", Payload: json.RawMessage(`{"id":7}`), Status: "PENDING",
+		NextAttemptAt: 1000, CreatedAt: 1000}
+
+	tests := []struct {
+		name    string
+		want    int64
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on insert",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(enqueueDeliveriesQueryTest).ExpectExec().
+					WithArgs(7, "OrderCreated", `{"id":7}`, "PENDING", 1000, 1000, "OrderCreated").
+					WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name: "success creates a delivery per subscription",
+			want: 2,
+			mockFn: func() {
+				mock.ExpectPrepare(enqueueDeliveriesQueryTest).ExpectExec().
+					WithArgs(7, "OrderCreated", `{"id":7}`, "PENDING", 1000, 1000, "OrderCreated").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.EnqueueDeliveries(context.Background(), delivery)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EnqueueDeliveries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("EnqueueDeliveries() got = %v, want %v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_ClaimDeliveries(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	masterDB := internalsql.NewMasterDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	claimDeliveriesQueryTest := masterDB.Rebind(`
+        UPDATE webhook_deliveries d
+        SET next_attempt_at = ?
+        FROM webhook_subscriptions s
+        WHERE s.id = d.subscription_id AND d.id IN (
+            SELECT pending.id FROM webhook_deliveries pending
+            JOIN webhook_subscriptions subscription ON subscription.id = pending.subscription_id
+            WHERE pending.status = 'PENDING' AND pending.next_attempt_at <= ? AND subscription.active
+            ORDER BY pending.next_attempt_at, pending.id
+            LIMIT ?
+            FOR UPDATE OF pending SKIP LOCKED
+        )
+        RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.response_status,
+            d.last_error, d.next_attempt_at, d.created_at, d.delivered_at, s.url, s.secret;
+    `)
+
+	tests := []struct {
+		name    string
+		want    []webhooks.Delivery
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on claim",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(claimDeliveriesQueryTest).ExpectQuery().
+					WithArgs(61000, 1000, 100).
+					WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name: "success sorts the claimed deliveries",
+			want: []webhooks.Delivery{
+				{ID: 4, SubscriptionID: 2, EventID: 7, EventType: "OrderCreated", Payload: json.RawMessage(`{"id":7}`), Status: "PENDING",
+					NextAttemptAt: 61000, CreatedAt: 900, URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef"},
+				{ID: 6, SubscriptionID: 3, EventID: 7, EventType: "OrderCreated", Payload: json.RawMessage(`{"id":7}`), Status: "PENDING",
+					Attempts: 1, ResponseStatus: 502, LastError: "subscriber responded with status 502", NextAttemptAt: 61000, CreatedAt: 900,
+					URL: "http://localhost:8080/events", Secret: "whsec_fedcba9876543210"},
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(claimDeliveriesQueryTest).ExpectQuery().
+					WithArgs(61000, 1000, 100).
+					WillReturnRows(sqlmock.NewRows(append(deliveryColumns, "url", "secret")).
+						AddRow(6, 3, 7, "OrderCreated", `{"id":7}`, "PENDING", 1, 502, "subscriber responded with status 502", 61000, 900, 0,
+							"http://localhost:8080/events", "whsec_fedcba9876543210").
+						AddRow(4, 2, 7, "OrderCreated", `{"id":7}`, "PENDING", 0, 0, "", 61000, 900, 0,
+							"https://partner.example.com/hooks", "whsec_0123456789abcdef"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				masterDB: masterDB,
+			}
+			got, err := r.ClaimDeliveries(context.Background(), 100, 1000, 61000)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ClaimDeliveries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClaimDeliveries() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func Test_repository_GetDeliveries(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	slaveDB := internalsql.NewSlaveDB(db, "sqlmock")
+	defer func() {
+		_ = db.Close()
+	}()
+
+	getDeliveriesQueryTest := slaveDB.Rebind(`
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, response_status, last_error,
+			next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = ? AND (? = '' OR status = ?)
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`)
+
+	tests := []struct {
+		name    string
+		want    []webhooks.Delivery
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on query",
+			wantErr: true,
+			mockFn: func() {
+				mock.ExpectPrepare(getDeliveriesQueryTest).ExpectQuery().
+					WithArgs(2, "FAILED", "FAILED", 10, 10).
+					WillReturnError(errors.New("connection reset"))
+			},
+		},
+		{
+			name: "success",
+			want: []webhooks.Delivery{
+				{ID: 6, SubscriptionID: 2, EventID: 7, EventType: "OrderCreated", Payload: json.RawMessage(`{"id":7}`), Status: "FAILED",
+					Attempts: 8, LastError: "dial tcp: connection refused", NextAttemptAt: 5000, CreatedAt: 900},
+			},
+			mockFn: func() {
+				mock.ExpectPrepare(getDeliveriesQueryTest).ExpectQuery().
+					WithArgs(2, "FAILED", "FAILED", 10, 10).
+					WillReturnRows(sqlmock.NewRows(deliveryColumns).
+						AddRow(6, 2, 7, "OrderCreated", `{"id":7}`, "FAILED", 8, 0, "dial tcp: connection refused", 5000, 900, 0))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			r := &repository{
+				slaveDB: slaveDB,
+			}
+			got, err := r.GetDeliveries(context.Background(), 2, "FAILED", 10, 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetDeliveries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetDeliveries() got = %+v, want %+v", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -4,3 +4,30 @@ type BaseResponse struct {
 	Result bool   `json:"result"`
 	Error  string `json:"error,omitempty"`
 }
+
+// Pagination is the page information of a list response
+type Pagination struct {
+	TotalItems int64  `json:"total_items"`
+	TotalPages int64  `json:"total_pages"`
+	PageIndex  int    `json:"page_index"`
+	PageSize   int    `json:"page_size"`
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPagination builds the pagination from the sanitized limit and offset, see util.GetLimitAndOffset
+func NewPagination(limit, offset int, totalItems int64) Pagination {
+	if limit <= 0 {
+		return Pagination{TotalItems: totalItems}
+	}
+
+	pageIndex := offset/limit + 1
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	return Pagination{
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+		PageIndex:  pageIndex,
+		PageSize:   limit,
+		HasNext:    int64(pageIndex) < totalPages,
+	}
+}
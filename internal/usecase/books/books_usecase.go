@@ -2,14 +2,40 @@ package books
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/signer"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"strings"
+	"time"
 )
 
+// booksCursorPurpose is signed along with the cursor so a token issued for something else can't be passed as a cursor
+const booksCursorPurpose = "books_cursor"
+
+// earliestPublishedDate is roughly when the printing press came around, anything older is a typo
+var earliestPublishedDate = time.Date(1450, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 //go:generate mockgen -package=books -source=books_usecase.go -destination=books_usecase_mock_test.go
 type booksRepository interface {
-	GetBooks(ctx context.Context, search string, limit, offset int) ([]books.Model, error)
+	GetBooks(ctx context.Context, filter books.Filter, after *books.Cursor, limit, offset int) (books.Page, error)
+	GetBookByID(ctx context.Context, id int64) (*books.Model, error)
+	GetBookByIDs(ctx context.Context, ids []int64) (map[int64]books.Model, error)
+	GetBookByISBN(ctx context.Context, isbn string) (*books.Model, error)
+	InsertBook(ctx context.Context, model books.Model) (*books.Model, error)
+	UpdateBook(ctx context.Context, model books.Model, stock *int) (*books.Model, error)
+	DeleteBook(ctx context.Context, id int64) error
+}
+
+// cursorToken is the payload of the next_cursor token, the filter checksum ties it to the filter it was issued for
+type cursorToken struct {
+	Filter string `json:"f"`
+	books.Cursor
 }
 
 type usecase struct {
@@ -21,8 +47,183 @@ func New(booksRepository booksRepository, cfg *configs.Config) *usecase {
 	return &usecase{booksRepository: booksRepository, cfg: cfg}
 }
 
-func (u *usecase) GetBooks(ctx context.Context, search string, pageSize, pageIndex int) ([]books.Model, error) {
+func (u *usecase) GetBooks(ctx context.Context, filter books.Filter, cursor string, pageSize, pageIndex int) ([]books.Model, response.Pagination, error) {
+	if !filter.Sort.IsValid() {
+		return nil, response.Pagination{}, fmt.Errorf("invalid sort: %s", filter.Sort)
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, response.Pagination{}, errors.New("invalid price range, min_price is greater than max_price")
+	}
+	if filter.PublishedFrom != nil && filter.PublishedTo != nil && filter.PublishedFrom.After(*filter.PublishedTo) {
+		return nil, response.Pagination{}, errors.New("invalid published date range, published_from is after published_to")
+	}
+
 	// convert to limit and offset
 	limit, offset := util.GetLimitAndOffset(pageIndex, pageSize)
-	return u.booksRepository.GetBooks(ctx, search, limit, offset)
+
+	// a cursor takes over the page index, the next page starts right after the last book of the previous one
+	var after *books.Cursor
+	if cursor != "" {
+		var token cursorToken
+		err := signer.Verify(booksCursorPurpose, cursor, u.cfg.Service.SecretKey, &token)
+		if err != nil || token.Filter != filterChecksum(filter) {
+			return nil, response.Pagination{}, errors.New("invalid cursor")
+		}
+		after = &token.Cursor
+	}
+
+	page, err := u.booksRepository.GetBooks(ctx, filter, after, limit, offset)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	pagination := response.NewPagination(limit, offset, page.TotalItems)
+	if after != nil {
+		pagination.PageIndex = 0 // the page index is unknown when paging by cursor
+	}
+	pagination.HasNext = page.HasNext
+	if page.Last != nil {
+		pagination.NextCursor, err = signer.Sign(booksCursorPurpose, cursorToken{Filter: filterChecksum(filter), Cursor: *page.Last}, u.cfg.Service.SecretKey)
+		if err != nil {
+			return nil, response.Pagination{}, err
+		}
+	}
+	return page.Books, pagination, nil
+}
+
+func (u *usecase) GetBookByID(ctx context.Context, id int64) (*books.Model, error) {
+	book, err := u.booksRepository.GetBookByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if book == nil {
+		return nil, fmt.Errorf("book with id: %d is not found", id)
+	}
+	return book, nil
+}
+
+func (u *usecase) CreateBook(ctx context.Context, req books.CreateBookRequest) (*books.Model, error) {
+	publishedDate, err := parsePublishedDate(req.PublishedDate)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	model := books.Model{
+		Title:         strings.TrimSpace(req.Title),
+		Author:        strings.TrimSpace(req.Author),
+		ISBN:          normalizeISBN(req.ISBN),
+		PublishedDate: publishedDate,
+		Price:         req.Price,
+		Stock:         req.Stock,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err = u.validateUniqueISBN(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.booksRepository.InsertBook(ctx, model)
+}
+
+func (u *usecase) UpdateBook(ctx context.Context, req books.UpdateBookRequest) (*books.Model, error) {
+	publishedDate, err := parsePublishedDate(req.PublishedDate)
+	if err != nil {
+		return nil, err
+	}
+
+	model := books.Model{
+		ID:            req.ID,
+		Title:         strings.TrimSpace(req.Title),
+		Author:        strings.TrimSpace(req.Author),
+		ISBN:          normalizeISBN(req.ISBN),
+		PublishedDate: publishedDate,
+		Price:         req.Price,
+		UpdatedAt:     time.Now().UnixMilli(),
+	}
+
+	err = u.validateUniqueISBN(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.booksRepository.UpdateBook(ctx, model, &req.Stock)
+}
+
+func (u *usecase) PatchBook(ctx context.Context, req books.PatchBookRequest) (*books.Model, error) {
+	bookMap, err := u.booksRepository.GetBookByIDs(ctx, []int64{req.ID})
+	if err != nil {
+		return nil, err
+	}
+	model, ok := bookMap[req.ID]
+	if !ok {
+		return nil, fmt.Errorf("book with id: %d is not found", req.ID)
+	}
+
+	if req.Title != nil {
+		model.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Author != nil {
+		model.Author = strings.TrimSpace(*req.Author)
+	}
+	if req.ISBN != nil {
+		model.ISBN = normalizeISBN(*req.ISBN)
+	}
+	if req.PublishedDate != nil {
+		model.PublishedDate, err = parsePublishedDate(*req.PublishedDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.Price != nil {
+		model.Price = *req.Price
+	}
+	model.UpdatedAt = time.Now().UnixMilli()
+
+	err = u.validateUniqueISBN(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.booksRepository.UpdateBook(ctx, model, req.Stock)
+}
+
+func (u *usecase) DeleteBook(ctx context.Context, id int64) error {
+	return u.booksRepository.DeleteBook(ctx, id)
+}
+
+// validateUniqueISBN makes sure no other book already uses the ISBN, the unique index still guards concurrent writes
+func (u *usecase) validateUniqueISBN(ctx context.Context, model books.Model) error {
+	book, err := u.booksRepository.GetBookByISBN(ctx, model.ISBN)
+	if err != nil {
+		return err
+	}
+	if book != nil && book.ID != model.ID {
+		return errors.New("isbn already exists")
+	}
+	return nil
+}
+
+func parsePublishedDate(date string) (time.Time, error) {
+	publishedDate, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, errors.New("invalid published date, format must be YYYY-MM-DD")
+	}
+	if publishedDate.Before(earliestPublishedDate) || publishedDate.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("invalid published date, must be between %s and today", earliestPublishedDate.Format(time.DateOnly))
+	}
+	return publishedDate, nil
+}
+
+// normalizeISBN strips the hyphens and spaces so the same ISBN can't be stored twice in different formats
+func normalizeISBN(isbn string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn))
+}
+
+// filterChecksum is a short digest of the filter, a cursor is only valid for the filter and sort it was issued for
+func filterChecksum(filter books.Filter) string {
+	sum := sha256.Sum256([]byte(filter.Encode()))
+	return hex.EncodeToString(sum[:8])
 }
@@ -35,17 +35,106 @@ func (m *MockbooksRepository) EXPECT() *MockbooksRepositoryMockRecorder {
 	return m.recorder
 }
 
+// DeleteBook mocks base method.
+func (m *MockbooksRepository) DeleteBook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBook indicates an expected call of DeleteBook.
+func (mr *MockbooksRepositoryMockRecorder) DeleteBook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockbooksRepository)(nil).DeleteBook), ctx, id)
+}
+
+// GetBookByID mocks base method.
+func (m *MockbooksRepository) GetBookByID(ctx context.Context, id int64) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByID", ctx, id)
+	ret0, _ := ret[0].(*books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByID indicates an expected call of GetBookByID.
+func (mr *MockbooksRepositoryMockRecorder) GetBookByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByID", reflect.TypeOf((*MockbooksRepository)(nil).GetBookByID), ctx, id)
+}
+
+// GetBookByIDs mocks base method.
+func (m *MockbooksRepository) GetBookByIDs(ctx context.Context, ids []int64) (map[int64]books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByIDs", ctx, ids)
+	ret0, _ := ret[0].(map[int64]books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByIDs indicates an expected call of GetBookByIDs.
+func (mr *MockbooksRepositoryMockRecorder) GetBookByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByIDs", reflect.TypeOf((*MockbooksRepository)(nil).GetBookByIDs), ctx, ids)
+}
+
+// GetBookByISBN mocks base method.
+func (m *MockbooksRepository) GetBookByISBN(ctx context.Context, isbn string) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByISBN", ctx, isbn)
+	ret0, _ := ret[0].(*books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByISBN indicates an expected call of GetBookByISBN.
+func (mr *MockbooksRepositoryMockRecorder) GetBookByISBN(ctx, isbn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByISBN", reflect.TypeOf((*MockbooksRepository)(nil).GetBookByISBN), ctx, isbn)
+}
+
 // GetBooks mocks base method.
-func (m *MockbooksRepository) GetBooks(ctx context.Context, search string, limit, offset int) ([]books.Model, error) {
+func (m *MockbooksRepository) GetBooks(ctx context.Context, filter books.Filter, after *books.Cursor, limit, offset int) (books.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBooks", ctx, search, limit, offset)
-	ret0, _ := ret[0].([]books.Model)
+	ret := m.ctrl.Call(m, "GetBooks", ctx, filter, after, limit, offset)
+	ret0, _ := ret[0].(books.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBooks indicates an expected call of GetBooks.
-func (mr *MockbooksRepositoryMockRecorder) GetBooks(ctx, search, limit, offset interface{}) *gomock.Call {
+func (mr *MockbooksRepositoryMockRecorder) GetBooks(ctx, filter, after, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockbooksRepository)(nil).GetBooks), ctx, filter, after, limit, offset)
+}
+
+// InsertBook mocks base method.
+func (m *MockbooksRepository) InsertBook(ctx context.Context, model books.Model) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertBook", ctx, model)
+	ret0, _ := ret[0].(*books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertBook indicates an expected call of InsertBook.
+func (mr *MockbooksRepositoryMockRecorder) InsertBook(ctx, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBook", reflect.TypeOf((*MockbooksRepository)(nil).InsertBook), ctx, model)
+}
+
+// UpdateBook mocks base method.
+func (m *MockbooksRepository) UpdateBook(ctx context.Context, model books.Model, stock *int) (*books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, model, stock)
+	ret0, _ := ret[0].(*books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockbooksRepositoryMockRecorder) UpdateBook(ctx, model, stock interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockbooksRepository)(nil).GetBooks), ctx, search, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockbooksRepository)(nil).UpdateBook), ctx, model, stock)
 }
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/signer"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_usecase_GetBooks(t *testing.T) {
@@ -14,24 +19,30 @@ func Test_usecase_GetBooks(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	minPrice, maxPrice := money.MustParse("5"), money.MustParse("10")
+	cfg := &configs.Config{Service: configs.Service{SecretKey: "secret"}}
+	nextCursor, _ := signer.Sign(booksCursorPurpose, cursorToken{Filter: filterChecksum(books.Filter{}), Cursor: books.Cursor{ID: 2}}, "secret")
+	searchCursor, _ := signer.Sign(booksCursorPurpose, cursorToken{Filter: filterChecksum(books.Filter{Search: "Book"}), Cursor: books.Cursor{Value: "0.5", ID: 2}}, "secret")
 	type args struct {
 		ctx       context.Context
-		search    string
+		filter    books.Filter
+		cursor    string
 		pageSize  int
 		pageIndex int
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    []books.Model
-		wantErr bool
-		mockFn  func(args args)
+		name           string
+		args           args
+		want           []books.Model
+		wantPagination response.Pagination
+		wantErr        bool
+		mockFn         func(args args)
 	}{
 		{
 			name: "error",
 			args: args{
 				ctx:       context.Background(),
-				search:    "Book",
+				filter:    books.Filter{Search: "Book"},
 				pageSize:  10,
 				pageIndex: 1,
 			},
@@ -39,14 +50,38 @@ func Test_usecase_GetBooks(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 			mockFn: func(args args) {
-				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.search, args.pageSize, 0).Return(nil, errors.New("failed"))
+				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.filter, nil, args.pageSize, 0).Return(books.Page{}, errors.New("failed"))
+			},
+		},
+		{
+			name: "error invalid sort",
+			args: args{
+				ctx:       context.Background(),
+				filter:    books.Filter{Sort: "cheapest"},
+				pageSize:  10,
+				pageIndex: 1,
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
+		},
+		{
+			name: "error min price greater than max price",
+			args: args{
+				ctx:       context.Background(),
+				filter:    books.Filter{MinPrice: &maxPrice, MaxPrice: &minPrice},
+				pageSize:  10,
+				pageIndex: 1,
 			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
 		},
 		{
 			name: "valid search, page 1, page size 10",
 			args: args{
 				ctx:       context.Background(),
-				search:    "Book",
+				filter:    books.Filter{Search: "Book"},
 				pageSize:  10,
 				pageIndex: 1,
 			},
@@ -55,19 +90,74 @@ func Test_usecase_GetBooks(t *testing.T) {
 				{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
 				{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
 			},
-			wantErr: false,
+			wantPagination: response.Pagination{TotalItems: 12, TotalPages: 2, PageIndex: 1, PageSize: 10, HasNext: true, NextCursor: searchCursor},
+			wantErr:        false,
 			mockFn: func(args args) {
-				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.search, args.pageSize, 0).Return([]books.Model{
-					{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
-					{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.filter, nil, args.pageSize, 0).Return(books.Page{
+					Books: []books.Model{
+						{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+						{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+					},
+					TotalItems: 12,
+					HasNext:    true,
+					Last:       &books.Cursor{Value: "0.5", ID: 2},
 				}, nil)
 			},
 		},
+		{
+			name: "valid search, next page by cursor",
+			args: args{
+				ctx:       context.Background(),
+				filter:    books.Filter{Search: "Book"},
+				cursor:    searchCursor,
+				pageSize:  10,
+				pageIndex: 5,
+			},
+			want: []books.Model{
+				{ID: 3, Title: "Book 3", Author: "Author 3", ISBN: "111111111", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+			},
+			wantPagination: response.Pagination{TotalItems: 12, TotalPages: 2, PageIndex: 0, PageSize: 10, HasNext: false},
+			wantErr:        false,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.filter, &books.Cursor{Value: "0.5", ID: 2}, args.pageSize, 40).Return(books.Page{
+					Books: []books.Model{
+						{ID: 3, Title: "Book 3", Author: "Author 3", ISBN: "111111111", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+					},
+					TotalItems: 12,
+				}, nil)
+			},
+		},
+		{
+			name: "error cursor issued for another filter",
+			args: args{
+				ctx:       context.Background(),
+				filter:    books.Filter{Search: "Book", Sort: books.SortByPriceAsc},
+				cursor:    searchCursor,
+				pageSize:  10,
+				pageIndex: 1,
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
+		},
+		{
+			name: "error tampered cursor",
+			args: args{
+				ctx:       context.Background(),
+				filter:    books.Filter{Search: "Book"},
+				cursor:    searchCursor + "x",
+				pageSize:  10,
+				pageIndex: 1,
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
+		},
 		{
 			name: "invalid page index (negative)",
 			args: args{
 				ctx:       context.Background(),
-				search:    "",
+				filter:    books.Filter{},
 				pageSize:  10,
 				pageIndex: -1,
 			},
@@ -75,11 +165,17 @@ func Test_usecase_GetBooks(t *testing.T) {
 				{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
 				{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
 			},
-			wantErr: false,
+			wantPagination: response.Pagination{TotalItems: 12, TotalPages: 2, PageIndex: 1, PageSize: 10, HasNext: true, NextCursor: nextCursor},
+			wantErr:        false,
 			mockFn: func(args args) {
-				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.search, args.pageSize, 0).Return([]books.Model{
-					{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
-					{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.filter, nil, args.pageSize, 0).Return(books.Page{
+					Books: []books.Model{
+						{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+						{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+					},
+					TotalItems: 12,
+					HasNext:    true,
+					Last:       &books.Cursor{ID: 2},
 				}, nil)
 			},
 		},
@@ -87,7 +183,7 @@ func Test_usecase_GetBooks(t *testing.T) {
 			name: "invalid page size (zero)",
 			args: args{
 				ctx:       context.Background(),
-				search:    "",
+				filter:    books.Filter{},
 				pageSize:  0,
 				pageIndex: 1,
 			},
@@ -95,11 +191,17 @@ func Test_usecase_GetBooks(t *testing.T) {
 				{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
 				{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
 			},
-			wantErr: false,
+			wantPagination: response.Pagination{TotalItems: 12, TotalPages: 2, PageIndex: 1, PageSize: 10, HasNext: true, NextCursor: nextCursor},
+			wantErr:        false,
 			mockFn: func(args args) {
-				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.search, 10, 0).Return([]books.Model{
-					{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
-					{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+				mockBooksRepo.EXPECT().GetBooks(args.ctx, args.filter, nil, 10, 0).Return(books.Page{
+					Books: []books.Model{
+						{ID: 1, Title: "Book 1", Author: "Author 1", ISBN: "123456789", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+						{ID: 2, Title: "Book 2", Author: "Author 2", ISBN: "987654321", CreatedAt: 1623582000, UpdatedAt: 1623582000},
+					},
+					TotalItems: 12,
+					HasNext:    true,
+					Last:       &books.Cursor{ID: 2},
 				}, nil)
 			},
 		},
@@ -109,8 +211,9 @@ func Test_usecase_GetBooks(t *testing.T) {
 			tt.mockFn(tt.args)
 			u := &usecase{
 				booksRepository: mockBooksRepo,
+				cfg:             cfg,
 			}
-			got, err := u.GetBooks(tt.args.ctx, tt.args.search, tt.args.pageSize, tt.args.pageIndex)
+			got, gotPagination, err := u.GetBooks(tt.args.ctx, tt.args.filter, tt.args.cursor, tt.args.pageSize, tt.args.pageIndex)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetBooks() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -118,6 +221,244 @@ func Test_usecase_GetBooks(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetBooks() got = %v, want %v", got, tt.want)
 			}
+			if !reflect.DeepEqual(gotPagination, tt.wantPagination) {
+				t.Errorf("GetBooks() gotPagination = %v, want %v", gotPagination, tt.wantPagination)
+			}
+		})
+	}
+}
+
+func Test_usecase_CreateBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	type args struct {
+		ctx context.Context
+		req books.CreateBookRequest
+	}
+	validRequest := books.CreateBookRequest{
+		Title:         " 1984 ",
+		Author:        "George Orwell",
+		ISBN:          "978-0-451-52493-5",
+		PublishedDate: "1949-06-08",
+		Price:         money.MustParse("9.99"),
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *books.Model
+		wantErr bool
+		mockFn  func(args args)
+	}{
+		{
+			name: "error invalid published date format",
+			args: args{
+				ctx: context.Background(),
+				req: books.CreateBookRequest{Title: "1984", PublishedDate: "08-06-1949"},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
+		},
+		{
+			name: "error published date in the future",
+			args: args{
+				ctx: context.Background(),
+				req: books.CreateBookRequest{Title: "1984", PublishedDate: time.Now().AddDate(1, 0, 0).Format(time.DateOnly)},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
+		},
+		{
+			name: "error isbn already exists",
+			args: args{
+				ctx: context.Background(),
+				req: validRequest,
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByISBN(args.ctx, "9780451524935").Return(&books.Model{ID: 3, ISBN: "9780451524935"}, nil)
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+				req: validRequest,
+			},
+			want:    &books.Model{ID: 1, Title: "1984", Author: "George Orwell", ISBN: "9780451524935", Price: money.MustParse("9.99")},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByISBN(args.ctx, "9780451524935").Return(nil, nil)
+				mockBooksRepo.EXPECT().InsertBook(args.ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, model books.Model) (*books.Model, error) {
+					if model.Title != "1984" || model.ISBN != "9780451524935" || model.PublishedDate.Format(time.DateOnly) != "1949-06-08" {
+						t.Errorf("InsertBook() called with unexpected model %v", model)
+					}
+					return &books.Model{ID: 1, Title: "1984", Author: "George Orwell", ISBN: "9780451524935", Price: money.MustParse("9.99")}, nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			u := &usecase{
+				booksRepository: mockBooksRepo,
+			}
+			got, err := u.CreateBook(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateBook() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CreateBook() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_usecase_PatchBook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	newPrice := money.MustParse("12.5")
+	existing := books.Model{
+		ID:            1,
+		Title:         "1984",
+		Author:        "George Orwell",
+		ISBN:          "9780451524935",
+		PublishedDate: time.Date(1949, time.June, 8, 0, 0, 0, 0, time.UTC),
+		Price:         money.MustParse("9.99"),
+	}
+
+	type args struct {
+		ctx context.Context
+		req books.PatchBookRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *books.Model
+		wantErr bool
+		mockFn  func(args args)
+	}{
+		{
+			name: "error book not found",
+			args: args{
+				ctx: context.Background(),
+				req: books.PatchBookRequest{ID: 99, Price: &newPrice},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, []int64{99}).Return(map[int64]books.Model{}, nil)
+			},
+		},
+		{
+			name: "success only updates price",
+			args: args{
+				ctx: context.Background(),
+				req: books.PatchBookRequest{ID: 1, Price: &newPrice},
+			},
+			want: &books.Model{
+				ID:            1,
+				Title:         "1984",
+				Author:        "George Orwell",
+				ISBN:          "9780451524935",
+				PublishedDate: existing.PublishedDate,
+				Price:         money.MustParse("12.5"),
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, []int64{1}).Return(map[int64]books.Model{1: existing}, nil)
+				mockBooksRepo.EXPECT().GetBookByISBN(args.ctx, "9780451524935").Return(&existing, nil)
+				mockBooksRepo.EXPECT().UpdateBook(args.ctx, gomock.Any(), nil).DoAndReturn(func(ctx context.Context, model books.Model, stock *int) (*books.Model, error) {
+					model.UpdatedAt = 0
+					return &model, nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			u := &usecase{
+				booksRepository: mockBooksRepo,
+			}
+			got, err := u.PatchBook(tt.args.ctx, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PatchBook() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PatchBook() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_usecase_GetBookByID(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *books.Model
+		wantErr bool
+		mockFn  func(args args)
+	}{
+		{
+			name:    "error",
+			args:    args{ctx: context.Background(), id: 1},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByID(args.ctx, args.id).Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name:    "error not found",
+			args:    args{ctx: context.Background(), id: 1},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByID(args.ctx, args.id).Return(nil, nil)
+			},
+		},
+		{
+			name:    "success",
+			args:    args{ctx: context.Background(), id: 1},
+			want:    &books.Model{ID: 1, Title: "Book 1"},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByID(args.ctx, args.id).Return(&books.Model{ID: 1, Title: "Book 1"}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn(tt.args)
+			u := &usecase{
+				booksRepository: mockBooksRepo,
+			}
+			got, err := u.GetBookByID(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetBookByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetBookByID() got = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -0,0 +1,236 @@
+package carts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/constant"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/carts"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"log"
+	"sort"
+)
+
+//go:generate mockgen -package=carts -source=carts_usecase.go -destination=carts_usecase_mock_test.go
+type cartsRepository interface {
+	GetCart(ctx context.Context, userID int64) (map[int64]int, error)
+	SetItem(ctx context.Context, userID, bookID int64, quantity int) error
+	DeleteItem(ctx context.Context, userID, bookID int64) error
+	DeleteCart(ctx context.Context, userID int64) error
+}
+
+type booksRepository interface {
+	GetBookByIDs(ctx context.Context, ids []int64) (map[int64]books.Model, error)
+}
+
+type idempotencyRepository interface {
+	Reserve(ctx context.Context, key, fingerprint string) (*idempotency.Record, error)
+	Save(ctx context.Context, key, fingerprint string, response interface{}) error
+	Release(ctx context.Context, key string) error
+}
+
+type ordersUsecase interface {
+	InsertOrder(ctx context.Context, order orders.CreateOrderRequest) (*orders.CreateOrderResponse, error)
+	QuoteOrder(ctx context.Context, req orders.QuoteRequest) (*orders.Quote, error)
+}
+
+type usecase struct {
+	cartsRepository       cartsRepository
+	booksRepository       booksRepository
+	idempotencyRepository idempotencyRepository
+	ordersUsecase         ordersUsecase
+}
+
+func New(cartsRepository cartsRepository, booksRepository booksRepository, idempotencyRepository idempotencyRepository,
+	ordersUsecase ordersUsecase) *usecase {
+	return &usecase{cartsRepository: cartsRepository, booksRepository: booksRepository, idempotencyRepository: idempotencyRepository,
+		ordersUsecase: ordersUsecase}
+}
+
+// GetCart returns the cart of the user priced with the current price of the books
+func (u *usecase) GetCart(ctx context.Context, userID int64) (*carts.Cart, error) {
+	quantities, err := u.cartsRepository.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return u.priceCart(ctx, quantities)
+}
+
+func (u *usecase) AddItem(ctx context.Context, req carts.AddItemRequest) (*carts.Cart, error) {
+	quantities, err := u.cartsRepository.GetCart(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.setItem(ctx, req.UserID, req.BookID, quantities[req.BookID]+req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return u.GetCart(ctx, req.UserID)
+}
+
+func (u *usecase) UpdateItem(ctx context.Context, req carts.UpdateItemRequest) (*carts.Cart, error) {
+	if req.Quantity == 0 {
+		err := u.cartsRepository.DeleteItem(ctx, req.UserID, req.BookID)
+		if err != nil {
+			return nil, err
+		}
+		return u.GetCart(ctx, req.UserID)
+	}
+
+	err := u.setItem(ctx, req.UserID, req.BookID, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	return u.GetCart(ctx, req.UserID)
+}
+
+func (u *usecase) ClearCart(ctx context.Context, userID int64) error {
+	return u.cartsRepository.DeleteCart(ctx, userID)
+}
+
+// Checkout places an order of everything in the cart at the current prices and empties the cart. Retries with the same
+// idempotency key get the first order back, the key is checked before the cart since the first checkout emptied it
+func (u *usecase) Checkout(ctx context.Context, req carts.CheckoutRequest) (*orders.CreateOrderResponse, error) {
+	if req.IdempotencyKey == "" {
+		return u.checkout(ctx, req)
+	}
+
+	// only the request the client sent is fingerprinted, the cart and the prices behind it may change between retries
+	key := fmt.Sprintf(constant.RedisKeyCheckoutIdempotency, req.UserID, req.IdempotencyKey)
+	fingerprint, err := idempotency.Fingerprint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := u.idempotencyRepository.Reserve(ctx, key, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		var createdOrder orders.CreateOrderResponse
+		err = record.Replay(fingerprint, &createdOrder)
+		if err != nil {
+			return nil, err
+		}
+		createdOrder.Replayed = true
+		return &createdOrder, nil
+	}
+
+	createdOrder, err := u.checkout(ctx, req)
+	if err != nil {
+		// nothing was created, so the client may retry with the same key
+		releaseErr := u.idempotencyRepository.Release(ctx, key)
+		if releaseErr != nil {
+			log.Printf("[Checkout] error when releasing idempotency key %s: %v", key, releaseErr)
+		}
+		return nil, err
+	}
+
+	// the order exists already, failing to save the response only makes retries wait until the key expires
+	err = u.idempotencyRepository.Save(ctx, key, fingerprint, createdOrder)
+	if err != nil {
+		log.Printf("[Checkout] error when saving idempotency key %s: %v", key, err)
+	}
+	return createdOrder, nil
+}
+
+func (u *usecase) checkout(ctx context.Context, req carts.CheckoutRequest) (*orders.CreateOrderResponse, error) {
+	cart, err := u.GetCart(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+
+	order := orders.CreateOrderRequest{
+		UserID:    req.UserID,
+		PromoCode: req.PromoCode,
+		Items:     make([]orders.CreateOrderItem, 0, len(cart.Items)),
+	}
+	for _, item := range cart.Items {
+		order.Items = append(order.Items, orders.CreateOrderItem{
+			BookID:   item.BookID,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+		})
+	}
+
+	// the order is placed at what a quote of the cart comes to, once the promo code is applied and the tax is added
+	quote, err := u.ordersUsecase.QuoteOrder(ctx, orders.QuoteRequest{UserID: req.UserID, PromoCode: req.PromoCode, Items: order.Items})
+	if err != nil {
+		return nil, err
+	}
+	order.TotalAmount = quote.GrandTotal
+
+	createdOrder, err := u.ordersUsecase.InsertOrder(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	// the order is placed already, a cart that couldn't be emptied can still be cleared by the user
+	err = u.cartsRepository.DeleteCart(ctx, req.UserID)
+	if err != nil {
+		log.Printf("[Checkout] error when deleting the cart of user %d: %v", req.UserID, err)
+	}
+	return createdOrder, nil
+}
+
+// setItem checks that the book exists and has enough stock before putting the quantity in the cart
+func (u *usecase) setItem(ctx context.Context, userID, bookID int64, quantity int) error {
+	bookMap, err := u.booksRepository.GetBookByIDs(ctx, []int64{bookID})
+	if err != nil {
+		return err
+	}
+	book, ok := bookMap[bookID]
+	if !ok {
+		return fmt.Errorf("book with id: %d is not found", bookID)
+	}
+	if quantity > book.Stock {
+		return fmt.Errorf("only %d left in stock for book with id: %d", book.Stock, bookID)
+	}
+	return u.cartsRepository.SetItem(ctx, userID, bookID, quantity)
+}
+
+// priceCart prices the quantities with the current price of the books, books that were removed from the catalog are skipped
+func (u *usecase) priceCart(ctx context.Context, quantities map[int64]int) (*carts.Cart, error) {
+	cart := &carts.Cart{Items: make([]carts.Item, 0, len(quantities))}
+	if len(quantities) == 0 {
+		return cart, nil
+	}
+
+	bookIDs := make([]int64, 0, len(quantities))
+	for bookID := range quantities {
+		bookIDs = append(bookIDs, bookID)
+	}
+	sort.Slice(bookIDs, func(i, j int) bool {
+		return bookIDs[i] < bookIDs[j]
+	})
+
+	bookMap, err := u.booksRepository.GetBookByIDs(ctx, bookIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bookID := range bookIDs {
+		book, ok := bookMap[bookID]
+		if !ok {
+			continue
+		}
+		item := carts.Item{
+			BookID:    book.ID,
+			Title:     book.Title,
+			Author:    book.Author,
+			Price:     book.Price,
+			Quantity:  quantities[bookID],
+			Subtotal:  book.Price.Mul(quantities[bookID]),
+			Available: book.Stock >= quantities[bookID],
+		}
+		cart.Items = append(cart.Items, item)
+		cart.TotalAmount = cart.TotalAmount.Add(item.Subtotal)
+	}
+	return cart, nil
+}
@@ -0,0 +1,252 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: carts_usecase.go
+
+// Package carts is a generated GoMock package.
+package carts
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	books "github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	idempotency "github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
+	orders "github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+)
+
+// MockcartsRepository is a mock of cartsRepository interface.
+type MockcartsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockcartsRepositoryMockRecorder
+}
+
+// MockcartsRepositoryMockRecorder is the mock recorder for MockcartsRepository.
+type MockcartsRepositoryMockRecorder struct {
+	mock *MockcartsRepository
+}
+
+// NewMockcartsRepository creates a new mock instance.
+func NewMockcartsRepository(ctrl *gomock.Controller) *MockcartsRepository {
+	mock := &MockcartsRepository{ctrl: ctrl}
+	mock.recorder = &MockcartsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcartsRepository) EXPECT() *MockcartsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteCart mocks base method.
+func (m *MockcartsRepository) DeleteCart(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCart", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCart indicates an expected call of DeleteCart.
+func (mr *MockcartsRepositoryMockRecorder) DeleteCart(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCart", reflect.TypeOf((*MockcartsRepository)(nil).DeleteCart), ctx, userID)
+}
+
+// DeleteItem mocks base method.
+func (m *MockcartsRepository) DeleteItem(ctx context.Context, userID, bookID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItem", ctx, userID, bookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteItem indicates an expected call of DeleteItem.
+func (mr *MockcartsRepositoryMockRecorder) DeleteItem(ctx, userID, bookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItem", reflect.TypeOf((*MockcartsRepository)(nil).DeleteItem), ctx, userID, bookID)
+}
+
+// GetCart mocks base method.
+func (m *MockcartsRepository) GetCart(ctx context.Context, userID int64) (map[int64]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCart", ctx, userID)
+	ret0, _ := ret[0].(map[int64]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCart indicates an expected call of GetCart.
+func (mr *MockcartsRepositoryMockRecorder) GetCart(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockcartsRepository)(nil).GetCart), ctx, userID)
+}
+
+// SetItem mocks base method.
+func (m *MockcartsRepository) SetItem(ctx context.Context, userID, bookID int64, quantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetItem", ctx, userID, bookID, quantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetItem indicates an expected call of SetItem.
+func (mr *MockcartsRepositoryMockRecorder) SetItem(ctx, userID, bookID, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetItem", reflect.TypeOf((*MockcartsRepository)(nil).SetItem), ctx, userID, bookID, quantity)
+}
+
+// MockbooksRepository is a mock of booksRepository interface.
+type MockbooksRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockbooksRepositoryMockRecorder
+}
+
+// MockbooksRepositoryMockRecorder is the mock recorder for MockbooksRepository.
+type MockbooksRepositoryMockRecorder struct {
+	mock *MockbooksRepository
+}
+
+// NewMockbooksRepository creates a new mock instance.
+func NewMockbooksRepository(ctrl *gomock.Controller) *MockbooksRepository {
+	mock := &MockbooksRepository{ctrl: ctrl}
+	mock.recorder = &MockbooksRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockbooksRepository) EXPECT() *MockbooksRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetBookByIDs mocks base method.
+func (m *MockbooksRepository) GetBookByIDs(ctx context.Context, ids []int64) (map[int64]books.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByIDs", ctx, ids)
+	ret0, _ := ret[0].(map[int64]books.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByIDs indicates an expected call of GetBookByIDs.
+func (mr *MockbooksRepositoryMockRecorder) GetBookByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByIDs", reflect.TypeOf((*MockbooksRepository)(nil).GetBookByIDs), ctx, ids)
+}
+
+// MockidempotencyRepository is a mock of idempotencyRepository interface.
+type MockidempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockidempotencyRepositoryMockRecorder
+}
+
+// MockidempotencyRepositoryMockRecorder is the mock recorder for MockidempotencyRepository.
+type MockidempotencyRepositoryMockRecorder struct {
+	mock *MockidempotencyRepository
+}
+
+// NewMockidempotencyRepository creates a new mock instance.
+func NewMockidempotencyRepository(ctrl *gomock.Controller) *MockidempotencyRepository {
+	mock := &MockidempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockidempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockidempotencyRepository) EXPECT() *MockidempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Release mocks base method.
+func (m *MockidempotencyRepository) Release(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockidempotencyRepositoryMockRecorder) Release(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockidempotencyRepository)(nil).Release), ctx, key)
+}
+
+// Reserve mocks base method.
+func (m *MockidempotencyRepository) Reserve(ctx context.Context, key, fingerprint string) (*idempotency.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, key, fingerprint)
+	ret0, _ := ret[0].(*idempotency.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockidempotencyRepositoryMockRecorder) Reserve(ctx, key, fingerprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockidempotencyRepository)(nil).Reserve), ctx, key, fingerprint)
+}
+
+// Save mocks base method.
+func (m *MockidempotencyRepository) Save(ctx context.Context, key, fingerprint string, response interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, key, fingerprint, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockidempotencyRepositoryMockRecorder) Save(ctx, key, fingerprint, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockidempotencyRepository)(nil).Save), ctx, key, fingerprint, response)
+}
+
+// MockordersUsecase is a mock of ordersUsecase interface.
+type MockordersUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockordersUsecaseMockRecorder
+}
+
+// MockordersUsecaseMockRecorder is the mock recorder for MockordersUsecase.
+type MockordersUsecaseMockRecorder struct {
+	mock *MockordersUsecase
+}
+
+// NewMockordersUsecase creates a new mock instance.
+func NewMockordersUsecase(ctrl *gomock.Controller) *MockordersUsecase {
+	mock := &MockordersUsecase{ctrl: ctrl}
+	mock.recorder = &MockordersUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockordersUsecase) EXPECT() *MockordersUsecaseMockRecorder {
+	return m.recorder
+}
+
+// InsertOrder mocks base method.
+func (m *MockordersUsecase) InsertOrder(ctx context.Context, order orders.CreateOrderRequest) (*orders.CreateOrderResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertOrder", ctx, order)
+	ret0, _ := ret[0].(*orders.CreateOrderResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertOrder indicates an expected call of InsertOrder.
+func (mr *MockordersUsecaseMockRecorder) InsertOrder(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrder", reflect.TypeOf((*MockordersUsecase)(nil).InsertOrder), ctx, order)
+}
+
+// QuoteOrder mocks base method.
+func (m *MockordersUsecase) QuoteOrder(ctx context.Context, req orders.QuoteRequest) (*orders.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteOrder", ctx, req)
+	ret0, _ := ret[0].(*orders.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteOrder indicates an expected call of QuoteOrder.
+func (mr *MockordersUsecaseMockRecorder) QuoteOrder(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteOrder", reflect.TypeOf((*MockordersUsecase)(nil).QuoteOrder), ctx, req)
+}
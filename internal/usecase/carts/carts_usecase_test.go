@@ -0,0 +1,392 @@
+package carts
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/carts"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"reflect"
+	"testing"
+)
+
+var bookMap = map[int64]books.Model{
+	101: {ID: 101, Title: "1984", Author: "George Orwell", Price: money.MustParse("10.99"), Stock: 5},
+	103: {ID: 103, Title: "Animal Farm", Author: "George Orwell", Price: money.MustParse("8.99"), Stock: 1},
+}
+
+func Test_usecase_GetCart(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCartsRepo := NewMockcartsRepository(mockCtrl)
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+
+	tests := []struct {
+		name    string
+		want    *carts.Cart
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error get cart",
+			wantErr: true,
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name: "empty cart",
+			want: &carts.Cart{Items: []carts.Item{}},
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{}, nil)
+			},
+		},
+		{
+			name: "priced with the current price, removed books are skipped",
+			want: &carts.Cart{
+				Items: []carts.Item{
+					{BookID: 101, Title: "1984", Author: "George Orwell", Price: money.MustParse("10.99"), Quantity: 3, Subtotal: money.MustParse("32.97"), Available: true},
+					{BookID: 103, Title: "Animal Farm", Author: "George Orwell", Price: money.MustParse("8.99"), Quantity: 2, Subtotal: money.MustParse("17.98"), Available: false},
+				},
+				TotalAmount: money.MustParse("50.95"),
+			},
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{103: 2, 101: 3, 999: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103, 999}).Return(bookMap, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				cartsRepository: mockCartsRepo,
+				booksRepository: mockBooksRepo,
+			}
+			got, err := u.GetCart(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetCart() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetCart() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_usecase_AddItem(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCartsRepo := NewMockcartsRepository(mockCtrl)
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+
+	tests := []struct {
+		name       string
+		req        carts.AddItemRequest
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error book not found",
+			req:        carts.AddItemRequest{UserID: 1, BookID: 999, Quantity: 1},
+			wantErrMsg: "book with id: 999 is not found",
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{999}).Return(map[int64]books.Model{}, nil)
+			},
+		},
+		{
+			name:       "error not enough stock with what is already in the cart",
+			req:        carts.AddItemRequest{UserID: 1, BookID: 101, Quantity: 2},
+			wantErrMsg: "only 5 left in stock for book with id: 101",
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 4}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(bookMap, nil)
+			},
+		},
+		{
+			name: "success adds to the quantity in the cart",
+			req:  carts.AddItemRequest{UserID: 1, BookID: 101, Quantity: 2},
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(bookMap, nil)
+				mockCartsRepo.EXPECT().SetItem(gomock.Any(), int64(1), int64(101), 3).Return(nil)
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 3}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(bookMap, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				cartsRepository: mockCartsRepo,
+				booksRepository: mockBooksRepo,
+			}
+			_, err := u.AddItem(context.Background(), tt.req)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("AddItem() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("AddItem() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_usecase_UpdateItem(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCartsRepo := NewMockcartsRepository(mockCtrl)
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+
+	u := &usecase{
+		cartsRepository: mockCartsRepo,
+		booksRepository: mockBooksRepo,
+	}
+
+	// zero quantity removes the book
+	mockCartsRepo.EXPECT().DeleteItem(gomock.Any(), int64(1), int64(101)).Return(nil)
+	mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{}, nil)
+	got, err := u.UpdateItem(context.Background(), carts.UpdateItemRequest{UserID: 1, BookID: 101, Quantity: 0})
+	if err != nil || len(got.Items) != 0 {
+		t.Errorf("UpdateItem() got = %v, error = %v", got, err)
+	}
+
+	// the quantity is replaced, not added
+	mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(bookMap, nil).Times(2)
+	mockCartsRepo.EXPECT().SetItem(gomock.Any(), int64(1), int64(101), 2).Return(nil)
+	mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 2}, nil)
+	got, err = u.UpdateItem(context.Background(), carts.UpdateItemRequest{UserID: 1, BookID: 101, Quantity: 2})
+	if err != nil || got.TotalAmount != money.MustParse("21.98") {
+		t.Errorf("UpdateItem() got = %v, error = %v", got, err)
+	}
+}
+
+func Test_usecase_Checkout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCartsRepo := NewMockcartsRepository(mockCtrl)
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockOrdersUC := NewMockordersUsecase(mockCtrl)
+
+	order := orders.CreateOrderRequest{
+		UserID:      1,
+		TotalAmount: money.MustParse("30.97"),
+		Items: []orders.CreateOrderItem{
+			{BookID: 101, Quantity: 2, Price: money.MustParse("10.99")},
+			{BookID: 103, Quantity: 1, Price: money.MustParse("8.99")},
+		},
+	}
+
+	discounted := order
+	discounted.PromoCode = "SAVE10"
+	discounted.TotalAmount = money.MustParse("27.87")
+
+	taxed := order
+	taxed.TotalAmount = money.MustParse("32.52")
+
+	tests := []struct {
+		name       string
+		promoCode  string
+		want       *orders.CreateOrderResponse
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error empty cart",
+			wantErrMsg: "cart is empty",
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{}, nil)
+			},
+		},
+		{
+			name:       "error insert order keeps the cart",
+			wantErrMsg: "out of stock for book_ids: 103",
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 2, 103: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(bookMap, nil)
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), orders.QuoteRequest{UserID: 1, Items: order.Items}).
+					Return(&orders.Quote{Subtotal: money.MustParse("30.97"), GrandTotal: money.MustParse("30.97")}, nil)
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), order).Return(nil, errors.New("out of stock for book_ids: 103"))
+			},
+		},
+		{
+			name: "success empties the cart",
+			want: &orders.CreateOrderResponse{OrderID: 7, Status: "NEW"},
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 2, 103: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(bookMap, nil)
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), orders.QuoteRequest{UserID: 1, Items: order.Items}).
+					Return(&orders.Quote{Subtotal: money.MustParse("30.97"), GrandTotal: money.MustParse("30.97")}, nil)
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), order).Return(&orders.CreateOrderResponse{OrderID: 7, Status: "NEW"}, nil)
+				mockCartsRepo.EXPECT().DeleteCart(gomock.Any(), int64(1)).Return(nil)
+			},
+		},
+		{
+			name: "success places the order with the tax added on top",
+			want: &orders.CreateOrderResponse{OrderID: 9, Status: "NEW"},
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 2, 103: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(bookMap, nil)
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), orders.QuoteRequest{UserID: 1, Items: order.Items}).
+					Return(&orders.Quote{Subtotal: money.MustParse("30.97"), Tax: money.MustParse("1.55"), GrandTotal: money.MustParse("32.52")}, nil)
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), taxed).Return(&orders.CreateOrderResponse{OrderID: 9, Status: "NEW"}, nil)
+				mockCartsRepo.EXPECT().DeleteCart(gomock.Any(), int64(1)).Return(nil)
+			},
+		},
+		{
+			name:       "error promo code keeps the cart",
+			promoCode:  "SAVE10",
+			wantErrMsg: "promo code SAVE10 has expired",
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 2, 103: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(bookMap, nil)
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), orders.QuoteRequest{UserID: 1, PromoCode: "SAVE10", Items: order.Items}).
+					Return(nil, errors.New("promo code SAVE10 has expired"))
+			},
+		},
+		{
+			name:      "success with promo code places the order at the discounted total",
+			promoCode: "SAVE10",
+			want:      &orders.CreateOrderResponse{OrderID: 8, Status: "NEW"},
+			mockFn: func() {
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 2, 103: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(bookMap, nil)
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), orders.QuoteRequest{UserID: 1, PromoCode: "SAVE10", Items: order.Items}).
+					Return(&orders.Quote{Subtotal: money.MustParse("30.97"), Discount: money.MustParse("3.10"), GrandTotal: money.MustParse("27.87")}, nil)
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), discounted).Return(&orders.CreateOrderResponse{OrderID: 8, Status: "NEW"}, nil)
+				mockCartsRepo.EXPECT().DeleteCart(gomock.Any(), int64(1)).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				cartsRepository: mockCartsRepo,
+				booksRepository: mockBooksRepo,
+				ordersUsecase:   mockOrdersUC,
+			}
+			got, err := u.Checkout(context.Background(), carts.CheckoutRequest{UserID: 1, PromoCode: tt.promoCode})
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("Checkout() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Checkout() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_usecase_CheckoutWithIdempotencyKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockCartsRepo := NewMockcartsRepository(mockCtrl)
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockIdempotencyRepo := NewMockidempotencyRepository(mockCtrl)
+	mockOrdersUC := NewMockordersUsecase(mockCtrl)
+
+	req := carts.CheckoutRequest{UserID: 1, IdempotencyKey: "checkout-1"}
+	fingerprint, _ := idempotency.Fingerprint(req)
+	otherFingerprint, _ := idempotency.Fingerprint(carts.CheckoutRequest{PromoCode: "SAVE10"})
+	key := "idempotency:checkout:1:checkout-1"
+	order := orders.CreateOrderRequest{
+		UserID:      1,
+		TotalAmount: money.MustParse("30.97"),
+		Items: []orders.CreateOrderItem{
+			{BookID: 101, Quantity: 2, Price: money.MustParse("10.99")},
+			{BookID: 103, Quantity: 1, Price: money.MustParse("8.99")},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		want       *orders.CreateOrderResponse
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name: "first request places the order and saves the response",
+			want: &orders.CreateOrderResponse{OrderID: 7, Status: "NEW"},
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).Return(nil, nil)
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{101: 2, 103: 1}, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(bookMap, nil)
+				mockOrdersUC.EXPECT().QuoteOrder(gomock.Any(), orders.QuoteRequest{UserID: 1, Items: order.Items}).
+					Return(&orders.Quote{Subtotal: money.MustParse("30.97"), GrandTotal: money.MustParse("30.97")}, nil)
+				mockOrdersUC.EXPECT().InsertOrder(gomock.Any(), order).Return(&orders.CreateOrderResponse{OrderID: 7, Status: "NEW"}, nil)
+				mockCartsRepo.EXPECT().DeleteCart(gomock.Any(), int64(1)).Return(nil)
+				mockIdempotencyRepo.EXPECT().Save(gomock.Any(), key, fingerprint, &orders.CreateOrderResponse{OrderID: 7, Status: "NEW"}).Return(nil)
+			},
+		},
+		{
+			name:       "failed request releases the key",
+			wantErrMsg: "cart is empty",
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).Return(nil, nil)
+				mockCartsRepo.EXPECT().GetCart(gomock.Any(), int64(1)).Return(map[int64]int{}, nil)
+				mockIdempotencyRepo.EXPECT().Release(gomock.Any(), key).Return(nil)
+			},
+		},
+		{
+			name: "retry replays the saved response without loading the emptied cart",
+			want: &orders.CreateOrderResponse{OrderID: 7, Status: "NEW", Replayed: true},
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).
+					Return(&idempotency.Record{Fingerprint: fingerprint, Response: []byte(`{"order_id":7,"status":"NEW"}`)}, nil)
+			},
+		},
+		{
+			name:       "retry while the first request is in progress",
+			wantErrMsg: "a request with the same idempotency key is still in progress",
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).Return(&idempotency.Record{Fingerprint: fingerprint}, nil)
+			},
+		},
+		{
+			name:       "key reused with a different promo code",
+			wantErrMsg: "idempotency key was already used with a different request",
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).
+					Return(&idempotency.Record{Fingerprint: otherFingerprint, Response: []byte(`{"order_id":7,"status":"NEW"}`)}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				cartsRepository:       mockCartsRepo,
+				booksRepository:       mockBooksRepo,
+				idempotencyRepository: mockIdempotencyRepo,
+				ordersUsecase:         mockOrdersUC,
+			}
+			got, err := u.Checkout(context.Background(), req)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("Checkout() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Checkout() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
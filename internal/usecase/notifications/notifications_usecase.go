@@ -0,0 +1,351 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/backoff"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/eventsink"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/mailer"
+	"html"
+	"html/template"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SinkName is the name of the outbox sink that emails the customers about their orders
+const SinkName = "notifications"
+
+// Defaults of the mail queue for the settings left out of the config
+const (
+	defaultQueueSize    = 1000
+	defaultWorkers      = 2
+	defaultMaxAttempts  = 5
+	defaultRetryBackoff = 2 * time.Second
+	defaultSendTimeout  = 10 * time.Second
+	maxRetryBackoff     = time.Minute
+)
+
+// Templates of the emails, each one is rendered inside templates/layout.html
+const (
+	templateWelcome            = "welcome.html"
+	templateEmailVerification  = "email_verification.html"
+	templateOrderConfirmation  = "order_confirmation.html"
+	templateOrderStatusChanged = "order_status_changed.html"
+	templatePasswordReset      = "password_reset.html"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// statusMessages are the order statuses the customer is emailed about, the ones in between aren't worth an email
+var statusMessages = map[orders.OrderStatus]string{
+	orders.OrderStatusPaid:              "We received your payment and are preparing your books.",
+	orders.OrderStatusShipped:           "Your books are on their way.",
+	orders.OrderStatusDelivered:         "Your books were delivered, enjoy reading!",
+	orders.OrderStatusCancelled:         "The order was cancelled, anything you paid is refunded.",
+	orders.OrderStatusRefunded:          "The order was refunded.",
+	orders.OrderStatusPartiallyRefunded: "The books you returned were refunded.",
+}
+
+//go:generate mockgen -package=notifications -source=notifications_usecase.go -destination=notifications_usecase_mock_test.go
+type usersRepository interface {
+	GetUserByID(ctx context.Context, id int64) (*users.Model, error)
+}
+
+type ordersRepository interface {
+	GetOrderDetail(ctx context.Context, id int64) (*orders.Detail, error)
+}
+
+type usecase struct {
+	transport        mailer.Transport
+	usersRepository  usersRepository
+	ordersRepository ordersRepository
+	templates        map[string]*template.Template
+	cfg              configs.MailConfig
+	queue            chan mailer.Message
+	sleep            func(ctx context.Context, d time.Duration) bool
+}
+
+func New(transport mailer.Transport, usersRepository usersRepository, ordersRepository ordersRepository, cfg *configs.Config) *usecase {
+	mailConfig := cfg.Mail
+	if mailConfig.QueueSize <= 0 {
+		mailConfig.QueueSize = defaultQueueSize
+	}
+	if mailConfig.Workers <= 0 {
+		mailConfig.Workers = defaultWorkers
+	}
+	if mailConfig.MaxAttempts <= 0 {
+		mailConfig.MaxAttempts = defaultMaxAttempts
+	}
+	if mailConfig.RetryBackoff <= 0 {
+		mailConfig.RetryBackoff = defaultRetryBackoff
+	}
+	if mailConfig.Timeout <= 0 {
+		mailConfig.Timeout = defaultSendTimeout
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, name := range []string{templateWelcome, templateEmailVerification, templateOrderConfirmation, templateOrderStatusChanged, templatePasswordReset} {
+		templates[name] = template.Must(template.ParseFS(templateFS, "templates/layout.html", "templates/"+name))
+	}
+
+	return &usecase{
+		transport:        transport,
+		usersRepository:  usersRepository,
+		ordersRepository: ordersRepository,
+		templates:        templates,
+		cfg:              mailConfig,
+		queue:            make(chan mailer.Message, mailConfig.QueueSize),
+		sleep:            sleep,
+	}
+}
+
+// Run sends the queued emails with the workers until the context is done, emails still queued by then are lost
+func (u *usecase) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < u.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case message := <-u.queue:
+					u.sendWithRetry(ctx, message)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// SendWelcome queues the welcome email of a new user, with the link to verify the email unless verifyURL is empty
+func (u *usecase) SendWelcome(ctx context.Context, user users.Model, verifyURL string, expiresIn time.Duration) error {
+	message, err := u.render(templateWelcome, user.Email, struct {
+		Email     string
+		VerifyURL string
+		ExpiresIn string
+	}{Email: user.Email, VerifyURL: verifyURL, ExpiresIn: humanizeDuration(expiresIn)})
+	if err != nil {
+		return err
+	}
+	return u.enqueue(message)
+}
+
+// SendEmailVerification queues the email with the link to verify the email, expiresIn is how long the link works
+func (u *usecase) SendEmailVerification(ctx context.Context, email, verifyURL string, expiresIn time.Duration) error {
+	message, err := u.render(templateEmailVerification, email, struct {
+		Email     string
+		VerifyURL string
+		ExpiresIn string
+	}{Email: email, VerifyURL: verifyURL, ExpiresIn: humanizeDuration(expiresIn)})
+	if err != nil {
+		return err
+	}
+	return u.enqueue(message)
+}
+
+// SendPasswordReset queues the email with the link to reset the password, expiresIn is how long the link works
+func (u *usecase) SendPasswordReset(ctx context.Context, email, resetURL string, expiresIn time.Duration) error {
+	message, err := u.render(templatePasswordReset, email, struct {
+		Email     string
+		ResetURL  string
+		ExpiresIn string
+	}{Email: email, ResetURL: resetURL, ExpiresIn: humanizeDuration(expiresIn)})
+	if err != nil {
+		return err
+	}
+	return u.enqueue(message)
+}
+
+// enqueue never blocks the caller, an email that doesn't fit in the queue is dropped
+func (u *usecase) enqueue(message mailer.Message) error {
+	select {
+	case u.queue <- message:
+		return nil
+	default:
+		return fmt.Errorf("mail queue is full, email %q to %s is dropped", message.Subject, strings.Join(message.To, ", "))
+	}
+}
+
+func (u *usecase) sendWithRetry(ctx context.Context, message mailer.Message) {
+	for attempt := 1; ; attempt++ {
+		err := u.transport.Send(ctx, message)
+		if err == nil {
+			return
+		}
+		if attempt >= u.cfg.MaxAttempts {
+			log.Printf("[Notifications] email %q to %s failed after %d attempts: %v", message.Subject,
+				strings.Join(message.To, ", "), attempt, err)
+			return
+		}
+		if !u.sleep(ctx, backoff.Exponential(attempt, u.cfg.RetryBackoff, maxRetryBackoff)) {
+			return
+		}
+	}
+}
+
+// Sink is the outbox sink that emails the customers about their orders
+func (u *usecase) Sink() eventsink.Sink {
+	return &ordersSink{usecase: u}
+}
+
+type ordersSink struct {
+	usecase *usecase
+}
+
+func (s *ordersSink) Name() string {
+	return SinkName
+}
+
+// Deliver sends the email of the event right away, so a failed send is retried by the outbox with its backoff and
+// dead-lettering. The send is cut off at the mail timeout, a hanging mail server holds up the other sinks no longer
+func (s *ordersSink) Deliver(ctx context.Context, event eventsink.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, s.usecase.cfg.Timeout)
+	defer cancel()
+
+	switch event.Type {
+	case orders.EventOrderCreated:
+		var created orders.CreatedEvent
+		if err := json.Unmarshal(event.Payload, &created); err != nil {
+			return err
+		}
+		return s.usecase.sendOrderConfirmation(ctx, created.OrderID)
+	case orders.EventOrderStatusChanged:
+		var changed orders.StatusChangedEvent
+		if err := json.Unmarshal(event.Payload, &changed); err != nil {
+			return err
+		}
+		return s.usecase.sendOrderStatusChanged(ctx, changed)
+	}
+	return nil
+}
+
+func (u *usecase) sendOrderConfirmation(ctx context.Context, orderID int64) error {
+	order, user, err := u.getOrderAndUser(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	message, err := u.render(templateOrderConfirmation, user.Email, struct {
+		Email string
+		Order *orders.Detail
+	}{Email: user.Email, Order: order})
+	if err != nil {
+		return err
+	}
+	return u.transport.Send(ctx, message)
+}
+
+func (u *usecase) sendOrderStatusChanged(ctx context.Context, changed orders.StatusChangedEvent) error {
+	statusMessage, ok := statusMessages[orders.OrderStatus(changed.ToStatus)]
+	if !ok {
+		return nil
+	}
+	_, user, err := u.getOrderAndUser(ctx, changed.OrderID)
+	if err != nil {
+		return err
+	}
+
+	message, err := u.render(templateOrderStatusChanged, user.Email, struct {
+		Email   string
+		OrderID int64
+		Status  string
+		Note    string
+		Message string
+	}{
+		Email:   user.Email,
+		OrderID: changed.OrderID,
+		Status:  humanizeStatus(changed.ToStatus),
+		Note:    changed.Note,
+		Message: statusMessage,
+	})
+	if err != nil {
+		return err
+	}
+	return u.transport.Send(ctx, message)
+}
+
+// getOrderAndUser fails when the order or its user isn't found, orders and users are never deleted so the replica
+// hasn't caught up with the outbox yet and the delivery is retried
+func (u *usecase) getOrderAndUser(ctx context.Context, orderID int64) (*orders.Detail, *users.Model, error) {
+	order, err := u.ordersRepository.GetOrderDetail(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order == nil {
+		return nil, nil, fmt.Errorf("order with id: %d is not found", orderID)
+	}
+	user, err := u.usersRepository.GetUserByID(ctx, order.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, fmt.Errorf("user with id: %d is not found", order.UserID)
+	}
+	return order, user, nil
+}
+
+// render fills the template in the layout, the subject is the title of the template without the HTML escaping
+func (u *usecase) render(name, to string, data interface{}) (mailer.Message, error) {
+	tmpl, ok := u.templates[name]
+	if !ok {
+		return mailer.Message{}, errors.New("unknown email template " + name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "title", data); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "layout", data); err != nil {
+		return mailer.Message{}, err
+	}
+	return mailer.Message{
+		From:    u.cfg.From,
+		To:      []string{to},
+		Subject: html.UnescapeString(strings.TrimSpace(subject.String())),
+		HTML:    body.String(),
+	}, nil
+}
+
+// humanizeStatus turns PARTIALLY_REFUNDED into partially refunded
+func humanizeStatus(status string) string {
+	return strings.ToLower(strings.ReplaceAll(status, "_", " "))
+}
+
+// humanizeDuration writes whole hours or minutes, e.g. 1 hour or 30 minutes
+func humanizeDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	}
+	minutes := int64(d / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// sleep waits for d unless the context is done first, it tells whether the whole wait passed
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,90 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notifications_usecase.go
+
+// Package notifications is a generated GoMock package.
+package notifications
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	orders "github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	users "github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+)
+
+// MockusersRepository is a mock of usersRepository interface.
+type MockusersRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockusersRepositoryMockRecorder
+}
+
+// MockusersRepositoryMockRecorder is the mock recorder for MockusersRepository.
+type MockusersRepositoryMockRecorder struct {
+	mock *MockusersRepository
+}
+
+// NewMockusersRepository creates a new mock instance.
+func NewMockusersRepository(ctrl *gomock.Controller) *MockusersRepository {
+	mock := &MockusersRepository{ctrl: ctrl}
+	mock.recorder = &MockusersRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockusersRepository) EXPECT() *MockusersRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetUserByID mocks base method.
+func (m *MockusersRepository) GetUserByID(ctx context.Context, id int64) (*users.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(*users.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockusersRepositoryMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockusersRepository)(nil).GetUserByID), ctx, id)
+}
+
+// MockordersRepository is a mock of ordersRepository interface.
+type MockordersRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockordersRepositoryMockRecorder
+}
+
+// MockordersRepositoryMockRecorder is the mock recorder for MockordersRepository.
+type MockordersRepositoryMockRecorder struct {
+	mock *MockordersRepository
+}
+
+// NewMockordersRepository creates a new mock instance.
+func NewMockordersRepository(ctrl *gomock.Controller) *MockordersRepository {
+	mock := &MockordersRepository{ctrl: ctrl}
+	mock.recorder = &MockordersRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockordersRepository) EXPECT() *MockordersRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetOrderDetail mocks base method.
+func (m *MockordersRepository) GetOrderDetail(ctx context.Context, id int64) (*orders.Detail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderDetail", ctx, id)
+	ret0, _ := ret[0].(*orders.Detail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderDetail indicates an expected call of GetOrderDetail.
+func (mr *MockordersRepositoryMockRecorder) GetOrderDetail(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderDetail", reflect.TypeOf((*MockordersRepository)(nil).GetOrderDetail), ctx, id)
+}
@@ -0,0 +1,279 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/eventsink"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/mailer"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTransport records the messages it sent, it fails with the errors in order before succeeding.
+// A hanging transport waits until the context is done like a mail server that never answers
+type fakeTransport struct {
+	mu       sync.Mutex
+	hang     bool
+	errs     []error
+	attempts int
+	sent     []mailer.Message
+}
+
+func (f *fakeTransport) Send(ctx context.Context, message mailer.Message) error {
+	if f.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	f.sent = append(f.sent, message)
+	return nil
+}
+
+func (f *fakeTransport) messages() []mailer.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]mailer.Message(nil), f.sent...)
+}
+
+var testConfig = &configs.Config{Mail: configs.MailConfig{From: "Gotu Books <no-reply@gotu.local>", QueueSize: 1, Workers: 1,
+	MaxAttempts: 3, RetryBackoff: time.Second, Timeout: 50 * time.Millisecond}}
+
+func Test_usecase_SendWelcome(t *testing.T) {
+	u := New(&fakeTransport{}, nil, nil, testConfig)
+
+	err := u.SendWelcome(context.Background(), users.Model{ID: 1, Email: "reader@example.com"},
+		"http://localhost:9999/email/verify?token=abc", 24*time.Hour)
+	assert.NoError(t, err)
+	message := <-u.queue
+	assert.Equal(t, "Gotu Books <no-reply@gotu.local>", message.From)
+	assert.Equal(t, []string{"reader@example.com"}, message.To)
+	assert.Equal(t, "Welcome to Gotu Books", message.Subject)
+	assert.Contains(t, message.HTML, "<p>Hi reader@example.com,</p>")
+	assert.Contains(t, message.HTML, `href="http://localhost:9999/email/verify?token=abc"`)
+	assert.Contains(t, message.HTML, "expires in 24 hours")
+
+	// without a link there is nothing to verify in the email
+	assert.NoError(t, u.SendWelcome(context.Background(), users.Model{ID: 1, Email: "reader@example.com"}, "", 0))
+	message = <-u.queue
+	assert.NotContains(t, message.HTML, "Verify email")
+
+	// the queue holds a single email, the next one is dropped rather than blocking the caller
+	assert.NoError(t, u.SendWelcome(context.Background(), users.Model{ID: 1, Email: "reader@example.com"}, "", 0))
+	assert.EqualError(t, u.SendWelcome(context.Background(), users.Model{ID: 2, Email: "writer@example.com"}, "", 0),
+		`mail queue is full, email "Welcome to Gotu Books" to writer@example.com is dropped`)
+}
+
+func Test_usecase_SendEmailVerification(t *testing.T) {
+	u := New(&fakeTransport{}, nil, nil, testConfig)
+
+	err := u.SendEmailVerification(context.Background(), "reader@example.com", "http://localhost:9999/email/verify?token=abc", 30*time.Minute)
+	assert.NoError(t, err)
+	message := <-u.queue
+	assert.Equal(t, "Verify your email address", message.Subject)
+	assert.Contains(t, message.HTML, `href="http://localhost:9999/email/verify?token=abc"`)
+	assert.Contains(t, message.HTML, "expires in 30 minutes")
+}
+
+func Test_usecase_SendPasswordReset(t *testing.T) {
+	u := New(&fakeTransport{}, nil, nil, testConfig)
+
+	err := u.SendPasswordReset(context.Background(), "reader@example.com", "http://localhost:9999/password/reset?token=abc&x=<y>", time.Hour)
+	assert.NoError(t, err)
+	message := <-u.queue
+	assert.Equal(t, "Reset your password", message.Subject)
+	assert.Contains(t, message.HTML, `href="http://localhost:9999/password/reset?token=abc&amp;x=%3cy%3e"`)
+	assert.Contains(t, message.HTML, "expires in 1 hour")
+}
+
+func Test_usecase_sendWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantSent     int
+		wantWaits    []time.Duration
+	}{
+		{name: "success on first attempt", wantAttempts: 1, wantSent: 1},
+		{name: "success after retries", errs: []error{errors.New("421 try again"), errors.New("421 try again")}, wantAttempts: 3, wantSent: 1,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "gives up after max attempts", errs: []error{errors.New("550 no"), errors.New("550 no"), errors.New("550 no")}, wantAttempts: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &fakeTransport{errs: tt.errs}
+			u := New(transport, nil, nil, testConfig)
+			var waits []time.Duration
+			u.sleep = func(ctx context.Context, d time.Duration) bool {
+				waits = append(waits, d)
+				return true
+			}
+
+			u.sendWithRetry(context.Background(), mailer.Message{To: []string{"reader@example.com"}, Subject: "Welcome to Gotu Books"})
+			assert.Equal(t, tt.wantAttempts, transport.attempts)
+			assert.Len(t, transport.messages(), tt.wantSent)
+			assert.Equal(t, tt.wantWaits, waits)
+		})
+	}
+}
+
+func Test_usecase_Run(t *testing.T) {
+	transport := &fakeTransport{}
+	u := New(transport, nil, nil, testConfig)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		u.Run(ctx)
+		close(done)
+	}()
+
+	assert.NoError(t, u.SendWelcome(context.Background(), users.Model{ID: 1, Email: "reader@example.com"}, "", 0))
+	assert.Eventually(t, func() bool { return len(transport.messages()) == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func Test_ordersSink_Deliver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+
+	order := &orders.Detail{ID: 3, UserID: 1, Subtotal: money.MustParse("30.00"), Discount: money.MustParse("3.00"), PromoCode: "ORWELL",
+		Tax: money.MustParse("2.68"), TaxInclusive: true, TotalAmount: money.MustParse("27.00"), Status: "NEW",
+		Items: []orders.ItemDetail{{BookID: 4, Title: "Animal Farm", Author: "George Orwell", Quantity: 2, Price: money.MustParse("15.00")}}}
+	user := &users.Model{ID: 1, Email: "reader@example.com"}
+
+	payload := func(v interface{}) json.RawMessage {
+		encoded, _ := json.Marshal(v)
+		return encoded
+	}
+
+	tests := []struct {
+		name        string
+		event       eventsink.Event
+		sendErr     error
+		hang        bool
+		wantErr     bool
+		wantSubject string
+		wantHTML    []string
+		mockFn      func()
+	}{
+		{
+			name:        "order created sends the confirmation",
+			event:       eventsink.Event{ID: 7, Type: "OrderCreated", Payload: payload(orders.CreatedEvent{OrderID: 3, UserID: 1})},
+			wantSubject: "Order #3 is confirmed",
+			wantHTML:    []string{"Animal Farm", "George Orwell", "Discount (ORWELL)", "-3.00", "Tax (included)", "27.00"},
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(3)).Return(order, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(user, nil)
+			},
+		},
+		{
+			name:    "error on get order is retried by the outbox",
+			event:   eventsink.Event{ID: 7, Type: "OrderCreated", Payload: payload(orders.CreatedEvent{OrderID: 3, UserID: 1})},
+			wantErr: true,
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(3)).Return(nil, errors.New("connection reset"))
+			},
+		},
+		{
+			name:    "error order not found yet on the replica is retried by the outbox",
+			event:   eventsink.Event{ID: 7, Type: "OrderCreated", Payload: payload(orders.CreatedEvent{OrderID: 3, UserID: 1})},
+			wantErr: true,
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(3)).Return(nil, nil)
+			},
+		},
+		{
+			name: "status change sends the new status",
+			event: eventsink.Event{ID: 8, Type: "OrderStatusChanged", Payload: payload(orders.StatusChangedEvent{OrderID: 3, FromStatus: "DELIVERED",
+				ToStatus: "PARTIALLY_REFUNDED", Note: "return #2 refunded"})},
+			wantSubject: "Order #3 is partially refunded",
+			wantHTML:    []string{"<strong>partially refunded</strong>", "return #2 refunded", "The books you returned were refunded."},
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(3)).Return(order, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(user, nil)
+			},
+		},
+		{
+			name: "error on send is retried by the outbox",
+			event: eventsink.Event{ID: 8, Type: "OrderStatusChanged", Payload: payload(orders.StatusChangedEvent{OrderID: 3, FromStatus: "PAID",
+				ToStatus: "SHIPPED"})},
+			sendErr: errors.New("dial tcp: connection refused"),
+			wantErr: true,
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(3)).Return(order, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(user, nil)
+			},
+		},
+		{
+			name: "error on a hanging mail server is cut off at the timeout",
+			event: eventsink.Event{ID: 8, Type: "OrderStatusChanged", Payload: payload(orders.StatusChangedEvent{OrderID: 3, FromStatus: "PAID",
+				ToStatus: "SHIPPED"})},
+			hang:    true,
+			wantErr: true,
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(3)).Return(order, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(user, nil)
+			},
+		},
+		{
+			name: "status in between isn't emailed",
+			event: eventsink.Event{ID: 8, Type: "OrderStatusChanged", Payload: payload(orders.StatusChangedEvent{OrderID: 3, FromStatus: "NEW",
+				ToStatus: "AWAITING_PAYMENT"})},
+			mockFn: func() {},
+		},
+		{
+			name:   "other events are skipped",
+			event:  eventsink.Event{ID: 9, Type: "BookCreated", Payload: json.RawMessage(`{}`)},
+			mockFn: func() {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			transport := &fakeTransport{hang: tt.hang}
+			if tt.sendErr != nil {
+				transport.errs = []error{tt.sendErr}
+			}
+			sink := New(transport, mockUsersRepo, mockOrdersRepo, testConfig).Sink()
+			assert.Equal(t, "notifications", sink.Name())
+
+			err := sink.Deliver(context.Background(), tt.event)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantSubject == "" {
+				assert.Empty(t, transport.messages())
+				return
+			}
+			sent := transport.messages()
+			if assert.Len(t, sent, 1) {
+				assert.Equal(t, []string{"reader@example.com"}, sent[0].To)
+				assert.Equal(t, tt.wantSubject, sent[0].Subject)
+				for _, want := range tt.wantHTML {
+					assert.Contains(t, sent[0].HTML, want)
+				}
+			}
+		})
+	}
+}
@@ -5,32 +5,157 @@ import (
 	"errors"
 	"fmt"
 	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/constant"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/signer"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/tax"
 	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"log"
+	"time"
 )
 
+const (
+	// ordersCursorPurpose is signed along with the cursor so a token issued for something else can't be passed as a cursor
+	ordersCursorPurpose = "orders_cursor"
+	// orderQuotePurpose is signed along with the quote so no other token can be passed as a quote id
+	orderQuotePurpose = "order_quote"
+)
+
+// quoteTTL is how long the prices of a quote are honored
+const quoteTTL = 10 * time.Minute
+
+// quoteToken is the payload of the quote id, it carries everything needed to create the order at the quoted prices
+type quoteToken struct {
+	UserID    int64                    `json:"u"`
+	Items     []orders.CreateOrderItem `json:"i"`
+	PromoCode string                   `json:"p,omitempty"`
+	Total     money.Amount             `json:"t"`
+	ExpiresAt int64                    `json:"e"`
+}
+
 //go:generate mockgen -package=orders -source=orders_usecase.go -destination=orders_usecase_mock_test.go
 type ordersRepository interface {
 	InsertOrder(ctx context.Context, order orders.CreateOrderRequest) (*orders.CreateOrderResponse, error)
-	GetOrdersByUserID(ctx context.Context, userID int64, limit, offset int) ([]orders.History, error)
+	GetOrdersByUserID(ctx context.Context, userID int64, after *orders.Cursor, limit, offset int) (orders.HistoryPage, error)
+	GetOrderByID(ctx context.Context, id int64) (*orders.Model, error)
+	UpdateOrderStatus(ctx context.Context, transition orders.StatusTransition) error
+	CancelOrder(ctx context.Context, transition orders.StatusTransition) ([]int64, error)
+	GetOrderDetail(ctx context.Context, id int64) (*orders.Detail, error)
+}
+
+type idempotencyRepository interface {
+	Reserve(ctx context.Context, key, fingerprint string) (*idempotency.Record, error)
+	Save(ctx context.Context, key, fingerprint string, response interface{}) error
+	Release(ctx context.Context, key string) error
 }
 
 type booksRepository interface {
 	GetBookByIDs(ctx context.Context, ids []int64) (map[int64]books.Model, error)
+	InvalidateBookCache(ids ...int64)
+}
+
+type usersRepository interface {
+	GetUserByID(ctx context.Context, id int64) (*users.Model, error)
+}
+
+type paymentsUsecase interface {
+	ReleasePayment(ctx context.Context, orderID int64) error
+}
+
+type promotionsUsecase interface {
+	ApplyPromotion(ctx context.Context, userID int64, code string, lines []promotions.Line) (*promotions.Applied, error)
 }
 
 type usecase struct {
-	ordersRepository ordersRepository
-	booksRepository  booksRepository
-	cfg              *configs.Config
+	ordersRepository      ordersRepository
+	booksRepository       booksRepository
+	idempotencyRepository idempotencyRepository
+	usersRepository       usersRepository
+	paymentsUsecase       paymentsUsecase
+	promotionsUsecase     promotionsUsecase
+	taxCalculator         *tax.Calculator
+	cfg                   *configs.Config
 }
 
-func New(ordersRepository ordersRepository, booksRepository booksRepository, cfg *configs.Config) *usecase {
-	return &usecase{ordersRepository: ordersRepository, booksRepository: booksRepository, cfg: cfg}
+func New(ordersRepository ordersRepository, booksRepository booksRepository, idempotencyRepository idempotencyRepository,
+	usersRepository usersRepository, paymentsUsecase paymentsUsecase, promotionsUsecase promotionsUsecase,
+	taxCalculator *tax.Calculator, cfg *configs.Config) *usecase {
+	return &usecase{
+		ordersRepository:      ordersRepository,
+		booksRepository:       booksRepository,
+		idempotencyRepository: idempotencyRepository,
+		usersRepository:       usersRepository,
+		paymentsUsecase:       paymentsUsecase,
+		promotionsUsecase:     promotionsUsecase,
+		taxCalculator:         taxCalculator,
+		cfg:                   cfg,
+	}
 }
 
+// InsertOrder creates the order, when the request carries an idempotency key the order is only created by the first
+// request with that key and retries get its response back
 func (u *usecase) InsertOrder(ctx context.Context, order orders.CreateOrderRequest) (*orders.CreateOrderResponse, error) {
+	if order.IdempotencyKey == "" {
+		return u.insertOrder(ctx, order)
+	}
+
+	key := fmt.Sprintf(constant.RedisKeyOrderIdempotency, order.UserID, order.IdempotencyKey)
+	fingerprint, err := idempotency.Fingerprint(order)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := u.idempotencyRepository.Reserve(ctx, key, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		return replayOrder(*record, fingerprint)
+	}
+
+	createdOrder, err := u.insertOrder(ctx, order)
+	if err != nil {
+		// nothing was created, so the client may retry with the same key
+		releaseErr := u.idempotencyRepository.Release(ctx, key)
+		if releaseErr != nil {
+			log.Printf("[InsertOrder] error when releasing idempotency key %s: %v", key, releaseErr)
+		}
+		return nil, err
+	}
+
+	// the order exists already, failing to save the response only makes retries wait until the key expires
+	err = u.idempotencyRepository.Save(ctx, key, fingerprint, createdOrder)
+	if err != nil {
+		log.Printf("[InsertOrder] error when saving idempotency key %s: %v", key, err)
+	}
+	return createdOrder, nil
+}
+
+func (u *usecase) insertOrder(ctx context.Context, order orders.CreateOrderRequest) (*orders.CreateOrderResponse, error) {
+	// the prices of a quote are honored until it expires, even when the price of a book changed in between
+	quoted := order.QuoteID != ""
+	if quoted {
+		if len(order.Items) > 0 {
+			return nil, errors.New("send either quote_id or items with total_amount, not both")
+		}
+		if order.PromoCode != "" {
+			return nil, errors.New("send the promo_code when requesting the quote, not along with quote_id")
+		}
+		token, err := u.verifyQuote(order.UserID, order.QuoteID)
+		if err != nil {
+			return nil, err
+		}
+		order.Items = token.Items
+		order.TotalAmount = token.Total
+		order.PromoCode = token.PromoCode
+	}
+
 	bookIDs := make([]int64, 0)
 	for _, item := range order.Items {
 		bookIDs = append(bookIDs, item.BookID)
@@ -42,24 +167,359 @@ func (u *usecase) InsertOrder(ctx context.Context, order orders.CreateOrderReque
 	}
 
 	// validate book price and total price
-	totalPrice := float64(0)
+	totalPrice := money.Amount(0)
 	for _, item := range order.Items {
-		totalPrice += item.Price * float64(item.Quantity)
+		totalPrice = totalPrice.Add(item.Price.Mul(item.Quantity))
 		if _, ok := bookMap[item.BookID]; !ok {
 			return nil, fmt.Errorf("book with id: %d is not found", item.BookID)
 		}
-		if item.Price != bookMap[item.BookID].Price {
+		if !quoted && item.Price != bookMap[item.BookID].Price {
 			return nil, fmt.Errorf("book with id: %d has different price", item.BookID)
 		}
 	}
-	if totalPrice != order.TotalAmount {
+
+	user, err := u.getUser(ctx, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsEmailVerified() {
+		return nil, errors.New("email is not verified, please verify your email before placing an order")
+	}
+
+	// the items are copied so the discounts and taxes don't end up in the items of the caller
+	items := make([]orders.CreateOrderItem, len(order.Items))
+	copy(items, order.Items)
+	order.Items = items
+	order.Subtotal = totalPrice
+
+	if order.PromoCode != "" {
+		applied, err := u.promotionsUsecase.ApplyPromotion(ctx, order.UserID, order.PromoCode, promotionLines(order.Items, bookMap))
+		if err != nil {
+			return nil, err
+		}
+		order.PromotionID = applied.PromotionID
+		order.PromoCode = applied.Code
+		order.Discount = applied.Discount
+		for i := range order.Items {
+			order.Items[i].Discount = applied.LineDiscounts[i]
+		}
+	}
+
+	taxed, err := u.taxItems(user, order.Items)
+	if err != nil {
+		return nil, err
+	}
+	order.BillingCountry = taxed.Country
+	order.Tax = taxed.Tax
+	order.TaxInclusive = taxed.Inclusive
+	for i, line := range taxed.Lines {
+		order.Items[i].TaxRate = line.Rate
+		order.Items[i].Tax = line.Tax
+	}
+	if taxed.Gross != order.TotalAmount {
 		return nil, errors.New("total amount is different, please refresh your cart")
 	}
 
-	return u.ordersRepository.InsertOrder(ctx, order)
+	createdOrder, err := u.ordersRepository.InsertOrder(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stock of the ordered books changed, so their cached availability is stale
+	u.booksRepository.InvalidateBookCache(bookIDs...)
+	return createdOrder, nil
 }
 
-func (u *usecase) GetOrdersByUserID(ctx context.Context, userID int64, pageIndex, pageSize int) ([]orders.History, error) {
+// QuoteOrder prices the items with the current price of the books and signs the result into a quote id
+func (u *usecase) QuoteOrder(ctx context.Context, req orders.QuoteRequest) (*orders.Quote, error) {
+	bookIDs := make([]int64, 0, len(req.Items))
+	for _, item := range req.Items {
+		bookIDs = append(bookIDs, item.BookID)
+	}
+
+	bookMap, err := u.booksRepository.GetBookByIDs(ctx, bookIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &orders.Quote{Items: make([]orders.QuoteItem, 0, len(req.Items))}
+	token := quoteToken{UserID: req.UserID, Items: make([]orders.CreateOrderItem, 0, len(req.Items))}
+	for _, item := range req.Items {
+		book, ok := bookMap[item.BookID]
+		if !ok {
+			return nil, fmt.Errorf("book with id: %d is not found", item.BookID)
+		}
+		line := orders.QuoteItem{
+			BookID:        book.ID,
+			Title:         book.Title,
+			Quantity:      item.Quantity,
+			Price:         book.Price,
+			ExpectedPrice: item.Price,
+			PriceChanged:  item.Price != book.Price,
+			LineTotal:     book.Price.Mul(item.Quantity),
+			Available:     book.Stock >= item.Quantity,
+		}
+		quote.Items = append(quote.Items, line)
+		quote.Subtotal = quote.Subtotal.Add(line.LineTotal)
+		token.Items = append(token.Items, orders.CreateOrderItem{BookID: book.ID, Quantity: item.Quantity, Price: book.Price})
+	}
+
+	if req.PromoCode != "" {
+		applied, err := u.promotionsUsecase.ApplyPromotion(ctx, req.UserID, req.PromoCode, promotionLines(token.Items, bookMap))
+		if err != nil {
+			return nil, err
+		}
+		quote.PromoCode = applied.Code
+		quote.Discount = applied.Discount
+		for i := range quote.Items {
+			quote.Items[i].Discount = applied.LineDiscounts[i]
+			token.Items[i].Discount = applied.LineDiscounts[i]
+		}
+		token.PromoCode = applied.Code
+	}
+
+	user, err := u.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	taxed, err := u.taxItems(user, token.Items)
+	if err != nil {
+		return nil, err
+	}
+	quote.BillingCountry = taxed.Country
+	quote.TaxInclusive = taxed.Inclusive
+	quote.Tax = taxed.Tax
+	for i, line := range taxed.Lines {
+		quote.Items[i].TaxRate = line.Rate
+		quote.Items[i].Tax = line.Tax
+	}
+	quote.GrandTotal = taxed.Gross
+
+	quote.ExpiresAt = time.Now().Add(quoteTTL).UnixMilli()
+	token.Total = quote.GrandTotal
+	token.ExpiresAt = quote.ExpiresAt
+	quote.QuoteID, err = signer.Sign(orderQuotePurpose, token, u.cfg.Service.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// promotionLines are the items of the order along with the author of their book, which promotions can be limited to
+func promotionLines(items []orders.CreateOrderItem, bookMap map[int64]books.Model) []promotions.Line {
+	lines := make([]promotions.Line, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, promotions.Line{
+			BookID:   item.BookID,
+			Author:   bookMap[item.BookID].Author,
+			Price:    item.Price,
+			Quantity: item.Quantity,
+		})
+	}
+	return lines
+}
+
+func (u *usecase) getUser(ctx context.Context, userID int64) (*users.Model, error) {
+	user, err := u.usersRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user with id: %d is not found", userID)
+	}
+	return user, nil
+}
+
+// taxItems taxes what is left to pay of every item with the rate of the billing country of the user
+func (u *usecase) taxItems(user *users.Model, items []orders.CreateOrderItem) (tax.Result, error) {
+	lines := make([]tax.Line, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, tax.Line{BookID: item.BookID, Amount: item.Price.Mul(item.Quantity).Sub(item.Discount)})
+	}
+	return u.taxCalculator.Calculate(user.BillingCountry, lines)
+}
+
+// verifyQuote reads the quote id back, a quote is only valid for the user it was issued to and until it expires
+func (u *usecase) verifyQuote(userID int64, quoteID string) (quoteToken, error) {
+	var token quoteToken
+	err := signer.Verify(orderQuotePurpose, quoteID, u.cfg.Service.SecretKey, &token)
+	if err != nil || token.UserID != userID || len(token.Items) == 0 {
+		return quoteToken{}, errors.New("invalid quote")
+	}
+	if time.Now().UnixMilli() > token.ExpiresAt {
+		return quoteToken{}, errors.New("quote has expired, please request a new one")
+	}
+	return token, nil
+}
+
+func (u *usecase) GetOrdersByUserID(ctx context.Context, userID int64, cursor string, pageIndex, pageSize int) ([]orders.History, response.Pagination, error) {
 	limit, offset := util.GetLimitAndOffset(pageIndex, pageSize)
-	return u.ordersRepository.GetOrdersByUserID(ctx, userID, limit, offset)
+
+	// a cursor takes over the page index, so orders placed while paging don't shift the next page
+	var after *orders.Cursor
+	if cursor != "" {
+		var token orders.Cursor
+		err := signer.Verify(ordersCursorPurpose, cursor, u.cfg.Service.SecretKey, &token)
+		if err != nil {
+			return nil, response.Pagination{}, errors.New("invalid cursor")
+		}
+		after = &token
+	}
+
+	page, err := u.ordersRepository.GetOrdersByUserID(ctx, userID, after, limit, offset)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	pagination := response.NewPagination(limit, offset, page.TotalItems)
+	if after != nil {
+		pagination.PageIndex = 0 // the page index is unknown when paging by cursor
+	}
+	pagination.HasNext = page.HasNext
+	if page.HasNext {
+		last := page.Histories[len(page.Histories)-1]
+		pagination.NextCursor, err = signer.Sign(ordersCursorPurpose, orders.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}, u.cfg.Service.SecretKey)
+		if err != nil {
+			return nil, response.Pagination{}, err
+		}
+	}
+	return page.Histories, pagination, nil
+}
+
+// GetOrderDetail returns the order to its owner, staff can see the order of any user
+func (u *usecase) GetOrderDetail(ctx context.Context, orderID, userID int64, role string) (*orders.Detail, error) {
+	order, err := u.ordersRepository.GetOrderDetail(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("order with id: %d is not found", orderID)
+	}
+	// the order of another user looks like it doesn't exist, so the ids of other orders can't be probed
+	if order.UserID != userID && !users.Role(role).IsStaff() {
+		return nil, fmt.Errorf("order with id: %d is not found", orderID)
+	}
+	return order, nil
+}
+
+// UpdateOrderStatus moves the order along its fulfilment or cancels it, PAID and the refunds are left to the payment and
+// the refund flows
+func (u *usecase) UpdateOrderStatus(ctx context.Context, req orders.UpdateOrderStatusRequest) (*orders.UpdateOrderStatusResponse, error) {
+	next := orders.OrderStatus(req.Status)
+	if !next.IsValid() {
+		return nil, fmt.Errorf("invalid status: %s", req.Status)
+	}
+
+	order, err := u.ordersRepository.GetOrderByID(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("order with id: %d is not found", req.OrderID)
+	}
+	if order.UpdatedAt != req.UpdatedAt {
+		return nil, errors.New("order was updated by another request, please reload it")
+	}
+
+	current := orders.OrderStatus(order.Status)
+	if !current.CanTransitionTo(next) {
+		return nil, fmt.Errorf("invalid status transition from %s to %s", current, next)
+	}
+	if !current.CanSetManually(next) {
+		return nil, fmt.Errorf("invalid status transition from %s to %s, it is made by the payment or the refund flow", current, next)
+	}
+	// a cancellation has to put the stock back, release the promotion and the payment like the customer cancelling
+	if next == orders.OrderStatusCancelled {
+		return u.cancelOrder(ctx, *order, req.ActorID, req.ActorRole, req.Note)
+	}
+
+	updatedAt := util.NextUpdatedAt(order.UpdatedAt)
+	err = u.ordersRepository.UpdateOrderStatus(ctx, orders.StatusTransition{
+		OrderID:           order.ID,
+		From:              current,
+		To:                next,
+		ExpectedUpdatedAt: order.UpdatedAt,
+		UpdatedAt:         updatedAt,
+		ActorID:           req.ActorID,
+		ActorRole:         req.ActorRole,
+		Note:              req.Note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &orders.UpdateOrderStatusResponse{
+		OrderID:   order.ID,
+		Status:    next.String(),
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+func (u *usecase) CancelOrder(ctx context.Context, req orders.CancelOrderRequest) (*orders.UpdateOrderStatusResponse, error) {
+	order, err := u.ordersRepository.GetOrderByID(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("order with id: %d is not found", req.OrderID)
+	}
+	if order.UserID != req.UserID {
+		return nil, fmt.Errorf("order with id: %d is not found", req.OrderID)
+	}
+
+	current := orders.OrderStatus(order.Status)
+	if current == orders.OrderStatusCancelled {
+		return nil, fmt.Errorf("order with id: %d is already cancelled", req.OrderID)
+	}
+	if !current.CanTransitionTo(orders.OrderStatusCancelled) {
+		return nil, fmt.Errorf("order with id: %d can't be cancelled anymore, its status is %s", req.OrderID, current)
+	}
+	return u.cancelOrder(ctx, *order, req.UserID, users.RoleCustomer.String(), req.Reason)
+}
+
+// cancelOrder cancels the order, puts the stock of its books back, releases its promotion and its payment
+func (u *usecase) cancelOrder(ctx context.Context, order orders.Model, actorID int64, actorRole, note string) (*orders.UpdateOrderStatusResponse, error) {
+	current := orders.OrderStatus(order.Status)
+	updatedAt := util.NextUpdatedAt(order.UpdatedAt)
+	bookIDs, err := u.ordersRepository.CancelOrder(ctx, orders.StatusTransition{
+		OrderID:           order.ID,
+		From:              current,
+		To:                orders.OrderStatusCancelled,
+		ExpectedUpdatedAt: order.UpdatedAt,
+		UpdatedAt:         updatedAt,
+		ActorID:           actorID,
+		ActorRole:         actorRole,
+		Note:              note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the stock of the cancelled books is back, so their cached availability is stale
+	u.booksRepository.InvalidateBookCache(bookIDs...)
+
+	// an order awaiting payment might already hold an authorization, the cancellation stands even when releasing it
+	// fails, so it is only logged for support to follow up
+	if current == orders.OrderStatusAwaitingPayment {
+		err = u.paymentsUsecase.ReleasePayment(ctx, order.ID)
+		if err != nil {
+			log.Printf("[cancelOrder] error when releasing the payment of order %d: %v", order.ID, err)
+		}
+	}
+	return &orders.UpdateOrderStatusResponse{
+		OrderID:   order.ID,
+		Status:    orders.OrderStatusCancelled.String(),
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+// replayOrder returns the response of the first request with the idempotency key
+func replayOrder(record idempotency.Record, fingerprint string) (*orders.CreateOrderResponse, error) {
+	var createdOrder orders.CreateOrderResponse
+	err := record.Replay(fingerprint, &createdOrder)
+	if err != nil {
+		return nil, err
+	}
+	createdOrder.Replayed = true
+	return &createdOrder, nil
 }
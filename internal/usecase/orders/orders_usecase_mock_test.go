@@ -10,7 +10,10 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	books "github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	idempotency "github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
 	orders "github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	promotions "github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	users "github.com/yeremiaaryo/gotu-assignment/internal/model/users"
 )
 
 // MockordersRepository is a mock of ordersRepository interface.
@@ -36,19 +39,64 @@ func (m *MockordersRepository) EXPECT() *MockordersRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CancelOrder mocks base method.
+func (m *MockordersRepository) CancelOrder(ctx context.Context, transition orders.StatusTransition) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelOrder", ctx, transition)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelOrder indicates an expected call of CancelOrder.
+func (mr *MockordersRepositoryMockRecorder) CancelOrder(ctx, transition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelOrder", reflect.TypeOf((*MockordersRepository)(nil).CancelOrder), ctx, transition)
+}
+
+// GetOrderByID mocks base method.
+func (m *MockordersRepository) GetOrderByID(ctx context.Context, id int64) (*orders.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByID", ctx, id)
+	ret0, _ := ret[0].(*orders.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByID indicates an expected call of GetOrderByID.
+func (mr *MockordersRepositoryMockRecorder) GetOrderByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockordersRepository)(nil).GetOrderByID), ctx, id)
+}
+
+// GetOrderDetail mocks base method.
+func (m *MockordersRepository) GetOrderDetail(ctx context.Context, id int64) (*orders.Detail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderDetail", ctx, id)
+	ret0, _ := ret[0].(*orders.Detail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderDetail indicates an expected call of GetOrderDetail.
+func (mr *MockordersRepositoryMockRecorder) GetOrderDetail(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderDetail", reflect.TypeOf((*MockordersRepository)(nil).GetOrderDetail), ctx, id)
+}
+
 // GetOrdersByUserID mocks base method.
-func (m *MockordersRepository) GetOrdersByUserID(ctx context.Context, userID int64, limit, offset int) ([]orders.History, error) {
+func (m *MockordersRepository) GetOrdersByUserID(ctx context.Context, userID int64, after *orders.Cursor, limit, offset int) (orders.HistoryPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrdersByUserID", ctx, userID, limit, offset)
-	ret0, _ := ret[0].([]orders.History)
+	ret := m.ctrl.Call(m, "GetOrdersByUserID", ctx, userID, after, limit, offset)
+	ret0, _ := ret[0].(orders.HistoryPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrdersByUserID indicates an expected call of GetOrdersByUserID.
-func (mr *MockordersRepositoryMockRecorder) GetOrdersByUserID(ctx, userID, limit, offset interface{}) *gomock.Call {
+func (mr *MockordersRepositoryMockRecorder) GetOrdersByUserID(ctx, userID, after, limit, offset interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockordersRepository)(nil).GetOrdersByUserID), ctx, userID, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockordersRepository)(nil).GetOrdersByUserID), ctx, userID, after, limit, offset)
 }
 
 // InsertOrder mocks base method.
@@ -66,6 +114,86 @@ func (mr *MockordersRepositoryMockRecorder) InsertOrder(ctx, order interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrder", reflect.TypeOf((*MockordersRepository)(nil).InsertOrder), ctx, order)
 }
 
+// UpdateOrderStatus mocks base method.
+func (m *MockordersRepository) UpdateOrderStatus(ctx context.Context, transition orders.StatusTransition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, transition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockordersRepositoryMockRecorder) UpdateOrderStatus(ctx, transition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockordersRepository)(nil).UpdateOrderStatus), ctx, transition)
+}
+
+// MockidempotencyRepository is a mock of idempotencyRepository interface.
+type MockidempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockidempotencyRepositoryMockRecorder
+}
+
+// MockidempotencyRepositoryMockRecorder is the mock recorder for MockidempotencyRepository.
+type MockidempotencyRepositoryMockRecorder struct {
+	mock *MockidempotencyRepository
+}
+
+// NewMockidempotencyRepository creates a new mock instance.
+func NewMockidempotencyRepository(ctrl *gomock.Controller) *MockidempotencyRepository {
+	mock := &MockidempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockidempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockidempotencyRepository) EXPECT() *MockidempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Release mocks base method.
+func (m *MockidempotencyRepository) Release(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockidempotencyRepositoryMockRecorder) Release(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockidempotencyRepository)(nil).Release), ctx, key)
+}
+
+// Reserve mocks base method.
+func (m *MockidempotencyRepository) Reserve(ctx context.Context, key, fingerprint string) (*idempotency.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, key, fingerprint)
+	ret0, _ := ret[0].(*idempotency.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockidempotencyRepositoryMockRecorder) Reserve(ctx, key, fingerprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockidempotencyRepository)(nil).Reserve), ctx, key, fingerprint)
+}
+
+// Save mocks base method.
+func (m *MockidempotencyRepository) Save(ctx context.Context, key, fingerprint string, response interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, key, fingerprint, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockidempotencyRepositoryMockRecorder) Save(ctx, key, fingerprint, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockidempotencyRepository)(nil).Save), ctx, key, fingerprint, response)
+}
+
 // MockbooksRepository is a mock of booksRepository interface.
 type MockbooksRepository struct {
 	ctrl     *gomock.Controller
@@ -103,3 +231,132 @@ func (mr *MockbooksRepositoryMockRecorder) GetBookByIDs(ctx, ids interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByIDs", reflect.TypeOf((*MockbooksRepository)(nil).GetBookByIDs), ctx, ids)
 }
+
+// InvalidateBookCache mocks base method.
+func (m *MockbooksRepository) InvalidateBookCache(ids ...int64) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateBookCache", varargs...)
+}
+
+// InvalidateBookCache indicates an expected call of InvalidateBookCache.
+func (mr *MockbooksRepositoryMockRecorder) InvalidateBookCache(ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateBookCache", reflect.TypeOf((*MockbooksRepository)(nil).InvalidateBookCache), ids...)
+}
+
+// MockusersRepository is a mock of usersRepository interface.
+type MockusersRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockusersRepositoryMockRecorder
+}
+
+// MockusersRepositoryMockRecorder is the mock recorder for MockusersRepository.
+type MockusersRepositoryMockRecorder struct {
+	mock *MockusersRepository
+}
+
+// NewMockusersRepository creates a new mock instance.
+func NewMockusersRepository(ctrl *gomock.Controller) *MockusersRepository {
+	mock := &MockusersRepository{ctrl: ctrl}
+	mock.recorder = &MockusersRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockusersRepository) EXPECT() *MockusersRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetUserByID mocks base method.
+func (m *MockusersRepository) GetUserByID(ctx context.Context, id int64) (*users.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(*users.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockusersRepositoryMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockusersRepository)(nil).GetUserByID), ctx, id)
+}
+
+// MockpaymentsUsecase is a mock of paymentsUsecase interface.
+type MockpaymentsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockpaymentsUsecaseMockRecorder
+}
+
+// MockpaymentsUsecaseMockRecorder is the mock recorder for MockpaymentsUsecase.
+type MockpaymentsUsecaseMockRecorder struct {
+	mock *MockpaymentsUsecase
+}
+
+// NewMockpaymentsUsecase creates a new mock instance.
+func NewMockpaymentsUsecase(ctrl *gomock.Controller) *MockpaymentsUsecase {
+	mock := &MockpaymentsUsecase{ctrl: ctrl}
+	mock.recorder = &MockpaymentsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpaymentsUsecase) EXPECT() *MockpaymentsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ReleasePayment mocks base method.
+func (m *MockpaymentsUsecase) ReleasePayment(ctx context.Context, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleasePayment", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleasePayment indicates an expected call of ReleasePayment.
+func (mr *MockpaymentsUsecaseMockRecorder) ReleasePayment(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleasePayment", reflect.TypeOf((*MockpaymentsUsecase)(nil).ReleasePayment), ctx, orderID)
+}
+
+// MockpromotionsUsecase is a mock of promotionsUsecase interface.
+type MockpromotionsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockpromotionsUsecaseMockRecorder
+}
+
+// MockpromotionsUsecaseMockRecorder is the mock recorder for MockpromotionsUsecase.
+type MockpromotionsUsecaseMockRecorder struct {
+	mock *MockpromotionsUsecase
+}
+
+// NewMockpromotionsUsecase creates a new mock instance.
+func NewMockpromotionsUsecase(ctrl *gomock.Controller) *MockpromotionsUsecase {
+	mock := &MockpromotionsUsecase{ctrl: ctrl}
+	mock.recorder = &MockpromotionsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpromotionsUsecase) EXPECT() *MockpromotionsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ApplyPromotion mocks base method.
+func (m *MockpromotionsUsecase) ApplyPromotion(ctx context.Context, userID int64, code string, lines []promotions.Line) (*promotions.Applied, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyPromotion", ctx, userID, code, lines)
+	ret0, _ := ret[0].(*promotions.Applied)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyPromotion indicates an expected call of ApplyPromotion.
+func (mr *MockpromotionsUsecaseMockRecorder) ApplyPromotion(ctx, userID, code, lines interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyPromotion", reflect.TypeOf((*MockpromotionsUsecase)(nil).ApplyPromotion), ctx, userID, code, lines)
+}
@@ -4,18 +4,49 @@ import (
 	"context"
 	"errors"
 	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/books"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/idempotency"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/signer"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/tax"
 	"reflect"
 	"testing"
+	"time"
 )
 
+// testTaxCalculator taxes the orders of the tests, prices include 11% tax in ID, the default country, while CA adds 5% on top
+func testTaxCalculator() *tax.Calculator {
+	calculator, err := tax.New([]tax.Rate{
+		{Country: "ID", Rate: percent.MustParse("11"), Inclusive: true},
+		{Country: "CA", Rate: percent.MustParse("5")},
+	}, "ID")
+	if err != nil {
+		panic(err)
+	}
+	return calculator
+}
+
 func Test_usecase_InsertOrder(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	mockBooksRepo := NewMockbooksRepository(mockCtrl)
 	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+
+	bookMap := map[int64]books.Model{
+		101: {
+			ID:    101,
+			Title: "Book 101",
+			Price: money.MustParse("50"),
+		},
+	}
 
 	type args struct {
 		ctx   context.Context
@@ -34,12 +65,12 @@ func Test_usecase_InsertOrder(t *testing.T) {
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -56,12 +87,12 @@ func Test_usecase_InsertOrder(t *testing.T) {
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    60.0,
+							Price:    money.MustParse("60"),
 						},
 					},
 				},
@@ -69,13 +100,30 @@ func Test_usecase_InsertOrder(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 			mockFn: func(args args) {
-				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(map[int64]books.Model{
-					101: {
-						ID:    101,
-						Title: "Book 101",
-						Price: 50.0,
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+			},
+		},
+		{
+			name: "error email not verified",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   101,
+							Quantity: 2,
+							Price:    money.MustParse("50"),
+						},
 					},
-				}, nil)
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1}, nil)
 			},
 		},
 		{
@@ -84,12 +132,12 @@ func Test_usecase_InsertOrder(t *testing.T) {
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 200.0, // Incorrect total amount
+					TotalAmount: money.MustParse("200"), // Incorrect total amount
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -97,27 +145,68 @@ func Test_usecase_InsertOrder(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 			mockFn: func(args args) {
-				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(map[int64]books.Model{
-					101: {
-						ID:    101,
-						Title: "Book 101",
-						Price: 50.0,
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+			},
+		},
+		{
+			name: "error due to total amount without the tax added on top",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   101,
+							Quantity: 2,
+							Price:    money.MustParse("50"),
+						},
 					},
-				}, nil)
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1, BillingCountry: "CA", EmailVerifiedAt: 1000}, nil)
 			},
 		},
 		{
-			name: "success",
+			name: "error billing country without tax rate",
 			args: args{
 				ctx: context.Background(),
 				order: orders.CreateOrderRequest{
 					UserID:      1,
-					TotalAmount: 100.0,
+					TotalAmount: money.MustParse("100"),
 					Items: []orders.CreateOrderItem{
 						{
 							BookID:   101,
 							Quantity: 2,
-							Price:    50.0,
+							Price:    money.MustParse("50"),
+						},
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1, BillingCountry: "US", EmailVerifiedAt: 1000}, nil)
+			},
+		},
+		{
+			name: "success with the tax included in the prices",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   101,
+							Quantity: 2,
+							Price:    money.MustParse("50"),
 						},
 					},
 				},
@@ -128,17 +217,124 @@ func Test_usecase_InsertOrder(t *testing.T) {
 			},
 			wantErr: false,
 			mockFn: func(args args) {
-				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(map[int64]books.Model{
-					101: {
-						ID:    101,
-						Title: "Book 101",
-						Price: 50.0,
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(args.ctx, orders.CreateOrderRequest{
+					UserID:         1,
+					Subtotal:       money.MustParse("100"),
+					BillingCountry: "ID",
+					Tax:            money.MustParse("9.91"),
+					TaxInclusive:   true,
+					TotalAmount:    money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("50"), TaxRate: percent.MustParse("11"), Tax: money.MustParse("9.91")},
 					},
+				}).Return(&orders.CreateOrderResponse{
+					OrderID: 1,
+					Status:  orders.OrderStatusNew.String(),
 				}, nil)
-				mockOrdersRepo.EXPECT().InsertOrder(args.ctx, args.order).Return(&orders.CreateOrderResponse{
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101))
+			},
+		},
+		{
+			name: "success with the tax added on top of the prices",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("105"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   101,
+							Quantity: 2,
+							Price:    money.MustParse("50"),
+						},
+					},
+				},
+			},
+			want: &orders.CreateOrderResponse{
+				OrderID: 1,
+				Status:  orders.OrderStatusNew.String(),
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1, BillingCountry: "CA", EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(args.ctx, orders.CreateOrderRequest{
+					UserID:         1,
+					Subtotal:       money.MustParse("100"),
+					BillingCountry: "CA",
+					Tax:            money.MustParse("5"),
+					TotalAmount:    money.MustParse("105"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("50"), TaxRate: percent.MustParse("5"), Tax: money.MustParse("5")},
+					},
+				}).Return(&orders.CreateOrderResponse{
 					OrderID: 1,
 					Status:  orders.OrderStatusNew.String(),
 				}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101))
+			},
+		},
+		{
+			name: "success with a total that float math gets wrong",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("32.97"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   1,
+							Quantity: 3,
+							Price:    money.MustParse("10.99"),
+						},
+					},
+				},
+			},
+			want: &orders.CreateOrderResponse{
+				OrderID: 2,
+				Status:  orders.OrderStatusNew.String(),
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, []int64{1}).Return(map[int64]books.Model{
+					1: {
+						ID:    1,
+						Title: "The Catcher in the Rye",
+						Price: money.MustParse("10.99"),
+					},
+				}, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(args.ctx, gomock.Any()).Return(&orders.CreateOrderResponse{
+					OrderID: 2,
+					Status:  orders.OrderStatusNew.String(),
+				}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(1))
+			},
+		},
+		{
+			name: "error out of stock",
+			args: args{
+				ctx: context.Background(),
+				order: orders.CreateOrderRequest{
+					UserID:      1,
+					TotalAmount: money.MustParse("100"),
+					Items: []orders.CreateOrderItem{
+						{
+							BookID:   101,
+							Quantity: 2,
+							Price:    money.MustParse("50"),
+						},
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockBooksRepo.EXPECT().GetBookByIDs(args.ctx, gomock.Any()).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(args.ctx, int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(args.ctx, gomock.Any()).Return(nil, errors.New("out of stock for book_ids: 101"))
 			},
 		},
 	}
@@ -148,6 +344,8 @@ func Test_usecase_InsertOrder(t *testing.T) {
 			u := &usecase{
 				booksRepository:  mockBooksRepo,
 				ordersRepository: mockOrdersRepo,
+				usersRepository:  mockUsersRepo,
+				taxCalculator:    testTaxCalculator(),
 			}
 			got, err := u.InsertOrder(tt.args.ctx, tt.args.order)
 			if (err != nil) != tt.wantErr {
@@ -166,19 +364,23 @@ func Test_usecase_GetOrdersByUserID(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	cfg := &configs.Config{Service: configs.Service{SecretKey: "secret"}}
+	cursor, _ := signer.Sign(ordersCursorPurpose, orders.Cursor{CreatedAt: 1623550900, ID: 2}, "secret")
 
 	type args struct {
 		ctx       context.Context
 		userID    int64
+		cursor    string
 		pageIndex int
 		pageSize  int
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    []orders.History
-		wantErr bool
-		mockFn  func(args args)
+		name           string
+		args           args
+		want           []orders.History
+		wantPagination response.Pagination
+		wantErr        bool
+		mockFn         func(args args)
 	}{
 		{
 			name: "error in repository",
@@ -191,7 +393,7 @@ func Test_usecase_GetOrdersByUserID(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 			mockFn: func(args args) {
-				mockOrdersRepo.EXPECT().GetOrdersByUserID(args.ctx, args.userID, 10, 0).Return(nil, errors.New("repository error"))
+				mockOrdersRepo.EXPECT().GetOrdersByUserID(args.ctx, args.userID, nil, 10, 0).Return(orders.HistoryPage{}, errors.New("repository error"))
 			},
 		},
 		{
@@ -205,7 +407,7 @@ func Test_usecase_GetOrdersByUserID(t *testing.T) {
 			want: []orders.History{
 				{
 					ID:          1,
-					TotalAmount: 100,
+					TotalAmount: money.MustParse("100"),
 					Status:      "NEW",
 					CreatedAt:   1623550814,
 					UpdatedAt:   1623550814,
@@ -214,29 +416,84 @@ func Test_usecase_GetOrdersByUserID(t *testing.T) {
 							ID:       1,
 							BookID:   1,
 							Quantity: 2,
-							Price:    10,
+							Price:    money.MustParse("10"),
 						},
 					},
 				},
 			},
-			wantErr: false,
+			wantPagination: response.Pagination{TotalItems: 1, TotalPages: 1, PageIndex: 1, PageSize: 10, HasNext: false},
+			wantErr:        false,
 			mockFn: func(args args) {
-				mockOrdersRepo.EXPECT().GetOrdersByUserID(args.ctx, args.userID, 10, 0).Return([]orders.History{
-					{
-						ID:          1,
-						TotalAmount: 100,
-						Status:      "NEW",
-						CreatedAt:   1623550814,
-						UpdatedAt:   1623550814,
-						Items: []orders.ItemHistory{
-							{
-								ID:       1,
-								BookID:   1,
-								Quantity: 2,
-								Price:    10,
+				mockOrdersRepo.EXPECT().GetOrdersByUserID(args.ctx, args.userID, nil, 10, 0).Return(orders.HistoryPage{
+					Histories: []orders.History{
+						{
+							ID:          1,
+							TotalAmount: money.MustParse("100"),
+							Status:      "NEW",
+							CreatedAt:   1623550814,
+							UpdatedAt:   1623550814,
+							Items: []orders.ItemHistory{
+								{
+									ID:       1,
+									BookID:   1,
+									Quantity: 2,
+									Price:    money.MustParse("10"),
+								},
 							},
 						},
 					},
+					TotalItems: 1,
+				}, nil)
+			},
+		},
+		{
+			name: "error invalid cursor",
+			args: args{
+				ctx:       context.Background(),
+				userID:    1,
+				cursor:    "tampered",
+				pageIndex: 1,
+				pageSize:  10,
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
+		},
+		{
+			name: "first page issues the next cursor",
+			args: args{
+				ctx:       context.Background(),
+				userID:    1,
+				pageIndex: 1,
+				pageSize:  2,
+			},
+			want:           []orders.History{{ID: 3, CreatedAt: 1623551000}, {ID: 2, CreatedAt: 1623550900}},
+			wantPagination: response.Pagination{TotalItems: 3, TotalPages: 2, PageIndex: 1, PageSize: 2, HasNext: true, NextCursor: cursor},
+			wantErr:        false,
+			mockFn: func(args args) {
+				mockOrdersRepo.EXPECT().GetOrdersByUserID(args.ctx, args.userID, nil, 2, 0).Return(orders.HistoryPage{
+					Histories:  []orders.History{{ID: 3, CreatedAt: 1623551000}, {ID: 2, CreatedAt: 1623550900}},
+					TotalItems: 3,
+					HasNext:    true,
+				}, nil)
+			},
+		},
+		{
+			name: "next page by cursor",
+			args: args{
+				ctx:       context.Background(),
+				userID:    1,
+				cursor:    cursor,
+				pageIndex: 1,
+				pageSize:  10,
+			},
+			want:           []orders.History{{ID: 1, CreatedAt: 1623550814}},
+			wantPagination: response.Pagination{TotalItems: 3, TotalPages: 1, PageIndex: 0, PageSize: 10, HasNext: false},
+			wantErr:        false,
+			mockFn: func(args args) {
+				mockOrdersRepo.EXPECT().GetOrdersByUserID(args.ctx, args.userID, &orders.Cursor{CreatedAt: 1623550900, ID: 2}, 10, 0).Return(orders.HistoryPage{
+					Histories:  []orders.History{{ID: 1, CreatedAt: 1623550814}},
+					TotalItems: 3,
 				}, nil)
 			},
 		},
@@ -246,8 +503,9 @@ func Test_usecase_GetOrdersByUserID(t *testing.T) {
 			tt.mockFn(tt.args)
 			u := &usecase{
 				ordersRepository: mockOrdersRepo,
+				cfg:              cfg,
 			}
-			got, err := u.GetOrdersByUserID(tt.args.ctx, tt.args.userID, tt.args.pageIndex, tt.args.pageSize)
+			got, gotPagination, err := u.GetOrdersByUserID(tt.args.ctx, tt.args.userID, tt.args.cursor, tt.args.pageIndex, tt.args.pageSize)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetOrdersByUserID() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -255,6 +513,798 @@ func Test_usecase_GetOrdersByUserID(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetOrdersByUserID() got = %v, want %v", got, tt.want)
 			}
+			if !reflect.DeepEqual(gotPagination, tt.wantPagination) {
+				t.Errorf("GetOrdersByUserID() gotPagination = %v, want %v", gotPagination, tt.wantPagination)
+			}
+		})
+	}
+}
+
+func Test_usecase_UpdateOrderStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	mockPaymentsUsecase := NewMockpaymentsUsecase(mockCtrl)
+
+	req := orders.UpdateOrderStatusRequest{
+		OrderID:   1,
+		ActorID:   9,
+		ActorRole: "admin",
+		Status:    "SHIPPED",
+		UpdatedAt: 1000,
+		Note:      "JNE 123",
+	}
+	paidOrder := &orders.Model{ID: 1, UserID: 2, Status: "PAID", UpdatedAt: 1000}
+
+	tests := []struct {
+		name       string
+		req        orders.UpdateOrderStatusRequest
+		wantStatus string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error unknown status",
+			req:        orders.UpdateOrderStatusRequest{OrderID: 1, Status: "LOST", UpdatedAt: 1000},
+			wantErrMsg: "invalid status: LOST",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error get order",
+			req:        req,
+			wantErrMsg: "failed to get order",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(nil, errors.New("failed to get order"))
+			},
+		},
+		{
+			name:       "error order not found",
+			req:        req,
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(nil, nil)
+			},
+		},
+		{
+			name:       "error stale updated_at",
+			req:        req,
+			wantErrMsg: "order was updated by another request, please reload it",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, Status: "PAID", UpdatedAt: 1500}, nil)
+			},
+		},
+		{
+			name:       "error invalid transition",
+			req:        req,
+			wantErrMsg: "invalid status transition from NEW to SHIPPED",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, Status: "NEW", UpdatedAt: 1000}, nil)
+			},
+		},
+		{
+			name:       "error final status",
+			req:        orders.UpdateOrderStatusRequest{OrderID: 1, Status: "PAID", UpdatedAt: 1000},
+			wantErrMsg: "invalid status transition from CANCELLED to PAID",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, Status: "CANCELLED", UpdatedAt: 1000}, nil)
+			},
+		},
+		{
+			name:       "error paid is set by the payment flow",
+			req:        orders.UpdateOrderStatusRequest{OrderID: 1, Status: "PAID", UpdatedAt: 1000},
+			wantErrMsg: "invalid status transition from AWAITING_PAYMENT to PAID, it is made by the payment or the refund flow",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, Status: "AWAITING_PAYMENT", UpdatedAt: 1000}, nil)
+			},
+		},
+		{
+			name:       "error refunded is set by the refund flow",
+			req:        orders.UpdateOrderStatusRequest{OrderID: 1, Status: "REFUNDED", UpdatedAt: 1000},
+			wantErrMsg: "invalid status transition from PAID to REFUNDED, it is made by the payment or the refund flow",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(paidOrder, nil)
+			},
+		},
+		{
+			name:       "error update conflict",
+			req:        req,
+			wantErrMsg: "order was updated by another request, please reload it",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(paidOrder, nil)
+				mockOrdersRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(errors.New("order was updated by another request, please reload it"))
+			},
+		},
+		{
+			name:       "success cancel restocks and releases the payment",
+			req:        orders.UpdateOrderStatusRequest{OrderID: 1, ActorID: 9, ActorRole: "admin", Status: "CANCELLED", UpdatedAt: 1000, Note: "fraud"},
+			wantStatus: "CANCELLED",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "AWAITING_PAYMENT", UpdatedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().CancelOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, transition orders.StatusTransition) ([]int64, error) {
+					if transition.From != orders.OrderStatusAwaitingPayment || transition.To != orders.OrderStatusCancelled ||
+						transition.ActorID != 9 || transition.ActorRole != "admin" || transition.Note != "fraud" {
+						t.Errorf("CancelOrder() unexpected transition = %+v", transition)
+					}
+					return []int64{3}, nil
+				})
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(3))
+				mockPaymentsUsecase.EXPECT().ReleasePayment(gomock.Any(), int64(1)).Return(nil)
+			},
+		},
+		{
+			name:       "success",
+			req:        req,
+			wantStatus: "SHIPPED",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(paidOrder, nil)
+				mockOrdersRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, transition orders.StatusTransition) error {
+					if transition.From != orders.OrderStatusPaid || transition.To != orders.OrderStatusShipped ||
+						transition.ExpectedUpdatedAt != 1000 || transition.UpdatedAt <= 1000 ||
+						transition.ActorID != 9 || transition.ActorRole != "admin" || transition.Note != "JNE 123" {
+						t.Errorf("UpdateOrderStatus() unexpected transition = %+v", transition)
+					}
+					return nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				ordersRepository: mockOrdersRepo,
+				booksRepository:  mockBooksRepo,
+				paymentsUsecase:  mockPaymentsUsecase,
+			}
+			got, err := u.UpdateOrderStatus(context.Background(), tt.req)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("UpdateOrderStatus() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("UpdateOrderStatus() unexpected error = %v", err)
+				return
+			}
+			if got.OrderID != 1 || got.Status != tt.wantStatus || got.UpdatedAt <= 1000 {
+				t.Errorf("UpdateOrderStatus() got = %+v", got)
+			}
+		})
+	}
+}
+
+func Test_usecase_CancelOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	mockPaymentsUsecase := NewMockpaymentsUsecase(mockCtrl)
+
+	req := orders.CancelOrderRequest{OrderID: 1, UserID: 2, Reason: "changed my mind"}
+
+	tests := []struct {
+		name       string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error order not found",
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(nil, nil)
+			},
+		},
+		{
+			name:       "error order of another user looks not found",
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 3, Status: "NEW", UpdatedAt: 1000}, nil)
+			},
+		},
+		{
+			name:       "error already cancelled",
+			wantErrMsg: "order with id: 1 is already cancelled",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "CANCELLED", UpdatedAt: 1000}, nil)
+			},
+		},
+		{
+			name:       "error already shipped",
+			wantErrMsg: "order with id: 1 can't be cancelled anymore, its status is SHIPPED",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "SHIPPED", UpdatedAt: 1000}, nil)
+			},
+		},
+		{
+			name:       "error cancel order",
+			wantErrMsg: "order was updated by another request, please reload it",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "NEW", UpdatedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().CancelOrder(gomock.Any(), gomock.Any()).Return(nil, errors.New("order was updated by another request, please reload it"))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "AWAITING_PAYMENT", UpdatedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().CancelOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, transition orders.StatusTransition) ([]int64, error) {
+					if transition.From != orders.OrderStatusAwaitingPayment || transition.To != orders.OrderStatusCancelled ||
+						transition.ExpectedUpdatedAt != 1000 || transition.ActorID != 2 || transition.ActorRole != "customer" ||
+						transition.Note != "changed my mind" {
+						t.Errorf("CancelOrder() unexpected transition = %+v", transition)
+					}
+					return []int64{101, 103}, nil
+				})
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101), int64(103))
+				mockPaymentsUsecase.EXPECT().ReleasePayment(gomock.Any(), int64(1)).Return(nil)
+			},
+		},
+		{
+			name: "success even when releasing the payment fails",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "AWAITING_PAYMENT", UpdatedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().CancelOrder(gomock.Any(), gomock.Any()).Return([]int64{101}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101))
+				mockPaymentsUsecase.EXPECT().ReleasePayment(gomock.Any(), int64(1)).Return(errors.New("provider is down"))
+			},
+		},
+		{
+			name: "success new order has no payment to release",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "NEW", UpdatedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().CancelOrder(gomock.Any(), gomock.Any()).Return([]int64{101}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				booksRepository:  mockBooksRepo,
+				ordersRepository: mockOrdersRepo,
+				paymentsUsecase:  mockPaymentsUsecase,
+			}
+			got, err := u.CancelOrder(context.Background(), req)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("CancelOrder() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("CancelOrder() unexpected error = %v", err)
+				return
+			}
+			if got.OrderID != 1 || got.Status != "CANCELLED" || got.UpdatedAt <= 1000 {
+				t.Errorf("CancelOrder() got = %+v", got)
+			}
+		})
+	}
+}
+
+func Test_usecase_GetOrderDetail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+
+	order := &orders.Detail{ID: 1, UserID: 2, Status: "NEW"}
+
+	type args struct {
+		userID int64
+		role   string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       *orders.Detail
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error order not found",
+			args:       args{userID: 2, role: "customer"},
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(1)).Return(nil, nil)
+			},
+		},
+		{
+			name:       "error order of another customer looks not found",
+			args:       args{userID: 3, role: "customer"},
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(1)).Return(order, nil)
+			},
+		},
+		{
+			name: "success owner",
+			args: args{userID: 2, role: "customer"},
+			want: order,
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(1)).Return(order, nil)
+			},
+		},
+		{
+			name: "success support sees any order",
+			args: args{userID: 9, role: "support"},
+			want: order,
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderDetail(gomock.Any(), int64(1)).Return(order, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				ordersRepository: mockOrdersRepo,
+			}
+			got, err := u.GetOrderDetail(context.Background(), 1, tt.args.userID, tt.args.role)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("GetOrderDetail() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetOrderDetail() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_usecase_InsertOrderWithIdempotencyKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	mockIdempotencyRepo := NewMockidempotencyRepository(mockCtrl)
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+
+	order := orders.CreateOrderRequest{
+		UserID:         1,
+		IdempotencyKey: "retry-me",
+		TotalAmount:    money.MustParse("100"),
+		Items: []orders.CreateOrderItem{
+			{BookID: 101, Quantity: 2, Price: money.MustParse("50")},
+		},
+	}
+	fingerprint, _ := idempotency.Fingerprint(order)
+	key := "idempotency:order:1:retry-me"
+	inserted := order
+	inserted.Subtotal = money.MustParse("100")
+	inserted.TaxInclusive = true
+	bookMap := map[int64]books.Model{101: {ID: 101, Price: money.MustParse("50")}}
+
+	tests := []struct {
+		name       string
+		want       *orders.CreateOrderResponse
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name: "first request creates the order and saves the response",
+			want: &orders.CreateOrderResponse{OrderID: 1, Status: "NEW"},
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).Return(nil, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(gomock.Any(), inserted).Return(&orders.CreateOrderResponse{OrderID: 1, Status: "NEW"}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101))
+				mockIdempotencyRepo.EXPECT().Save(gomock.Any(), key, fingerprint, &orders.CreateOrderResponse{OrderID: 1, Status: "NEW"}).Return(nil)
+			},
+		},
+		{
+			name:       "failed request releases the key",
+			wantErrMsg: "out of stock for book_ids: 101",
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).Return(nil, nil)
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(gomock.Any(), inserted).Return(nil, errors.New("out of stock for book_ids: 101"))
+				mockIdempotencyRepo.EXPECT().Release(gomock.Any(), key).Return(nil)
+			},
+		},
+		{
+			name: "retry replays the saved response",
+			want: &orders.CreateOrderResponse{OrderID: 1, Status: "NEW", Replayed: true},
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).
+					Return(&idempotency.Record{Fingerprint: fingerprint, Response: []byte(`{"order_id":1,"status":"NEW"}`)}, nil)
+			},
+		},
+		{
+			name:       "retry while the first request is in progress",
+			wantErrMsg: "a request with the same idempotency key is still in progress",
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).Return(&idempotency.Record{Fingerprint: fingerprint}, nil)
+			},
+		},
+		{
+			name:       "key reused with a different body",
+			wantErrMsg: "idempotency key was already used with a different request",
+			mockFn: func() {
+				mockIdempotencyRepo.EXPECT().Reserve(gomock.Any(), key, fingerprint).
+					Return(&idempotency.Record{Fingerprint: "other", Response: []byte(`{"order_id":1,"status":"NEW"}`)}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				booksRepository:       mockBooksRepo,
+				ordersRepository:      mockOrdersRepo,
+				idempotencyRepository: mockIdempotencyRepo,
+				usersRepository:       mockUsersRepo,
+				taxCalculator:         &tax.Calculator{},
+			}
+			got, err := u.InsertOrder(context.Background(), order)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("InsertOrder() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InsertOrder() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_usecase_QuoteOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockPromotionsUC := NewMockpromotionsUsecase(mockCtrl)
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	cfg := &configs.Config{Service: configs.Service{SecretKey: "secret"}}
+
+	req := orders.QuoteRequest{
+		UserID: 1,
+		Items: []orders.CreateOrderItem{
+			{BookID: 101, Quantity: 3, Price: money.MustParse("10.99")},
+			{BookID: 103, Quantity: 2, Price: money.MustParse("7.99")},
+		},
+	}
+
+	t.Run("error book not found", func(t *testing.T) {
+		mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(map[int64]books.Model{
+			101: {ID: 101, Title: "1984", Price: money.MustParse("10.99"), Stock: 5},
+		}, nil)
+		u := &usecase{booksRepository: mockBooksRepo, usersRepository: mockUsersRepo, taxCalculator: testTaxCalculator(), cfg: cfg}
+		_, err := u.QuoteOrder(context.Background(), req)
+		if err == nil || err.Error() != "book with id: 103 is not found" {
+			t.Errorf("QuoteOrder() error = %v", err)
+		}
+	})
+
+	t.Run("success reports the price change", func(t *testing.T) {
+		mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(map[int64]books.Model{
+			101: {ID: 101, Title: "1984", Price: money.MustParse("10.99"), Stock: 5},
+			103: {ID: 103, Title: "Animal Farm", Price: money.MustParse("8.49"), Stock: 1},
+		}, nil)
+		mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+		u := &usecase{booksRepository: mockBooksRepo, usersRepository: mockUsersRepo, taxCalculator: testTaxCalculator(), cfg: cfg}
+		got, err := u.QuoteOrder(context.Background(), req)
+		if err != nil {
+			t.Fatalf("QuoteOrder() error = %v", err)
+		}
+
+		wantItems := []orders.QuoteItem{
+			{BookID: 101, Title: "1984", Quantity: 3, Price: money.MustParse("10.99"), ExpectedPrice: money.MustParse("10.99"), LineTotal: money.MustParse("32.97"), Available: true, TaxRate: percent.MustParse("11"), Tax: money.MustParse("3.27")},
+			{BookID: 103, Title: "Animal Farm", Quantity: 2, Price: money.MustParse("8.49"), ExpectedPrice: money.MustParse("7.99"), PriceChanged: true, LineTotal: money.MustParse("16.98"), Available: false, TaxRate: percent.MustParse("11"), Tax: money.MustParse("1.68")},
+		}
+		if !reflect.DeepEqual(got.Items, wantItems) {
+			t.Errorf("QuoteOrder() items = %v, want %v", got.Items, wantItems)
+		}
+		if got.Subtotal != money.MustParse("49.95") || got.Tax != money.MustParse("4.95") || got.GrandTotal != money.MustParse("49.95") {
+			t.Errorf("QuoteOrder() subtotal = %v, tax = %v, grand total = %v", got.Subtotal, got.Tax, got.GrandTotal)
+		}
+		if got.BillingCountry != "ID" || !got.TaxInclusive {
+			t.Errorf("QuoteOrder() billing country = %v, tax inclusive = %v", got.BillingCountry, got.TaxInclusive)
+		}
+
+		token, err := u.verifyQuote(1, got.QuoteID)
+		if err != nil {
+			t.Fatalf("verifyQuote() error = %v", err)
+		}
+		if token.Total != got.GrandTotal || token.Items[1].Price != money.MustParse("8.49") {
+			t.Errorf("verifyQuote() token = %+v", token)
+		}
+	})
+
+	t.Run("error promo code", func(t *testing.T) {
+		mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(map[int64]books.Model{
+			101: {ID: 101, Title: "1984", Price: money.MustParse("10.99"), Stock: 5},
+			103: {ID: 103, Title: "Animal Farm", Price: money.MustParse("8.49"), Stock: 1},
+		}, nil)
+		mockPromotionsUC.EXPECT().ApplyPromotion(gomock.Any(), int64(1), "EXPIRED", gomock.Any()).Return(nil, errors.New("promo code EXPIRED has expired"))
+		u := &usecase{booksRepository: mockBooksRepo, promotionsUsecase: mockPromotionsUC, usersRepository: mockUsersRepo, taxCalculator: testTaxCalculator(), cfg: cfg}
+		promoReq := req
+		promoReq.PromoCode = "EXPIRED"
+		_, err := u.QuoteOrder(context.Background(), promoReq)
+		if err == nil || err.Error() != "promo code EXPIRED has expired" {
+			t.Errorf("QuoteOrder() error = %v", err)
+		}
+	})
+
+	t.Run("success with promo code taxes the discounted lines on top", func(t *testing.T) {
+		mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 103}).Return(map[int64]books.Model{
+			101: {ID: 101, Title: "1984", Author: "George Orwell", Price: money.MustParse("10.99"), Stock: 5},
+			103: {ID: 103, Title: "Animal Farm", Author: "George Orwell", Price: money.MustParse("8.49"), Stock: 1},
+		}, nil)
+		mockPromotionsUC.EXPECT().ApplyPromotion(gomock.Any(), int64(1), "orwell", []promotions.Line{
+			{BookID: 101, Author: "George Orwell", Price: money.MustParse("10.99"), Quantity: 3},
+			{BookID: 103, Author: "George Orwell", Price: money.MustParse("8.49"), Quantity: 2},
+		}).Return(&promotions.Applied{
+			PromotionID:   4,
+			Code:          "ORWELL",
+			Discount:      money.MustParse("5"),
+			LineDiscounts: []money.Amount{money.MustParse("3.30"), money.MustParse("1.70")},
+		}, nil)
+		mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, BillingCountry: "CA", EmailVerifiedAt: 1000}, nil)
+		u := &usecase{booksRepository: mockBooksRepo, promotionsUsecase: mockPromotionsUC, usersRepository: mockUsersRepo, taxCalculator: testTaxCalculator(), cfg: cfg}
+		promoReq := req
+		promoReq.PromoCode = "orwell"
+		got, err := u.QuoteOrder(context.Background(), promoReq)
+		if err != nil {
+			t.Fatalf("QuoteOrder() error = %v", err)
+		}
+		if got.PromoCode != "ORWELL" || got.Discount != money.MustParse("5") || got.Tax != money.MustParse("2.24") || got.GrandTotal != money.MustParse("47.19") {
+			t.Errorf("QuoteOrder() promo code = %v, discount = %v, tax = %v, grand total = %v", got.PromoCode, got.Discount, got.Tax, got.GrandTotal)
+		}
+		if got.Items[0].Discount != money.MustParse("3.30") || got.Items[1].Discount != money.MustParse("1.70") ||
+			got.Items[0].Tax != money.MustParse("1.48") || got.Items[1].Tax != money.MustParse("0.76") {
+			t.Errorf("QuoteOrder() items = %v", got.Items)
+		}
+
+		token, err := u.verifyQuote(1, got.QuoteID)
+		if err != nil || token.PromoCode != "ORWELL" || token.Total != money.MustParse("47.19") {
+			t.Errorf("verifyQuote() token = %+v, error = %v", token, err)
+		}
+	})
+}
+
+func Test_usecase_InsertOrderWithPromoCode(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	mockPromotionsUC := NewMockpromotionsUsecase(mockCtrl)
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+
+	bookMap := map[int64]books.Model{
+		101: {ID: 101, Author: "George Orwell", Price: money.MustParse("10")},
+		102: {ID: 102, Author: "Aldous Huxley", Price: money.MustParse("20")},
+	}
+	items := []orders.CreateOrderItem{
+		{BookID: 101, Quantity: 2, Price: money.MustParse("10")},
+		{BookID: 102, Quantity: 1, Price: money.MustParse("20")},
+	}
+	lines := []promotions.Line{
+		{BookID: 101, Author: "George Orwell", Price: money.MustParse("10"), Quantity: 2},
+		{BookID: 102, Author: "Aldous Huxley", Price: money.MustParse("20"), Quantity: 1},
+	}
+	applied := &promotions.Applied{
+		PromotionID:   4,
+		Code:          "ORWELL",
+		Discount:      money.MustParse("5"),
+		LineDiscounts: []money.Amount{money.MustParse("5"), 0},
+	}
+
+	tests := []struct {
+		name       string
+		order      orders.CreateOrderRequest
+		want       *orders.CreateOrderResponse
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error promo code can't be applied",
+			order:      orders.CreateOrderRequest{UserID: 1, PromoCode: "orwell", TotalAmount: money.MustParse("35"), Items: items},
+			wantErrMsg: "promo code ORWELL has reached its usage limit for this user",
+			mockFn: func() {
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 102}).Return(bookMap, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockPromotionsUC.EXPECT().ApplyPromotion(gomock.Any(), int64(1), "orwell", lines).
+					Return(nil, errors.New("promo code ORWELL has reached its usage limit for this user"))
+			},
+		},
+		{
+			name:       "error total without the discount",
+			order:      orders.CreateOrderRequest{UserID: 1, PromoCode: "orwell", TotalAmount: money.MustParse("40"), Items: items},
+			wantErrMsg: "total amount is different, please refresh your cart",
+			mockFn: func() {
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 102}).Return(bookMap, nil)
+				mockPromotionsUC.EXPECT().ApplyPromotion(gomock.Any(), int64(1), "orwell", lines).Return(applied, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+			},
+		},
+		{
+			name:  "success records the discount of the order and its items",
+			order: orders.CreateOrderRequest{UserID: 1, PromoCode: "orwell", TotalAmount: money.MustParse("35"), Items: items},
+			want:  &orders.CreateOrderResponse{OrderID: 3, Status: "NEW"},
+			mockFn: func() {
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101, 102}).Return(bookMap, nil)
+				mockPromotionsUC.EXPECT().ApplyPromotion(gomock.Any(), int64(1), "orwell", lines).Return(applied, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(gomock.Any(), orders.CreateOrderRequest{
+					UserID:       1,
+					PromoCode:    "ORWELL",
+					Subtotal:     money.MustParse("40"),
+					TaxInclusive: true,
+					TotalAmount:  money.MustParse("35"),
+					Items: []orders.CreateOrderItem{
+						{BookID: 101, Quantity: 2, Price: money.MustParse("10"), Discount: money.MustParse("5")},
+						{BookID: 102, Quantity: 1, Price: money.MustParse("20")},
+					},
+					PromotionID: 4,
+					Discount:    money.MustParse("5"),
+				}).Return(&orders.CreateOrderResponse{OrderID: 3, Status: "NEW"}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101), int64(102))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				booksRepository:   mockBooksRepo,
+				ordersRepository:  mockOrdersRepo,
+				promotionsUsecase: mockPromotionsUC,
+				usersRepository:   mockUsersRepo,
+				taxCalculator:     &tax.Calculator{},
+			}
+			got, err := u.InsertOrder(context.Background(), tt.order)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("InsertOrder() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InsertOrder() got = %v, error = %v, want %v", got, err, tt.want)
+			}
+			if tt.order.Items[0].Discount != 0 {
+				t.Errorf("InsertOrder() changed the items of the caller: %v", tt.order.Items)
+			}
+		})
+	}
+}
+
+func Test_usecase_InsertOrderWithQuote(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	mockPromotionsUC := NewMockpromotionsUsecase(mockCtrl)
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	cfg := &configs.Config{Service: configs.Service{SecretKey: "secret"}}
+
+	items := []orders.CreateOrderItem{{BookID: 101, Quantity: 2, Price: money.MustParse("10.99")}}
+	sign := func(token quoteToken) string {
+		quoteID, _ := signer.Sign(orderQuotePurpose, token, "secret")
+		return quoteID
+	}
+	validQuote := sign(quoteToken{UserID: 1, Items: items, Total: money.MustParse("21.98"), ExpiresAt: time.Now().Add(time.Minute).UnixMilli()})
+	promoQuote := sign(quoteToken{UserID: 1, Items: items, PromoCode: "SAVE10", Total: money.MustParse("19.78"), ExpiresAt: time.Now().Add(time.Minute).UnixMilli()})
+
+	tests := []struct {
+		name       string
+		order      orders.CreateOrderRequest
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error quote of another user",
+			order:      orders.CreateOrderRequest{UserID: 2, QuoteID: validQuote},
+			wantErrMsg: "invalid quote",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error tampered quote",
+			order:      orders.CreateOrderRequest{UserID: 1, QuoteID: validQuote + "x"},
+			wantErrMsg: "invalid quote",
+			mockFn:     func() {},
+		},
+		{
+			name: "error expired quote",
+			order: orders.CreateOrderRequest{UserID: 1, QuoteID: sign(quoteToken{
+				UserID: 1, Items: items, Total: money.MustParse("21.98"), ExpiresAt: time.Now().Add(-time.Minute).UnixMilli(),
+			})},
+			wantErrMsg: "quote has expired, please request a new one",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error quote sent with items",
+			order:      orders.CreateOrderRequest{UserID: 1, QuoteID: validQuote, Items: items},
+			wantErrMsg: "send either quote_id or items with total_amount, not both",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error quote sent with a promo code",
+			order:      orders.CreateOrderRequest{UserID: 1, QuoteID: validQuote, PromoCode: "SAVE10"},
+			wantErrMsg: "send the promo_code when requesting the quote, not along with quote_id",
+			mockFn:     func() {},
+		},
+		{
+			name:  "success applies the promo code of the quote",
+			order: orders.CreateOrderRequest{UserID: 1, QuoteID: promoQuote},
+			mockFn: func() {
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(map[int64]books.Model{
+					101: {ID: 101, Author: "George Orwell", Price: money.MustParse("12.99")},
+				}, nil)
+				mockPromotionsUC.EXPECT().ApplyPromotion(gomock.Any(), int64(1), "SAVE10", []promotions.Line{
+					{BookID: 101, Author: "George Orwell", Price: money.MustParse("10.99"), Quantity: 2},
+				}).Return(&promotions.Applied{PromotionID: 4, Code: "SAVE10", Discount: money.MustParse("2.20"), LineDiscounts: []money.Amount{money.MustParse("2.20")}}, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(gomock.Any(), orders.CreateOrderRequest{
+					UserID:       1,
+					QuoteID:      promoQuote,
+					PromoCode:    "SAVE10",
+					Subtotal:     money.MustParse("21.98"),
+					TaxInclusive: true,
+					TotalAmount:  money.MustParse("19.78"),
+					Items:        []orders.CreateOrderItem{{BookID: 101, Quantity: 2, Price: money.MustParse("10.99"), Discount: money.MustParse("2.20")}},
+					PromotionID:  4,
+					Discount:     money.MustParse("2.20"),
+				}).Return(&orders.CreateOrderResponse{OrderID: 2, Status: "NEW"}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101))
+			},
+		},
+		{
+			name:  "success honors the quoted price after a price change",
+			order: orders.CreateOrderRequest{UserID: 1, QuoteID: validQuote},
+			mockFn: func() {
+				mockBooksRepo.EXPECT().GetBookByIDs(gomock.Any(), []int64{101}).Return(map[int64]books.Model{
+					101: {ID: 101, Price: money.MustParse("12.99")},
+				}, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+				mockOrdersRepo.EXPECT().InsertOrder(gomock.Any(), orders.CreateOrderRequest{
+					UserID:       1,
+					QuoteID:      validQuote,
+					Subtotal:     money.MustParse("21.98"),
+					TaxInclusive: true,
+					TotalAmount:  money.MustParse("21.98"),
+					Items:        items,
+				}).Return(&orders.CreateOrderResponse{OrderID: 1, Status: "NEW"}, nil)
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(101))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				booksRepository:   mockBooksRepo,
+				ordersRepository:  mockOrdersRepo,
+				promotionsUsecase: mockPromotionsUC,
+				usersRepository:   mockUsersRepo,
+				taxCalculator:     &tax.Calculator{},
+				cfg:               cfg,
+			}
+			_, err := u.InsertOrder(context.Background(), tt.order)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("InsertOrder() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("InsertOrder() unexpected error = %v", err)
+			}
 		})
 	}
 }
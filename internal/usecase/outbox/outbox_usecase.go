@@ -0,0 +1,157 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/outbox"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/backoff"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/eventsink"
+	"log"
+	"strings"
+	"time"
+)
+
+// Defaults of the dispatcher for the settings left out of the config
+const (
+	defaultPollInterval    = time.Second
+	defaultBatchSize       = 100
+	defaultMaxAttempts     = 10
+	defaultRetryBackoff    = 5 * time.Second
+	defaultMaxRetryBackoff = time.Hour
+	defaultLease           = time.Minute
+)
+
+//go:generate mockgen -package=outbox -source=outbox_usecase.go -destination=outbox_usecase_mock_test.go
+type outboxRepository interface {
+	ClaimEvents(ctx context.Context, limit int, now, leaseUntil int64) ([]outbox.Event, error)
+	UpdateDelivery(ctx context.Context, event outbox.Event) error
+}
+
+// usecase is the dispatcher of the outbox, it delivers every event at least once to each of the sinks
+type usecase struct {
+	outboxRepository outboxRepository
+	sinks            []eventsink.Sink
+	cfg              configs.OutboxConfig
+	now              func() time.Time
+}
+
+func New(outboxRepository outboxRepository, sinks []eventsink.Sink, cfg *configs.Config) *usecase {
+	outboxConfig := cfg.Outbox
+	if outboxConfig.PollInterval <= 0 {
+		outboxConfig.PollInterval = defaultPollInterval
+	}
+	if outboxConfig.BatchSize <= 0 {
+		outboxConfig.BatchSize = defaultBatchSize
+	}
+	if outboxConfig.MaxAttempts <= 0 {
+		outboxConfig.MaxAttempts = defaultMaxAttempts
+	}
+	if outboxConfig.RetryBackoff <= 0 {
+		outboxConfig.RetryBackoff = defaultRetryBackoff
+	}
+	if outboxConfig.MaxRetryBackoff < outboxConfig.RetryBackoff {
+		outboxConfig.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+	if outboxConfig.Lease <= 0 {
+		outboxConfig.Lease = defaultLease
+	}
+
+	return &usecase{
+		outboxRepository: outboxRepository,
+		sinks:            sinks,
+		cfg:              outboxConfig,
+		now:              time.Now,
+	}
+}
+
+// Run dispatches the due events until the context is done, a full batch is followed by the next one right away
+func (u *usecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(u.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := u.Dispatch(ctx)
+		if err != nil {
+			log.Printf("[Outbox] error when claiming events: %v", err)
+		}
+		if err == nil && claimed == u.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch claims a batch of due events and delivers each of them to the sinks that didn't receive it yet,
+// it returns how many events were claimed
+func (u *usecase) Dispatch(ctx context.Context) (int, error) {
+	now := u.now()
+	events, err := u.outboxRepository.ClaimEvents(ctx, u.cfg.BatchSize, now.UnixMilli(), now.Add(u.cfg.Lease).UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		event = u.deliver(ctx, event)
+		// the event is claimed again once its lease is over, so it is delivered again rather than lost
+		err = u.outboxRepository.UpdateDelivery(ctx, event)
+		if err != nil {
+			log.Printf("[Outbox] error when updating the delivery of event %d: %v", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// deliver sends the event to every sink it wasn't delivered to yet and records the outcome of the attempt
+func (u *usecase) deliver(ctx context.Context, event outbox.Event) outbox.Event {
+	message := eventsink.Event{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       event.Payload,
+		CreatedAt:     event.CreatedAt,
+	}
+
+	var failures []string
+	for _, sink := range u.sinks {
+		if event.DeliveredToSink(sink.Name()) {
+			continue
+		}
+		err := sink.Deliver(ctx, message)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sink.Name(), err))
+			continue
+		}
+		event.DeliveredTo = append(event.DeliveredTo, sink.Name())
+	}
+
+	event.Attempts++
+	now := u.now()
+	if len(failures) == 0 {
+		event.Status = outbox.StatusDelivered.String()
+		event.LastError = ""
+		event.DeliveredAt = now.UnixMilli()
+		return event
+	}
+
+	event.LastError = strings.Join(failures, "; ")
+	if event.Attempts >= u.cfg.MaxAttempts {
+		event.Status = outbox.StatusDead.String()
+		log.Printf("[Outbox] event %d %s of %s %d is dead-lettered after %d attempts: %s", event.ID, event.EventType,
+			event.AggregateType, event.AggregateID, event.Attempts, event.LastError)
+		return event
+	}
+	event.NextAttemptAt = now.Add(u.backoff(event.Attempts)).UnixMilli()
+	return event
+}
+
+// backoff is the wait before the next attempt, it doubles on every failed attempt
+func (u *usecase) backoff(attempts int) time.Duration {
+	return backoff.Exponential(attempts, u.cfg.RetryBackoff, u.cfg.MaxRetryBackoff)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: outbox_usecase.go
+
+// Package outbox is a generated GoMock package.
+package outbox
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	outbox "github.com/yeremiaaryo/gotu-assignment/internal/model/outbox"
+)
+
+// MockoutboxRepository is a mock of outboxRepository interface.
+type MockoutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockoutboxRepositoryMockRecorder
+}
+
+// MockoutboxRepositoryMockRecorder is the mock recorder for MockoutboxRepository.
+type MockoutboxRepositoryMockRecorder struct {
+	mock *MockoutboxRepository
+}
+
+// NewMockoutboxRepository creates a new mock instance.
+func NewMockoutboxRepository(ctrl *gomock.Controller) *MockoutboxRepository {
+	mock := &MockoutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockoutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockoutboxRepository) EXPECT() *MockoutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimEvents mocks base method.
+func (m *MockoutboxRepository) ClaimEvents(ctx context.Context, limit int, now, leaseUntil int64) ([]outbox.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimEvents", ctx, limit, now, leaseUntil)
+	ret0, _ := ret[0].([]outbox.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimEvents indicates an expected call of ClaimEvents.
+func (mr *MockoutboxRepositoryMockRecorder) ClaimEvents(ctx, limit, now, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimEvents", reflect.TypeOf((*MockoutboxRepository)(nil).ClaimEvents), ctx, limit, now, leaseUntil)
+}
+
+// UpdateDelivery mocks base method.
+func (m *MockoutboxRepository) UpdateDelivery(ctx context.Context, event outbox.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDelivery", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDelivery indicates an expected call of UpdateDelivery.
+func (mr *MockoutboxRepositoryMockRecorder) UpdateDelivery(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDelivery", reflect.TypeOf((*MockoutboxRepository)(nil).UpdateDelivery), ctx, event)
+}
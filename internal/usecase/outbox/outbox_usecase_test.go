@@ -0,0 +1,197 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/outbox"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/eventsink"
+	"testing"
+	"time"
+)
+
+// fakeSink records the events it got and fails while err is set
+type fakeSink struct {
+	name      string
+	err       error
+	delivered []eventsink.Event
+}
+
+func (f *fakeSink) Name() string {
+	return f.name
+}
+
+func (f *fakeSink) Deliver(ctx context.Context, event eventsink.Event) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.delivered = append(f.delivered, event)
+	return nil
+}
+
+func Test_usecase_Dispatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockOutboxRepo := NewMockoutboxRepository(mockCtrl)
+	cfg := &configs.Config{Outbox: configs.OutboxConfig{BatchSize: 10, MaxAttempts: 3, RetryBackoff: 5 * time.Second,
+		MaxRetryBackoff: time.Minute, Lease: time.Minute}}
+
+	event := outbox.Event{
+		ID:            3,
+		AggregateType: "order",
+		AggregateID:   1,
+		EventType:     "OrderCreated",
+		Payload:       json.RawMessage(`{"order_id":1}`),
+		Status:        "PENDING",
+		NextAttemptAt: 61000,
+		CreatedAt:     900,
+	}
+	message := eventsink.Event{ID: 3, Type: "OrderCreated", AggregateType: "order", AggregateID: 1, Payload: json.RawMessage(`{"order_id":1}`), CreatedAt: 900}
+
+	tests := []struct {
+		name         string
+		events       []outbox.Event
+		webhookErr   error
+		want         int
+		wantErr      bool
+		wantLog      []eventsink.Event
+		wantWebhook  []eventsink.Event
+		wantDelivery []outbox.Event
+		updateErr    error
+		claimErr     error
+	}{
+		{
+			name:     "error on claim",
+			claimErr: errors.New("connection reset"),
+			wantErr:  true,
+		},
+		{
+			name:   "nothing is due",
+			events: []outbox.Event{},
+		},
+		{
+			name:        "success delivers to every sink",
+			events:      []outbox.Event{event},
+			want:        1,
+			wantLog:     []eventsink.Event{message},
+			wantWebhook: []eventsink.Event{message},
+			wantDelivery: []outbox.Event{func() outbox.Event {
+				delivered := event
+				delivered.Status = "DELIVERED"
+				delivered.Attempts = 1
+				delivered.DeliveredTo = []string{"log", "webhook"}
+				delivered.DeliveredAt = 1000
+				return delivered
+			}()},
+		},
+		{
+			name:       "failed sink is retried later with a backoff",
+			events:     []outbox.Event{event},
+			webhookErr: errors.New("webhook responded with status 502"),
+			want:       1,
+			wantLog:    []eventsink.Event{message},
+			wantDelivery: []outbox.Event{func() outbox.Event {
+				failed := event
+				failed.Attempts = 1
+				failed.DeliveredTo = []string{"log"}
+				failed.LastError = "webhook: webhook responded with status 502"
+				failed.NextAttemptAt = 6000
+				return failed
+			}()},
+		},
+		{
+			name: "retry only goes to the sinks that didn't get the event",
+			events: []outbox.Event{func() outbox.Event {
+				retried := event
+				retried.Attempts = 1
+				retried.DeliveredTo = []string{"log"}
+				retried.LastError = "webhook: webhook responded with status 502"
+				return retried
+			}()},
+			want:        1,
+			wantWebhook: []eventsink.Event{message},
+			wantDelivery: []outbox.Event{func() outbox.Event {
+				delivered := event
+				delivered.Status = "DELIVERED"
+				delivered.Attempts = 2
+				delivered.DeliveredTo = []string{"log", "webhook"}
+				delivered.DeliveredAt = 1000
+				return delivered
+			}()},
+		},
+		{
+			name: "last failed attempt dead-letters the event",
+			events: []outbox.Event{func() outbox.Event {
+				retried := event
+				retried.Attempts = 2
+				retried.DeliveredTo = []string{"log"}
+				return retried
+			}()},
+			webhookErr: errors.New("timeout"),
+			want:       1,
+			wantDelivery: []outbox.Event{func() outbox.Event {
+				dead := event
+				dead.Status = "DEAD"
+				dead.Attempts = 3
+				dead.DeliveredTo = []string{"log"}
+				dead.LastError = "webhook: timeout"
+				return dead
+			}()},
+		},
+		{
+			name:        "error on update keeps dispatching the batch",
+			events:      []outbox.Event{event, func() outbox.Event { next := event; next.ID = 4; return next }()},
+			updateErr:   errors.New("connection reset"),
+			want:        2,
+			wantLog:     []eventsink.Event{message, func() eventsink.Event { next := message; next.ID = 4; return next }()},
+			wantWebhook: []eventsink.Event{message, func() eventsink.Event { next := message; next.ID = 4; return next }()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logSink := &fakeSink{name: "log"}
+			webhookSink := &fakeSink{name: "webhook", err: tt.webhookErr}
+			u := New(mockOutboxRepo, []eventsink.Sink{logSink, webhookSink}, cfg)
+			u.now = func() time.Time { return time.UnixMilli(1000) }
+
+			mockOutboxRepo.EXPECT().ClaimEvents(gomock.Any(), 10, int64(1000), int64(61000)).Return(tt.events, tt.claimErr)
+			for _, delivery := range tt.wantDelivery {
+				mockOutboxRepo.EXPECT().UpdateDelivery(gomock.Any(), delivery).Return(nil)
+			}
+			if tt.updateErr != nil {
+				mockOutboxRepo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).Return(tt.updateErr).Times(len(tt.events))
+			}
+
+			got, err := u.Dispatch(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantLog, logSink.delivered)
+			assert.Equal(t, tt.wantWebhook, webhookSink.delivered)
+		})
+	}
+}
+
+func Test_usecase_backoff(t *testing.T) {
+	u := New(nil, nil, &configs.Config{Outbox: configs.OutboxConfig{RetryBackoff: 5 * time.Second, MaxRetryBackoff: time.Minute}})
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 5 * time.Second},
+		{attempts: 2, want: 10 * time.Second},
+		{attempts: 4, want: 40 * time.Second},
+		{attempts: 5, want: time.Minute},
+		{attempts: 50, want: time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, u.backoff(tt.attempts), "attempts %d", tt.attempts)
+	}
+}
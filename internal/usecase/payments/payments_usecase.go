@@ -0,0 +1,324 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/payment"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"log"
+	"time"
+)
+
+//go:generate mockgen -package=payments -source=payments_usecase.go -destination=payments_usecase_mock_test.go
+type paymentsRepository interface {
+	InsertPayment(ctx context.Context, model payments.Model) (*payments.Model, error)
+	GetPaymentsByOrderID(ctx context.Context, orderID int64) ([]payments.Model, error)
+	GetPaymentByReference(ctx context.Context, provider, reference string) (*payments.Model, error)
+	UpdatePaymentStatus(ctx context.Context, id int64, from, to payments.Status, failureReason string, updatedAt int64) error
+	RefundPayment(ctx context.Context, id int64, amount money.Amount, status payments.Status, updatedAt int64) error
+}
+
+type ordersRepository interface {
+	GetOrderByID(ctx context.Context, id int64) (*orders.Model, error)
+	UpdateOrderStatus(ctx context.Context, transition orders.StatusTransition) error
+}
+
+type provider interface {
+	Name() string
+	Authorize(ctx context.Context, req payment.AuthorizeRequest) (payment.Transaction, error)
+	Capture(ctx context.Context, reference string, amount money.Amount) (payment.Transaction, error)
+	Refund(ctx context.Context, reference string, amount money.Amount) (payment.Transaction, error)
+	Void(ctx context.Context, reference string) (payment.Transaction, error)
+	VerifyWebhook(payload []byte, signature string) (payment.Event, error)
+}
+
+type usecase struct {
+	paymentsRepository paymentsRepository
+	ordersRepository   ordersRepository
+	provider           provider
+}
+
+func New(paymentsRepository paymentsRepository, ordersRepository ordersRepository, provider provider) *usecase {
+	return &usecase{
+		paymentsRepository: paymentsRepository,
+		ordersRepository:   ordersRepository,
+		provider:           provider,
+	}
+}
+
+// Pay authorizes and captures the total of the order. A capture the provider reports later through the webhook leaves
+// the payment AUTHORIZED and the order AWAITING_PAYMENT until the webhook comes in.
+func (u *usecase) Pay(ctx context.Context, req payments.PayRequest) (*payments.Model, error) {
+	order, err := u.ordersRepository.GetOrderByID(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	// the order of another user is not found either, so callers can't probe which order ids exist
+	if order == nil || order.UserID != req.UserID {
+		return nil, fmt.Errorf("order with id: %d is not found", req.OrderID)
+	}
+
+	current := orders.OrderStatus(order.Status)
+	if current != orders.OrderStatusNew && current != orders.OrderStatusAwaitingPayment {
+		return nil, fmt.Errorf("order with id: %d can't be paid, its status is %s", req.OrderID, current)
+	}
+
+	// checked again by the database when the payment is inserted, this only saves a call to the provider
+	existing, err := u.paymentsRepository.GetPaymentsByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range existing {
+		if payments.Status(p.Status).IsActive() {
+			return nil, fmt.Errorf("order with id: %d is already paid or has a payment in progress", req.OrderID)
+		}
+	}
+
+	if current == orders.OrderStatusNew {
+		updatedAt := util.NextUpdatedAt(order.UpdatedAt)
+		err = u.ordersRepository.UpdateOrderStatus(ctx, orders.StatusTransition{
+			OrderID:           order.ID,
+			From:              current,
+			To:                orders.OrderStatusAwaitingPayment,
+			ExpectedUpdatedAt: order.UpdatedAt,
+			UpdatedAt:         updatedAt,
+			ActorID:           req.UserID,
+			ActorRole:         users.RoleCustomer.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	authorization, err := u.provider.Authorize(ctx, payment.AuthorizeRequest{
+		OrderID: order.ID,
+		Amount:  order.TotalAmount,
+		Token:   req.PaymentToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	model := payments.Model{
+		OrderID:       order.ID,
+		Provider:      u.provider.Name(),
+		Reference:     authorization.Reference,
+		Amount:        order.TotalAmount,
+		Status:        payments.StatusAuthorized.String(),
+		FailureReason: authorization.FailureReason,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if authorization.Status == payment.StatusFailed {
+		// the declined attempt is kept so the customer and support can see why it failed
+		model.Status = payments.StatusFailed.String()
+		_, err = u.paymentsRepository.InsertPayment(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("payment was declined: %s", authorization.FailureReason)
+	}
+
+	inserted, err := u.paymentsRepository.InsertPayment(ctx, model)
+	if err != nil {
+		// a concurrent payment of the order won, the hold of this one is given back so the customer isn't charged twice
+		_, voidErr := u.provider.Void(ctx, authorization.Reference)
+		if voidErr != nil {
+			log.Printf("[Pay] error when voiding payment %s: %v", authorization.Reference, voidErr)
+		}
+		return nil, err
+	}
+
+	capture, err := u.provider.Capture(ctx, inserted.Reference, inserted.Amount)
+	if err != nil {
+		return nil, err
+	}
+	switch capture.Status {
+	case payment.StatusCaptured:
+		err = u.markCaptured(ctx, inserted, inserted.Amount)
+		if err != nil {
+			return nil, err
+		}
+	case payment.StatusFailed:
+		err = u.markFailed(ctx, inserted, capture.FailureReason)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("payment was declined: %s", capture.FailureReason)
+	}
+	return inserted, nil
+}
+
+// HandleWebhook applies an event of the provider to its payment. Providers retry webhooks, so an event for a payment
+// that already moved on is ignored.
+func (u *usecase) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	event, err := u.provider.VerifyWebhook(payload, signature)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			return err
+		}
+		return fmt.Errorf("invalid webhook payload: %v", err)
+	}
+
+	model, err := u.paymentsRepository.GetPaymentByReference(ctx, u.provider.Name(), event.Reference)
+	if err != nil {
+		return err
+	}
+	if model == nil {
+		return fmt.Errorf("payment with reference: %s is not found", event.Reference)
+	}
+
+	switch event.Type {
+	case payment.EventCaptured:
+		return u.markCaptured(ctx, model, event.Amount)
+	case payment.EventFailed:
+		return u.markFailed(ctx, model, event.FailureReason)
+	default:
+		log.Printf("[HandleWebhook] ignoring event %s of payment %s", event.Type, event.Reference)
+		return nil
+	}
+}
+
+// ReleasePayment gives the money of a cancelled order back, an authorization is voided and a capture is refunded
+func (u *usecase) ReleasePayment(ctx context.Context, orderID int64) error {
+	paymentList, err := u.paymentsRepository.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for i := range paymentList {
+		p := &paymentList[i]
+		switch payments.Status(p.Status) {
+		case payments.StatusAuthorized:
+			transaction, err := u.provider.Void(ctx, p.Reference)
+			if err != nil {
+				return err
+			}
+			if transaction.Status == payment.StatusFailed {
+				return fmt.Errorf("payment with reference: %s can't be released: %s", p.Reference, transaction.FailureReason)
+			}
+			err = u.paymentsRepository.UpdatePaymentStatus(ctx, p.ID, payments.StatusAuthorized, payments.StatusVoided, "", util.NextUpdatedAt(p.UpdatedAt))
+			if err != nil {
+				return err
+			}
+		case payments.StatusCaptured:
+			err = u.refund(ctx, p, p.Amount.Sub(p.RefundedAmount))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RefundPayment refunds the amount of the order from its captured payment, the rest of the capture can be refunded later
+func (u *usecase) RefundPayment(ctx context.Context, orderID int64, amount money.Amount) error {
+	paymentList, err := u.paymentsRepository.GetPaymentsByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for i := range paymentList {
+		p := &paymentList[i]
+		if payments.Status(p.Status) == payments.StatusCaptured && p.Amount.Sub(p.RefundedAmount) >= amount {
+			return u.refund(ctx, p, amount)
+		}
+	}
+	return fmt.Errorf("order with id: %d has no captured payment to refund %s from", orderID, amount)
+}
+
+// refund refunds the amount at the provider and records it on the payment, which is REFUNDED once nothing is left
+func (u *usecase) refund(ctx context.Context, model *payments.Model, amount money.Amount) error {
+	transaction, err := u.provider.Refund(ctx, model.Reference, amount)
+	if err != nil {
+		return err
+	}
+	if transaction.Status == payment.StatusFailed {
+		return fmt.Errorf("refund of payment with reference: %s failed: %s", model.Reference, transaction.FailureReason)
+	}
+
+	status := payments.StatusCaptured
+	if model.RefundedAmount.Add(amount) == model.Amount {
+		status = payments.StatusRefunded
+	}
+	updatedAt := util.NextUpdatedAt(model.UpdatedAt)
+	err = u.paymentsRepository.RefundPayment(ctx, model.ID, amount, status, updatedAt)
+	if err != nil {
+		return err
+	}
+	model.RefundedAmount = model.RefundedAmount.Add(amount)
+	model.Status = status.String()
+	model.UpdatedAt = updatedAt
+	return nil
+}
+
+// markCaptured settles the payment and moves its order to PAID. A capture of another amount than the total of the order
+// leaves both untouched for support to reconcile.
+func (u *usecase) markCaptured(ctx context.Context, model *payments.Model, captured money.Amount) error {
+	status := payments.Status(model.Status)
+	// a capture of a captured payment is a retried webhook, the order might still be waiting for it when the first
+	// delivery failed halfway
+	if status != payments.StatusAuthorized && status != payments.StatusCaptured {
+		log.Printf("[markCaptured] ignoring capture of payment %s, its status is %s", model.Reference, model.Status)
+		return nil
+	}
+
+	order, err := u.ordersRepository.GetOrderByID(ctx, model.OrderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return fmt.Errorf("order with id: %d is not found", model.OrderID)
+	}
+	if captured != order.TotalAmount {
+		return fmt.Errorf("captured amount %s of payment %s doesn't match the total %s of order with id: %d",
+			captured, model.Reference, order.TotalAmount, order.ID)
+	}
+
+	if status == payments.StatusAuthorized {
+		updatedAt := util.NextUpdatedAt(model.UpdatedAt)
+		err = u.paymentsRepository.UpdatePaymentStatus(ctx, model.ID, payments.StatusAuthorized, payments.StatusCaptured, "", updatedAt)
+		if err != nil {
+			return err
+		}
+		model.Status = payments.StatusCaptured.String()
+		model.UpdatedAt = updatedAt
+	}
+
+	if orders.OrderStatus(order.Status) != orders.OrderStatusAwaitingPayment {
+		return nil
+	}
+	return u.ordersRepository.UpdateOrderStatus(ctx, orders.StatusTransition{
+		OrderID:           order.ID,
+		From:              orders.OrderStatusAwaitingPayment,
+		To:                orders.OrderStatusPaid,
+		ExpectedUpdatedAt: order.UpdatedAt,
+		UpdatedAt:         util.NextUpdatedAt(order.UpdatedAt),
+		ActorRole:         orders.ActorRoleSystem,
+		Note:              fmt.Sprintf("payment %s captured", model.Reference),
+	})
+}
+
+// markFailed fails a payment that is still authorized, the order stays AWAITING_PAYMENT so it can be paid again
+func (u *usecase) markFailed(ctx context.Context, model *payments.Model, reason string) error {
+	if payments.Status(model.Status) != payments.StatusAuthorized {
+		log.Printf("[markFailed] ignoring failure of payment %s, its status is %s", model.Reference, model.Status)
+		return nil
+	}
+
+	updatedAt := util.NextUpdatedAt(model.UpdatedAt)
+	err := u.paymentsRepository.UpdatePaymentStatus(ctx, model.ID, payments.StatusAuthorized, payments.StatusFailed, reason, updatedAt)
+	if err != nil {
+		return err
+	}
+	model.Status = payments.StatusFailed.String()
+	model.FailureReason = reason
+	model.UpdatedAt = updatedAt
+	return nil
+}
@@ -0,0 +1,276 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: payments_usecase.go
+
+// Package payments is a generated GoMock package.
+package payments
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	orders "github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	payments "github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+	money "github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	payment "github.com/yeremiaaryo/gotu-assignment/pkg/payment"
+)
+
+// MockpaymentsRepository is a mock of paymentsRepository interface.
+type MockpaymentsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockpaymentsRepositoryMockRecorder
+}
+
+// MockpaymentsRepositoryMockRecorder is the mock recorder for MockpaymentsRepository.
+type MockpaymentsRepositoryMockRecorder struct {
+	mock *MockpaymentsRepository
+}
+
+// NewMockpaymentsRepository creates a new mock instance.
+func NewMockpaymentsRepository(ctrl *gomock.Controller) *MockpaymentsRepository {
+	mock := &MockpaymentsRepository{ctrl: ctrl}
+	mock.recorder = &MockpaymentsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpaymentsRepository) EXPECT() *MockpaymentsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetPaymentByReference mocks base method.
+func (m *MockpaymentsRepository) GetPaymentByReference(ctx context.Context, provider, reference string) (*payments.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentByReference", ctx, provider, reference)
+	ret0, _ := ret[0].(*payments.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentByReference indicates an expected call of GetPaymentByReference.
+func (mr *MockpaymentsRepositoryMockRecorder) GetPaymentByReference(ctx, provider, reference interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentByReference", reflect.TypeOf((*MockpaymentsRepository)(nil).GetPaymentByReference), ctx, provider, reference)
+}
+
+// GetPaymentsByOrderID mocks base method.
+func (m *MockpaymentsRepository) GetPaymentsByOrderID(ctx context.Context, orderID int64) ([]payments.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentsByOrderID", ctx, orderID)
+	ret0, _ := ret[0].([]payments.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentsByOrderID indicates an expected call of GetPaymentsByOrderID.
+func (mr *MockpaymentsRepositoryMockRecorder) GetPaymentsByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentsByOrderID", reflect.TypeOf((*MockpaymentsRepository)(nil).GetPaymentsByOrderID), ctx, orderID)
+}
+
+// InsertPayment mocks base method.
+func (m *MockpaymentsRepository) InsertPayment(ctx context.Context, model payments.Model) (*payments.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertPayment", ctx, model)
+	ret0, _ := ret[0].(*payments.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertPayment indicates an expected call of InsertPayment.
+func (mr *MockpaymentsRepositoryMockRecorder) InsertPayment(ctx, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPayment", reflect.TypeOf((*MockpaymentsRepository)(nil).InsertPayment), ctx, model)
+}
+
+// RefundPayment mocks base method.
+func (m *MockpaymentsRepository) RefundPayment(ctx context.Context, id int64, amount money.Amount, status payments.Status, updatedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPayment", ctx, id, amount, status, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefundPayment indicates an expected call of RefundPayment.
+func (mr *MockpaymentsRepositoryMockRecorder) RefundPayment(ctx, id, amount, status, updatedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPayment", reflect.TypeOf((*MockpaymentsRepository)(nil).RefundPayment), ctx, id, amount, status, updatedAt)
+}
+
+// UpdatePaymentStatus mocks base method.
+func (m *MockpaymentsRepository) UpdatePaymentStatus(ctx context.Context, id int64, from, to payments.Status, failureReason string, updatedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePaymentStatus", ctx, id, from, to, failureReason, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePaymentStatus indicates an expected call of UpdatePaymentStatus.
+func (mr *MockpaymentsRepositoryMockRecorder) UpdatePaymentStatus(ctx, id, from, to, failureReason, updatedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePaymentStatus", reflect.TypeOf((*MockpaymentsRepository)(nil).UpdatePaymentStatus), ctx, id, from, to, failureReason, updatedAt)
+}
+
+// MockordersRepository is a mock of ordersRepository interface.
+type MockordersRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockordersRepositoryMockRecorder
+}
+
+// MockordersRepositoryMockRecorder is the mock recorder for MockordersRepository.
+type MockordersRepositoryMockRecorder struct {
+	mock *MockordersRepository
+}
+
+// NewMockordersRepository creates a new mock instance.
+func NewMockordersRepository(ctrl *gomock.Controller) *MockordersRepository {
+	mock := &MockordersRepository{ctrl: ctrl}
+	mock.recorder = &MockordersRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockordersRepository) EXPECT() *MockordersRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetOrderByID mocks base method.
+func (m *MockordersRepository) GetOrderByID(ctx context.Context, id int64) (*orders.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByID", ctx, id)
+	ret0, _ := ret[0].(*orders.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByID indicates an expected call of GetOrderByID.
+func (mr *MockordersRepositoryMockRecorder) GetOrderByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockordersRepository)(nil).GetOrderByID), ctx, id)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockordersRepository) UpdateOrderStatus(ctx context.Context, transition orders.StatusTransition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, transition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockordersRepositoryMockRecorder) UpdateOrderStatus(ctx, transition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockordersRepository)(nil).UpdateOrderStatus), ctx, transition)
+}
+
+// Mockprovider is a mock of provider interface.
+type Mockprovider struct {
+	ctrl     *gomock.Controller
+	recorder *MockproviderMockRecorder
+}
+
+// MockproviderMockRecorder is the mock recorder for Mockprovider.
+type MockproviderMockRecorder struct {
+	mock *Mockprovider
+}
+
+// NewMockprovider creates a new mock instance.
+func NewMockprovider(ctrl *gomock.Controller) *Mockprovider {
+	mock := &Mockprovider{ctrl: ctrl}
+	mock.recorder = &MockproviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockprovider) EXPECT() *MockproviderMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *Mockprovider) Authorize(ctx context.Context, req payment.AuthorizeRequest) (payment.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, req)
+	ret0, _ := ret[0].(payment.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockproviderMockRecorder) Authorize(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*Mockprovider)(nil).Authorize), ctx, req)
+}
+
+// Capture mocks base method.
+func (m *Mockprovider) Capture(ctx context.Context, reference string, amount money.Amount) (payment.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capture", ctx, reference, amount)
+	ret0, _ := ret[0].(payment.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Capture indicates an expected call of Capture.
+func (mr *MockproviderMockRecorder) Capture(ctx, reference, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capture", reflect.TypeOf((*Mockprovider)(nil).Capture), ctx, reference, amount)
+}
+
+// Name mocks base method.
+func (m *Mockprovider) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockproviderMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*Mockprovider)(nil).Name))
+}
+
+// Refund mocks base method.
+func (m *Mockprovider) Refund(ctx context.Context, reference string, amount money.Amount) (payment.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, reference, amount)
+	ret0, _ := ret[0].(payment.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockproviderMockRecorder) Refund(ctx, reference, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*Mockprovider)(nil).Refund), ctx, reference, amount)
+}
+
+// VerifyWebhook mocks base method.
+func (m *Mockprovider) VerifyWebhook(payload []byte, signature string) (payment.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyWebhook", payload, signature)
+	ret0, _ := ret[0].(payment.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyWebhook indicates an expected call of VerifyWebhook.
+func (mr *MockproviderMockRecorder) VerifyWebhook(payload, signature interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyWebhook", reflect.TypeOf((*Mockprovider)(nil).VerifyWebhook), payload, signature)
+}
+
+// Void mocks base method.
+func (m *Mockprovider) Void(ctx context.Context, reference string) (payment.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Void", ctx, reference)
+	ret0, _ := ret[0].(payment.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Void indicates an expected call of Void.
+func (mr *MockproviderMockRecorder) Void(ctx, reference interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Void", reflect.TypeOf((*Mockprovider)(nil).Void), ctx, reference)
+}
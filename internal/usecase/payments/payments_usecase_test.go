@@ -0,0 +1,425 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/payments"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/payment"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/payment/fake"
+	"testing"
+)
+
+func Test_usecase_Pay(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPaymentsRepo := NewMockpaymentsRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+
+	total := money.MustParse("19.98")
+	newOrder := &orders.Model{ID: 1, UserID: 2, TotalAmount: total, Status: "NEW", UpdatedAt: 1000}
+	awaitingOrder := &orders.Model{ID: 1, UserID: 2, TotalAmount: total, Status: "AWAITING_PAYMENT", UpdatedAt: 1001}
+
+	insertPayment := func(status string) {
+		mockPaymentsRepo.EXPECT().InsertPayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, model payments.Model) (*payments.Model, error) {
+			if model.OrderID != 1 || model.Provider != "fake" || model.Reference != "fake_1_1" || model.Amount != total || model.Status != status {
+				t.Errorf("InsertPayment() unexpected payment = %+v", model)
+			}
+			model.ID = 7
+			return &model, nil
+		})
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error order not found",
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(nil, nil)
+			},
+		},
+		{
+			name:       "error order of another user is not found",
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 3, Status: "NEW"}, nil)
+			},
+		},
+		{
+			name:       "error order already paid",
+			wantErrMsg: "order with id: 1 can't be paid, its status is PAID",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "PAID"}, nil)
+			},
+		},
+		{
+			name:       "error payment in progress",
+			wantErrMsg: "order with id: 1 is already paid or has a payment in progress",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(awaitingOrder, nil)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{
+					{ID: 6, Status: "FAILED"},
+					{ID: 7, Status: "AUTHORIZED"},
+				}, nil)
+			},
+		},
+		{
+			name:       "error concurrent payment won voids the authorization",
+			token:      "tok_visa",
+			wantErrMsg: "order with id: 1 is already paid or has a payment in progress",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(awaitingOrder, nil)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{}, nil)
+				mockPaymentsRepo.EXPECT().InsertPayment(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("order with id: 1 is already paid or has a payment in progress"))
+			},
+		},
+		{
+			name:       "error order updated by another request",
+			token:      "tok_visa",
+			wantErrMsg: "order was updated by another request, please reload it",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(newOrder, nil)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{}, nil)
+				mockOrdersRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).Return(errors.New("order was updated by another request, please reload it"))
+			},
+		},
+		{
+			name:       "error declined",
+			token:      fake.TokenDeclined,
+			wantErrMsg: "payment was declined: card declined",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(awaitingOrder, nil)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{}, nil)
+				insertPayment("FAILED")
+			},
+		},
+		{
+			name:       "error capture failed",
+			token:      fake.TokenCaptureFailed,
+			wantErrMsg: "payment was declined: capture failed",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(awaitingOrder, nil)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{}, nil)
+				insertPayment("AUTHORIZED")
+				mockPaymentsRepo.EXPECT().UpdatePaymentStatus(gomock.Any(), int64(7), payments.StatusAuthorized, payments.StatusFailed, "capture failed", gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:       "success capture pending",
+			token:      fake.TokenCapturePending,
+			wantStatus: "AUTHORIZED",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(awaitingOrder, nil)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{{ID: 6, Status: "FAILED"}}, nil)
+				insertPayment("AUTHORIZED")
+			},
+		},
+		{
+			name:       "success captured",
+			token:      "tok_visa",
+			wantStatus: "CAPTURED",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(newOrder, nil)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{}, nil)
+				mockOrdersRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, transition orders.StatusTransition) error {
+					if transition.From != orders.OrderStatusNew || transition.To != orders.OrderStatusAwaitingPayment ||
+						transition.ExpectedUpdatedAt != 1000 || transition.ActorID != 2 || transition.ActorRole != "customer" {
+						t.Errorf("UpdateOrderStatus() unexpected transition = %+v", transition)
+					}
+					return nil
+				})
+				insertPayment("AUTHORIZED")
+				mockPaymentsRepo.EXPECT().UpdatePaymentStatus(gomock.Any(), int64(7), payments.StatusAuthorized, payments.StatusCaptured, "", gomock.Any()).Return(nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(awaitingOrder, nil)
+				mockOrdersRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, transition orders.StatusTransition) error {
+					if transition.From != orders.OrderStatusAwaitingPayment || transition.To != orders.OrderStatusPaid ||
+						transition.ExpectedUpdatedAt != 1001 || transition.ActorID != 0 || transition.ActorRole != "system" ||
+						transition.Note != "payment fake_1_1 captured" {
+						t.Errorf("UpdateOrderStatus() unexpected transition = %+v", transition)
+					}
+					return nil
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				paymentsRepository: mockPaymentsRepo,
+				ordersRepository:   mockOrdersRepo,
+				provider:           fake.New("secret"),
+			}
+			got, err := u.Pay(context.Background(), payments.PayRequest{OrderID: 1, UserID: 2, PaymentToken: tt.token})
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("Pay() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Pay() unexpected error = %v", err)
+				return
+			}
+			if got.ID != 7 || got.Status != tt.wantStatus {
+				t.Errorf("Pay() got = %+v, want status %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func Test_usecase_HandleWebhook(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPaymentsRepo := NewMockpaymentsRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+
+	provider := fake.New("secret")
+	total := money.MustParse("19.98")
+	captured, capturedSignature, _ := provider.SignEvent(payment.Event{Type: payment.EventCaptured, Reference: "fake_1_1", Amount: total})
+	failed, failedSignature, _ := provider.SignEvent(payment.Event{Type: payment.EventFailed, Reference: "fake_1_1", FailureReason: "insufficient funds"})
+	unknown, unknownSignature, _ := provider.SignEvent(payment.Event{Type: "payment.disputed", Reference: "fake_1_1"})
+
+	authorized := func() *payments.Model {
+		return &payments.Model{ID: 7, OrderID: 1, Provider: "fake", Reference: "fake_1_1", Amount: total, Status: "AUTHORIZED", UpdatedAt: 1000}
+	}
+
+	tests := []struct {
+		name       string
+		payload    []byte
+		signature  string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error invalid signature",
+			payload:    captured,
+			signature:  failedSignature,
+			wantErrMsg: "invalid webhook signature",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error payment not found",
+			payload:    captured,
+			signature:  capturedSignature,
+			wantErrMsg: "payment with reference: fake_1_1 is not found",
+			mockFn: func() {
+				mockPaymentsRepo.EXPECT().GetPaymentByReference(gomock.Any(), "fake", "fake_1_1").Return(nil, nil)
+			},
+		},
+		{
+			name:       "error captured amount differs from the order total",
+			payload:    captured,
+			signature:  capturedSignature,
+			wantErrMsg: "captured amount 19.98 of payment fake_1_1 doesn't match the total 29.98 of order with id: 1",
+			mockFn: func() {
+				mockPaymentsRepo.EXPECT().GetPaymentByReference(gomock.Any(), "fake", "fake_1_1").Return(authorized(), nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).
+					Return(&orders.Model{ID: 1, UserID: 2, TotalAmount: money.MustParse("29.98"), Status: "AWAITING_PAYMENT", UpdatedAt: 1001}, nil)
+			},
+		},
+		{
+			name:      "success captured",
+			payload:   captured,
+			signature: capturedSignature,
+			mockFn: func() {
+				mockPaymentsRepo.EXPECT().GetPaymentByReference(gomock.Any(), "fake", "fake_1_1").Return(authorized(), nil)
+				mockPaymentsRepo.EXPECT().UpdatePaymentStatus(gomock.Any(), int64(7), payments.StatusAuthorized, payments.StatusCaptured, "", gomock.Any()).Return(nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, TotalAmount: total, Status: "AWAITING_PAYMENT", UpdatedAt: 1001}, nil)
+				mockOrdersRepo.EXPECT().UpdateOrderStatus(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, transition orders.StatusTransition) error {
+					if transition.From != orders.OrderStatusAwaitingPayment || transition.To != orders.OrderStatusPaid || transition.ActorRole != "system" {
+						t.Errorf("UpdateOrderStatus() unexpected transition = %+v", transition)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:      "success retried capture of a paid order",
+			payload:   captured,
+			signature: capturedSignature,
+			mockFn: func() {
+				model := authorized()
+				model.Status = "CAPTURED"
+				mockPaymentsRepo.EXPECT().GetPaymentByReference(gomock.Any(), "fake", "fake_1_1").Return(model, nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, TotalAmount: total, Status: "PAID", UpdatedAt: 1002}, nil)
+			},
+		},
+		{
+			name:      "success capture of a voided payment is ignored",
+			payload:   captured,
+			signature: capturedSignature,
+			mockFn: func() {
+				model := authorized()
+				model.Status = "VOIDED"
+				mockPaymentsRepo.EXPECT().GetPaymentByReference(gomock.Any(), "fake", "fake_1_1").Return(model, nil)
+			},
+		},
+		{
+			name:      "success failed",
+			payload:   failed,
+			signature: failedSignature,
+			mockFn: func() {
+				mockPaymentsRepo.EXPECT().GetPaymentByReference(gomock.Any(), "fake", "fake_1_1").Return(authorized(), nil)
+				mockPaymentsRepo.EXPECT().UpdatePaymentStatus(gomock.Any(), int64(7), payments.StatusAuthorized, payments.StatusFailed, "insufficient funds", gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:      "success unknown event is ignored",
+			payload:   unknown,
+			signature: unknownSignature,
+			mockFn: func() {
+				mockPaymentsRepo.EXPECT().GetPaymentByReference(gomock.Any(), "fake", "fake_1_1").Return(authorized(), nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				paymentsRepository: mockPaymentsRepo,
+				ordersRepository:   mockOrdersRepo,
+				provider:           provider,
+			}
+			err := u.HandleWebhook(context.Background(), tt.payload, tt.signature)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("HandleWebhook() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("HandleWebhook() unexpected error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_usecase_ReleasePayment(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPaymentsRepo := NewMockpaymentsRepository(mockCtrl)
+	total := money.MustParse("19.98")
+
+	t.Run("voids the authorization and refunds the capture", func(t *testing.T) {
+		provider := fake.New("secret")
+		authorized, _ := provider.Authorize(context.Background(), payment.AuthorizeRequest{OrderID: 1, Amount: total, Token: fake.TokenCapturePending})
+		captured, _ := provider.Authorize(context.Background(), payment.AuthorizeRequest{OrderID: 1, Amount: total, Token: "tok_visa"})
+		_, _ = provider.Capture(context.Background(), captured.Reference, total)
+		_, _ = provider.Refund(context.Background(), captured.Reference, money.MustParse("9.99"))
+
+		mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{
+			{ID: 5, Reference: "fake_1_0", Status: "FAILED"},
+			{ID: 6, Reference: authorized.Reference, Amount: total, Status: "AUTHORIZED", UpdatedAt: 1000},
+			{ID: 7, Reference: captured.Reference, Amount: total, RefundedAmount: money.MustParse("9.99"), Status: "CAPTURED", UpdatedAt: 1000},
+		}, nil)
+		mockPaymentsRepo.EXPECT().UpdatePaymentStatus(gomock.Any(), int64(6), payments.StatusAuthorized, payments.StatusVoided, "", gomock.Any()).Return(nil)
+		mockPaymentsRepo.EXPECT().RefundPayment(gomock.Any(), int64(7), money.MustParse("9.99"), payments.StatusRefunded, gomock.Any()).Return(nil)
+
+		u := &usecase{paymentsRepository: mockPaymentsRepo, provider: provider}
+		if err := u.ReleasePayment(context.Background(), 1); err != nil {
+			t.Errorf("ReleasePayment() unexpected error = %v", err)
+		}
+	})
+
+	t.Run("error provider doesn't know the payment", func(t *testing.T) {
+		mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{
+			{ID: 6, Reference: "fake_9_9", Amount: total, Status: "AUTHORIZED", UpdatedAt: 1000},
+		}, nil)
+
+		u := &usecase{paymentsRepository: mockPaymentsRepo, provider: fake.New("secret")}
+		if err := u.ReleasePayment(context.Background(), 1); err == nil {
+			t.Errorf("ReleasePayment() expected an error")
+		}
+	})
+}
+
+func Test_usecase_RefundPayment(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPaymentsRepo := NewMockpaymentsRepository(mockCtrl)
+	total := money.MustParse("19.98")
+
+	tests := []struct {
+		name       string
+		amount     money.Amount
+		wantErrMsg string
+		mockFn     func(provider *fake.Provider)
+	}{
+		{
+			name:       "error no captured payment",
+			amount:     money.MustParse("9.99"),
+			wantErrMsg: "order with id: 1 has no captured payment to refund 9.99 from",
+			mockFn: func(provider *fake.Provider) {
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{
+					{ID: 6, Reference: "fake_1_1", Amount: total, Status: "FAILED"},
+				}, nil)
+			},
+		},
+		{
+			name:       "error more than what is left of the capture",
+			amount:     money.MustParse("19.98"),
+			wantErrMsg: "order with id: 1 has no captured payment to refund 19.98 from",
+			mockFn: func(provider *fake.Provider) {
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{
+					{ID: 7, Reference: "fake_1_1", Amount: total, RefundedAmount: money.MustParse("9.99"), Status: "CAPTURED"},
+				}, nil)
+			},
+		},
+		{
+			name:   "success partial refund",
+			amount: money.MustParse("9.99"),
+			mockFn: func(provider *fake.Provider) {
+				captured, _ := provider.Authorize(context.Background(), payment.AuthorizeRequest{OrderID: 1, Amount: total, Token: "tok_visa"})
+				_, _ = provider.Capture(context.Background(), captured.Reference, total)
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{
+					{ID: 7, Reference: captured.Reference, Amount: total, Status: "CAPTURED", UpdatedAt: 1000},
+				}, nil)
+				mockPaymentsRepo.EXPECT().RefundPayment(gomock.Any(), int64(7), money.MustParse("9.99"), payments.StatusCaptured, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:   "success refund of the rest",
+			amount: money.MustParse("9.99"),
+			mockFn: func(provider *fake.Provider) {
+				captured, _ := provider.Authorize(context.Background(), payment.AuthorizeRequest{OrderID: 1, Amount: total, Token: "tok_visa"})
+				_, _ = provider.Capture(context.Background(), captured.Reference, total)
+				_, _ = provider.Refund(context.Background(), captured.Reference, money.MustParse("9.99"))
+				mockPaymentsRepo.EXPECT().GetPaymentsByOrderID(gomock.Any(), int64(1)).Return([]payments.Model{
+					{ID: 7, Reference: captured.Reference, Amount: total, RefundedAmount: money.MustParse("9.99"), Status: "CAPTURED", UpdatedAt: 1000},
+				}, nil)
+				mockPaymentsRepo.EXPECT().RefundPayment(gomock.Any(), int64(7), money.MustParse("9.99"), payments.StatusRefunded, gomock.Any()).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := fake.New("secret")
+			tt.mockFn(provider)
+			u := &usecase{paymentsRepository: mockPaymentsRepo, provider: provider}
+			err := u.RefundPayment(context.Background(), 1, tt.amount)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("RefundPayment() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("RefundPayment() unexpected error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen -package=promotions -source=promotions_usecase.go -destination=promotions_usecase_mock_test.go
+type promotionsRepository interface {
+	GetPromotions(ctx context.Context) ([]promotions.Model, error)
+	GetPromotionByID(ctx context.Context, id int64) (*promotions.Model, error)
+	GetPromotionByCode(ctx context.Context, code string) (*promotions.Model, error)
+	InsertPromotion(ctx context.Context, model promotions.Model) (*promotions.Model, error)
+	UpdatePromotion(ctx context.Context, model promotions.Model) (*promotions.Model, error)
+	DeletePromotion(ctx context.Context, id int64) error
+	CountUserRedemptions(ctx context.Context, promotionID, userID int64) (int, error)
+}
+
+type usecase struct {
+	promotionsRepository promotionsRepository
+}
+
+func New(promotionsRepository promotionsRepository) *usecase {
+	return &usecase{promotionsRepository: promotionsRepository}
+}
+
+func (u *usecase) GetPromotions(ctx context.Context) ([]promotions.Model, error) {
+	return u.promotionsRepository.GetPromotions(ctx)
+}
+
+func (u *usecase) GetPromotionByID(ctx context.Context, id int64) (*promotions.Model, error) {
+	promotion, err := u.promotionsRepository.GetPromotionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if promotion == nil {
+		return nil, fmt.Errorf("promotion with id: %d is not found", id)
+	}
+	return promotion, nil
+}
+
+func (u *usecase) CreatePromotion(ctx context.Context, req promotions.CreatePromotionRequest) (*promotions.Model, error) {
+	now := time.Now().UnixMilli()
+	model, err := buildPromotion(req, now)
+	if err != nil {
+		return nil, err
+	}
+	model.CreatedAt = now
+	return u.promotionsRepository.InsertPromotion(ctx, model)
+}
+
+func (u *usecase) UpdatePromotion(ctx context.Context, req promotions.UpdatePromotionRequest) (*promotions.Model, error) {
+	model, err := buildPromotion(req.CreatePromotionRequest, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	model.ID = req.ID
+	return u.promotionsRepository.UpdatePromotion(ctx, model)
+}
+
+// DeletePromotion only deletes promotions that were never redeemed, a redeemed one can be ended by moving its ends_at instead
+func (u *usecase) DeletePromotion(ctx context.Context, id int64) error {
+	return u.promotionsRepository.DeletePromotion(ctx, id)
+}
+
+// ApplyPromotion checks that the user can redeem the promo code on the lines and works out the discount of every line.
+// The usage limits are only checked ahead here, the order takes its redemptions when it is placed, and the locks on the
+// promotion and on the redemptions of the user keep concurrent orders from going over them.
+func (u *usecase) ApplyPromotion(ctx context.Context, userID int64, code string, lines []promotions.Line) (*promotions.Applied, error) {
+	code = normalizeCode(code)
+	promotion, err := u.promotionsRepository.GetPromotionByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if promotion == nil {
+		return nil, fmt.Errorf("promo code %s is not found", code)
+	}
+
+	now := time.Now().UnixMilli()
+	if now < promotion.StartsAt {
+		return nil, fmt.Errorf("promo code %s is not active yet", code)
+	}
+	if promotion.EndsAt != 0 && now >= promotion.EndsAt {
+		return nil, fmt.Errorf("promo code %s has expired", code)
+	}
+	if promotion.UsageLimit > 0 && promotion.UsedCount >= promotion.UsageLimit {
+		return nil, fmt.Errorf("promo code %s has reached its usage limit", code)
+	}
+	if promotion.PerUserLimit > 0 {
+		redemptions, err := u.promotionsRepository.CountUserRedemptions(ctx, promotion.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if redemptions >= promotion.PerUserLimit {
+			return nil, fmt.Errorf("promo code %s has reached its usage limit for this user", code)
+		}
+	}
+
+	lineTotals := make([]money.Amount, len(lines))
+	eligibleSubtotal := money.Amount(0)
+	for i, line := range lines {
+		if promotion.IsEligible(line) {
+			lineTotals[i] = line.Price.Mul(line.Quantity)
+			eligibleSubtotal = eligibleSubtotal.Add(lineTotals[i])
+		}
+	}
+	if eligibleSubtotal == 0 {
+		return nil, fmt.Errorf("promo code %s doesn't apply to any book of the order", code)
+	}
+	if eligibleSubtotal < promotion.MinSpend {
+		return nil, fmt.Errorf("promo code %s needs a minimum spend of %s on eligible books", code, promotion.MinSpend)
+	}
+
+	discount := promotion.DiscountOn(eligibleSubtotal)
+	return &promotions.Applied{
+		PromotionID:   promotion.ID,
+		Code:          promotion.Code,
+		Discount:      discount,
+		LineDiscounts: allocateDiscount(discount, lineTotals, eligibleSubtotal),
+	}, nil
+}
+
+// allocateDiscount spreads the discount over the eligible lines in proportion to their totals, lines that aren't eligible
+// have a zero total. The cents lost to rounding down go to the first eligible lines so the shares add up to the discount.
+func allocateDiscount(discount money.Amount, lineTotals []money.Amount, eligibleSubtotal money.Amount) []money.Amount {
+	shares := make([]money.Amount, len(lineTotals))
+	allocated := money.Amount(0)
+	for i, total := range lineTotals {
+		shares[i] = money.FromCents(discount.Cents() * total.Cents() / eligibleSubtotal.Cents())
+		allocated = allocated.Add(shares[i])
+	}
+	for i := 0; allocated < discount; i++ {
+		if lineTotals[i] > shares[i] {
+			shares[i] = shares[i].Add(money.FromCents(1))
+			allocated = allocated.Add(money.FromCents(1))
+		}
+	}
+	return shares
+}
+
+func buildPromotion(req promotions.CreatePromotionRequest, now int64) (promotions.Model, error) {
+	if promotions.DiscountType(req.DiscountType) == promotions.DiscountTypePercentage && !req.DiscountPercentage.Valid() {
+		return promotions.Model{}, errors.New("invalid discount_value, a percentage can't be more than 100")
+	}
+	if promotions.DiscountType(req.DiscountType) == promotions.DiscountTypeFixed && req.MaxDiscount > 0 {
+		return promotions.Model{}, errors.New("invalid max_discount, only percentage promotions can be capped")
+	}
+
+	startsAt := req.StartsAt
+	if startsAt == 0 {
+		startsAt = now
+	}
+	if req.EndsAt != 0 && req.EndsAt <= startsAt {
+		return promotions.Model{}, errors.New("invalid ends_at, must be after starts_at")
+	}
+
+	model := promotions.Model{
+		Code:               normalizeCode(req.Code),
+		Description:        strings.TrimSpace(req.Description),
+		DiscountType:       req.DiscountType,
+		DiscountPercentage: req.DiscountPercentage,
+		DiscountAmount:     req.DiscountAmount,
+		MaxDiscount:        req.MaxDiscount,
+		MinSpend:           req.MinSpend,
+		BookIDs:            make([]int64, 0, len(req.BookIDs)),
+		Authors:            make([]string, 0, len(req.Authors)),
+		StartsAt:           startsAt,
+		EndsAt:             req.EndsAt,
+		UsageLimit:         req.UsageLimit,
+		PerUserLimit:       req.PerUserLimit,
+		UpdatedAt:          now,
+	}
+	if model.Code == "" || strings.ContainsAny(model.Code, " \t\n") {
+		return promotions.Model{}, errors.New("invalid code, must not be empty or contain spaces")
+	}
+
+	seenBooks := make(map[int64]bool, len(req.BookIDs))
+	for _, id := range req.BookIDs {
+		if !seenBooks[id] {
+			seenBooks[id] = true
+			model.BookIDs = append(model.BookIDs, id)
+		}
+	}
+	seenAuthors := make(map[string]bool, len(req.Authors))
+	for _, author := range req.Authors {
+		author = strings.TrimSpace(author)
+		if author != "" && !seenAuthors[strings.ToLower(author)] {
+			seenAuthors[strings.ToLower(author)] = true
+			model.Authors = append(model.Authors, author)
+		}
+	}
+	return model, nil
+}
+
+// normalizeCode makes promo codes case-insensitive, they are stored in upper case
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
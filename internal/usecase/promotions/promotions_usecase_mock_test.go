@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: promotions_usecase.go
+
+// Package promotions is a generated GoMock package.
+package promotions
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	promotions "github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+)
+
+// MockpromotionsRepository is a mock of promotionsRepository interface.
+type MockpromotionsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockpromotionsRepositoryMockRecorder
+}
+
+// MockpromotionsRepositoryMockRecorder is the mock recorder for MockpromotionsRepository.
+type MockpromotionsRepositoryMockRecorder struct {
+	mock *MockpromotionsRepository
+}
+
+// NewMockpromotionsRepository creates a new mock instance.
+func NewMockpromotionsRepository(ctrl *gomock.Controller) *MockpromotionsRepository {
+	mock := &MockpromotionsRepository{ctrl: ctrl}
+	mock.recorder = &MockpromotionsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpromotionsRepository) EXPECT() *MockpromotionsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountUserRedemptions mocks base method.
+func (m *MockpromotionsRepository) CountUserRedemptions(ctx context.Context, promotionID, userID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserRedemptions", ctx, promotionID, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserRedemptions indicates an expected call of CountUserRedemptions.
+func (mr *MockpromotionsRepositoryMockRecorder) CountUserRedemptions(ctx, promotionID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserRedemptions", reflect.TypeOf((*MockpromotionsRepository)(nil).CountUserRedemptions), ctx, promotionID, userID)
+}
+
+// DeletePromotion mocks base method.
+func (m *MockpromotionsRepository) DeletePromotion(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePromotion", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePromotion indicates an expected call of DeletePromotion.
+func (mr *MockpromotionsRepositoryMockRecorder) DeletePromotion(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePromotion", reflect.TypeOf((*MockpromotionsRepository)(nil).DeletePromotion), ctx, id)
+}
+
+// GetPromotionByCode mocks base method.
+func (m *MockpromotionsRepository) GetPromotionByCode(ctx context.Context, code string) (*promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotionByCode", ctx, code)
+	ret0, _ := ret[0].(*promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotionByCode indicates an expected call of GetPromotionByCode.
+func (mr *MockpromotionsRepositoryMockRecorder) GetPromotionByCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotionByCode", reflect.TypeOf((*MockpromotionsRepository)(nil).GetPromotionByCode), ctx, code)
+}
+
+// GetPromotionByID mocks base method.
+func (m *MockpromotionsRepository) GetPromotionByID(ctx context.Context, id int64) (*promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotionByID", ctx, id)
+	ret0, _ := ret[0].(*promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotionByID indicates an expected call of GetPromotionByID.
+func (mr *MockpromotionsRepositoryMockRecorder) GetPromotionByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotionByID", reflect.TypeOf((*MockpromotionsRepository)(nil).GetPromotionByID), ctx, id)
+}
+
+// GetPromotions mocks base method.
+func (m *MockpromotionsRepository) GetPromotions(ctx context.Context) ([]promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotions", ctx)
+	ret0, _ := ret[0].([]promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotions indicates an expected call of GetPromotions.
+func (mr *MockpromotionsRepositoryMockRecorder) GetPromotions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotions", reflect.TypeOf((*MockpromotionsRepository)(nil).GetPromotions), ctx)
+}
+
+// InsertPromotion mocks base method.
+func (m *MockpromotionsRepository) InsertPromotion(ctx context.Context, model promotions.Model) (*promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertPromotion", ctx, model)
+	ret0, _ := ret[0].(*promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertPromotion indicates an expected call of InsertPromotion.
+func (mr *MockpromotionsRepositoryMockRecorder) InsertPromotion(ctx, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPromotion", reflect.TypeOf((*MockpromotionsRepository)(nil).InsertPromotion), ctx, model)
+}
+
+// UpdatePromotion mocks base method.
+func (m *MockpromotionsRepository) UpdatePromotion(ctx context.Context, model promotions.Model) (*promotions.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePromotion", ctx, model)
+	ret0, _ := ret[0].(*promotions.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePromotion indicates an expected call of UpdatePromotion.
+func (mr *MockpromotionsRepositoryMockRecorder) UpdatePromotion(ctx, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePromotion", reflect.TypeOf((*MockpromotionsRepository)(nil).UpdatePromotion), ctx, model)
+}
@@ -0,0 +1,299 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/promotions"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_usecase_ApplyPromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPromotionsRepo := NewMockpromotionsRepository(mockCtrl)
+
+	now := time.Now().UnixMilli()
+	lines := []promotions.Line{
+		{BookID: 101, Author: "George Orwell", Price: money.MustParse("10.99"), Quantity: 3},
+		{BookID: 102, Author: "Aldous Huxley", Price: money.MustParse("20"), Quantity: 1},
+		{BookID: 103, Author: "george orwell", Price: money.MustParse("8.49"), Quantity: 2},
+	}
+	percentage := promotions.Model{ID: 4, Code: "ORWELL", DiscountType: "PERCENTAGE", DiscountPercentage: percent.MustParse("10"),
+		Authors: []string{"George Orwell"}, StartsAt: now - 1000}
+
+	tests := []struct {
+		name       string
+		code       string
+		want       *promotions.Applied
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error code not found",
+			code:       " orwell ",
+			wantErrMsg: "promo code ORWELL is not found",
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(nil, nil)
+			},
+		},
+		{
+			name:       "error not started",
+			code:       "ORWELL",
+			wantErrMsg: "promo code ORWELL is not active yet",
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&promotions.Model{Code: "ORWELL", StartsAt: now + 60000}, nil)
+			},
+		},
+		{
+			name:       "error expired",
+			code:       "ORWELL",
+			wantErrMsg: "promo code ORWELL has expired",
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&promotions.Model{Code: "ORWELL", StartsAt: now - 2000, EndsAt: now - 1000}, nil)
+			},
+		},
+		{
+			name:       "error usage limit reached",
+			code:       "ORWELL",
+			wantErrMsg: "promo code ORWELL has reached its usage limit",
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&promotions.Model{Code: "ORWELL", UsageLimit: 10, UsedCount: 10}, nil)
+			},
+		},
+		{
+			name:       "error usage limit of the user reached",
+			code:       "ORWELL",
+			wantErrMsg: "promo code ORWELL has reached its usage limit for this user",
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&promotions.Model{ID: 4, Code: "ORWELL", PerUserLimit: 1}, nil)
+				mockPromotionsRepo.EXPECT().CountUserRedemptions(gomock.Any(), int64(4), int64(2)).Return(1, nil)
+			},
+		},
+		{
+			name:       "error no eligible book",
+			code:       "ORWELL",
+			wantErrMsg: "promo code ORWELL doesn't apply to any book of the order",
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&promotions.Model{Code: "ORWELL", BookIDs: []int64{999}}, nil)
+			},
+		},
+		{
+			name:       "error minimum spend on eligible books",
+			code:       "ORWELL",
+			wantErrMsg: "promo code ORWELL needs a minimum spend of 50.00 on eligible books",
+			mockFn: func() {
+				model := percentage
+				model.MinSpend = money.MustParse("50")
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&model, nil)
+			},
+		},
+		{
+			name: "success percentage of the books of the author",
+			code: "orwell",
+			// 10% of 32.97 + 16.98 is 5.00 rounded, 3.30 and 1.69 rounded down with the lost cent going to the first line
+			want: &promotions.Applied{
+				PromotionID:   4,
+				Code:          "ORWELL",
+				Discount:      money.MustParse("5"),
+				LineDiscounts: []money.Amount{money.MustParse("3.31"), 0, money.MustParse("1.69")},
+			},
+			mockFn: func() {
+				model := percentage
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&model, nil)
+			},
+		},
+		{
+			name: "success percentage capped by the max discount",
+			code: "ORWELL",
+			want: &promotions.Applied{
+				PromotionID:   4,
+				Code:          "ORWELL",
+				Discount:      money.MustParse("2"),
+				LineDiscounts: []money.Amount{money.MustParse("1.33"), 0, money.MustParse("0.67")},
+			},
+			mockFn: func() {
+				model := percentage
+				model.MaxDiscount = money.MustParse("2")
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "ORWELL").Return(&model, nil)
+			},
+		},
+		{
+			name: "success fixed amount of the listed book within the user limit",
+			code: "BRAVE",
+			want: &promotions.Applied{
+				PromotionID:   5,
+				Code:          "BRAVE",
+				Discount:      money.MustParse("20"),
+				LineDiscounts: []money.Amount{0, money.MustParse("20"), 0},
+			},
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "BRAVE").Return(&promotions.Model{
+					ID: 5, Code: "BRAVE", DiscountType: "FIXED", DiscountAmount: money.MustParse("25"), BookIDs: []int64{102},
+					StartsAt: now - 1000, EndsAt: now + 60000, PerUserLimit: 2,
+				}, nil)
+				mockPromotionsRepo.EXPECT().CountUserRedemptions(gomock.Any(), int64(5), int64(2)).Return(1, nil)
+			},
+		},
+		{
+			name: "success fixed amount spreads the rounding cents",
+			code: "WELCOME",
+			want: &promotions.Applied{
+				PromotionID:   6,
+				Code:          "WELCOME",
+				Discount:      money.MustParse("1"),
+				LineDiscounts: []money.Amount{money.MustParse("0.48"), money.MustParse("0.28"), money.MustParse("0.24")},
+			},
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().GetPromotionByCode(gomock.Any(), "WELCOME").Return(&promotions.Model{
+					ID: 6, Code: "WELCOME", DiscountType: "FIXED", DiscountAmount: money.MustParse("1"),
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{promotionsRepository: mockPromotionsRepo}
+			got, err := u.ApplyPromotion(context.Background(), 2, tt.code, lines)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("ApplyPromotion() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyPromotion() got = %+v, error = %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_usecase_CreatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPromotionsRepo := NewMockpromotionsRepository(mockCtrl)
+
+	tests := []struct {
+		name       string
+		req        promotions.CreatePromotionRequest
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error percentage over 100",
+			req:        promotions.CreatePromotionRequest{Code: "ALL", DiscountType: "PERCENTAGE", DiscountPercentage: percent.MustParse("100.01")},
+			wantErrMsg: "invalid discount_value, a percentage can't be more than 100",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error capped fixed amount",
+			req:        promotions.CreatePromotionRequest{Code: "FIVE", DiscountType: "FIXED", DiscountAmount: money.MustParse("5"), MaxDiscount: money.MustParse("5")},
+			wantErrMsg: "invalid max_discount, only percentage promotions can be capped",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error ends before it starts",
+			req:        promotions.CreatePromotionRequest{Code: "FIVE", DiscountType: "FIXED", DiscountAmount: money.MustParse("5"), StartsAt: 2000, EndsAt: 1000},
+			wantErrMsg: "invalid ends_at, must be after starts_at",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error code with spaces",
+			req:        promotions.CreatePromotionRequest{Code: "FIVE OFF", DiscountType: "FIXED", DiscountAmount: money.MustParse("5")},
+			wantErrMsg: "invalid code, must not be empty or contain spaces",
+			mockFn:     func() {},
+		},
+		{
+			name:       "error code already exists",
+			req:        promotions.CreatePromotionRequest{Code: "five", DiscountType: "FIXED", DiscountAmount: money.MustParse("5")},
+			wantErrMsg: "promo code FIVE already exists",
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().InsertPromotion(gomock.Any(), gomock.Any()).Return(nil, errors.New("promo code FIVE already exists"))
+			},
+		},
+		{
+			name: "success normalizes the code and the eligibility",
+			req: promotions.CreatePromotionRequest{
+				Code:               " orwell ",
+				DiscountType:       "PERCENTAGE",
+				DiscountPercentage: percent.MustParse("10"),
+				BookIDs:            []int64{101, 101},
+				Authors:            []string{"George Orwell", " george orwell "},
+				StartsAt:           1000,
+				PerUserLimit:       1,
+			},
+			mockFn: func() {
+				mockPromotionsRepo.EXPECT().InsertPromotion(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, model promotions.Model) (*promotions.Model, error) {
+						if model.Code != "ORWELL" || !reflect.DeepEqual(model.BookIDs, []int64{101}) ||
+							!reflect.DeepEqual(model.Authors, []string{"George Orwell"}) || model.StartsAt != 1000 ||
+							model.PerUserLimit != 1 || model.CreatedAt == 0 || model.CreatedAt != model.UpdatedAt {
+							t.Errorf("InsertPromotion() unexpected promotion = %+v", model)
+						}
+						model.ID = 4
+						return &model, nil
+					})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{promotionsRepository: mockPromotionsRepo}
+			got, err := u.CreatePromotion(context.Background(), tt.req)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("CreatePromotion() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || got.ID != 4 {
+				t.Errorf("CreatePromotion() got = %+v, error = %v", got, err)
+			}
+		})
+	}
+}
+
+func Test_usecase_UpdatePromotion(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPromotionsRepo := NewMockpromotionsRepository(mockCtrl)
+	mockPromotionsRepo.EXPECT().UpdatePromotion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, model promotions.Model) (*promotions.Model, error) {
+			if model.ID != 4 || model.Code != "ORWELL" || model.StartsAt == 0 || model.CreatedAt != 0 {
+				t.Errorf("UpdatePromotion() unexpected promotion = %+v", model)
+			}
+			return &model, nil
+		})
+
+	u := &usecase{promotionsRepository: mockPromotionsRepo}
+	_, err := u.UpdatePromotion(context.Background(), promotions.UpdatePromotionRequest{
+		ID:                     4,
+		CreatePromotionRequest: promotions.CreatePromotionRequest{Code: "orwell", DiscountType: "FIXED", DiscountAmount: money.MustParse("5")},
+	})
+	if err != nil {
+		t.Errorf("UpdatePromotion() unexpected error = %v", err)
+	}
+}
+
+func Test_usecase_GetPromotionByID(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPromotionsRepo := NewMockpromotionsRepository(mockCtrl)
+	mockPromotionsRepo.EXPECT().GetPromotionByID(gomock.Any(), int64(4)).Return(nil, nil)
+
+	u := &usecase{promotionsRepository: mockPromotionsRepo}
+	_, err := u.GetPromotionByID(context.Background(), 4)
+	if err == nil || err.Error() != "promotion with id: 4 is not found" {
+		t.Errorf("GetPromotionByID() error = %v", err)
+	}
+}
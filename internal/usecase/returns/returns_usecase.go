@@ -0,0 +1,303 @@
+package returns
+
+import (
+	"context"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"log"
+	"time"
+)
+
+//go:generate mockgen -package=returns -source=returns_usecase.go -destination=returns_usecase_mock_test.go
+type returnsRepository interface {
+	InsertReturn(ctx context.Context, model returns.Model, history orders.StatusHistory) (*returns.Model, error)
+	GetReturnByID(ctx context.Context, id int64) (*returns.Model, error)
+	GetReturnsByOrderID(ctx context.Context, orderID int64) ([]returns.Model, error)
+	GetOrderItems(ctx context.Context, orderID int64) ([]returns.OrderItem, error)
+	UpdateReturnStatus(ctx context.Context, id int64, from, to returns.Status, note string, updatedAt int64) error
+	RejectReturn(ctx context.Context, id int64, note string, updatedAt int64, history orders.StatusHistory) error
+	CompleteReturn(ctx context.Context, model returns.Model, transition orders.StatusTransition) ([]int64, error)
+}
+
+type ordersRepository interface {
+	GetOrderByID(ctx context.Context, id int64) (*orders.Model, error)
+}
+
+type paymentsUsecase interface {
+	RefundPayment(ctx context.Context, orderID int64, amount money.Amount) error
+}
+
+type booksRepository interface {
+	InvalidateBookCache(ids ...int64)
+}
+
+type usecase struct {
+	returnsRepository returnsRepository
+	ordersRepository  ordersRepository
+	paymentsUsecase   paymentsUsecase
+	booksRepository   booksRepository
+}
+
+func New(returnsRepository returnsRepository, ordersRepository ordersRepository, paymentsUsecase paymentsUsecase, booksRepository booksRepository) *usecase {
+	return &usecase{
+		returnsRepository: returnsRepository,
+		ordersRepository:  ordersRepository,
+		paymentsUsecase:   paymentsUsecase,
+		booksRepository:   booksRepository,
+	}
+}
+
+// CreateReturn opens a return of some of the items of a delivered order, an order has at most one open return
+func (u *usecase) CreateReturn(ctx context.Context, req returns.CreateReturnRequest) (*returns.Model, error) {
+	order, err := u.ordersRepository.GetOrderByID(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	// the order of another user is not found either, so callers can't probe which order ids exist
+	if order == nil || order.UserID != req.UserID {
+		return nil, fmt.Errorf("order with id: %d is not found", req.OrderID)
+	}
+
+	current := orders.OrderStatus(order.Status)
+	if current != orders.OrderStatusDelivered && current != orders.OrderStatusPartiallyRefunded {
+		return nil, fmt.Errorf("order with id: %d can't be returned, its status is %s", req.OrderID, current)
+	}
+
+	existing, err := u.returnsRepository.GetReturnsByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range existing {
+		if returns.Status(r.Status).IsOpen() {
+			return nil, fmt.Errorf("order with id: %d already has an open return with id: %d", req.OrderID, r.ID)
+		}
+	}
+
+	orderItems, err := u.returnsRepository.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	orderItemsMap := make(map[int64]returns.OrderItem, len(orderItems))
+	for _, item := range orderItems {
+		orderItemsMap[item.ID] = item
+	}
+
+	now := time.Now().UnixMilli()
+	model := returns.Model{
+		OrderID:   order.ID,
+		UserID:    req.UserID,
+		Status:    returns.StatusRequested.String(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Items:     make([]returns.Item, 0, len(req.Items)),
+	}
+	requested := make(map[int64]bool, len(req.Items))
+	for _, item := range req.Items {
+		orderItem, ok := orderItemsMap[item.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("order item with id: %d is not part of order with id: %d", item.OrderItemID, order.ID)
+		}
+		if requested[item.OrderItemID] {
+			return nil, fmt.Errorf("order item with id: %d is listed more than once", item.OrderItemID)
+		}
+		requested[item.OrderItemID] = true
+
+		returnable := orderItem.Quantity - orderItem.ReturnedQuantity
+		if item.Quantity > returnable {
+			return nil, fmt.Errorf("only %d of order item with id: %d can be returned", returnable, item.OrderItemID)
+		}
+
+		refund := orderItem.RefundFor(item.Quantity)
+		model.Items = append(model.Items, returns.Item{
+			OrderItemID:  item.OrderItemID,
+			BookID:       orderItem.BookID,
+			Quantity:     item.Quantity,
+			Reason:       item.Reason,
+			RefundAmount: refund,
+		})
+		model.RefundAmount = model.RefundAmount.Add(refund)
+	}
+
+	return u.returnsRepository.InsertReturn(ctx, model, orders.StatusHistory{
+		OrderID:   order.ID,
+		From:      current.String(),
+		To:        current.String(),
+		ActorID:   req.UserID,
+		ActorRole: users.RoleCustomer.String(),
+		Note:      fmt.Sprintf("return of %s requested", model.RefundAmount),
+		CreatedAt: now,
+	})
+}
+
+// GetReturns returns the returns of the order, to its owner or to staff
+func (u *usecase) GetReturns(ctx context.Context, orderID, userID int64, role string) ([]returns.Model, error) {
+	order, err := u.ordersRepository.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || (order.UserID != userID && !users.Role(role).IsStaff()) {
+		return nil, fmt.Errorf("order with id: %d is not found", orderID)
+	}
+
+	return u.returnsRepository.GetReturnsByOrderID(ctx, orderID)
+}
+
+// UpdateReturnStatus approves or rejects a requested return. An approved return is claimed first so it can't be refunded
+// twice, then refunded through the payment and marked REFUNDING before the refund is recorded along with the restock
+// and the next status of the order. Approving a REFUNDING return only records its refund. A return with nothing to
+// refund, e.g. of a free order, skips the provider.
+func (u *usecase) UpdateReturnStatus(ctx context.Context, req returns.UpdateReturnStatusRequest) (*returns.Model, error) {
+	model, err := u.returnsRepository.GetReturnByID(ctx, req.ReturnID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, fmt.Errorf("return with id: %d is not found", req.ReturnID)
+	}
+	status := returns.Status(model.Status)
+	resume := status == returns.StatusRefunding && returns.Status(req.Status) == returns.StatusApproved
+	if status != returns.StatusRequested && !resume {
+		return nil, fmt.Errorf("return with id: %d is already %s", req.ReturnID, model.Status)
+	}
+
+	order, err := u.ordersRepository.GetOrderByID(ctx, model.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("order with id: %d is not found", model.OrderID)
+	}
+	current := orders.OrderStatus(order.Status)
+
+	if resume {
+		// the provider already refunded the money, it must not be asked again
+		next, err := u.nextOrderStatus(ctx, order, model.Items)
+		if err != nil {
+			return nil, err
+		}
+		return u.completeReturn(ctx, model, order, next, req.ActorID)
+	}
+
+	updatedAt := util.NextUpdatedAt(model.UpdatedAt)
+	if returns.Status(req.Status) == returns.StatusRejected {
+		err = u.returnsRepository.RejectReturn(ctx, model.ID, req.Note, updatedAt, orders.StatusHistory{
+			OrderID:   order.ID,
+			From:      current.String(),
+			To:        current.String(),
+			ActorID:   req.ActorID,
+			ActorRole: users.RoleAdmin.String(),
+			Note:      rejectNote(model.ID, req.Note),
+			CreatedAt: updatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		model.Status = returns.StatusRejected.String()
+		model.Note = req.Note
+		model.UpdatedAt = updatedAt
+		return model, nil
+	}
+
+	// checked before the provider is called, an order that can't take the refund fails here with nothing refunded
+	next, err := u.nextOrderStatus(ctx, order, model.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.returnsRepository.UpdateReturnStatus(ctx, model.ID, returns.StatusRequested, returns.StatusApproved, req.Note, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	model.Status = returns.StatusApproved.String()
+	model.Note = req.Note
+	model.UpdatedAt = updatedAt
+
+	// the books were free or discounted to nothing, there is no payment to give money back from
+	if model.RefundAmount > 0 {
+		err = u.paymentsUsecase.RefundPayment(ctx, order.ID, model.RefundAmount)
+		if err != nil {
+			// nothing was refunded, the return goes back to REQUESTED so it can be approved again
+			revertErr := u.returnsRepository.UpdateReturnStatus(ctx, model.ID, returns.StatusApproved, returns.StatusRequested, req.Note, util.NextUpdatedAt(updatedAt))
+			if revertErr != nil {
+				log.Printf("[UpdateReturnStatus] error when reverting return %d to requested: %v", model.ID, revertErr)
+			}
+			return nil, fmt.Errorf("refund of return with id: %d failed: %v", model.ID, err)
+		}
+	}
+
+	// the money is back to the customer, the return says so before the refund is recorded on the order
+	refundingAt := util.NextUpdatedAt(updatedAt)
+	err = u.returnsRepository.UpdateReturnStatus(ctx, model.ID, returns.StatusApproved, returns.StatusRefunding, req.Note, refundingAt)
+	if err != nil {
+		log.Printf("[UpdateReturnStatus] return %d was refunded but marking it as refunding failed: %v", model.ID, err)
+		return nil, err
+	}
+	model.Status = returns.StatusRefunding.String()
+	model.UpdatedAt = refundingAt
+
+	return u.completeReturn(ctx, model, order, next, req.ActorID)
+}
+
+// completeReturn records the refund of a REFUNDING return on the order, the return stays REFUNDING when it fails so
+// approving it again finishes it
+func (u *usecase) completeReturn(ctx context.Context, model *returns.Model, order *orders.Model, next orders.OrderStatus,
+	actorID int64) (*returns.Model, error) {
+	refundedAt := util.NextUpdatedAt(model.UpdatedAt)
+	bookIDs, err := u.returnsRepository.CompleteReturn(ctx, *model, orders.StatusTransition{
+		OrderID:           order.ID,
+		From:              orders.OrderStatus(order.Status),
+		To:                next,
+		ExpectedUpdatedAt: order.UpdatedAt,
+		UpdatedAt:         refundedAt,
+		ActorID:           actorID,
+		ActorRole:         users.RoleAdmin.String(),
+		Note:              fmt.Sprintf("return %d refunded %s", model.ID, model.RefundAmount),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("return with id: %d was refunded but recording it failed, approve it again to finish it: %v", model.ID, err)
+	}
+
+	// the stock of the returned books is back, so their cached availability is stale
+	u.booksRepository.InvalidateBookCache(bookIDs...)
+	model.Status = returns.StatusRefunded.String()
+	model.UpdatedAt = refundedAt
+	return model, nil
+}
+
+// nextOrderStatus is REFUNDED when the return covers everything that wasn't returned yet, PARTIALLY_REFUNDED otherwise.
+// It fails when the order can't be refunded from its current status.
+func (u *usecase) nextOrderStatus(ctx context.Context, order *orders.Model, items []returns.Item) (orders.OrderStatus, error) {
+	orderItems, err := u.returnsRepository.GetOrderItems(ctx, order.ID)
+	if err != nil {
+		return "", err
+	}
+
+	remaining := 0
+	for _, item := range orderItems {
+		remaining += item.Quantity - item.ReturnedQuantity
+	}
+	for _, item := range items {
+		remaining -= item.Quantity
+	}
+	next := orders.OrderStatusPartiallyRefunded
+	if remaining <= 0 {
+		next = orders.OrderStatusRefunded
+	}
+
+	current := orders.OrderStatus(order.Status)
+	if current != next && !current.CanTransitionTo(next) {
+		return "", fmt.Errorf("order with id: %d can't be refunded, its status is %s", order.ID, current)
+	}
+	return next, nil
+}
+
+func rejectNote(returnID int64, note string) string {
+	if note == "" {
+		return fmt.Sprintf("return %d rejected", returnID)
+	}
+	return fmt.Sprintf("return %d rejected: %s", returnID, note)
+}
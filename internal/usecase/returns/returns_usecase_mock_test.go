@@ -0,0 +1,255 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: returns_usecase.go
+
+// Package returns is a generated GoMock package.
+package returns
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	orders "github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	returns "github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+	money "github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+// MockreturnsRepository is a mock of returnsRepository interface.
+type MockreturnsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockreturnsRepositoryMockRecorder
+}
+
+// MockreturnsRepositoryMockRecorder is the mock recorder for MockreturnsRepository.
+type MockreturnsRepositoryMockRecorder struct {
+	mock *MockreturnsRepository
+}
+
+// NewMockreturnsRepository creates a new mock instance.
+func NewMockreturnsRepository(ctrl *gomock.Controller) *MockreturnsRepository {
+	mock := &MockreturnsRepository{ctrl: ctrl}
+	mock.recorder = &MockreturnsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockreturnsRepository) EXPECT() *MockreturnsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CompleteReturn mocks base method.
+func (m *MockreturnsRepository) CompleteReturn(ctx context.Context, model returns.Model, transition orders.StatusTransition) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteReturn", ctx, model, transition)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteReturn indicates an expected call of CompleteReturn.
+func (mr *MockreturnsRepositoryMockRecorder) CompleteReturn(ctx, model, transition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteReturn", reflect.TypeOf((*MockreturnsRepository)(nil).CompleteReturn), ctx, model, transition)
+}
+
+// GetOrderItems mocks base method.
+func (m *MockreturnsRepository) GetOrderItems(ctx context.Context, orderID int64) ([]returns.OrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderItems", ctx, orderID)
+	ret0, _ := ret[0].([]returns.OrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderItems indicates an expected call of GetOrderItems.
+func (mr *MockreturnsRepositoryMockRecorder) GetOrderItems(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderItems", reflect.TypeOf((*MockreturnsRepository)(nil).GetOrderItems), ctx, orderID)
+}
+
+// GetReturnByID mocks base method.
+func (m *MockreturnsRepository) GetReturnByID(ctx context.Context, id int64) (*returns.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReturnByID", ctx, id)
+	ret0, _ := ret[0].(*returns.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReturnByID indicates an expected call of GetReturnByID.
+func (mr *MockreturnsRepositoryMockRecorder) GetReturnByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReturnByID", reflect.TypeOf((*MockreturnsRepository)(nil).GetReturnByID), ctx, id)
+}
+
+// GetReturnsByOrderID mocks base method.
+func (m *MockreturnsRepository) GetReturnsByOrderID(ctx context.Context, orderID int64) ([]returns.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReturnsByOrderID", ctx, orderID)
+	ret0, _ := ret[0].([]returns.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReturnsByOrderID indicates an expected call of GetReturnsByOrderID.
+func (mr *MockreturnsRepositoryMockRecorder) GetReturnsByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReturnsByOrderID", reflect.TypeOf((*MockreturnsRepository)(nil).GetReturnsByOrderID), ctx, orderID)
+}
+
+// InsertReturn mocks base method.
+func (m *MockreturnsRepository) InsertReturn(ctx context.Context, model returns.Model, history orders.StatusHistory) (*returns.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertReturn", ctx, model, history)
+	ret0, _ := ret[0].(*returns.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertReturn indicates an expected call of InsertReturn.
+func (mr *MockreturnsRepositoryMockRecorder) InsertReturn(ctx, model, history interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertReturn", reflect.TypeOf((*MockreturnsRepository)(nil).InsertReturn), ctx, model, history)
+}
+
+// RejectReturn mocks base method.
+func (m *MockreturnsRepository) RejectReturn(ctx context.Context, id int64, note string, updatedAt int64, history orders.StatusHistory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectReturn", ctx, id, note, updatedAt, history)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectReturn indicates an expected call of RejectReturn.
+func (mr *MockreturnsRepositoryMockRecorder) RejectReturn(ctx, id, note, updatedAt, history interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectReturn", reflect.TypeOf((*MockreturnsRepository)(nil).RejectReturn), ctx, id, note, updatedAt, history)
+}
+
+// UpdateReturnStatus mocks base method.
+func (m *MockreturnsRepository) UpdateReturnStatus(ctx context.Context, id int64, from, to returns.Status, note string, updatedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReturnStatus", ctx, id, from, to, note, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateReturnStatus indicates an expected call of UpdateReturnStatus.
+func (mr *MockreturnsRepositoryMockRecorder) UpdateReturnStatus(ctx, id, from, to, note, updatedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReturnStatus", reflect.TypeOf((*MockreturnsRepository)(nil).UpdateReturnStatus), ctx, id, from, to, note, updatedAt)
+}
+
+// MockordersRepository is a mock of ordersRepository interface.
+type MockordersRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockordersRepositoryMockRecorder
+}
+
+// MockordersRepositoryMockRecorder is the mock recorder for MockordersRepository.
+type MockordersRepositoryMockRecorder struct {
+	mock *MockordersRepository
+}
+
+// NewMockordersRepository creates a new mock instance.
+func NewMockordersRepository(ctrl *gomock.Controller) *MockordersRepository {
+	mock := &MockordersRepository{ctrl: ctrl}
+	mock.recorder = &MockordersRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockordersRepository) EXPECT() *MockordersRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetOrderByID mocks base method.
+func (m *MockordersRepository) GetOrderByID(ctx context.Context, id int64) (*orders.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByID", ctx, id)
+	ret0, _ := ret[0].(*orders.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByID indicates an expected call of GetOrderByID.
+func (mr *MockordersRepositoryMockRecorder) GetOrderByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockordersRepository)(nil).GetOrderByID), ctx, id)
+}
+
+// MockpaymentsUsecase is a mock of paymentsUsecase interface.
+type MockpaymentsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockpaymentsUsecaseMockRecorder
+}
+
+// MockpaymentsUsecaseMockRecorder is the mock recorder for MockpaymentsUsecase.
+type MockpaymentsUsecaseMockRecorder struct {
+	mock *MockpaymentsUsecase
+}
+
+// NewMockpaymentsUsecase creates a new mock instance.
+func NewMockpaymentsUsecase(ctrl *gomock.Controller) *MockpaymentsUsecase {
+	mock := &MockpaymentsUsecase{ctrl: ctrl}
+	mock.recorder = &MockpaymentsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpaymentsUsecase) EXPECT() *MockpaymentsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// RefundPayment mocks base method.
+func (m *MockpaymentsUsecase) RefundPayment(ctx context.Context, orderID int64, amount money.Amount) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPayment", ctx, orderID, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefundPayment indicates an expected call of RefundPayment.
+func (mr *MockpaymentsUsecaseMockRecorder) RefundPayment(ctx, orderID, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPayment", reflect.TypeOf((*MockpaymentsUsecase)(nil).RefundPayment), ctx, orderID, amount)
+}
+
+// MockbooksRepository is a mock of booksRepository interface.
+type MockbooksRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockbooksRepositoryMockRecorder
+}
+
+// MockbooksRepositoryMockRecorder is the mock recorder for MockbooksRepository.
+type MockbooksRepositoryMockRecorder struct {
+	mock *MockbooksRepository
+}
+
+// NewMockbooksRepository creates a new mock instance.
+func NewMockbooksRepository(ctrl *gomock.Controller) *MockbooksRepository {
+	mock := &MockbooksRepository{ctrl: ctrl}
+	mock.recorder = &MockbooksRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockbooksRepository) EXPECT() *MockbooksRepositoryMockRecorder {
+	return m.recorder
+}
+
+// InvalidateBookCache mocks base method.
+func (m *MockbooksRepository) InvalidateBookCache(ids ...int64) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateBookCache", varargs...)
+}
+
+// InvalidateBookCache indicates an expected call of InvalidateBookCache.
+func (mr *MockbooksRepositoryMockRecorder) InvalidateBookCache(ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateBookCache", reflect.TypeOf((*MockbooksRepository)(nil).InvalidateBookCache), ids...)
+}
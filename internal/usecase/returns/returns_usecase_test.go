@@ -0,0 +1,401 @@
+package returns
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/orders"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/returns"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"reflect"
+	"testing"
+)
+
+func Test_usecase_CreateReturn(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockReturnsRepo := NewMockreturnsRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+
+	delivered := &orders.Model{ID: 1, UserID: 2, Status: "DELIVERED", UpdatedAt: 1000}
+	orderItems := []returns.OrderItem{
+		{ID: 5, BookID: 3, Quantity: 2, Price: money.MustParse("9.99")},
+		{ID: 6, BookID: 7, Quantity: 1, Price: money.MustParse("5.00"), ReturnedQuantity: 1},
+		// paid 29.00 for the 3 books after the discount, the first one was refunded 9.66 already
+		{ID: 8, BookID: 9, Quantity: 3, Price: money.MustParse("10.00"), Discount: money.MustParse("1.00"), ReturnedQuantity: 1},
+		// the 5% tax was added on top of the price, so it is refunded along with the book
+		{ID: 10, BookID: 11, Quantity: 2, Price: money.MustParse("10.00"), Tax: money.MustParse("1.00")},
+	}
+
+	tests := []struct {
+		name       string
+		items      []returns.CreateReturnItem
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error order not found",
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(nil, nil)
+			},
+		},
+		{
+			name:       "error order of another user is not found",
+			wantErrMsg: "order with id: 1 is not found",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 3, Status: "DELIVERED"}, nil)
+			},
+		},
+		{
+			name:       "error order not delivered",
+			wantErrMsg: "order with id: 1 can't be returned, its status is SHIPPED",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "SHIPPED"}, nil)
+			},
+		},
+		{
+			name:       "error open return",
+			wantErrMsg: "order with id: 1 already has an open return with id: 4",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetReturnsByOrderID(gomock.Any(), int64(1)).Return([]returns.Model{
+					{ID: 3, Status: "REJECTED"},
+					{ID: 4, Status: "REQUESTED"},
+				}, nil)
+			},
+		},
+		{
+			name:       "error item of another order",
+			items:      []returns.CreateReturnItem{{OrderItemID: 9, Quantity: 1, Reason: "damaged"}},
+			wantErrMsg: "order item with id: 9 is not part of order with id: 1",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetReturnsByOrderID(gomock.Any(), int64(1)).Return([]returns.Model{}, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return(orderItems, nil)
+			},
+		},
+		{
+			name:       "error item listed twice",
+			items:      []returns.CreateReturnItem{{OrderItemID: 5, Quantity: 1, Reason: "damaged"}, {OrderItemID: 5, Quantity: 1, Reason: "damaged"}},
+			wantErrMsg: "order item with id: 5 is listed more than once",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetReturnsByOrderID(gomock.Any(), int64(1)).Return([]returns.Model{}, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return(orderItems, nil)
+			},
+		},
+		{
+			name:       "error item already returned",
+			items:      []returns.CreateReturnItem{{OrderItemID: 6, Quantity: 1, Reason: "damaged"}},
+			wantErrMsg: "only 0 of order item with id: 6 can be returned",
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetReturnsByOrderID(gomock.Any(), int64(1)).Return([]returns.Model{}, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return(orderItems, nil)
+			},
+		},
+		{
+			name:  "success",
+			items: []returns.CreateReturnItem{{OrderItemID: 5, Quantity: 2, Reason: "damaged"}},
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetReturnsByOrderID(gomock.Any(), int64(1)).Return([]returns.Model{{ID: 3, Status: "REFUNDED"}}, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return(orderItems, nil)
+				mockReturnsRepo.EXPECT().InsertReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, model returns.Model, history orders.StatusHistory) (*returns.Model, error) {
+						wantItems := []returns.Item{{OrderItemID: 5, BookID: 3, Quantity: 2, Reason: "damaged", RefundAmount: money.MustParse("19.98")}}
+						if model.OrderID != 1 || model.UserID != 2 || model.Status != "REQUESTED" ||
+							model.RefundAmount != money.MustParse("19.98") || !reflect.DeepEqual(model.Items, wantItems) {
+							t.Errorf("InsertReturn() unexpected return = %+v", model)
+						}
+						if history.From != "DELIVERED" || history.To != "DELIVERED" || history.ActorID != 2 ||
+							history.ActorRole != "customer" || history.Note != "return of 19.98 requested" {
+							t.Errorf("InsertReturn() unexpected history = %+v", history)
+						}
+						model.ID = 4
+						return &model, nil
+					})
+			},
+		},
+		{
+			name:  "success refunds what the discounted item was paid for",
+			items: []returns.CreateReturnItem{{OrderItemID: 8, Quantity: 2, Reason: "wrong edition"}},
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetReturnsByOrderID(gomock.Any(), int64(1)).Return([]returns.Model{}, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return(orderItems, nil)
+				mockReturnsRepo.EXPECT().InsertReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, model returns.Model, history orders.StatusHistory) (*returns.Model, error) {
+						if model.RefundAmount != money.MustParse("19.34") || model.Items[0].RefundAmount != money.MustParse("19.34") {
+							t.Errorf("InsertReturn() unexpected refund = %+v", model)
+						}
+						model.ID = 4
+						return &model, nil
+					})
+			},
+		},
+		{
+			name:  "success refunds the tax added on top of the price",
+			items: []returns.CreateReturnItem{{OrderItemID: 10, Quantity: 1, Reason: "damaged"}},
+			mockFn: func() {
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetReturnsByOrderID(gomock.Any(), int64(1)).Return([]returns.Model{}, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return(orderItems, nil)
+				mockReturnsRepo.EXPECT().InsertReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, model returns.Model, history orders.StatusHistory) (*returns.Model, error) {
+						if model.RefundAmount != money.MustParse("10.50") {
+							t.Errorf("InsertReturn() unexpected refund = %+v", model)
+						}
+						model.ID = 4
+						return &model, nil
+					})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				returnsRepository: mockReturnsRepo,
+				ordersRepository:  mockOrdersRepo,
+			}
+			got, err := u.CreateReturn(context.Background(), returns.CreateReturnRequest{OrderID: 1, UserID: 2, Items: tt.items})
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("CreateReturn() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("CreateReturn() unexpected error = %v", err)
+				return
+			}
+			if got.ID != 4 {
+				t.Errorf("CreateReturn() got = %+v", got)
+			}
+		})
+	}
+}
+
+func Test_usecase_UpdateReturnStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockReturnsRepo := NewMockreturnsRepository(mockCtrl)
+	mockOrdersRepo := NewMockordersRepository(mockCtrl)
+	mockPaymentsUC := NewMockpaymentsUsecase(mockCtrl)
+	mockBooksRepo := NewMockbooksRepository(mockCtrl)
+
+	requested := func() *returns.Model {
+		return &returns.Model{
+			ID:           4,
+			OrderID:      1,
+			UserID:       2,
+			Status:       "REQUESTED",
+			RefundAmount: money.MustParse("9.99"),
+			UpdatedAt:    1000,
+			Items:        []returns.Item{{ID: 8, OrderItemID: 5, BookID: 3, Quantity: 1, RefundAmount: money.MustParse("9.99")}},
+		}
+	}
+	delivered := &orders.Model{ID: 1, UserID: 2, Status: "DELIVERED", UpdatedAt: 900}
+
+	tests := []struct {
+		name       string
+		req        returns.UpdateReturnStatusRequest
+		wantStatus string
+		wantErrMsg string
+		mockFn     func()
+	}{
+		{
+			name:       "error return not found",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED"},
+			wantErrMsg: "return with id: 4 is not found",
+			mockFn: func() {
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(nil, nil)
+			},
+		},
+		{
+			name:       "error return already decided",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED"},
+			wantErrMsg: "return with id: 4 is already REJECTED",
+			mockFn: func() {
+				model := requested()
+				model.Status = "REJECTED"
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(model, nil)
+			},
+		},
+		{
+			name:       "success rejected",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "REJECTED", Note: "used"},
+			wantStatus: "REJECTED",
+			mockFn: func() {
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(requested(), nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().RejectReturn(gomock.Any(), int64(4), "used", gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ int64, _ string, _ int64, history orders.StatusHistory) error {
+						if history.From != "DELIVERED" || history.To != "DELIVERED" || history.ActorID != 9 ||
+							history.ActorRole != "admin" || history.Note != "return 4 rejected: used" {
+							t.Errorf("RejectReturn() unexpected history = %+v", history)
+						}
+						return nil
+					})
+			},
+		},
+		{
+			name:       "error refund failed reverts the approval",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED"},
+			wantErrMsg: "refund of return with id: 4 failed: order with id: 1 has no captured payment to refund 9.99 from",
+			mockFn: func() {
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(requested(), nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return([]returns.OrderItem{{ID: 5, Quantity: 2}}, nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusRequested, returns.StatusApproved, "", gomock.Any()).Return(nil)
+				mockPaymentsUC.EXPECT().RefundPayment(gomock.Any(), int64(1), money.MustParse("9.99")).
+					Return(errors.New("order with id: 1 has no captured payment to refund 9.99 from"))
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusApproved, returns.StatusRequested, "", gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:       "error recording the refund leaves the return refunding",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED"},
+			wantErrMsg: "return with id: 4 was refunded but recording it failed, approve it again to finish it: order was updated by another request, please reload it",
+			mockFn: func() {
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(requested(), nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return([]returns.OrderItem{{ID: 5, Quantity: 2}}, nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusRequested, returns.StatusApproved, "", gomock.Any()).Return(nil)
+				mockPaymentsUC.EXPECT().RefundPayment(gomock.Any(), int64(1), money.MustParse("9.99")).Return(nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusApproved, returns.StatusRefunding, "", gomock.Any()).Return(nil)
+				mockReturnsRepo.EXPECT().CompleteReturn(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("order was updated by another request, please reload it"))
+			},
+		},
+		{
+			name:       "error rejecting a refunding return",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "REJECTED"},
+			wantErrMsg: "return with id: 4 is already REFUNDING",
+			mockFn: func() {
+				model := requested()
+				model.Status = "REFUNDING"
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(model, nil)
+			},
+		},
+		{
+			name:       "success approving a refunding return only records the refund",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED"},
+			wantStatus: "REFUNDED",
+			mockFn: func() {
+				model := requested()
+				model.Status = "REFUNDING"
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(model, nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return([]returns.OrderItem{{ID: 5, Quantity: 2}}, nil)
+				mockReturnsRepo.EXPECT().CompleteReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, model returns.Model, transition orders.StatusTransition) ([]int64, error) {
+						if model.Status != "REFUNDING" || transition.To != orders.OrderStatusPartiallyRefunded {
+							t.Errorf("CompleteReturn() unexpected return = %+v, transition = %+v", model, transition)
+						}
+						return []int64{3}, nil
+					})
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(3))
+			},
+		},
+		{
+			name:       "success return of a free order skips the provider",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED"},
+			wantStatus: "REFUNDED",
+			mockFn: func() {
+				model := requested()
+				model.RefundAmount = 0
+				model.Items[0].RefundAmount = 0
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(model, nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return([]returns.OrderItem{{ID: 5, Quantity: 1}}, nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusRequested, returns.StatusApproved, "", gomock.Any()).Return(nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusApproved, returns.StatusRefunding, "", gomock.Any()).Return(nil)
+				mockReturnsRepo.EXPECT().CompleteReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, model returns.Model, transition orders.StatusTransition) ([]int64, error) {
+						if transition.To != orders.OrderStatusRefunded || transition.Note != "return 4 refunded 0.00" {
+							t.Errorf("CompleteReturn() unexpected transition = %+v", transition)
+						}
+						return []int64{3}, nil
+					})
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(3))
+			},
+		},
+		{
+			name:       "success partial refund",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED", Note: "ok"},
+			wantStatus: "REFUNDED",
+			mockFn: func() {
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(requested(), nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(delivered, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return([]returns.OrderItem{{ID: 5, Quantity: 2}}, nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusRequested, returns.StatusApproved, "ok", gomock.Any()).Return(nil)
+				mockPaymentsUC.EXPECT().RefundPayment(gomock.Any(), int64(1), money.MustParse("9.99")).Return(nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusApproved, returns.StatusRefunding, "ok", gomock.Any()).Return(nil)
+				mockReturnsRepo.EXPECT().CompleteReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, model returns.Model, transition orders.StatusTransition) ([]int64, error) {
+						if model.ID != 4 || model.Status != "REFUNDING" || model.Note != "ok" {
+							t.Errorf("CompleteReturn() unexpected return = %+v", model)
+						}
+						if transition.From != orders.OrderStatusDelivered || transition.To != orders.OrderStatusPartiallyRefunded ||
+							transition.ExpectedUpdatedAt != 900 || transition.ActorID != 9 || transition.ActorRole != "admin" ||
+							transition.Note != "return 4 refunded 9.99" {
+							t.Errorf("CompleteReturn() unexpected transition = %+v", transition)
+						}
+						return []int64{3}, nil
+					})
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(3))
+			},
+		},
+		{
+			name:       "success full refund",
+			req:        returns.UpdateReturnStatusRequest{ReturnID: 4, ActorID: 9, Status: "APPROVED"},
+			wantStatus: "REFUNDED",
+			mockFn: func() {
+				mockReturnsRepo.EXPECT().GetReturnByID(gomock.Any(), int64(4)).Return(requested(), nil)
+				mockOrdersRepo.EXPECT().GetOrderByID(gomock.Any(), int64(1)).Return(&orders.Model{ID: 1, UserID: 2, Status: "PARTIALLY_REFUNDED"}, nil)
+				mockReturnsRepo.EXPECT().GetOrderItems(gomock.Any(), int64(1)).Return([]returns.OrderItem{{ID: 5, Quantity: 2, ReturnedQuantity: 1}}, nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusRequested, returns.StatusApproved, "", gomock.Any()).Return(nil)
+				mockPaymentsUC.EXPECT().RefundPayment(gomock.Any(), int64(1), money.MustParse("9.99")).Return(nil)
+				mockReturnsRepo.EXPECT().UpdateReturnStatus(gomock.Any(), int64(4), returns.StatusApproved, returns.StatusRefunding, "", gomock.Any()).Return(nil)
+				mockReturnsRepo.EXPECT().CompleteReturn(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ returns.Model, transition orders.StatusTransition) ([]int64, error) {
+						if transition.From != orders.OrderStatusPartiallyRefunded || transition.To != orders.OrderStatusRefunded {
+							t.Errorf("CompleteReturn() unexpected transition = %+v", transition)
+						}
+						return []int64{3}, nil
+					})
+				mockBooksRepo.EXPECT().InvalidateBookCache(int64(3))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				returnsRepository: mockReturnsRepo,
+				ordersRepository:  mockOrdersRepo,
+				paymentsUsecase:   mockPaymentsUC,
+				booksRepository:   mockBooksRepo,
+			}
+			got, err := u.UpdateReturnStatus(context.Background(), tt.req)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("UpdateReturnStatus() error = %v, want %v", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("UpdateReturnStatus() unexpected error = %v", err)
+				return
+			}
+			if got.Status != tt.wantStatus || got.UpdatedAt <= 1000 {
+				t.Errorf("UpdateReturnStatus() got = %+v, want status %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -2,6 +2,10 @@ package users
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
@@ -10,30 +14,78 @@ import (
 	"github.com/yeremiaaryo/gotu-assignment/pkg/jwt"
 	"golang.org/x/crypto/bcrypt"
 	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Sizes of the random tokens sent by email, of the refresh tokens and of the session ids
+const (
+	emailTokenBytes   = 32
+	refreshTokenBytes = 32
+	sessionIDBytes    = 16
+)
+
+// Defaults of how long the links sent by email and the login tokens work when the config leaves them out
+const (
+	defaultEmailVerificationTTL = 24 * time.Hour
+	defaultPasswordResetTTL     = time.Hour
+	defaultAccessTokenTTL       = 15 * time.Minute
+	defaultRefreshTokenTTL      = 30 * 24 * time.Hour
+	defaultMaxSessions          = 10
+)
+
 //go:generate mockgen -package=users -source=users_usecase.go -destination=users_usecase_mock_test.go
 type usersRepository interface {
 	GetUser(ctx context.Context, email string) (*users.Model, error)
+	GetUserByID(ctx context.Context, id int64) (*users.Model, error)
 	InsertUser(ctx context.Context, model users.Model) (*users.Model, error)
+	VerifyEmail(ctx context.Context, id int64, verifiedAt int64) error
+	UpdatePassword(ctx context.Context, id int64, password string, updatedAt int64) error
+}
+
+type sessionsRepository interface {
+	GetSessions(ctx context.Context, userID int64) ([]users.Session, error)
+	GetSession(ctx context.Context, userID int64, sessionID string) (*users.Session, error)
+	SaveSession(ctx context.Context, session users.Session, ttl time.Duration) error
+	RotateSession(ctx context.Context, session users.Session, previousRefreshHash string, ttl time.Duration) (bool, error)
+	DeleteSession(ctx context.Context, userID int64, sessionID string) (bool, error)
+	DeleteSessions(ctx context.Context, userID int64) error
 }
 
 type redis interface {
 	Set(key string, value string, ttl int64, field ...interface{}) (interface{}, error)
+	GetDel(key string) (string, error)
+	Del(key string, field ...interface{}) (bool, error)
+}
+
+type notifier interface {
+	SendWelcome(ctx context.Context, user users.Model, verifyURL string, expiresIn time.Duration) error
+	SendEmailVerification(ctx context.Context, email, verifyURL string, expiresIn time.Duration) error
+	SendPasswordReset(ctx context.Context, email, resetURL string, expiresIn time.Duration) error
 }
 
 type usecase struct {
-	usersRepository usersRepository
-	redis           redis
-	cfg             *configs.Config
+	usersRepository    usersRepository
+	sessionsRepository sessionsRepository
+	redis              redis
+	notifier           notifier
+	cfg                *configs.Config
 }
 
-func New(usersRepository usersRepository, redis redis, cfg *configs.Config) *usecase {
-	return &usecase{usersRepository: usersRepository, redis: redis, cfg: cfg}
+func New(usersRepository usersRepository, sessionsRepository sessionsRepository, redis redis, notifier notifier, cfg *configs.Config) *usecase {
+	return &usecase{usersRepository: usersRepository, sessionsRepository: sessionsRepository, redis: redis, notifier: notifier, cfg: cfg}
 }
 
 func (u *usecase) CreateUser(ctx context.Context, req users.CreateUserRequest) (*users.Model, error) {
+	req.Email = users.NormalizeEmail(req.Email)
+	req.BillingCountry = strings.ToUpper(strings.TrimSpace(req.BillingCountry))
+	if !u.supportsBillingCountry(req.BillingCountry) {
+		return nil, fmt.Errorf("billing country %s is not supported", req.BillingCountry)
+	}
+
 	user, err := u.usersRepository.GetUser(ctx, req.Email)
 	if err != nil {
 		return nil, err
@@ -49,36 +101,499 @@ func (u *usecase) CreateUser(ctx context.Context, req users.CreateUserRequest) (
 
 	now := time.Now().UnixMilli()
 	model := users.Model{
-		Email:     req.Email,
-		Password:  string(pass),
-		CreatedAt: now,
-		UpdatedAt: now,
+		Email:          req.Email,
+		Password:       string(pass),
+		Role:           users.RoleCustomer.String(),
+		BillingCountry: req.BillingCountry,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 
-	return u.usersRepository.InsertUser(ctx, model)
+	user, err = u.usersRepository.InsertUser(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	// the account is created already, a verification link or a welcome email that fails isn't worth failing the sign up,
+	// the user can ask for another link
+	verifyURL, err := u.createVerificationURL(user.ID)
+	if err != nil {
+		log.Printf("[CreateUser] error when create email verification token of user %d: %v", user.ID, err)
+	}
+	if err = u.notifier.SendWelcome(ctx, *user, verifyURL, u.emailVerificationTTL()); err != nil {
+		log.Printf("[CreateUser] error when send welcome email to %s: %v", user.Email, err)
+	}
+	return user, nil
 }
 
-func (u *usecase) Login(ctx context.Context, req users.LoginRequest) (string, error) {
-	user, err := u.usersRepository.GetUser(ctx, req.Email)
+// supportsBillingCountry checks the country has a tax rate, every order of the user is taxed with it.
+// An empty country falls back to the default country and without rates no tax is charged at all.
+func (u *usecase) supportsBillingCountry(country string) bool {
+	if country == "" || len(u.cfg.Tax.Rates) == 0 {
+		return true
+	}
+	for _, rate := range u.cfg.Tax.Rates {
+		if strings.EqualFold(rate.Country, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// SendEmailVerification emails a new verification link to the user, the links sent before keep working until they expire
+func (u *usecase) SendEmailVerification(ctx context.Context, userID int64) error {
+	user, err := u.usersRepository.GetUserByID(ctx, userID)
 	if err != nil {
-		return "", err
+		return err
 	}
 	if user == nil {
-		return "", errors.New("invalid email")
+		return fmt.Errorf("user with id: %d is not found", userID)
+	}
+	if user.IsEmailVerified() {
+		return errors.New("email is already verified")
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	verifyURL, err := u.createVerificationURL(user.ID)
+	if err != nil {
+		return err
+	}
+	return u.notifier.SendEmailVerification(ctx, user.Email, verifyURL, u.emailVerificationTTL())
+}
+
+// VerifyEmail verifies the email of the user the token was sent to, a token can only be used once
+func (u *usecase) VerifyEmail(ctx context.Context, token string) (*users.Model, error) {
+	userID, err := u.consumeEmailToken(constant.RedisKeyEmailVerification, token)
 	if err != nil {
-		return "", errors.New("invalid email or password")
+		return nil, err
+	}
+	if userID == 0 {
+		return nil, errors.New("invalid or expired verification token")
+	}
+
+	user, err := u.usersRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user with id: %d is not found", userID)
+	}
+	if user.IsEmailVerified() {
+		return user, nil
 	}
 
-	token, err := jwt.CreateToken(user.ID, user.Email, u.cfg.Service.SecretKey)
+	now := time.Now().UnixMilli()
+	err = u.usersRepository.VerifyEmail(ctx, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = now
+	user.UpdatedAt = now
+	return user, nil
+}
+
+// ForgotPassword emails a link to reset the password, only the latest link works. It succeeds for an unknown email too,
+// so the response doesn't tell whether an account exists
+func (u *usecase) ForgotPassword(ctx context.Context, req users.ForgotPasswordRequest) error {
+	user, err := u.usersRepository.GetUser(ctx, users.NormalizeEmail(req.Email))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	ttl := u.passwordResetTTL()
+	token, err := u.createEmailToken(constant.RedisKeyPasswordReset, user.ID, ttl)
+	if err != nil {
+		return err
+	}
+	userKey := fmt.Sprintf(constant.RedisKeyPasswordResetUser, user.ID)
+	previous, err := u.redis.GetDel(userKey)
+	if err != nil {
+		return err
+	}
+	if previous != "" {
+		if _, err = u.redis.Del(fmt.Sprintf(constant.RedisKeyPasswordReset, previous)); err != nil {
+			return err
+		}
+	}
+	if _, err = u.redis.Set(userKey, hashToken(token), int64(ttl.Seconds())); err != nil {
+		return err
+	}
+
+	resetURL := u.cfg.Service.BaseURL + "/password/reset?token=" + url.QueryEscape(token)
+	if err = u.notifier.SendPasswordReset(ctx, user.Email, resetURL, ttl); err != nil {
+		log.Printf("[ForgotPassword] error when send password reset email to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+// ResetPassword sets the password of the user the token was sent to, a token can only be used once
+func (u *usecase) ResetPassword(ctx context.Context, req users.ResetPasswordRequest) error {
+	userID, err := u.consumeEmailToken(constant.RedisKeyPasswordReset, req.Token)
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		return errors.New("invalid or expired password reset token")
+	}
+	if _, err = u.redis.Del(fmt.Sprintf(constant.RedisKeyPasswordResetUser, userID)); err != nil {
+		log.Printf("[ResetPassword] error when delete password reset token of user %d: %v", userID, err)
+	}
+
+	user, err := u.usersRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user with id: %d is not found", userID)
+	}
+	return u.updatePassword(ctx, user.ID, req.Password)
+}
+
+// ChangePassword sets a new password after checking the current one. Every session is logged out, the returned tokens
+// are of the new session of the caller
+func (u *usecase) ChangePassword(ctx context.Context, req users.ChangePasswordRequest) (*users.Tokens, error) {
+	user, err := u.usersRepository.GetUserByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user with id: %d is not found", req.UserID)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword))
+	if err != nil {
+		return nil, errors.New("invalid current password")
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return nil, errors.New("invalid new password, it must be different from the current password")
+	}
+
+	err = u.updatePassword(ctx, user.ID, req.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+	return u.createSession(ctx, *user, req.UserAgent, req.IPAddress)
+}
+
+// updatePassword logs out every session of the user before setting the password, a failed logout leaves the password as it was
+func (u *usecase) updatePassword(ctx context.Context, userID int64, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err = u.sessionsRepository.DeleteSessions(ctx, userID); err != nil {
+		return err
+	}
+	return u.usersRepository.UpdatePassword(ctx, userID, string(hashed), time.Now().UnixMilli())
+}
+
+// createVerificationURL stores a new verification token of the user and returns the link to verify with it
+func (u *usecase) createVerificationURL(userID int64) (string, error) {
+	token, err := u.createEmailToken(constant.RedisKeyEmailVerification, userID, u.emailVerificationTTL())
+	if err != nil {
+		return "", err
+	}
+	return u.cfg.Service.BaseURL + "/email/verify?token=" + url.QueryEscape(token), nil
+}
+
+// createEmailToken stores a new random token of the user under the key format and returns the token.
+// Only the hash of the token is stored, so the tokens can't be read back from redis
+func (u *usecase) createEmailToken(keyFormat string, userID int64, ttl time.Duration) (string, error) {
+	token, err := randomToken(emailTokenBytes)
 	if err != nil {
 		return "", err
 	}
-	_, err = u.redis.Set(fmt.Sprintf(constant.RedisKeyToken, user.ID), token, int64((time.Hour * 24).Seconds()))
+
+	_, err = u.redis.Set(fmt.Sprintf(keyFormat, hashToken(token)), strconv.FormatInt(userID, 10), int64(ttl.Seconds()))
 	if err != nil {
-		log.Printf("[Login] error when set token to redis")
+		return "", err
 	}
 	return token, nil
 }
+
+// consumeEmailToken deletes the token and returns its user, the user is 0 for an unknown, used or expired token
+func (u *usecase) consumeEmailToken(keyFormat, token string) (int64, error) {
+	value, err := u.redis.GetDel(fmt.Sprintf(keyFormat, hashToken(token)))
+	if err != nil {
+		return 0, err
+	}
+	userID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+	return userID, nil
+}
+
+func (u *usecase) emailVerificationTTL() time.Duration {
+	if u.cfg.Auth.EmailVerificationTTL <= 0 {
+		return defaultEmailVerificationTTL
+	}
+	return u.cfg.Auth.EmailVerificationTTL
+}
+
+func (u *usecase) passwordResetTTL() time.Duration {
+	if u.cfg.Auth.PasswordResetTTL <= 0 {
+		return defaultPasswordResetTTL
+	}
+	return u.cfg.Auth.PasswordResetTTL
+}
+
+func (u *usecase) accessTokenTTL() time.Duration {
+	if u.cfg.Auth.AccessTokenTTL <= 0 {
+		return defaultAccessTokenTTL
+	}
+	return u.cfg.Auth.AccessTokenTTL
+}
+
+func (u *usecase) refreshTokenTTL() time.Duration {
+	if u.cfg.Auth.RefreshTokenTTL <= 0 {
+		return defaultRefreshTokenTTL
+	}
+	return u.cfg.Auth.RefreshTokenTTL
+}
+
+func (u *usecase) maxSessions() int {
+	if u.cfg.Auth.MaxSessions <= 0 {
+		return defaultMaxSessions
+	}
+	return u.cfg.Auth.MaxSessions
+}
+
+func randomToken(size int) (string, error) {
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Login starts a new session for the device of the request, the other sessions of the user stay logged in
+func (u *usecase) Login(ctx context.Context, req users.LoginRequest) (*users.Tokens, error) {
+	user, err := u.usersRepository.GetUser(ctx, users.NormalizeEmail(req.Email))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("invalid email")
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	if err != nil {
+		return nil, errors.New("invalid email or password")
+	}
+
+	return u.createSession(ctx, *user, req.UserAgent, req.IPAddress)
+}
+
+// RefreshToken rotates the refresh token of the session and issues a new access token. A refresh token that was rotated
+// already means it leaked or the client lost the latest one, the session is logged out since it can't tell which.
+// The rotation is a compare-and-swap on the refresh hash, so of two refreshes with the same token the second one is a reuse too
+func (u *usecase) RefreshToken(ctx context.Context, req users.RefreshTokenRequest) (*users.Tokens, error) {
+	userID, sessionID, secret, ok := parseRefreshToken(req.RefreshToken)
+	if !ok {
+		return nil, errors.New("invalid refresh token")
+	}
+	session, err := u.sessionsRepository.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	now := time.Now()
+	if session.IsExpired(now.UnixMilli()) {
+		if _, err = u.sessionsRepository.DeleteSession(ctx, userID, sessionID); err != nil {
+			log.Printf("[RefreshToken] error when delete expired session %s of user %d: %v", sessionID, userID, err)
+		}
+		return nil, errors.New("refresh token is expired, please re-login")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(session.RefreshHash)) != 1 {
+		return nil, u.logoutReusedSession(ctx, userID, sessionID)
+	}
+
+	user, err := u.usersRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	secret, err = randomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	previousRefreshHash := session.RefreshHash
+	session.RefreshHash = hashToken(secret)
+	session.LastUsedAt = now.UnixMilli()
+	session.ExpiresAt = now.Add(u.refreshTokenTTL()).UnixMilli()
+	if req.UserAgent != "" {
+		session.UserAgent = req.UserAgent
+	}
+	if req.IPAddress != "" {
+		session.IPAddress = req.IPAddress
+	}
+	rotated, err := u.sessionsRepository.RotateSession(ctx, *session, previousRefreshHash, u.refreshTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, u.logoutReusedSession(ctx, userID, sessionID)
+	}
+	return u.createTokens(*user, session.ID, secret)
+}
+
+// logoutReusedSession logs out the session of a refresh token that was used already and returns the error of the refresh
+func (u *usecase) logoutReusedSession(ctx context.Context, userID int64, sessionID string) error {
+	if _, err := u.sessionsRepository.DeleteSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	log.Printf("[RefreshToken] refresh token of session %s of user %d is reused, the session is logged out", sessionID, userID)
+	return errors.New("refresh token is already used, the session is logged out, please re-login")
+}
+
+// Logout logs out the session, a session that is gone already is logged out too
+func (u *usecase) Logout(ctx context.Context, userID int64, sessionID string) error {
+	_, err := u.sessionsRepository.DeleteSession(ctx, userID, sessionID)
+	return err
+}
+
+// GetSessions returns the sessions of the user that are still logged in, the most recently used first
+func (u *usecase) GetSessions(ctx context.Context, userID int64, currentSessionID string) ([]users.SessionInfo, error) {
+	sessions, err := u.sessionsRepository.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	infos := make([]users.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsExpired(now) {
+			continue
+		}
+		infos = append(infos, session.Info(currentSessionID))
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].LastUsedAt > infos[j].LastUsedAt
+	})
+	return infos, nil
+}
+
+// RevokeSession logs out a session of the user, its access token stops working right away
+func (u *usecase) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	deleted, err := u.sessionsRepository.DeleteSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("session with id: %s is not found", sessionID)
+	}
+	return nil
+}
+
+// RevokeOtherSessions logs out every session of the user except the current one
+func (u *usecase) RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) error {
+	sessions, err := u.sessionsRepository.GetSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+		if _, err = u.sessionsRepository.DeleteSession(ctx, userID, session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSession stores a new session of the user and returns its tokens. The expired sessions are cleaned up on the way,
+// and the least recently used sessions are logged out when the user has too many
+func (u *usecase) createSession(ctx context.Context, user users.Model, userAgent, ipAddress string) (*users.Tokens, error) {
+	sessions, err := u.sessionsRepository.GetSessions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]users.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.IsExpired(now.UnixMilli()) {
+			active = append(active, session)
+			continue
+		}
+		if _, err = u.sessionsRepository.DeleteSession(ctx, user.ID, session.ID); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].LastUsedAt < active[j].LastUsedAt
+	})
+	for len(active) >= u.maxSessions() {
+		if _, err = u.sessionsRepository.DeleteSession(ctx, user.ID, active[0].ID); err != nil {
+			return nil, err
+		}
+		active = active[1:]
+	}
+
+	sessionID, err := randomToken(sessionIDBytes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	session := users.Session{
+		ID:          sessionID,
+		UserID:      user.ID,
+		UserAgent:   userAgent,
+		IPAddress:   ipAddress,
+		RefreshHash: hashToken(secret),
+		CreatedAt:   now.UnixMilli(),
+		LastUsedAt:  now.UnixMilli(),
+		ExpiresAt:   now.Add(u.refreshTokenTTL()).UnixMilli(),
+	}
+	err = u.sessionsRepository.SaveSession(ctx, session, u.refreshTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+	return u.createTokens(user, session.ID, secret)
+}
+
+// createTokens signs the access token of the session, the refresh token carries the user and the session so the
+// session can be found without the access token
+func (u *usecase) createTokens(user users.Model, sessionID, secret string) (*users.Tokens, error) {
+	ttl := u.accessTokenTTL()
+	token, err := jwt.CreateToken(jwt.Claims{ID: user.ID, Email: user.Email, Role: user.Role, SessionID: sessionID},
+		u.cfg.Service.SecretKey, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &users.Tokens{
+		Token:        token,
+		RefreshToken: fmt.Sprintf("%d.%s.%s", user.ID, sessionID, secret),
+		ExpiresIn:    int64(ttl.Seconds()),
+	}, nil
+}
+
+// parseRefreshToken splits the refresh token into its user, session and secret
+func parseRefreshToken(token string) (int64, string, string, bool) {
+	parts := strings.SplitN(token, ".", 3)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return 0, "", "", false
+	}
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", "", false
+	}
+	return userID, parts[1], parts[2], true
+}
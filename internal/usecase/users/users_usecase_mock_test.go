@@ -7,6 +7,7 @@ package users
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	users "github.com/yeremiaaryo/gotu-assignment/internal/model/users"
@@ -50,6 +51,21 @@ func (mr *MockusersRepositoryMockRecorder) GetUser(ctx, email interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockusersRepository)(nil).GetUser), ctx, email)
 }
 
+// GetUserByID mocks base method.
+func (m *MockusersRepository) GetUserByID(ctx context.Context, id int64) (*users.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(*users.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockusersRepositoryMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockusersRepository)(nil).GetUserByID), ctx, id)
+}
+
 // InsertUser mocks base method.
 func (m *MockusersRepository) InsertUser(ctx context.Context, model users.Model) (*users.Model, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +81,145 @@ func (mr *MockusersRepositoryMockRecorder) InsertUser(ctx, model interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUser", reflect.TypeOf((*MockusersRepository)(nil).InsertUser), ctx, model)
 }
 
+// UpdatePassword mocks base method.
+func (m *MockusersRepository) UpdatePassword(ctx context.Context, id int64, password string, updatedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, password, updatedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockusersRepositoryMockRecorder) UpdatePassword(ctx, id, password, updatedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockusersRepository)(nil).UpdatePassword), ctx, id, password, updatedAt)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockusersRepository) VerifyEmail(ctx context.Context, id, verifiedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, id, verifiedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockusersRepositoryMockRecorder) VerifyEmail(ctx, id, verifiedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockusersRepository)(nil).VerifyEmail), ctx, id, verifiedAt)
+}
+
+// MocksessionsRepository is a mock of sessionsRepository interface.
+type MocksessionsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MocksessionsRepositoryMockRecorder
+}
+
+// MocksessionsRepositoryMockRecorder is the mock recorder for MocksessionsRepository.
+type MocksessionsRepositoryMockRecorder struct {
+	mock *MocksessionsRepository
+}
+
+// NewMocksessionsRepository creates a new mock instance.
+func NewMocksessionsRepository(ctrl *gomock.Controller) *MocksessionsRepository {
+	mock := &MocksessionsRepository{ctrl: ctrl}
+	mock.recorder = &MocksessionsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocksessionsRepository) EXPECT() *MocksessionsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteSession mocks base method.
+func (m *MocksessionsRepository) DeleteSession(ctx context.Context, userID int64, sessionID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MocksessionsRepositoryMockRecorder) DeleteSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MocksessionsRepository)(nil).DeleteSession), ctx, userID, sessionID)
+}
+
+// DeleteSessions mocks base method.
+func (m *MocksessionsRepository) DeleteSessions(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSessions indicates an expected call of DeleteSessions.
+func (mr *MocksessionsRepositoryMockRecorder) DeleteSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessions", reflect.TypeOf((*MocksessionsRepository)(nil).DeleteSessions), ctx, userID)
+}
+
+// GetSession mocks base method.
+func (m *MocksessionsRepository) GetSession(ctx context.Context, userID int64, sessionID string) (*users.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(*users.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MocksessionsRepositoryMockRecorder) GetSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MocksessionsRepository)(nil).GetSession), ctx, userID, sessionID)
+}
+
+// GetSessions mocks base method.
+func (m *MocksessionsRepository) GetSessions(ctx context.Context, userID int64) ([]users.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, userID)
+	ret0, _ := ret[0].([]users.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MocksessionsRepositoryMockRecorder) GetSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MocksessionsRepository)(nil).GetSessions), ctx, userID)
+}
+
+// RotateSession mocks base method.
+func (m *MocksessionsRepository) RotateSession(ctx context.Context, session users.Session, previousRefreshHash string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSession", ctx, session, previousRefreshHash, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSession indicates an expected call of RotateSession.
+func (mr *MocksessionsRepositoryMockRecorder) RotateSession(ctx, session, previousRefreshHash, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSession", reflect.TypeOf((*MocksessionsRepository)(nil).RotateSession), ctx, session, previousRefreshHash, ttl)
+}
+
+// SaveSession mocks base method.
+func (m *MocksessionsRepository) SaveSession(ctx context.Context, session users.Session, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", ctx, session, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession.
+func (mr *MocksessionsRepositoryMockRecorder) SaveSession(ctx, session, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MocksessionsRepository)(nil).SaveSession), ctx, session, ttl)
+}
+
 // Mockredis is a mock of redis interface.
 type Mockredis struct {
 	ctrl     *gomock.Controller
@@ -88,6 +243,41 @@ func (m *Mockredis) EXPECT() *MockredisMockRecorder {
 	return m.recorder
 }
 
+// Del mocks base method.
+func (m *Mockredis) Del(key string, field ...interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{key}
+	for _, a := range field {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Del", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockredisMockRecorder) Del(key interface{}, field ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{key}, field...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*Mockredis)(nil).Del), varargs...)
+}
+
+// GetDel mocks base method.
+func (m *Mockredis) GetDel(key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDel", key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDel indicates an expected call of GetDel.
+func (mr *MockredisMockRecorder) GetDel(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDel", reflect.TypeOf((*Mockredis)(nil).GetDel), key)
+}
+
 // Set mocks base method.
 func (m *Mockredis) Set(key, value string, ttl int64, field ...interface{}) (interface{}, error) {
 	m.ctrl.T.Helper()
@@ -107,3 +297,68 @@ func (mr *MockredisMockRecorder) Set(key, value, ttl interface{}, field ...inter
 	varargs := append([]interface{}{key, value, ttl}, field...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*Mockredis)(nil).Set), varargs...)
 }
+
+// Mocknotifier is a mock of notifier interface.
+type Mocknotifier struct {
+	ctrl     *gomock.Controller
+	recorder *MocknotifierMockRecorder
+}
+
+// MocknotifierMockRecorder is the mock recorder for Mocknotifier.
+type MocknotifierMockRecorder struct {
+	mock *Mocknotifier
+}
+
+// NewMocknotifier creates a new mock instance.
+func NewMocknotifier(ctrl *gomock.Controller) *Mocknotifier {
+	mock := &Mocknotifier{ctrl: ctrl}
+	mock.recorder = &MocknotifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocknotifier) EXPECT() *MocknotifierMockRecorder {
+	return m.recorder
+}
+
+// SendEmailVerification mocks base method.
+func (m *Mocknotifier) SendEmailVerification(ctx context.Context, email, verifyURL string, expiresIn time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendEmailVerification", ctx, email, verifyURL, expiresIn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendEmailVerification indicates an expected call of SendEmailVerification.
+func (mr *MocknotifierMockRecorder) SendEmailVerification(ctx, email, verifyURL, expiresIn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendEmailVerification", reflect.TypeOf((*Mocknotifier)(nil).SendEmailVerification), ctx, email, verifyURL, expiresIn)
+}
+
+// SendPasswordReset mocks base method.
+func (m *Mocknotifier) SendPasswordReset(ctx context.Context, email, resetURL string, expiresIn time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendPasswordReset", ctx, email, resetURL, expiresIn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendPasswordReset indicates an expected call of SendPasswordReset.
+func (mr *MocknotifierMockRecorder) SendPasswordReset(ctx, email, resetURL, expiresIn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPasswordReset", reflect.TypeOf((*Mocknotifier)(nil).SendPasswordReset), ctx, email, resetURL, expiresIn)
+}
+
+// SendWelcome mocks base method.
+func (m *Mocknotifier) SendWelcome(ctx context.Context, user users.Model, verifyURL string, expiresIn time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendWelcome", ctx, user, verifyURL, expiresIn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendWelcome indicates an expected call of SendWelcome.
+func (mr *MocknotifierMockRecorder) SendWelcome(ctx, user, verifyURL, expiresIn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendWelcome", reflect.TypeOf((*Mocknotifier)(nil).SendWelcome), ctx, user, verifyURL, expiresIn)
+}
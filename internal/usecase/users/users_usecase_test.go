@@ -4,19 +4,41 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
 	"github.com/yeremiaaryo/gotu-assignment/internal/model/users"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var testConfig = &configs.Config{
+	Service: configs.Service{BaseURL: "http://localhost:9999"},
+	Auth:    configs.AuthConfig{EmailVerificationTTL: time.Hour},
+	Tax: configs.TaxConfig{
+		DefaultCountry: "ID",
+		Rates:          []configs.TaxRateConfig{{Country: "ID", Rate: "11", Inclusive: true}, {Country: "CA", Rate: "5"}},
+	},
+}
+
 func Test_usecase_CreateUser(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
 	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockRedis := NewMockredis(mockCtrl)
+	mockNotifier := NewMocknotifier(mockCtrl)
+
+	// the link carries a fresh random token, only its prefix is known upfront
+	expectVerifyURL := func(ctx context.Context, user users.Model, verifyURL string, expiresIn time.Duration) error {
+		if !strings.HasPrefix(verifyURL, "http://localhost:9999/email/verify?token=") {
+			t.Errorf("SendWelcome() verifyURL = %v", verifyURL)
+		}
+		return nil
+	}
 
 	type args struct {
 		ctx context.Context
@@ -57,8 +79,52 @@ func Test_usecase_CreateUser(t *testing.T) {
 				mockUsersRepo.EXPECT().GetUser(gomock.Any(), args.req.Email).Return(&users.Model{}, nil)
 			},
 		},
+		{
+			name: "error billing country without tax rate",
+			args: args{
+				ctx: context.Background(),
+				req: users.CreateUserRequest{
+					Email:          "email@email.com",
+					Password:       "pass",
+					BillingCountry: "FR",
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn:  func(args args) {},
+		},
 		{
 			name: "success",
+			args: args{
+				ctx: context.Background(),
+				req: users.CreateUserRequest{
+					Email:          " Email@Email.com ",
+					Password:       "pass",
+					BillingCountry: "ca",
+				},
+			},
+			want: &users.Model{
+				ID:             1,
+				Email:          "email@email.com",
+				Role:           users.RoleCustomer.String(),
+				BillingCountry: "CA",
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(nil, nil)
+				mockUsersRepo.EXPECT().InsertUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, model users.Model) (*users.Model, error) {
+					model.ID = 1
+					model.Password = ""
+					model.CreatedAt, model.UpdatedAt = 0, 0
+					return &model, nil
+				})
+				mockRedis.EXPECT().Set(gomock.Any(), "1", int64(3600)).Return("OK", nil)
+				mockNotifier.EXPECT().SendWelcome(gomock.Any(), users.Model{ID: 1, Email: "email@email.com", Role: users.RoleCustomer.String(),
+					BillingCountry: "CA"}, gomock.Any(), time.Hour).DoAndReturn(expectVerifyURL)
+			},
+		},
+		{
+			name: "success when the verification token and welcome email fail",
 			args: args{
 				ctx: context.Background(),
 				req: users.CreateUserRequest{
@@ -69,14 +135,35 @@ func Test_usecase_CreateUser(t *testing.T) {
 			want: &users.Model{
 				ID:    1,
 				Email: "email@email.com",
+				Role:  users.RoleCustomer.String(),
 			},
 			wantErr: false,
 			mockFn: func(args args) {
 				mockUsersRepo.EXPECT().GetUser(gomock.Any(), args.req.Email).Return(nil, nil)
-				mockUsersRepo.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(&users.Model{
-					ID:    1,
-					Email: "email@email.com",
-				}, nil)
+				mockUsersRepo.EXPECT().InsertUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, model users.Model) (*users.Model, error) {
+					model.ID = 1
+					model.Password = ""
+					model.CreatedAt, model.UpdatedAt = 0, 0
+					return &model, nil
+				})
+				mockRedis.EXPECT().Set(gomock.Any(), "1", int64(3600)).Return(nil, errors.New("failed"))
+				mockNotifier.EXPECT().SendWelcome(gomock.Any(), gomock.Any(), "", time.Hour).Return(errors.New("mail queue is full"))
+			},
+		},
+		{
+			name: "error when InsertUser",
+			args: args{
+				ctx: context.Background(),
+				req: users.CreateUserRequest{
+					Email:    "email@email.com",
+					Password: "pass",
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			mockFn: func(args args) {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), args.req.Email).Return(nil, nil)
+				mockUsersRepo.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(nil, errors.New("failed"))
 			},
 		},
 	}
@@ -85,6 +172,9 @@ func Test_usecase_CreateUser(t *testing.T) {
 			tt.mockFn(tt.args)
 			u := &usecase{
 				usersRepository: mockUsersRepo,
+				redis:           mockRedis,
+				notifier:        mockNotifier,
+				cfg:             testConfig,
 			}
 			got, err := u.CreateUser(tt.args.ctx, tt.args.req)
 			if (err != nil) != tt.wantErr {
@@ -103,7 +193,18 @@ func Test_usecase_Login(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	mockUsersRepo := NewMockusersRepository(mockCtrl)
-	mockRedis := NewMockredis(mockCtrl)
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+
+	now := time.Now().UnixMilli()
+	expectNewSession := func(ctx context.Context, session users.Session, ttl time.Duration) error {
+		assert.Equal(t, int64(123), session.UserID)
+		assert.Equal(t, "Firefox", session.UserAgent)
+		assert.Equal(t, "10.0.0.1", session.IPAddress)
+		assert.NotEmpty(t, session.ID)
+		assert.NotEmpty(t, session.RefreshHash)
+		assert.Equal(t, 720*time.Hour, ttl)
+		return nil
+	}
 
 	type args struct {
 		ctx context.Context
@@ -142,7 +243,21 @@ func Test_usecase_Login(t *testing.T) {
 			},
 		},
 		{
-			name: "success",
+			name: "error wrong password",
+			args: args{
+				ctx: context.Background(),
+				req: users.LoginRequest{
+					Email:    "email@email.com",
+					Password: "54321",
+				},
+			},
+			wantErr: true,
+			mockFn: func(args args) {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(&users.Model{ID: 123, Password: `$2a$10$Kes/ccWjDAw01VM1STV8mePua4YOpMwldDqlLq7GltRvJr/zdj7zq`}, nil)
+			},
+		},
+		{
+			name: "error when SaveSession",
 			args: args{
 				ctx: context.Background(),
 				req: users.LoginRequest{
@@ -150,10 +265,53 @@ func Test_usecase_Login(t *testing.T) {
 					Password: "12345",
 				},
 			},
+			wantErr: true,
+			mockFn: func(args args) {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(&users.Model{ID: 123, Password: `$2a$10$Kes/ccWjDAw01VM1STV8mePua4YOpMwldDqlLq7GltRvJr/zdj7zq`}, nil)
+				mockSessionsRepo.EXPECT().GetSessions(gomock.Any(), int64(123)).Return(nil, nil)
+				mockSessionsRepo.EXPECT().SaveSession(gomock.Any(), gomock.Any(), 720*time.Hour).Return(errors.New("failed"))
+			},
+		},
+		{
+			name: "success keeps the other sessions",
+			args: args{
+				ctx: context.Background(),
+				req: users.LoginRequest{
+					Email:     "Email@email.com",
+					Password:  "12345",
+					UserAgent: "Firefox",
+					IPAddress: "10.0.0.1",
+				},
+			},
 			wantErr: false,
 			mockFn: func(args args) {
-				mockUsersRepo.EXPECT().GetUser(gomock.Any(), args.req.Email).Return(&users.Model{ID: 123, Password: `$2a$10$Kes/ccWjDAw01VM1STV8mePua4YOpMwldDqlLq7GltRvJr/zdj7zq`}, nil)
-				mockRedis.EXPECT().Set("token:123", gomock.Any(), int64((24 * time.Hour).Seconds()))
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(&users.Model{ID: 123, Password: `$2a$10$Kes/ccWjDAw01VM1STV8mePua4YOpMwldDqlLq7GltRvJr/zdj7zq`}, nil)
+				mockSessionsRepo.EXPECT().GetSessions(gomock.Any(), int64(123)).Return([]users.Session{{ID: "a1", UserID: 123, ExpiresAt: now + 1000}}, nil)
+				mockSessionsRepo.EXPECT().SaveSession(gomock.Any(), gomock.Any(), 720*time.Hour).DoAndReturn(expectNewSession)
+			},
+		},
+		{
+			name: "success cleans up expired sessions and logs out the least recently used one",
+			args: args{
+				ctx: context.Background(),
+				req: users.LoginRequest{
+					Email:     "email@email.com",
+					Password:  "12345",
+					UserAgent: "Firefox",
+					IPAddress: "10.0.0.1",
+				},
+			},
+			wantErr: false,
+			mockFn: func(args args) {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(&users.Model{ID: 123, Password: `$2a$10$Kes/ccWjDAw01VM1STV8mePua4YOpMwldDqlLq7GltRvJr/zdj7zq`}, nil)
+				mockSessionsRepo.EXPECT().GetSessions(gomock.Any(), int64(123)).Return([]users.Session{
+					{ID: "a1", UserID: 123, LastUsedAt: now - 1000, ExpiresAt: now + 1000},
+					{ID: "b2", UserID: 123, LastUsedAt: now - 3000, ExpiresAt: now - 1000},
+					{ID: "c3", UserID: 123, LastUsedAt: now - 2000, ExpiresAt: now + 1000},
+				}, nil)
+				mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "b2").Return(true, nil)
+				mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "c3").Return(true, nil)
+				mockSessionsRepo.EXPECT().SaveSession(gomock.Any(), gomock.Any(), 720*time.Hour).DoAndReturn(expectNewSession)
 			},
 		},
 	}
@@ -161,12 +319,13 @@ func Test_usecase_Login(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockFn(tt.args)
 			u := &usecase{
-				usersRepository: mockUsersRepo,
-				redis:           mockRedis,
+				usersRepository:    mockUsersRepo,
+				sessionsRepository: mockSessionsRepo,
 				cfg: &configs.Config{
 					Service: configs.Service{
 						SecretKey: "secretkey",
 					},
+					Auth: configs.AuthConfig{MaxSessions: 2},
 				},
 			}
 			got, err := u.Login(tt.args.ctx, tt.args.req)
@@ -174,9 +333,575 @@ func Test_usecase_Login(t *testing.T) {
 				t.Errorf("Login() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got == "" && !tt.wantErr {
-				t.Errorf("Login() got = %v", got)
+			if !tt.wantErr {
+				assert.NotEmpty(t, got.Token)
+				assert.True(t, strings.HasPrefix(got.RefreshToken, "123."))
+				assert.Equal(t, int64((15 * time.Minute).Seconds()), got.ExpiresIn)
+			}
+		})
+	}
+}
+
+func Test_usecase_SendEmailVerification(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockRedis := NewMockredis(mockCtrl)
+	mockNotifier := NewMocknotifier(mockCtrl)
+
+	tests := []struct {
+		name    string
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name:    "error user not found",
+			wantErr: "user with id: 1 is not found",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(nil, nil)
+			},
+		},
+		{
+			name:    "error already verified",
+			wantErr: "email is already verified",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, EmailVerifiedAt: 1000}, nil)
+			},
+		},
+		{
+			name:    "error when set token",
+			wantErr: "failed",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, Email: "email@email.com"}, nil)
+				mockRedis.EXPECT().Set(gomock.Any(), "1", int64(3600)).Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, Email: "email@email.com"}, nil)
+				mockRedis.EXPECT().Set(gomock.Any(), "1", int64(3600)).Return("OK", nil)
+				mockNotifier.EXPECT().SendEmailVerification(gomock.Any(), "email@email.com", gomock.Any(), time.Hour).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				usersRepository: mockUsersRepo,
+				redis:           mockRedis,
+				notifier:        mockNotifier,
+				cfg:             testConfig,
+			}
+			err := u.SendEmailVerification(context.Background(), 1)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_usecase_VerifyEmail(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockRedis := NewMockredis(mockCtrl)
+
+	key := "email_verification:" + hashToken("token")
+
+	tests := []struct {
+		name    string
+		want    *users.Model
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name:    "error unknown, expired or used token",
+			wantErr: "invalid or expired verification token",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("", nil)
+			},
+		},
+		{
+			name:    "error when get token",
+			wantErr: "failed",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("", errors.New("failed"))
+			},
+		},
+		{
+			name:    "error user not found",
+			wantErr: "user with id: 1 is not found",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("1", nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(nil, nil)
+			},
+		},
+		{
+			name: "success already verified",
+			want: &users.Model{ID: 1, Email: "email@email.com", EmailVerifiedAt: 1000},
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("1", nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, Email: "email@email.com", EmailVerifiedAt: 1000}, nil)
+			},
+		},
+		{
+			name:    "error when verify email",
+			wantErr: "failed",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("1", nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, Email: "email@email.com"}, nil)
+				mockUsersRepo.EXPECT().VerifyEmail(gomock.Any(), int64(1), gomock.Any()).Return(errors.New("failed"))
+			},
+		},
+		{
+			name: "success",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("1", nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1, Email: "email@email.com"}, nil)
+				mockUsersRepo.EXPECT().VerifyEmail(gomock.Any(), int64(1), gomock.Any()).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				usersRepository: mockUsersRepo,
+				redis:           mockRedis,
+				cfg:             testConfig,
 			}
+			got, err := u.VerifyEmail(context.Background(), "token")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.want != nil {
+				assert.Equal(t, tt.want, got)
+				return
+			}
+			assert.True(t, got.IsEmailVerified())
 		})
 	}
 }
+
+func Test_usecase_ForgotPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockRedis := NewMockredis(mockCtrl)
+	mockNotifier := NewMocknotifier(mockCtrl)
+
+	tests := []struct {
+		name    string
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error when GetUser",
+			wantErr: true,
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name: "success unknown email sends nothing",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(nil, nil)
+			},
+		},
+		{
+			name:    "error when set token",
+			wantErr: true,
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(&users.Model{ID: 1, Email: "email@email.com"}, nil)
+				mockRedis.EXPECT().Set(gomock.Any(), "1", int64(1800)).Return(nil, errors.New("failed"))
+			},
+		},
+		{
+			name: "success revokes the link sent before",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(&users.Model{ID: 1, Email: "email@email.com"}, nil)
+				mockRedis.EXPECT().Set(gomock.Any(), "1", int64(1800)).Return("OK", nil)
+				mockRedis.EXPECT().GetDel("password_reset:user:1").Return("previous", nil)
+				mockRedis.EXPECT().Del("password_reset:previous").Return(true, nil)
+				mockRedis.EXPECT().Set("password_reset:user:1", gomock.Any(), int64(1800)).Return("OK", nil)
+				mockNotifier.EXPECT().SendPasswordReset(gomock.Any(), "email@email.com", gomock.Any(), 30*time.Minute).
+					DoAndReturn(func(ctx context.Context, email, resetURL string, expiresIn time.Duration) error {
+						assert.True(t, strings.HasPrefix(resetURL, "http://localhost:9999/password/reset?token="))
+						return nil
+					})
+			},
+		},
+		{
+			name: "success when the email can't be queued",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUser(gomock.Any(), "email@email.com").Return(&users.Model{ID: 1, Email: "email@email.com"}, nil)
+				mockRedis.EXPECT().Set(gomock.Any(), "1", int64(1800)).Return("OK", nil)
+				mockRedis.EXPECT().GetDel("password_reset:user:1").Return("", nil)
+				mockRedis.EXPECT().Set("password_reset:user:1", gomock.Any(), int64(1800)).Return("OK", nil)
+				mockNotifier.EXPECT().SendPasswordReset(gomock.Any(), "email@email.com", gomock.Any(), 30*time.Minute).
+					Return(errors.New("mail queue is full"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				usersRepository: mockUsersRepo,
+				redis:           mockRedis,
+				notifier:        mockNotifier,
+				cfg: &configs.Config{
+					Service: configs.Service{BaseURL: "http://localhost:9999"},
+					Auth:    configs.AuthConfig{PasswordResetTTL: 30 * time.Minute},
+				},
+			}
+			err := u.ForgotPassword(context.Background(), users.ForgotPasswordRequest{Email: "Email@email.com"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ForgotPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_usecase_ResetPassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockRedis := NewMockredis(mockCtrl)
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+
+	key := "password_reset:" + hashToken("token")
+
+	tests := []struct {
+		name    string
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name:    "error unknown, expired or used token",
+			wantErr: "invalid or expired password reset token",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("", nil)
+			},
+		},
+		{
+			name:    "error when log out the sessions",
+			wantErr: "failed",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("1", nil)
+				mockRedis.EXPECT().Del("password_reset:user:1").Return(true, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1}, nil)
+				mockSessionsRepo.EXPECT().DeleteSessions(gomock.Any(), int64(1)).Return(errors.New("failed"))
+			},
+		},
+		{
+			name: "success logs out every session",
+			mockFn: func() {
+				mockRedis.EXPECT().GetDel(key).Return("1", nil)
+				mockRedis.EXPECT().Del("password_reset:user:1").Return(true, nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(1)).Return(&users.Model{ID: 1}, nil)
+				mockSessionsRepo.EXPECT().DeleteSessions(gomock.Any(), int64(1)).Return(nil)
+				mockUsersRepo.EXPECT().UpdatePassword(gomock.Any(), int64(1), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, id int64, password string, updatedAt int64) error {
+						assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(password), []byte("new-password")))
+						return nil
+					})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				usersRepository:    mockUsersRepo,
+				sessionsRepository: mockSessionsRepo,
+				redis:              mockRedis,
+				cfg:                testConfig,
+			}
+			err := u.ResetPassword(context.Background(), users.ResetPasswordRequest{Token: "token", Password: "new-password"})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_usecase_ChangePassword(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+
+	// the hash of the current password 12345
+	user := &users.Model{ID: 123, Email: "email@email.com", Password: `$2a$10$Kes/ccWjDAw01VM1STV8mePua4YOpMwldDqlLq7GltRvJr/zdj7zq`}
+
+	tests := []struct {
+		name    string
+		req     users.ChangePasswordRequest
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name:    "error wrong current password",
+			req:     users.ChangePasswordRequest{UserID: 123, CurrentPassword: "54321", NewPassword: "new-password"},
+			wantErr: "invalid current password",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(user, nil)
+			},
+		},
+		{
+			name:    "error same password",
+			req:     users.ChangePasswordRequest{UserID: 123, CurrentPassword: "12345", NewPassword: "12345"},
+			wantErr: "invalid new password, it must be different from the current password",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(user, nil)
+			},
+		},
+		{
+			name:    "error when UpdatePassword",
+			req:     users.ChangePasswordRequest{UserID: 123, CurrentPassword: "12345", NewPassword: "new-password"},
+			wantErr: "failed",
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(user, nil)
+				mockSessionsRepo.EXPECT().DeleteSessions(gomock.Any(), int64(123)).Return(nil)
+				mockUsersRepo.EXPECT().UpdatePassword(gomock.Any(), int64(123), gomock.Any(), gomock.Any()).Return(errors.New("failed"))
+			},
+		},
+		{
+			name: "success logs out every session and starts a new one",
+			req: users.ChangePasswordRequest{UserID: 123, CurrentPassword: "12345", NewPassword: "new-password", UserAgent: "Firefox",
+				IPAddress: "10.0.0.1"},
+			mockFn: func() {
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(user, nil)
+				mockSessionsRepo.EXPECT().DeleteSessions(gomock.Any(), int64(123)).Return(nil)
+				mockUsersRepo.EXPECT().UpdatePassword(gomock.Any(), int64(123), gomock.Any(), gomock.Any()).Return(nil)
+				mockSessionsRepo.EXPECT().GetSessions(gomock.Any(), int64(123)).Return(nil, nil)
+				mockSessionsRepo.EXPECT().SaveSession(gomock.Any(), gomock.Any(), 720*time.Hour).
+					DoAndReturn(func(ctx context.Context, session users.Session, ttl time.Duration) error {
+						assert.Equal(t, "Firefox", session.UserAgent)
+						return nil
+					})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				usersRepository:    mockUsersRepo,
+				sessionsRepository: mockSessionsRepo,
+				cfg: &configs.Config{
+					Service: configs.Service{SecretKey: "secretkey"},
+				},
+			}
+			tokens, err := u.ChangePassword(context.Background(), tt.req)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.Token)
+			assert.NotEmpty(t, tokens.RefreshToken)
+		})
+	}
+}
+
+func Test_usecase_RefreshToken(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUsersRepo := NewMockusersRepository(mockCtrl)
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+
+	now := time.Now().UnixMilli()
+	session := func(expiresAt int64) *users.Session {
+		return &users.Session{ID: "a1", UserID: 123, UserAgent: "Firefox", IPAddress: "10.0.0.1", RefreshHash: hashToken("secret"),
+			CreatedAt: now - 5000, LastUsedAt: now - 5000, ExpiresAt: expiresAt}
+	}
+
+	tests := []struct {
+		name         string
+		refreshToken string
+		wantErr      string
+		mockFn       func()
+	}{
+		{
+			name:         "error malformed token",
+			refreshToken: "secret",
+			wantErr:      "invalid refresh token",
+			mockFn:       func() {},
+		},
+		{
+			name:         "error session is logged out",
+			refreshToken: "123.a1.secret",
+			wantErr:      "invalid refresh token",
+			mockFn: func() {
+				mockSessionsRepo.EXPECT().GetSession(gomock.Any(), int64(123), "a1").Return(nil, nil)
+			},
+		},
+		{
+			name:         "error session is expired",
+			refreshToken: "123.a1.secret",
+			wantErr:      "refresh token is expired, please re-login",
+			mockFn: func() {
+				mockSessionsRepo.EXPECT().GetSession(gomock.Any(), int64(123), "a1").Return(session(now-1000), nil)
+				mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "a1").Return(true, nil)
+			},
+		},
+		{
+			name:         "error reused token logs out the session",
+			refreshToken: "123.a1.rotated",
+			wantErr:      "refresh token is already used, the session is logged out, please re-login",
+			mockFn: func() {
+				mockSessionsRepo.EXPECT().GetSession(gomock.Any(), int64(123), "a1").Return(session(now+100000), nil)
+				mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "a1").Return(true, nil)
+			},
+		},
+		{
+			name:         "error user is gone",
+			refreshToken: "123.a1.secret",
+			wantErr:      "invalid refresh token",
+			mockFn: func() {
+				mockSessionsRepo.EXPECT().GetSession(gomock.Any(), int64(123), "a1").Return(session(now+100000), nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(nil, nil)
+			},
+		},
+		{
+			name:         "success rotates the refresh token",
+			refreshToken: "123.a1.secret",
+			mockFn: func() {
+				mockSessionsRepo.EXPECT().GetSession(gomock.Any(), int64(123), "a1").Return(session(now+100000), nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(&users.Model{ID: 123, Role: "customer"}, nil)
+				mockSessionsRepo.EXPECT().RotateSession(gomock.Any(), gomock.Any(), hashToken("secret"), 720*time.Hour).
+					DoAndReturn(func(ctx context.Context, session users.Session, previousRefreshHash string, ttl time.Duration) (bool, error) {
+						assert.Equal(t, "a1", session.ID)
+						assert.NotEqual(t, hashToken("secret"), session.RefreshHash)
+						assert.Equal(t, "Firefox", session.UserAgent)
+						assert.Equal(t, "10.0.0.2", session.IPAddress)
+						assert.GreaterOrEqual(t, session.LastUsedAt, now)
+						assert.Greater(t, session.ExpiresAt, now+100000)
+						return true, nil
+					})
+			},
+		},
+		{
+			name:         "error concurrent refresh with the same token logs out the session",
+			refreshToken: "123.a1.secret",
+			wantErr:      "refresh token is already used, the session is logged out, please re-login",
+			mockFn: func() {
+				mockSessionsRepo.EXPECT().GetSession(gomock.Any(), int64(123), "a1").Return(session(now+100000), nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(&users.Model{ID: 123, Role: "customer"}, nil)
+				mockSessionsRepo.EXPECT().RotateSession(gomock.Any(), gomock.Any(), hashToken("secret"), 720*time.Hour).Return(false, nil)
+				mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "a1").Return(true, nil)
+			},
+		},
+		{
+			name:         "error on rotate session",
+			refreshToken: "123.a1.secret",
+			wantErr:      "connection refused",
+			mockFn: func() {
+				mockSessionsRepo.EXPECT().GetSession(gomock.Any(), int64(123), "a1").Return(session(now+100000), nil)
+				mockUsersRepo.EXPECT().GetUserByID(gomock.Any(), int64(123)).Return(&users.Model{ID: 123, Role: "customer"}, nil)
+				mockSessionsRepo.EXPECT().RotateSession(gomock.Any(), gomock.Any(), hashToken("secret"), 720*time.Hour).
+					Return(false, errors.New("connection refused"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := &usecase{
+				usersRepository:    mockUsersRepo,
+				sessionsRepository: mockSessionsRepo,
+				cfg: &configs.Config{
+					Service: configs.Service{SecretKey: "secretkey"},
+				},
+			}
+			tokens, err := u.RefreshToken(context.Background(), users.RefreshTokenRequest{RefreshToken: tt.refreshToken, IPAddress: "10.0.0.2"})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.Token)
+			assert.True(t, strings.HasPrefix(tokens.RefreshToken, "123.a1."))
+			assert.NotEqual(t, tt.refreshToken, tokens.RefreshToken)
+		})
+	}
+}
+
+func Test_usecase_Logout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+	u := &usecase{sessionsRepository: mockSessionsRepo, cfg: testConfig}
+
+	mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "a1").Return(false, nil)
+	assert.NoError(t, u.Logout(context.Background(), 123, "a1"))
+
+	mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "a1").Return(false, errors.New("failed"))
+	assert.EqualError(t, u.Logout(context.Background(), 123, "a1"), "failed")
+}
+
+func Test_usecase_GetSessions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+	u := &usecase{sessionsRepository: mockSessionsRepo, cfg: testConfig}
+
+	now := time.Now().UnixMilli()
+	mockSessionsRepo.EXPECT().GetSessions(gomock.Any(), int64(123)).Return([]users.Session{
+		{ID: "a1", UserID: 123, UserAgent: "Firefox", RefreshHash: "h1", CreatedAt: 1000, LastUsedAt: 2000, ExpiresAt: now + 1000},
+		{ID: "b2", UserID: 123, UserAgent: "iPhone", RefreshHash: "h2", CreatedAt: 1500, LastUsedAt: 1500, ExpiresAt: now - 1000},
+		{ID: "c3", UserID: 123, UserAgent: "Chrome", RefreshHash: "h3", CreatedAt: 1600, LastUsedAt: 3000, ExpiresAt: now + 1000},
+	}, nil)
+
+	got, err := u.GetSessions(context.Background(), 123, "a1")
+	assert.NoError(t, err)
+	assert.Equal(t, []users.SessionInfo{
+		{ID: "c3", UserAgent: "Chrome", CreatedAt: 1600, LastUsedAt: 3000, ExpiresAt: now + 1000},
+		{ID: "a1", UserAgent: "Firefox", CreatedAt: 1000, LastUsedAt: 2000, ExpiresAt: now + 1000, Current: true},
+	}, got)
+}
+
+func Test_usecase_RevokeSession(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+	u := &usecase{sessionsRepository: mockSessionsRepo, cfg: testConfig}
+
+	mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "b2").Return(false, nil)
+	assert.EqualError(t, u.RevokeSession(context.Background(), 123, "b2"), "session with id: b2 is not found")
+
+	mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "b2").Return(true, nil)
+	assert.NoError(t, u.RevokeSession(context.Background(), 123, "b2"))
+}
+
+func Test_usecase_RevokeOtherSessions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockSessionsRepo := NewMocksessionsRepository(mockCtrl)
+	u := &usecase{sessionsRepository: mockSessionsRepo, cfg: testConfig}
+
+	mockSessionsRepo.EXPECT().GetSessions(gomock.Any(), int64(123)).Return([]users.Session{{ID: "a1"}, {ID: "b2"}, {ID: "c3"}}, nil)
+	mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "b2").Return(true, nil)
+	mockSessionsRepo.EXPECT().DeleteSession(gomock.Any(), int64(123), "c3").Return(true, nil)
+	assert.NoError(t, u.RevokeOtherSessions(context.Background(), 123, "a1"))
+}
@@ -0,0 +1,362 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/backoff"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/eventsink"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/util"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/webhook"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// SinkName is the name of the outbox sink that hands the events over to the subscriptions
+const SinkName = "webhook_subscriptions"
+
+// Defaults of the sending for the settings left out of the config
+const (
+	defaultPollInterval    = time.Second
+	defaultBatchSize       = 50
+	defaultMaxAttempts     = 8
+	defaultRetryBackoff    = 10 * time.Second
+	defaultMaxRetryBackoff = 6 * time.Hour
+	defaultLease           = 2 * time.Minute
+	defaultTimeout         = 10 * time.Second
+	maxDeliveriesPageSize  = 100
+)
+
+//go:generate mockgen -package=webhooks -source=webhooks_usecase.go -destination=webhooks_usecase_mock_test.go
+type webhooksRepository interface {
+	GetSubscriptions(ctx context.Context) ([]webhooks.Subscription, error)
+	GetSubscriptionByID(ctx context.Context, id int64) (*webhooks.Subscription, error)
+	InsertSubscription(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error)
+	UpdateSubscription(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	EnqueueDeliveries(ctx context.Context, delivery webhooks.Delivery) (int64, error)
+	ClaimDeliveries(ctx context.Context, limit int, now, leaseUntil int64) ([]webhooks.Delivery, error)
+	UpdateDelivery(ctx context.Context, delivery webhooks.Delivery) error
+	GetDeliveries(ctx context.Context, subscriptionID int64, status string, limit, offset int) ([]webhooks.Delivery, error)
+	CountDeliveries(ctx context.Context, subscriptionID int64, status string) (int64, error)
+}
+
+type sender interface {
+	Send(ctx context.Context, request webhook.Request) (int, error)
+}
+
+type usecase struct {
+	webhooksRepository webhooksRepository
+	sender             sender
+	cfg                configs.WebhooksConfig
+	now                func() time.Time
+}
+
+func New(webhooksRepository webhooksRepository, sender sender, cfg *configs.Config) *usecase {
+	webhooksConfig := cfg.Webhooks
+	if webhooksConfig.PollInterval <= 0 {
+		webhooksConfig.PollInterval = defaultPollInterval
+	}
+	if webhooksConfig.BatchSize <= 0 {
+		webhooksConfig.BatchSize = defaultBatchSize
+	}
+	if webhooksConfig.MaxAttempts <= 0 {
+		webhooksConfig.MaxAttempts = defaultMaxAttempts
+	}
+	if webhooksConfig.RetryBackoff <= 0 {
+		webhooksConfig.RetryBackoff = defaultRetryBackoff
+	}
+	if webhooksConfig.MaxRetryBackoff < webhooksConfig.RetryBackoff {
+		webhooksConfig.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+	if webhooksConfig.Timeout <= 0 {
+		webhooksConfig.Timeout = defaultTimeout
+	}
+	// a batch is sent at once, so the lease only has to outlast a single request
+	if webhooksConfig.Lease <= webhooksConfig.Timeout {
+		webhooksConfig.Lease = defaultLease + webhooksConfig.Timeout
+	}
+
+	return &usecase{
+		webhooksRepository: webhooksRepository,
+		sender:             sender,
+		cfg:                webhooksConfig,
+		now:                time.Now,
+	}
+}
+
+// GetSubscriptions lists the subscriptions without their secrets
+func (u *usecase) GetSubscriptions(ctx context.Context) ([]webhooks.Subscription, error) {
+	list, err := u.webhooksRepository.GetSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Secret = ""
+	}
+	return list, nil
+}
+
+func (u *usecase) GetSubscriptionByID(ctx context.Context, id int64) (*webhooks.Subscription, error) {
+	subscription, err := u.getSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// CreateSubscription returns the subscription with its secret, which isn't shown anymore afterwards
+func (u *usecase) CreateSubscription(ctx context.Context, req webhooks.CreateSubscriptionRequest) (*webhooks.Subscription, error) {
+	err := validateURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	now := u.now().UnixMilli()
+	return u.webhooksRepository.InsertSubscription(ctx, webhooks.Subscription{
+		URL:         req.URL,
+		Secret:      secret,
+		EventTypes:  uniqueEventTypes(req.EventTypes),
+		Description: req.Description,
+		Active:      active,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+// UpdateSubscription replaces the subscription, the secret and active are kept when they are left out of the request.
+// Deliveries that are already enqueued are sent to the new url.
+func (u *usecase) UpdateSubscription(ctx context.Context, req webhooks.UpdateSubscriptionRequest) (*webhooks.Subscription, error) {
+	err := validateURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := u.getSubscription(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	model := webhooks.Subscription{
+		ID:          req.ID,
+		URL:         req.URL,
+		Secret:      current.Secret,
+		EventTypes:  uniqueEventTypes(req.EventTypes),
+		Description: req.Description,
+		Active:      current.Active,
+		UpdatedAt:   u.now().UnixMilli(),
+	}
+	if req.Secret != "" {
+		model.Secret = req.Secret
+	}
+	if req.Active != nil {
+		model.Active = *req.Active
+	}
+
+	subscription, err := u.webhooksRepository.UpdateSubscription(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// DeleteSubscription deletes the subscription with its delivery log
+func (u *usecase) DeleteSubscription(ctx context.Context, id int64) error {
+	return u.webhooksRepository.DeleteSubscription(ctx, id)
+}
+
+// GetDeliveries is a page of the delivery log of the subscription, newest first
+func (u *usecase) GetDeliveries(ctx context.Context, req webhooks.DeliveriesRequest) ([]webhooks.Delivery, response.Pagination, error) {
+	_, err := u.getSubscription(ctx, req.SubscriptionID)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+
+	if req.PageSize > maxDeliveriesPageSize {
+		req.PageSize = maxDeliveriesPageSize
+	}
+	limit, offset := util.GetLimitAndOffset(req.PageIndex, req.PageSize)
+	deliveries, err := u.webhooksRepository.GetDeliveries(ctx, req.SubscriptionID, req.Status, limit, offset)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+	total, err := u.webhooksRepository.CountDeliveries(ctx, req.SubscriptionID, req.Status)
+	if err != nil {
+		return nil, response.Pagination{}, err
+	}
+	return deliveries, response.NewPagination(limit, offset, total), nil
+}
+
+func (u *usecase) getSubscription(ctx context.Context, id int64) (*webhooks.Subscription, error) {
+	subscription, err := u.webhooksRepository.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, fmt.Errorf("webhook subscription with id: %d is not found", id)
+	}
+	return subscription, nil
+}
+
+// Sink is the outbox sink that enqueues a delivery of every event for each subscription of its type
+func (u *usecase) Sink() eventsink.Sink {
+	return &subscriptionsSink{usecase: u}
+}
+
+type subscriptionsSink struct {
+	usecase *usecase
+}
+
+func (s *subscriptionsSink) Name() string {
+	return SinkName
+}
+
+// Deliver only enqueues the deliveries, they are sent by Run so a slow subscriber doesn't hold up the other sinks
+func (s *subscriptionsSink) Deliver(ctx context.Context, event eventsink.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := s.usecase.now().UnixMilli()
+	_, err = s.usecase.webhooksRepository.EnqueueDeliveries(ctx, webhooks.Delivery{
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       body,
+		Status:        webhooks.DeliveryStatusPending.String(),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	return err
+}
+
+// Run sends the due deliveries until the context is done, a full batch is followed by the next one right away
+func (u *usecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(u.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := u.Dispatch(ctx)
+		if err != nil {
+			log.Printf("[Webhooks] error when claiming deliveries: %v", err)
+		}
+		if err == nil && claimed == u.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch claims a batch of due deliveries and sends them at once, it returns how many deliveries were claimed
+func (u *usecase) Dispatch(ctx context.Context) (int, error) {
+	now := u.now()
+	deliveries, err := u.webhooksRepository.ClaimDeliveries(ctx, u.cfg.BatchSize, now.UnixMilli(), now.Add(u.cfg.Lease).UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery webhooks.Delivery) {
+			defer wg.Done()
+			delivery = u.send(ctx, delivery)
+			err := u.webhooksRepository.UpdateDelivery(ctx, delivery)
+			if err != nil {
+				log.Printf("[Webhooks] error when updating delivery %d: %v", delivery.ID, err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// send posts the delivery to its subscription and records the outcome of the attempt
+func (u *usecase) send(ctx context.Context, delivery webhooks.Delivery) webhooks.Delivery {
+	statusCode, err := u.sender.Send(ctx, webhook.Request{
+		URL:        delivery.URL,
+		Secret:     delivery.Secret,
+		DeliveryID: delivery.ID,
+		EventID:    delivery.EventID,
+		EventType:  delivery.EventType,
+		Body:       delivery.Payload,
+	})
+
+	delivery.Attempts++
+	delivery.ResponseStatus = statusCode
+	now := u.now()
+	if err == nil {
+		delivery.Status = webhooks.DeliveryStatusDelivered.String()
+		delivery.LastError = ""
+		delivery.DeliveredAt = now.UnixMilli()
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= u.cfg.MaxAttempts {
+		delivery.Status = webhooks.DeliveryStatusFailed.String()
+		log.Printf("[Webhooks] delivery %d of event %d to subscription %d failed after %d attempts: %s", delivery.ID,
+			delivery.EventID, delivery.SubscriptionID, delivery.Attempts, delivery.LastError)
+		return delivery
+	}
+	delivery.NextAttemptAt = now.Add(backoff.Exponential(delivery.Attempts, u.cfg.RetryBackoff, u.cfg.MaxRetryBackoff)).UnixMilli()
+	return delivery
+}
+
+// validateURL only accepts absolute http and https urls, the validator also lets other schemes through
+func validateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("invalid url, must be an http or https url")
+	}
+	return nil
+}
+
+func uniqueEventTypes(eventTypes []string) []string {
+	unique := make([]string, 0, len(eventTypes))
+	seen := make(map[string]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	return unique
+}
+
+// generateSecret is a random signing secret for a subscription created without one
+func generateSecret() (string, error) {
+	random := make([]byte, 24)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(random), nil
+}
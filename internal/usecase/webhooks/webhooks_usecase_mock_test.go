@@ -0,0 +1,223 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: webhooks_usecase.go
+
+// Package webhooks is a generated GoMock package.
+package webhooks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	webhooks "github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	webhook "github.com/yeremiaaryo/gotu-assignment/pkg/webhook"
+)
+
+// MockwebhooksRepository is a mock of webhooksRepository interface.
+type MockwebhooksRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockwebhooksRepositoryMockRecorder
+}
+
+// MockwebhooksRepositoryMockRecorder is the mock recorder for MockwebhooksRepository.
+type MockwebhooksRepositoryMockRecorder struct {
+	mock *MockwebhooksRepository
+}
+
+// NewMockwebhooksRepository creates a new mock instance.
+func NewMockwebhooksRepository(ctrl *gomock.Controller) *MockwebhooksRepository {
+	mock := &MockwebhooksRepository{ctrl: ctrl}
+	mock.recorder = &MockwebhooksRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockwebhooksRepository) EXPECT() *MockwebhooksRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimDeliveries mocks base method.
+func (m *MockwebhooksRepository) ClaimDeliveries(ctx context.Context, limit int, now, leaseUntil int64) ([]webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDeliveries", ctx, limit, now, leaseUntil)
+	ret0, _ := ret[0].([]webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDeliveries indicates an expected call of ClaimDeliveries.
+func (mr *MockwebhooksRepositoryMockRecorder) ClaimDeliveries(ctx, limit, now, leaseUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDeliveries", reflect.TypeOf((*MockwebhooksRepository)(nil).ClaimDeliveries), ctx, limit, now, leaseUntil)
+}
+
+// CountDeliveries mocks base method.
+func (m *MockwebhooksRepository) CountDeliveries(ctx context.Context, subscriptionID int64, status string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDeliveries", ctx, subscriptionID, status)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDeliveries indicates an expected call of CountDeliveries.
+func (mr *MockwebhooksRepositoryMockRecorder) CountDeliveries(ctx, subscriptionID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDeliveries", reflect.TypeOf((*MockwebhooksRepository)(nil).CountDeliveries), ctx, subscriptionID, status)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockwebhooksRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockwebhooksRepositoryMockRecorder) DeleteSubscription(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockwebhooksRepository)(nil).DeleteSubscription), ctx, id)
+}
+
+// EnqueueDeliveries mocks base method.
+func (m *MockwebhooksRepository) EnqueueDeliveries(ctx context.Context, delivery webhooks.Delivery) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueDeliveries", ctx, delivery)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueDeliveries indicates an expected call of EnqueueDeliveries.
+func (mr *MockwebhooksRepositoryMockRecorder) EnqueueDeliveries(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueDeliveries", reflect.TypeOf((*MockwebhooksRepository)(nil).EnqueueDeliveries), ctx, delivery)
+}
+
+// GetDeliveries mocks base method.
+func (m *MockwebhooksRepository) GetDeliveries(ctx context.Context, subscriptionID int64, status string, limit, offset int) ([]webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveries", ctx, subscriptionID, status, limit, offset)
+	ret0, _ := ret[0].([]webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeliveries indicates an expected call of GetDeliveries.
+func (mr *MockwebhooksRepositoryMockRecorder) GetDeliveries(ctx, subscriptionID, status, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveries", reflect.TypeOf((*MockwebhooksRepository)(nil).GetDeliveries), ctx, subscriptionID, status, limit, offset)
+}
+
+// GetSubscriptionByID mocks base method.
+func (m *MockwebhooksRepository) GetSubscriptionByID(ctx context.Context, id int64) (*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptionByID", ctx, id)
+	ret0, _ := ret[0].(*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptionByID indicates an expected call of GetSubscriptionByID.
+func (mr *MockwebhooksRepositoryMockRecorder) GetSubscriptionByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptionByID", reflect.TypeOf((*MockwebhooksRepository)(nil).GetSubscriptionByID), ctx, id)
+}
+
+// GetSubscriptions mocks base method.
+func (m *MockwebhooksRepository) GetSubscriptions(ctx context.Context) ([]webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscriptions", ctx)
+	ret0, _ := ret[0].([]webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscriptions indicates an expected call of GetSubscriptions.
+func (mr *MockwebhooksRepositoryMockRecorder) GetSubscriptions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscriptions", reflect.TypeOf((*MockwebhooksRepository)(nil).GetSubscriptions), ctx)
+}
+
+// InsertSubscription mocks base method.
+func (m *MockwebhooksRepository) InsertSubscription(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertSubscription", ctx, model)
+	ret0, _ := ret[0].(*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertSubscription indicates an expected call of InsertSubscription.
+func (mr *MockwebhooksRepositoryMockRecorder) InsertSubscription(ctx, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSubscription", reflect.TypeOf((*MockwebhooksRepository)(nil).InsertSubscription), ctx, model)
+}
+
+// UpdateDelivery mocks base method.
+func (m *MockwebhooksRepository) UpdateDelivery(ctx context.Context, delivery webhooks.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDelivery indicates an expected call of UpdateDelivery.
+func (mr *MockwebhooksRepositoryMockRecorder) UpdateDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDelivery", reflect.TypeOf((*MockwebhooksRepository)(nil).UpdateDelivery), ctx, delivery)
+}
+
+// UpdateSubscription mocks base method.
+func (m *MockwebhooksRepository) UpdateSubscription(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscription", ctx, model)
+	ret0, _ := ret[0].(*webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSubscription indicates an expected call of UpdateSubscription.
+func (mr *MockwebhooksRepositoryMockRecorder) UpdateSubscription(ctx, model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscription", reflect.TypeOf((*MockwebhooksRepository)(nil).UpdateSubscription), ctx, model)
+}
+
+// Mocksender is a mock of sender interface.
+type Mocksender struct {
+	ctrl     *gomock.Controller
+	recorder *MocksenderMockRecorder
+}
+
+// MocksenderMockRecorder is the mock recorder for Mocksender.
+type MocksenderMockRecorder struct {
+	mock *Mocksender
+}
+
+// NewMocksender creates a new mock instance.
+func NewMocksender(ctrl *gomock.Controller) *Mocksender {
+	mock := &Mocksender{ctrl: ctrl}
+	mock.recorder = &MocksenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocksender) EXPECT() *MocksenderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *Mocksender) Send(ctx context.Context, request webhook.Request) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, request)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Send indicates an expected call of Send.
+func (mr *MocksenderMockRecorder) Send(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*Mocksender)(nil).Send), ctx, request)
+}
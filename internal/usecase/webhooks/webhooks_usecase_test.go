@@ -0,0 +1,333 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/internal/configs"
+	"github.com/yeremiaaryo/gotu-assignment/internal/model/webhooks"
+	"github.com/yeremiaaryo/gotu-assignment/internal/response"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/eventsink"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/webhook"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testConfig = &configs.Config{Webhooks: configs.WebhooksConfig{BatchSize: 10, MaxAttempts: 3, RetryBackoff: 10 * time.Second,
+	MaxRetryBackoff: time.Hour, Lease: time.Minute, Timeout: 5 * time.Second}}
+
+func Test_usecase_CreateSubscription(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksRepo := NewMockwebhooksRepository(mockCtrl)
+	inactive := false
+
+	tests := []struct {
+		name    string
+		req     webhooks.CreateSubscriptionRequest
+		want    *webhooks.Subscription
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name:    "error on url that isn't http",
+			req:     webhooks.CreateSubscriptionRequest{URL: "ftp://partner.example.com/hooks", EventTypes: []string{"OrderCreated"}},
+			wantErr: "invalid url, must be an http or https url",
+			mockFn:  func() {},
+		},
+		{
+			name: "success with the given secret",
+			req: webhooks.CreateSubscriptionRequest{URL: "https://partner.example.com/hooks", Secret: "0123456789abcdef",
+				EventTypes: []string{"OrderCreated", "OrderCreated"}, Description: "fulfilment", Active: &inactive},
+			want: &webhooks.Subscription{ID: 2, URL: "https://partner.example.com/hooks", Secret: "0123456789abcdef",
+				EventTypes: []string{"OrderCreated"}, Description: "fulfilment", CreatedAt: 1000, UpdatedAt: 1000},
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().InsertSubscription(gomock.Any(), webhooks.Subscription{URL: "https://partner.example.com/hooks",
+					Secret: "0123456789abcdef", EventTypes: []string{"OrderCreated"}, Description: "fulfilment", CreatedAt: 1000, UpdatedAt: 1000}).
+					DoAndReturn(func(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error) {
+						model.ID = 2
+						return &model, nil
+					})
+			},
+		},
+		{
+			name: "success generates a secret and is active",
+			req:  webhooks.CreateSubscriptionRequest{URL: "http://localhost:8080/events", EventTypes: []string{"OrderStatusChanged"}},
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().InsertSubscription(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error) {
+						assert.True(t, strings.HasPrefix(model.Secret, "whsec_"))
+						assert.Len(t, model.Secret, len("whsec_")+48)
+						assert.True(t, model.Active)
+						model.ID = 3
+						return &model, nil
+					})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := New(mockWebhooksRepo, nil, testConfig)
+			u.now = func() time.Time { return time.UnixMilli(1000) }
+
+			got, err := u.CreateSubscription(context.Background(), tt.req)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.want != nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_usecase_UpdateSubscription(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksRepo := NewMockwebhooksRepository(mockCtrl)
+	current := &webhooks.Subscription{ID: 2, URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef",
+		EventTypes: []string{"OrderCreated"}, Active: false, CreatedAt: 500, UpdatedAt: 500}
+
+	tests := []struct {
+		name    string
+		req     webhooks.UpdateSubscriptionRequest
+		want    *webhooks.Subscription
+		wantErr string
+		mockFn  func()
+	}{
+		{
+			name: "error not found",
+			req: webhooks.UpdateSubscriptionRequest{ID: 2, CreateSubscriptionRequest: webhooks.CreateSubscriptionRequest{
+				URL: "https://partner.example.com/v2/hooks", EventTypes: []string{"OrderCreated"}}},
+			wantErr: "webhook subscription with id: 2 is not found",
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().GetSubscriptionByID(gomock.Any(), int64(2)).Return(nil, nil)
+			},
+		},
+		{
+			name: "success keeps the secret and active and hides the secret",
+			req: webhooks.UpdateSubscriptionRequest{ID: 2, CreateSubscriptionRequest: webhooks.CreateSubscriptionRequest{
+				URL: "https://partner.example.com/v2/hooks", EventTypes: []string{"OrderCreated", "OrderStatusChanged"}}},
+			want: &webhooks.Subscription{ID: 2, URL: "https://partner.example.com/v2/hooks", EventTypes: []string{"OrderCreated", "OrderStatusChanged"},
+				CreatedAt: 500, UpdatedAt: 1000},
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().GetSubscriptionByID(gomock.Any(), int64(2)).Return(current, nil)
+				mockWebhooksRepo.EXPECT().UpdateSubscription(gomock.Any(), webhooks.Subscription{ID: 2, URL: "https://partner.example.com/v2/hooks",
+					Secret: "whsec_0123456789abcdef", EventTypes: []string{"OrderCreated", "OrderStatusChanged"}, UpdatedAt: 1000}).
+					DoAndReturn(func(ctx context.Context, model webhooks.Subscription) (*webhooks.Subscription, error) {
+						model.CreatedAt = 500
+						return &model, nil
+					})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := New(mockWebhooksRepo, nil, testConfig)
+			u.now = func() time.Time { return time.UnixMilli(1000) }
+
+			got, err := u.UpdateSubscription(context.Background(), tt.req)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_usecase_GetDeliveries(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksRepo := NewMockwebhooksRepository(mockCtrl)
+	deliveries := []webhooks.Delivery{{ID: 6, SubscriptionID: 2, EventID: 7, EventType: "OrderCreated", Status: "FAILED", Attempts: 8}}
+
+	tests := []struct {
+		name           string
+		req            webhooks.DeliveriesRequest
+		want           []webhooks.Delivery
+		wantPagination response.Pagination
+		wantErr        string
+		mockFn         func()
+	}{
+		{
+			name:    "error subscription not found",
+			req:     webhooks.DeliveriesRequest{SubscriptionID: 2},
+			wantErr: "webhook subscription with id: 2 is not found",
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().GetSubscriptionByID(gomock.Any(), int64(2)).Return(nil, nil)
+			},
+		},
+		{
+			name:    "error on count",
+			req:     webhooks.DeliveriesRequest{SubscriptionID: 2},
+			wantErr: "connection reset",
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().GetSubscriptionByID(gomock.Any(), int64(2)).Return(&webhooks.Subscription{ID: 2}, nil)
+				mockWebhooksRepo.EXPECT().GetDeliveries(gomock.Any(), int64(2), "", 10, 0).Return(deliveries, nil)
+				mockWebhooksRepo.EXPECT().CountDeliveries(gomock.Any(), int64(2), "").Return(int64(0), errors.New("connection reset"))
+			},
+		},
+		{
+			name:           "success caps the page size",
+			req:            webhooks.DeliveriesRequest{SubscriptionID: 2, Status: "FAILED", PageIndex: 2, PageSize: 500},
+			want:           deliveries,
+			wantPagination: response.Pagination{TotalItems: 101, TotalPages: 2, PageIndex: 2, PageSize: 100},
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().GetSubscriptionByID(gomock.Any(), int64(2)).Return(&webhooks.Subscription{ID: 2}, nil)
+				mockWebhooksRepo.EXPECT().GetDeliveries(gomock.Any(), int64(2), "FAILED", 100, 100).Return(deliveries, nil)
+				mockWebhooksRepo.EXPECT().CountDeliveries(gomock.Any(), int64(2), "FAILED").Return(int64(101), nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := New(mockWebhooksRepo, nil, testConfig)
+
+			got, pagination, err := u.GetDeliveries(context.Background(), tt.req)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPagination, pagination)
+		})
+	}
+}
+
+func Test_subscriptionsSink_Deliver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksRepo := NewMockwebhooksRepository(mockCtrl)
+	u := New(mockWebhooksRepo, nil, testConfig)
+	u.now = func() time.Time { return time.UnixMilli(1000) }
+
+	event := eventsink.Event{ID: 7, Type: "OrderCreated", AggregateType: "order", AggregateID: 3, Payload: json.RawMessage(`{"order_id":3}`), CreatedAt: 900}
+	mockWebhooksRepo.EXPECT().EnqueueDeliveries(gomock.Any(), webhooks.Delivery{
+		EventID:       7,
+		EventType:     "OrderCreated",
+		Payload:       json.RawMessage(`{"id":7,"type":"OrderCreated","aggregate_type":"order","aggregate_id":3,"payload":{"order_id":3},"created_at":900}`),
+		Status:        "PENDING",
+		NextAttemptAt: 1000,
+		CreatedAt:     1000,
+	}).Return(int64(2), nil)
+	mockWebhooksRepo.EXPECT().EnqueueDeliveries(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("connection reset"))
+
+	sink := u.Sink()
+	assert.Equal(t, "webhook_subscriptions", sink.Name())
+	assert.NoError(t, sink.Deliver(context.Background(), event))
+	assert.EqualError(t, sink.Deliver(context.Background(), event), "connection reset")
+}
+
+func Test_usecase_Dispatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockWebhooksRepo := NewMockwebhooksRepository(mockCtrl)
+	mockSender := NewMocksender(mockCtrl)
+
+	delivery := webhooks.Delivery{ID: 4, SubscriptionID: 2, EventID: 7, EventType: "OrderCreated", Payload: json.RawMessage(`{"id":7}`),
+		Status: "PENDING", NextAttemptAt: 61000, CreatedAt: 900, URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef"}
+	request := webhook.Request{URL: "https://partner.example.com/hooks", Secret: "whsec_0123456789abcdef", DeliveryID: 4, EventID: 7,
+		EventType: "OrderCreated", Body: []byte(`{"id":7}`)}
+
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+		mockFn  func()
+	}{
+		{
+			name:    "error on claim",
+			wantErr: true,
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, int64(1000), int64(61000)).Return(nil, errors.New("connection reset"))
+			},
+		},
+		{
+			name: "success delivered",
+			want: 1,
+			mockFn: func() {
+				mockWebhooksRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, int64(1000), int64(61000)).Return([]webhooks.Delivery{delivery}, nil)
+				mockSender.EXPECT().Send(gomock.Any(), request).Return(204, nil)
+				delivered := delivery
+				delivered.Status = "DELIVERED"
+				delivered.Attempts = 1
+				delivered.ResponseStatus = 204
+				delivered.DeliveredAt = 1000
+				mockWebhooksRepo.EXPECT().UpdateDelivery(gomock.Any(), delivered).Return(nil)
+			},
+		},
+		{
+			name: "failed attempt is retried with a backoff",
+			want: 1,
+			mockFn: func() {
+				retried := delivery
+				retried.Attempts = 1
+				mockWebhooksRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, int64(1000), int64(61000)).Return([]webhooks.Delivery{retried}, nil)
+				mockSender.EXPECT().Send(gomock.Any(), request).Return(502, errors.New("subscriber responded with status 502"))
+				failed := delivery
+				failed.Attempts = 2
+				failed.ResponseStatus = 502
+				failed.LastError = "subscriber responded with status 502"
+				failed.NextAttemptAt = 21000
+				mockWebhooksRepo.EXPECT().UpdateDelivery(gomock.Any(), failed).Return(nil)
+			},
+		},
+		{
+			name: "last failed attempt marks the delivery failed",
+			want: 1,
+			mockFn: func() {
+				retried := delivery
+				retried.Attempts = 2
+				mockWebhooksRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, int64(1000), int64(61000)).Return([]webhooks.Delivery{retried}, nil)
+				mockSender.EXPECT().Send(gomock.Any(), request).Return(0, errors.New("dial tcp: connection refused"))
+				failed := delivery
+				failed.Status = "FAILED"
+				failed.Attempts = 3
+				failed.LastError = "dial tcp: connection refused"
+				mockWebhooksRepo.EXPECT().UpdateDelivery(gomock.Any(), failed).Return(nil)
+			},
+		},
+		{
+			name: "error on update keeps sending the batch",
+			want: 2,
+			mockFn: func() {
+				next := delivery
+				next.ID = 5
+				mockWebhooksRepo.EXPECT().ClaimDeliveries(gomock.Any(), 10, int64(1000), int64(61000)).Return([]webhooks.Delivery{delivery, next}, nil)
+				mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(200, nil).Times(2)
+				mockWebhooksRepo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).Return(errors.New("connection reset")).Times(2)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockFn()
+			u := New(mockWebhooksRepo, mockSender, testConfig)
+			u.now = func() time.Time { return time.UnixMilli(1000) }
+
+			got, err := u.Dispatch(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
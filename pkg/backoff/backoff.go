@@ -0,0 +1,16 @@
+package backoff
+
+import "time"
+
+// Exponential is the wait before the retry that follows the given number of failed attempts, it starts at base and
+// doubles on every attempt up to max
+func Exponential(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
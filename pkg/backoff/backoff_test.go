@@ -0,0 +1,27 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first attempt waits the base", attempts: 1, want: 5 * time.Second},
+		{name: "zero attempts waits the base", attempts: 0, want: 5 * time.Second},
+		{name: "doubles on every attempt", attempts: 4, want: 40 * time.Second},
+		{name: "capped at max", attempts: 5, want: time.Minute},
+		{name: "many attempts don't overflow", attempts: 500, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Exponential(tt.attempts, 5*time.Second, time.Minute); got != tt.want {
+				t.Errorf("Exponential() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
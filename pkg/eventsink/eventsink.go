@@ -0,0 +1,24 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Event is what a sink delivers. Delivery is at least once, so a consumer may see the same event again
+// and should skip the ids it already handled.
+type Event struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     int64           `json:"created_at"`
+}
+
+// Sink delivers events to a destination outside the service, an error means the event has to be delivered again
+type Sink interface {
+	// Name identifies the sink, an event remembers the sinks it was delivered to by their names
+	Name() string
+	Deliver(ctx context.Context, event Event) error
+}
@@ -0,0 +1,100 @@
+package eventsink
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/webhook"
+)
+
+var testEvent = Event{
+	ID:            7,
+	Type:          "OrderCreated",
+	AggregateType: "order",
+	AggregateID:   3,
+	Payload:       []byte(`{"order_id":3}`),
+	CreatedAt:     1000,
+}
+
+func TestWebhook_Deliver(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		statusCode int
+		wantErr    string
+	}{
+		{name: "success signs the body", secret: "secret", statusCode: http.StatusNoContent},
+		{name: "success without a secret", statusCode: http.StatusOK},
+		{name: "error response", secret: "secret", statusCode: http.StatusBadGateway, wantErr: "subscriber responded with status 502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				body    []byte
+				headers http.Header
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = io.ReadAll(r.Body)
+				headers = r.Header
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			err := NewWebhook("webhook", server.URL, tt.secret, time.Second).Deliver(context.Background(), testEvent)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"id":7,"type":"OrderCreated","aggregate_type":"order","aggregate_id":3,"payload":{"order_id":3},"created_at":1000}`, string(body))
+			assert.Equal(t, "7", headers.Get(webhook.HeaderDeliveryID))
+			assert.Equal(t, "7", headers.Get(webhook.HeaderEventID))
+			assert.Equal(t, "OrderCreated", headers.Get(webhook.HeaderEventType))
+			if tt.secret == "" {
+				assert.Empty(t, headers.Get(webhook.HeaderSignature))
+				return
+			}
+			// the receiver verifies the sink the same way as a webhook subscription
+			assert.NoError(t, webhook.Verify(tt.secret, headers.Get(webhook.HeaderSignature), headers.Get(webhook.HeaderTimestamp), body,
+				time.Now(), time.Minute))
+		})
+	}
+}
+
+type fakeStream struct {
+	stream string
+	maxLen int64
+	fields map[string]string
+	err    error
+}
+
+func (f *fakeStream) XAdd(stream string, maxLen int64, fields map[string]string) (string, error) {
+	f.stream, f.maxLen, f.fields = stream, maxLen, fields
+	return "1-0", f.err
+}
+
+func TestRedisStream_Deliver(t *testing.T) {
+	redis := &fakeStream{}
+	err := NewRedisStream("redis", redis, "orders.events", 1000).Deliver(context.Background(), testEvent)
+	assert.NoError(t, err)
+	assert.Equal(t, "orders.events", redis.stream)
+	assert.Equal(t, int64(1000), redis.maxLen)
+	assert.Equal(t, map[string]string{
+		"id":             "7",
+		"type":           "OrderCreated",
+		"aggregate_type": "order",
+		"aggregate_id":   "3",
+		"payload":        `{"order_id":3}`,
+		"created_at":     "1000",
+	}, redis.fields)
+
+	redis.err = errors.New("connection refused")
+	err = NewRedisStream("redis", redis, "orders.events", 0).Deliver(context.Background(), testEvent)
+	assert.EqualError(t, err, "connection refused")
+}
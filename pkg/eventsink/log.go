@@ -0,0 +1,24 @@
+package eventsink
+
+import (
+	"context"
+	"log"
+)
+
+// Log writes the events to the service log, it never fails
+type Log struct {
+	name string
+}
+
+func NewLog(name string) *Log {
+	return &Log{name: name}
+}
+
+func (l *Log) Name() string {
+	return l.name
+}
+
+func (l *Log) Deliver(ctx context.Context, event Event) error {
+	log.Printf("[outbox] %s %s %d event %d: %s", event.Type, event.AggregateType, event.AggregateID, event.ID, event.Payload)
+	return nil
+}
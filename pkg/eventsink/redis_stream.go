@@ -0,0 +1,44 @@
+package eventsink
+
+import (
+	"context"
+	"strconv"
+)
+
+type streamAdder interface {
+	XAdd(stream string, maxLen int64, fields map[string]string) (string, error)
+}
+
+// RedisStream appends every event as an entry of a Redis stream, consumers read it with their own consumer groups
+type RedisStream struct {
+	name   string
+	stream string
+	maxLen int64
+	redis  streamAdder
+}
+
+// NewRedisStream keeps about maxLen entries in the stream, 0 keeps every entry
+func NewRedisStream(name string, redis streamAdder, stream string, maxLen int64) *RedisStream {
+	return &RedisStream{
+		name:   name,
+		stream: stream,
+		maxLen: maxLen,
+		redis:  redis,
+	}
+}
+
+func (r *RedisStream) Name() string {
+	return r.name
+}
+
+func (r *RedisStream) Deliver(ctx context.Context, event Event) error {
+	_, err := r.redis.XAdd(r.stream, r.maxLen, map[string]string{
+		"id":             strconv.FormatInt(event.ID, 10),
+		"type":           event.Type,
+		"aggregate_type": event.AggregateType,
+		"aggregate_id":   strconv.FormatInt(event.AggregateID, 10),
+		"payload":        string(event.Payload),
+		"created_at":     strconv.FormatInt(event.CreatedAt, 10),
+	})
+	return err
+}
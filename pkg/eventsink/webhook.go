@@ -0,0 +1,49 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/yeremiaaryo/gotu-assignment/pkg/webhook"
+)
+
+// Webhook posts every event as JSON to a URL with the same headers and signature as the webhook subscriptions,
+// so a receiver verifies both with webhook.Verify. Any response other than 2xx is a failed delivery.
+type Webhook struct {
+	name   string
+	url    string
+	secret string
+	client *webhook.Client
+}
+
+func NewWebhook(name, url, secret string, timeout time.Duration) *Webhook {
+	return &Webhook{
+		name:   name,
+		url:    url,
+		secret: secret,
+		client: webhook.New(timeout),
+	}
+}
+
+func (w *Webhook) Name() string {
+	return w.name
+}
+
+func (w *Webhook) Deliver(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// the sink has a single delivery of every event, so the event id is the delivery id too
+	_, err = w.client.Send(ctx, webhook.Request{
+		URL:        w.url,
+		Secret:     w.secret,
+		DeliveryID: event.ID,
+		EventID:    event.ID,
+		EventType:  event.Type,
+		Body:       body,
+	})
+	return err
+}
@@ -7,12 +7,25 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func CreateToken(id int64, email, secretKey string) (string, error) {
+// ErrTokenExpired is returned by VerifyToken for a token that is valid but past its expiry
+var ErrTokenExpired = jwt.ErrTokenExpired
+
+// Claims are the user information carried inside the token, SessionID is the login session the token was issued for
+type Claims struct {
+	ID        int64
+	Email     string
+	Role      string
+	SessionID string
+}
+
+func CreateToken(claims Claims, secretKey string, ttl time.Duration) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
 		jwt.MapClaims{
-			"email": email,
-			"id":    id,
-			"exp":   time.Now().Add(time.Hour * 24).Unix(),
+			"email": claims.Email,
+			"id":    claims.ID,
+			"role":  claims.Role,
+			"sid":   claims.SessionID,
+			"exp":   time.Now().Add(ttl).Unix(),
 		})
 
 	key := []byte(secretKey)
@@ -24,7 +37,7 @@ func CreateToken(id int64, email, secretKey string) (string, error) {
 	return tokenStr, nil
 }
 
-func VerifyToken(tokenStr, secretKey string) (int64, error) {
+func VerifyToken(tokenStr, secretKey string) (*Claims, error) {
 	key := []byte(secretKey)
 	claims := jwt.MapClaims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
@@ -32,12 +45,26 @@ func VerifyToken(tokenStr, secretKey string) (int64, error) {
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return 0, fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	id, ok := claims["id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid token")
 	}
+	// tokens issued before roles and sessions existed don't carry them, leave them empty and let the caller decide
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+	sessionID, _ := claims["sid"].(string)
 
-	return int64(claims["id"].(float64)), nil
+	return &Claims{
+		ID:        int64(id),
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
+	}, nil
 }
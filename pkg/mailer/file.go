@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// unsafeFileChars are replaced in the file names made of the recipient
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+// File writes every message as an .eml file to a directory, the files open in any mail client
+type File struct {
+	dir string
+	now func() time.Time
+}
+
+func NewFile(dir string) *File {
+	return &File{dir: dir, now: time.Now}
+}
+
+func (f *File) Send(ctx context.Context, message Message) error {
+	now := f.now()
+	raw, err := message.Bytes(now)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(f.dir, 0o755)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), unsafeFileChars.ReplaceAllString(message.To[0], "_"))
+	return os.WriteFile(filepath.Join(f.dir, name), raw, 0o644)
+}
+
+// Writer writes every message to a writer such as stdout, one after the other
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, now: time.Now}
+}
+
+func (w *Writer) Send(ctx context.Context, message Message) error {
+	raw, err := message.Bytes(w.now())
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = fmt.Fprintf(w.w, "%s\r\n", raw)
+	return err
+}
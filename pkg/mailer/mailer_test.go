@@ -0,0 +1,192 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testMessage = Message{
+	From:    "Gotu Books <no-reply@gotu.local>",
+	To:      []string{"reader@example.com"},
+	Subject: "Your order #3 is confirmed",
+	HTML:    "<p>Thank you for your order</p>",
+	Text:    "Thank you for your order",
+}
+
+func TestMessage_Bytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  Message
+		wantErr  string
+		wantType string
+		wantBody []string
+	}{
+		{
+			name:     "html only",
+			message:  Message{From: testMessage.From, To: testMessage.To, Subject: "Welcome", HTML: "<p>Hi</p>"},
+			wantType: "text/html",
+			wantBody: []string{"<p>Hi</p>"},
+		},
+		{
+			name:     "html with a text alternative",
+			message:  testMessage,
+			wantType: "multipart/alternative",
+			wantBody: []string{"Thank you for your order", "<p>Thank you for your order</p>"},
+		},
+		{
+			name:    "error without recipient",
+			message: Message{From: testMessage.From, Subject: "Welcome"},
+			wantErr: `message "Welcome" has no recipient`,
+		},
+		{
+			name:    "error on invalid recipient",
+			message: Message{From: testMessage.From, To: []string{"not an address"}, Subject: "Welcome"},
+			wantErr: `invalid address "not an address"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := tt.message.Bytes(time.Unix(1700000000, 0))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.message.From, parsed.Header.Get("From"))
+			assert.Equal(t, "reader@example.com", parsed.Header.Get("To"))
+			assert.Equal(t, tt.message.Subject, decodeHeader(t, parsed.Header.Get("Subject")))
+			assert.True(t, strings.HasPrefix(parsed.Header.Get("Content-Type"), tt.wantType))
+			assert.True(t, strings.HasSuffix(parsed.Header.Get("Message-ID"), "@gotu.local>"))
+			body, _ := io.ReadAll(parsed.Body)
+			for _, want := range tt.wantBody {
+				assert.Contains(t, string(body), want)
+			}
+		})
+	}
+}
+
+func decodeHeader(t *testing.T, value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	assert.NoError(t, err)
+	return decoded
+}
+
+func TestFile_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	file := NewFile(dir)
+	file.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	assert.NoError(t, file.Send(context.Background(), testMessage))
+	raw, err := os.ReadFile(filepath.Join(dir, "1700000000000000000-reader@example.com.eml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(raw), "Thank you for your order")
+
+	assert.Error(t, file.Send(context.Background(), Message{From: testMessage.From}))
+}
+
+func TestWriter_Send(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, NewWriter(&buf).Send(context.Background(), testMessage))
+	assert.Contains(t, buf.String(), "To: reader@example.com")
+}
+
+// fakeSMTPServer accepts a single message and records the envelope and data it got
+type fakeSMTPServer struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil || dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTP_Send(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	defer server.listener.Close()
+
+	err := NewSMTP(server.listener.Addr().String(), "", "", time.Second).Send(context.Background(), testMessage)
+	assert.NoError(t, err)
+	<-server.done
+	assert.Equal(t, "no-reply@gotu.local", server.from)
+	assert.Equal(t, []string{"reader@example.com"}, server.to)
+	assert.Contains(t, server.data, "Subject: ")
+	assert.Contains(t, server.data, "Thank you for your order")
+}
+
+func TestSMTP_Send_unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	assert.Error(t, NewSMTP(address, "", "", time.Second).Send(context.Background(), testMessage))
+}
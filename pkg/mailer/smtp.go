@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTP sends the messages through a mail server, STARTTLS is used whenever the server offers it
+type SMTP struct {
+	address  string
+	username string
+	password string
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+func NewSMTP(address, username, password string, timeout time.Duration) *SMTP {
+	return &SMTP{
+		address:  address,
+		username: username,
+		password: password,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	raw, err := message.Bytes(s.now())
+	if err != nil {
+		return err
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	host, _, err := net.SplitHostPort(s.address)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(message.From)
+	if err = client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range message.To {
+		recipient, _ := mail.ParseAddress(to)
+		if err = client.Rcpt(recipient.Address); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(raw); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
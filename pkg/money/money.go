@@ -0,0 +1,153 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is an exact amount of money in cents, it maps to DECIMAL(10, 2) columns and marshals to a JSON number
+// with two decimals so prices and totals never go through float rounding
+type Amount int64
+
+var errInvalidAmount = errors.New("invalid amount, must be a number with at most 2 decimals")
+
+// FromCents returns the amount of the given cents
+func FromCents(cents int64) Amount {
+	return Amount(cents)
+}
+
+// Parse parses a decimal string such as "10.99", more than 2 decimals are only accepted when they are zeros
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	units, fraction, _ := strings.Cut(s, ".")
+	if units == "" && fraction == "" {
+		return 0, errInvalidAmount
+	}
+	if len(fraction) > 2 {
+		if strings.Trim(fraction[2:], "0") != "" {
+			return 0, errInvalidAmount
+		}
+		fraction = fraction[:2]
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	if units == "" {
+		units = "0"
+	}
+
+	unitsValue, err := strconv.ParseUint(units, 10, 63)
+	if err != nil || unitsValue > math.MaxInt64/100-1 {
+		return 0, errInvalidAmount
+	}
+	fractionValue, err := strconv.ParseUint(fraction, 10, 8)
+	if err != nil {
+		return 0, errInvalidAmount
+	}
+
+	cents := int64(unitsValue)*100 + int64(fractionValue)
+	if negative {
+		cents = -cents
+	}
+	return Amount(cents), nil
+}
+
+// MustParse is like Parse but panics on an invalid amount, only meant for constants
+func MustParse(s string) Amount {
+	amount, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// Cents returns the amount in cents
+func (a Amount) Cents() int64 {
+	return int64(a)
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+	return a + b
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+	return a - b
+}
+
+// Mul returns the amount multiplied by a quantity
+func (a Amount) Mul(quantity int) Amount {
+	return a * Amount(quantity)
+}
+
+// String formats the amount with two decimals, e.g. "10.99"
+func (a Amount) String() string {
+	cents := int64(a)
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON reads the amount from a JSON number, a quoted number is accepted too
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Scan reads the amount from a DECIMAL column, which the driver returns as text
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	case int64:
+		*a = Amount(v * 100)
+		return nil
+	case float64:
+		*a = Amount(math.Round(v * 100))
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into money.Amount", src)
+}
+
+func (a *Amount) scanString(s string) error {
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value writes the amount as text so postgres stores the exact decimal
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,112 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Amount
+		wantErr bool
+	}{
+		{name: "two decimals", input: "10.99", want: 1099},
+		{name: "one decimal", input: "10.9", want: 1090},
+		{name: "no decimals", input: "10", want: 1000},
+		{name: "trailing zeros", input: "10.990", want: 1099},
+		{name: "leading dot", input: ".5", want: 50},
+		{name: "negative", input: "-0.05", want: -5},
+		{name: "error more than 2 decimals", input: "10.999", wantErr: true},
+		{name: "error exponent", input: "1e2", wantErr: true},
+		{name: "error empty", input: "", wantErr: true},
+		{name: "error not a number", input: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAmount_Arithmetic(t *testing.T) {
+	price := MustParse("10.99")
+
+	// 3 × 10.99 is not 32.97 in float64
+	assert.Equal(t, MustParse("32.97"), price.Mul(3))
+	assert.Equal(t, MustParse("43.96"), price.Mul(3).Add(price))
+	assert.Equal(t, MustParse("21.98"), price.Mul(3).Sub(price))
+}
+
+func TestAmount_String(t *testing.T) {
+	tests := []struct {
+		input Amount
+		want  string
+	}{
+		{input: 1099, want: "10.99"},
+		{input: 1000, want: "10.00"},
+		{input: 5, want: "0.05"},
+		{input: -250, want: "-2.50"},
+		{input: 0, want: "0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.input.String())
+		})
+	}
+}
+
+func TestAmount_JSON(t *testing.T) {
+	var got struct {
+		Price Amount `json:"price"`
+		Total Amount `json:"total"`
+	}
+	err := json.Unmarshal([]byte(`{"price":10.99,"total":"32.97"}`), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, Amount(1099), got.Price)
+	assert.Equal(t, Amount(3297), got.Total)
+
+	data, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price":10.99,"total":32.97}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"price":10.999}`), &got)
+	assert.Error(t, err)
+}
+
+func TestAmount_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Amount
+		wantErr bool
+	}{
+		{name: "decimal column", src: []byte("10.99"), want: 1099},
+		{name: "string", src: "7.5", want: 750},
+		{name: "integer", src: int64(3), want: 300},
+		{name: "float", src: 9.99, want: 999},
+		{name: "null", src: nil, want: 0},
+		{name: "error unsupported type", src: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Amount
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
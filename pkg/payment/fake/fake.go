@@ -0,0 +1,161 @@
+// Package fake is an in-process payment provider with deterministic outcomes, it is meant for local runs and tests.
+// The outcome is chosen by the payment token:
+//
+//	tok_declined         the authorization is declined
+//	tok_capture_pending  the capture is pending and has to be confirmed through the webhook
+//	tok_capture_failed   the capture fails after a successful authorization
+//	anything else        the payment is authorized and captured right away
+package fake
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/payment"
+	"sync"
+)
+
+const (
+	TokenDeclined       = "tok_declined"
+	TokenCapturePending = "tok_capture_pending"
+	TokenCaptureFailed  = "tok_capture_failed"
+)
+
+// Provider keeps its transactions in memory, references are numbered in the order the authorizations come in
+type Provider struct {
+	secret string
+
+	mu       sync.Mutex
+	sequence int64
+	tokens   map[string]string // token used for every authorized reference
+	status   map[string]payment.Status
+	amounts  map[string]money.Amount // authorized amount of every reference
+	refunded map[string]money.Amount
+}
+
+func New(secret string) *Provider {
+	return &Provider{
+		secret:   secret,
+		tokens:   make(map[string]string),
+		status:   make(map[string]payment.Status),
+		amounts:  make(map[string]money.Amount),
+		refunded: make(map[string]money.Amount),
+	}
+}
+
+func (p *Provider) Name() string {
+	return "fake"
+}
+
+func (p *Provider) Authorize(ctx context.Context, req payment.AuthorizeRequest) (payment.Transaction, error) {
+	if req.Amount <= 0 {
+		return payment.Transaction{}, fmt.Errorf("invalid amount: %s", req.Amount)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sequence++
+	reference := fmt.Sprintf("fake_%d_%d", req.OrderID, p.sequence)
+	if req.Token == TokenDeclined {
+		p.status[reference] = payment.StatusFailed
+		return payment.Transaction{Reference: reference, Status: payment.StatusFailed, FailureReason: "card declined"}, nil
+	}
+
+	p.tokens[reference] = req.Token
+	p.amounts[reference] = req.Amount
+	p.status[reference] = payment.StatusAuthorized
+	return payment.Transaction{Reference: reference, Status: payment.StatusAuthorized}, nil
+}
+
+func (p *Provider) Capture(ctx context.Context, reference string, amount money.Amount) (payment.Transaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status[reference] != payment.StatusAuthorized {
+		return payment.Transaction{}, fmt.Errorf("reference %s is not authorized", reference)
+	}
+
+	switch p.tokens[reference] {
+	case TokenCapturePending:
+		return payment.Transaction{Reference: reference, Status: payment.StatusPending}, nil
+	case TokenCaptureFailed:
+		p.status[reference] = payment.StatusFailed
+		return payment.Transaction{Reference: reference, Status: payment.StatusFailed, FailureReason: "capture failed"}, nil
+	}
+	p.status[reference] = payment.StatusCaptured
+	return payment.Transaction{Reference: reference, Status: payment.StatusCaptured}, nil
+}
+
+func (p *Provider) Refund(ctx context.Context, reference string, amount money.Amount) (payment.Transaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status[reference] != payment.StatusCaptured {
+		return payment.Transaction{}, fmt.Errorf("reference %s is not captured", reference)
+	}
+	refunded := p.refunded[reference].Add(amount)
+	if amount <= 0 || refunded > p.amounts[reference] {
+		return payment.Transaction{}, fmt.Errorf("invalid refund amount: %s", amount)
+	}
+
+	// a partial refund leaves the rest of the capture to be refunded later
+	p.refunded[reference] = refunded
+	if refunded == p.amounts[reference] {
+		p.status[reference] = payment.StatusRefunded
+	}
+	return payment.Transaction{Reference: reference, Status: payment.StatusRefunded}, nil
+}
+
+func (p *Provider) Void(ctx context.Context, reference string) (payment.Transaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.status[reference] != payment.StatusAuthorized {
+		return payment.Transaction{}, fmt.Errorf("reference %s is not authorized", reference)
+	}
+	p.status[reference] = payment.StatusVoided
+	return payment.Transaction{Reference: reference, Status: payment.StatusVoided}, nil
+}
+
+func (p *Provider) VerifyWebhook(payload []byte, signature string) (payment.Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.mac(payload)) {
+		return payment.Event{}, payment.ErrInvalidSignature
+	}
+
+	var event payment.Event
+	err = json.Unmarshal(payload, &event)
+	if err != nil {
+		return payment.Event{}, err
+	}
+	return event, nil
+}
+
+// Settle completes a pending capture the way the gateway would and returns the signed webhook it sends for it
+func (p *Provider) Settle(reference string, amount money.Amount) (payload []byte, signature string, err error) {
+	p.mu.Lock()
+	p.status[reference] = payment.StatusCaptured
+	p.mu.Unlock()
+
+	return p.SignEvent(payment.Event{Type: payment.EventCaptured, Reference: reference, Amount: amount})
+}
+
+// SignEvent builds the webhook payload of the event along with its signature
+func (p *Provider) SignEvent(event payment.Event) (payload []byte, signature string, err error) {
+	payload, err = json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, hex.EncodeToString(p.mac(payload)), nil
+}
+
+func (p *Provider) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,112 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/payment"
+)
+
+func TestProvider_Flow(t *testing.T) {
+	amount := money.MustParse("19.98")
+
+	tests := []struct {
+		name          string
+		token         string
+		wantAuthorize payment.Status
+		wantCapture   payment.Status
+		wantReason    string
+	}{
+		{
+			name:          "authorized and captured",
+			token:         "tok_visa",
+			wantAuthorize: payment.StatusAuthorized,
+			wantCapture:   payment.StatusCaptured,
+		},
+		{
+			name:          "declined",
+			token:         TokenDeclined,
+			wantAuthorize: payment.StatusFailed,
+			wantReason:    "card declined",
+		},
+		{
+			name:          "capture pending",
+			token:         TokenCapturePending,
+			wantAuthorize: payment.StatusAuthorized,
+			wantCapture:   payment.StatusPending,
+		},
+		{
+			name:          "capture failed",
+			token:         TokenCaptureFailed,
+			wantAuthorize: payment.StatusAuthorized,
+			wantCapture:   payment.StatusFailed,
+			wantReason:    "capture failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New("secret")
+			authorization, err := p.Authorize(context.Background(), payment.AuthorizeRequest{OrderID: 1, Amount: amount, Token: tt.token})
+			assert.NoError(t, err)
+			assert.Equal(t, "fake_1_1", authorization.Reference)
+			assert.Equal(t, tt.wantAuthorize, authorization.Status)
+			if authorization.Status == payment.StatusFailed {
+				assert.Equal(t, tt.wantReason, authorization.FailureReason)
+				return
+			}
+
+			capture, err := p.Capture(context.Background(), authorization.Reference, amount)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCapture, capture.Status)
+			assert.Equal(t, tt.wantReason, capture.FailureReason)
+		})
+	}
+}
+
+func TestProvider_VoidAndRefund(t *testing.T) {
+	amount := money.MustParse("19.98")
+	p := New("secret")
+
+	authorized, err := p.Authorize(context.Background(), payment.AuthorizeRequest{OrderID: 1, Amount: amount, Token: TokenCapturePending})
+	assert.NoError(t, err)
+	_, err = p.Refund(context.Background(), authorized.Reference, amount)
+	assert.Error(t, err, "an authorization can't be refunded")
+	voided, err := p.Void(context.Background(), authorized.Reference)
+	assert.NoError(t, err)
+	assert.Equal(t, payment.StatusVoided, voided.Status)
+
+	captured, err := p.Authorize(context.Background(), payment.AuthorizeRequest{OrderID: 2, Amount: amount, Token: "tok_visa"})
+	assert.NoError(t, err)
+	_, err = p.Capture(context.Background(), captured.Reference, amount)
+	assert.NoError(t, err)
+	_, err = p.Void(context.Background(), captured.Reference)
+	assert.Error(t, err, "a capture can't be voided")
+	_, err = p.Refund(context.Background(), captured.Reference, money.MustParse("20"))
+	assert.Error(t, err, "more than the capture can't be refunded")
+	refunded, err := p.Refund(context.Background(), captured.Reference, money.MustParse("9.99"))
+	assert.NoError(t, err)
+	assert.Equal(t, payment.StatusRefunded, refunded.Status)
+	_, err = p.Refund(context.Background(), captured.Reference, money.MustParse("9.99"))
+	assert.NoError(t, err, "the rest of a partially refunded capture can still be refunded")
+	_, err = p.Refund(context.Background(), captured.Reference, money.MustParse("0.01"))
+	assert.Error(t, err, "a fully refunded capture can't be refunded anymore")
+}
+
+func TestProvider_VerifyWebhook(t *testing.T) {
+	p := New("secret")
+	payload, signature, err := p.Settle("fake_1_1", money.MustParse("19.98"))
+	assert.NoError(t, err)
+
+	event, err := p.VerifyWebhook(payload, signature)
+	assert.NoError(t, err)
+	assert.Equal(t, payment.Event{Type: payment.EventCaptured, Reference: "fake_1_1", Amount: money.MustParse("19.98")}, event)
+
+	_, err = p.VerifyWebhook(payload, "not-hex")
+	assert.ErrorIs(t, err, payment.ErrInvalidSignature)
+	_, err = New("other-secret").VerifyWebhook(payload, signature)
+	assert.ErrorIs(t, err, payment.ErrInvalidSignature)
+	_, err = p.VerifyWebhook(append(payload, ' '), signature)
+	assert.ErrorIs(t, err, payment.ErrInvalidSignature)
+}
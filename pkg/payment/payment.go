@@ -0,0 +1,65 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+// Status is the state of a transaction at the provider
+type Status string
+
+const (
+	StatusAuthorized Status = "AUTHORIZED"
+	StatusCaptured   Status = "CAPTURED"
+	// StatusPending means the provider accepted the request and reports its outcome through a webhook later
+	StatusPending  Status = "PENDING"
+	StatusFailed   Status = "FAILED"
+	StatusVoided   Status = "VOIDED"
+	StatusRefunded Status = "REFUNDED"
+)
+
+// Event types sent to the webhook
+const (
+	EventCaptured = "payment.captured"
+	EventFailed   = "payment.failed"
+)
+
+// ErrInvalidSignature is returned when a webhook isn't signed by the provider
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Provider is a payment gateway. A decline is not an error, it is a transaction with StatusFailed and a FailureReason,
+// errors are left for requests that didn't reach the provider or that it rejected as malformed.
+type Provider interface {
+	// Name identifies the provider, references are only unique per provider
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error)
+	Capture(ctx context.Context, reference string, amount money.Amount) (Transaction, error)
+	// Refund gives back part or all of the captured amount, partial refunds add up until the whole capture is refunded
+	Refund(ctx context.Context, reference string, amount money.Amount) (Transaction, error)
+	Void(ctx context.Context, reference string) (Transaction, error)
+	// VerifyWebhook checks the signature of the webhook payload and parses it
+	VerifyWebhook(payload []byte, signature string) (Event, error)
+}
+
+// AuthorizeRequest holds the amount on the payment method, Token is the payment method tokenized by the client
+type AuthorizeRequest struct {
+	OrderID int64
+	Amount  money.Amount
+	Token   string
+}
+
+// Transaction is the result of a request to the provider, Reference identifies the payment in every later request
+type Transaction struct {
+	Reference     string
+	Status        Status
+	FailureReason string
+}
+
+// Event is a webhook callback of the provider about a payment
+type Event struct {
+	Type          string       `json:"type"`
+	Reference     string       `json:"reference"`
+	Amount        money.Amount `json:"amount"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+}
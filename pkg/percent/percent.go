@@ -0,0 +1,147 @@
+package percent
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+// Rate is a percentage in basis points, 1100 is 11%. It maps to DECIMAL(5, 2) columns and marshals to a JSON number
+// with two decimals such as 11.00, it is never an amount of money.
+type Rate int64
+
+// Hundred is 100%
+const Hundred Rate = 100 * 100
+
+var errInvalidRate = errors.New("invalid percentage, must be a number with at most 2 decimals")
+
+// Parse parses a percentage such as "11" or "7.5", more than 2 decimals are only accepted when they are zeros
+func Parse(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	units, fraction, _ := strings.Cut(s, ".")
+	if units == "" && fraction == "" {
+		return 0, errInvalidRate
+	}
+	if len(fraction) > 2 {
+		if strings.Trim(fraction[2:], "0") != "" {
+			return 0, errInvalidRate
+		}
+		fraction = fraction[:2]
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	if units == "" {
+		units = "0"
+	}
+	// a percentage is never far from 100, the bound keeps the basis points from overflowing
+	unitsValue, err := strconv.ParseUint(units, 10, 32)
+	if err != nil {
+		return 0, errInvalidRate
+	}
+	fractionValue, err := strconv.ParseUint(fraction, 10, 8)
+	if err != nil {
+		return 0, errInvalidRate
+	}
+	rate := Rate(int64(unitsValue)*100 + int64(fractionValue))
+	if negative {
+		rate = -rate
+	}
+	return rate, nil
+}
+
+// MustParse is like Parse but panics on an invalid percentage, only meant for constants
+func MustParse(s string) Rate {
+	rate, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return rate
+}
+
+// FromBasisPoints returns the rate of the given basis points
+func FromBasisPoints(basisPoints int64) Rate {
+	return Rate(basisPoints)
+}
+
+// BasisPoints returns the rate in basis points
+func (r Rate) BasisPoints() int64 {
+	return int64(r)
+}
+
+// Valid checks the rate is between 0 and 100%
+func (r Rate) Valid() bool {
+	return r >= 0 && r <= Hundred
+}
+
+// Of is the rate of the amount rounded half up to the nearest cent, the amount is never negative
+func (r Rate) Of(amount money.Amount) money.Amount {
+	return money.FromCents((amount.Cents()*int64(r) + int64(Hundred)/2) / int64(Hundred))
+}
+
+// String formats the rate with two decimals, e.g. "11.00"
+func (r Rate) String() string {
+	basisPoints := int64(r)
+	sign := ""
+	if basisPoints < 0 {
+		sign = "-"
+		basisPoints = -basisPoints
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, basisPoints/100, basisPoints%100)
+}
+
+// MarshalJSON writes the rate as a JSON number with two decimals
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON reads the rate from a JSON number, a quoted number is accepted too
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	rate, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// Scan reads the rate from a DECIMAL column, which the driver returns as text
+func (r *Rate) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = 0
+		return nil
+	case []byte:
+		return r.scanString(string(v))
+	case string:
+		return r.scanString(v)
+	case int64:
+		*r = Rate(v * 100)
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into percent.Rate", src)
+}
+
+func (r *Rate) scanString(s string) error {
+	rate, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// Value writes the rate as text so postgres stores the exact decimal
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
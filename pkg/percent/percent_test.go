@@ -0,0 +1,107 @@
+package percent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Rate
+		wantErr bool
+	}{
+		{name: "whole", input: "11", want: 1100},
+		{name: "one decimal", input: "7.5", want: 750},
+		{name: "two decimals", input: "12.25", want: 1225},
+		{name: "trailing zeros", input: "5.000", want: 500},
+		{name: "negative", input: "-7", want: -700},
+		{name: "error three decimals", input: "7.125", wantErr: true},
+		{name: "error empty", input: "", wantErr: true},
+		{name: "error not a number", input: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRate_Of(t *testing.T) {
+	tests := []struct {
+		name   string
+		rate   Rate
+		amount money.Amount
+		want   money.Amount
+	}{
+		{name: "rounded half up", rate: MustParse("11"), amount: money.MustParse("10.99"), want: money.MustParse("1.21")},
+		{name: "decimal rate", rate: MustParse("12.5"), amount: money.MustParse("100"), want: money.MustParse("12.50")},
+		{name: "hundred percent", rate: Hundred, amount: money.MustParse("35.96"), want: money.MustParse("35.96")},
+		{name: "zero", rate: 0, amount: money.MustParse("35.96"), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rate.Of(tt.amount))
+		})
+	}
+}
+
+func TestRate_Valid(t *testing.T) {
+	assert.True(t, Rate(0).Valid())
+	assert.True(t, Hundred.Valid())
+	assert.False(t, MustParse("100.01").Valid())
+	assert.False(t, MustParse("-1").Valid())
+}
+
+func TestRate_JSON(t *testing.T) {
+	var got struct {
+		Rate Rate `json:"rate"`
+	}
+	err := json.Unmarshal([]byte(`{"rate":7.5}`), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, Rate(750), got.Rate)
+
+	data, err := json.Marshal(got)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"rate":7.50}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"rate":7.125}`), &got)
+	assert.Error(t, err)
+}
+
+func TestRate_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    Rate
+		wantErr bool
+	}{
+		{name: "decimal column", src: []byte("11.00"), want: 1100},
+		{name: "string", src: "7.5", want: 750},
+		{name: "integer", src: int64(5), want: 500},
+		{name: "null", src: nil, want: 0},
+		{name: "error unsupported type", src: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Rate
+			err := got.Scan(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	redigo "github.com/gomodule/redigo/redis"
+	"sort"
 	"time"
 )
 
@@ -94,6 +95,25 @@ func (r *Redis) Set(key string, value string, ttl int64, field ...interface{}) (
 	return redigo.String(conn.Do("SET", key, value))
 }
 
+// SetNX sets the key only when it doesn't exist yet and returns whether it was set, so only one caller can claim a key
+func (r *Redis) SetNX(key string, value string, ttl int64) (bool, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	args := redigo.Args{}.Add(key, value, "NX")
+	if ttl > 0 {
+		args = args.Add("EX", ttl)
+	}
+	_, err := redigo.String(conn.Do("SET", args...))
+	if err == redigo.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (r *Redis) Get(key string, field ...interface{}) (string, error) {
 	conn := r.pool.Get()
 	defer conn.Close()
@@ -107,6 +127,49 @@ func (r *Redis) Get(key string, field ...interface{}) (string, error) {
 	return redigo.String(conn.Do("GET", key))
 }
 
+// GetDel returns the value of the key and deletes it in one transaction, so only one caller ever gets the value.
+// The value is empty when the key doesn't exist
+func (r *Redis) GetDel(key string) (string, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	if err := conn.Send("MULTI"); err != nil {
+		return "", err
+	}
+	if err := conn.Send("GET", key); err != nil {
+		return "", err
+	}
+	if err := conn.Send("DEL", key); err != nil {
+		return "", err
+	}
+	values, err := redigo.Values(conn.Do("EXEC"))
+	if err != nil {
+		return "", err
+	}
+	value, err := redigo.String(values[0], nil)
+	if err == redigo.ErrNil {
+		return "", nil
+	}
+	return value, err
+}
+
+// Eval runs the lua script with the keys and the args. The server runs a script atomically, nothing else touches
+// the keys until it is done, so a read and a write in the same script can't race with another client
+func (r *Redis) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	return redigo.NewScript(len(keys), script).Do(conn, redigo.Args{}.AddFlat(keys).Add(args...)...)
+}
+
+// GetAll returns every field of the hash, it is empty when the key doesn't exist
+func (r *Redis) GetAll(key string) (map[string]string, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	return redigo.StringMap(conn.Do("HGETALL", key))
+}
+
 func (r *Redis) Del(key string, field ...interface{}) (bool, error) {
 	conn := r.pool.Get()
 	defer conn.Close()
@@ -128,3 +191,65 @@ func (r *Redis) SetExpire(key string, ttl int64) (int64, error) {
 	}
 	return 0, nil
 }
+
+// DelByPattern deletes every key matching the glob pattern. It walks the keyspace with SCAN
+// instead of KEYS so a large keyspace never blocks the server.
+func (r *Redis) DelByPattern(pattern string) (int64, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	var (
+		cursor  int64
+		deleted int64
+	)
+	for {
+		values, err := redigo.Values(conn.Do("SCAN", cursor, "MATCH", pattern, "COUNT", 100))
+		if err != nil {
+			return deleted, err
+		}
+
+		cursor, err = redigo.Int64(values[0], nil)
+		if err != nil {
+			return deleted, err
+		}
+		keys, err := redigo.Strings(values[1], nil)
+		if err != nil {
+			return deleted, err
+		}
+
+		if len(keys) > 0 {
+			n, err := redigo.Int64(conn.Do("DEL", redigo.Args{}.AddFlat(keys)...))
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// XAdd appends an entry with the fields to the stream and returns the id of the entry.
+// When maxLen is set the stream is trimmed to about that many entries, the oldest ones are dropped.
+func (r *Redis) XAdd(stream string, maxLen int64, fields map[string]string) (string, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+
+	args := redigo.Args{}.Add(stream)
+	if maxLen > 0 {
+		args = args.Add("MAXLEN", "~", maxLen)
+	}
+	args = args.Add("*")
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = args.Add(name, fields[name])
+	}
+	return redigo.String(conn.Do("XADD", args...))
+}
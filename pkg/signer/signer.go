@@ -0,0 +1,57 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ErrInvalidToken is returned when the token is malformed or its signature doesn't match
+var ErrInvalidToken = errors.New("invalid token")
+
+// Sign serializes the payload into an opaque, tamper-evident token signed with HMAC-SHA256.
+// The purpose is part of the signature so a token issued for one purpose can't be replayed for another.
+func Sign(purpose string, payload interface{}, key string) (string, error) {
+	data, err := jsoniter.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	signature := base64.RawURLEncoding.EncodeToString(sign(purpose, encoded, key))
+	return encoded + "." + signature, nil
+}
+
+// Verify checks the signature of the token and decodes its payload into dest
+func Verify(purpose, token, key string, dest interface{}) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	gotSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(gotSignature, sign(purpose, encoded, key)) {
+		return ErrInvalidToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err = jsoniter.Unmarshal(data, dest); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func sign(purpose, encoded, key string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(purpose))
+	mac.Write([]byte("."))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,74 @@
+package signer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+func TestSignAndVerify(t *testing.T) {
+	token, err := Sign("cursor", payload{Value: "9.99", ID: 3}, "secret")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		purpose string
+		token   string
+		key     string
+		want    payload
+		wantErr bool
+	}{
+		{
+			name:    "success",
+			purpose: "cursor",
+			token:   token,
+			key:     "secret",
+			want:    payload{Value: "9.99", ID: 3},
+		},
+		{
+			name:    "error different key",
+			purpose: "cursor",
+			token:   token,
+			key:     "other-secret",
+			wantErr: true,
+		},
+		{
+			name:    "error different purpose",
+			purpose: "quote",
+			token:   token,
+			key:     "secret",
+			wantErr: true,
+		},
+		{
+			name:    "error tampered payload",
+			purpose: "cursor",
+			token:   "eyJ2IjoiMC4wMSIsImlkIjozfQ" + token[len("eyJ2IjoiOS45OSIsImlkIjozfQ"):],
+			key:     "secret",
+			wantErr: true,
+		},
+		{
+			name:    "error malformed token",
+			purpose: "cursor",
+			token:   "not-a-token",
+			key:     "secret",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got payload
+			err := Verify(tt.purpose, tt.token, tt.key, &got)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidToken)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
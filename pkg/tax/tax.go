@@ -0,0 +1,147 @@
+package tax
+
+import (
+	"fmt"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+	"strings"
+)
+
+// Rate is the tax of a country. Inclusive prices already include the tax, which is then worked out of the price,
+// exclusive prices get the tax added on top.
+type Rate struct {
+	Country   string
+	Rate      percent.Rate
+	Inclusive bool
+	Reduced   []ReducedRate
+}
+
+// ReducedRate is the rate of some of the books instead of the standard rate of the country, e.g. a zero rate for textbooks
+type ReducedRate struct {
+	Rate    percent.Rate
+	BookIDs []int64
+}
+
+// Line is an order line to be taxed, Amount is what the line is sold for once its discount is taken off
+type Line struct {
+	BookID int64
+	Amount money.Amount
+}
+
+// LineTax is the tax of a line, Net + Tax = Gross whether the price includes the tax or not
+type LineTax struct {
+	Rate  percent.Rate
+	Net   money.Amount
+	Tax   money.Amount
+	Gross money.Amount
+}
+
+// Result is the tax of all the lines, Lines follows the order of the lines and the totals add them up
+type Result struct {
+	Country   string
+	Inclusive bool
+	Lines     []LineTax
+	Net       money.Amount
+	Tax       money.Amount
+	Gross     money.Amount
+}
+
+// Calculator taxes order lines with the rate of the billing country. A calculator without rates charges no tax.
+type Calculator struct {
+	rates          map[string]Rate
+	defaultCountry string
+}
+
+// New checks the rates, the default country is used for customers without a billing country
+func New(rates []Rate, defaultCountry string) (*Calculator, error) {
+	c := &Calculator{
+		rates:          make(map[string]Rate, len(rates)),
+		defaultCountry: normalizeCountry(defaultCountry),
+	}
+	for _, rate := range rates {
+		rate.Country = normalizeCountry(rate.Country)
+		if len(rate.Country) != 2 {
+			return nil, fmt.Errorf("invalid tax country %q, must be an ISO 3166 alpha-2 code", rate.Country)
+		}
+		if _, ok := c.rates[rate.Country]; ok {
+			return nil, fmt.Errorf("tax rate of %s is configured twice", rate.Country)
+		}
+		if !rate.Rate.Valid() {
+			return nil, fmt.Errorf("invalid tax rate %s of %s, must be between 0 and 100", rate.Rate, rate.Country)
+		}
+		for _, reduced := range rate.Reduced {
+			if !reduced.Rate.Valid() {
+				return nil, fmt.Errorf("invalid reduced tax rate %s of %s, must be between 0 and 100", reduced.Rate, rate.Country)
+			}
+		}
+		c.rates[rate.Country] = rate
+	}
+	if len(c.rates) > 0 {
+		if _, ok := c.rates[c.defaultCountry]; !ok {
+			return nil, fmt.Errorf("default tax country %q has no tax rate", c.defaultCountry)
+		}
+	}
+	return c, nil
+}
+
+// Calculate taxes every line on its own, the tax of a line is rounded to the nearest cent
+func (c *Calculator) Calculate(country string, lines []Line) (Result, error) {
+	country = normalizeCountry(country)
+	if country == "" {
+		country = c.defaultCountry
+	}
+
+	result := Result{Country: country, Inclusive: true, Lines: make([]LineTax, 0, len(lines))}
+	rate, ok := c.rates[country]
+	if !ok && len(c.rates) > 0 {
+		return Result{}, fmt.Errorf("billing country %s is not supported", country)
+	}
+	if ok {
+		result.Inclusive = rate.Inclusive
+	}
+
+	for _, line := range lines {
+		lineTax := rate.taxOf(line)
+		result.Lines = append(result.Lines, lineTax)
+		result.Net = result.Net.Add(lineTax.Net)
+		result.Tax = result.Tax.Add(lineTax.Tax)
+		result.Gross = result.Gross.Add(lineTax.Gross)
+	}
+	return result, nil
+}
+
+func (r Rate) taxOf(line Line) LineTax {
+	lineTax := LineTax{Rate: r.rateOf(line.BookID)}
+	if r.Inclusive {
+		// the price is the gross, the net is what is left once the tax is worked out of it
+		lineTax.Gross = line.Amount
+		lineTax.Net = money.FromCents(divideRounded(line.Amount.Cents()*percent.Hundred.BasisPoints(), (percent.Hundred + lineTax.Rate).BasisPoints()))
+		lineTax.Tax = lineTax.Gross.Sub(lineTax.Net)
+		return lineTax
+	}
+	lineTax.Net = line.Amount
+	lineTax.Tax = lineTax.Rate.Of(line.Amount)
+	lineTax.Gross = lineTax.Net.Add(lineTax.Tax)
+	return lineTax
+}
+
+// rateOf is the reduced rate of the book when it has one, the standard rate of the country otherwise
+func (r Rate) rateOf(bookID int64) percent.Rate {
+	for _, reduced := range r.Reduced {
+		for _, id := range reduced.BookIDs {
+			if id == bookID {
+				return reduced.Rate
+			}
+		}
+	}
+	return r.Rate
+}
+
+// divideRounded divides rounding half up, the amounts taxed are never negative
+func divideRounded(numerator, denominator int64) int64 {
+	return (numerator + denominator/2) / denominator
+}
+
+func normalizeCountry(country string) string {
+	return strings.ToUpper(strings.TrimSpace(country))
+}
@@ -0,0 +1,195 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/money"
+	"github.com/yeremiaaryo/gotu-assignment/pkg/percent"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		rates          []Rate
+		defaultCountry string
+		wantErr        string
+	}{
+		{name: "no rates", defaultCountry: ""},
+		{name: "valid rates", rates: []Rate{{Country: "id", Rate: percent.MustParse("11"), Inclusive: true}}, defaultCountry: "ID"},
+		{
+			name:           "error invalid country",
+			rates:          []Rate{{Country: "IDN", Rate: percent.MustParse("11")}},
+			defaultCountry: "IDN",
+			wantErr:        `invalid tax country "IDN", must be an ISO 3166 alpha-2 code`,
+		},
+		{
+			name:           "error country configured twice",
+			rates:          []Rate{{Country: "ID", Rate: percent.MustParse("11")}, {Country: "id", Rate: percent.MustParse("12")}},
+			defaultCountry: "ID",
+			wantErr:        "tax rate of ID is configured twice",
+		},
+		{
+			name:           "error rate over 100",
+			rates:          []Rate{{Country: "ID", Rate: percent.MustParse("110")}},
+			defaultCountry: "ID",
+			wantErr:        "invalid tax rate 110.00 of ID, must be between 0 and 100",
+		},
+		{
+			name:           "error negative reduced rate",
+			rates:          []Rate{{Country: "DE", Rate: percent.MustParse("19"), Reduced: []ReducedRate{{Rate: percent.MustParse("-7")}}}},
+			defaultCountry: "DE",
+			wantErr:        "invalid reduced tax rate -7.00 of DE, must be between 0 and 100",
+		},
+		{
+			name:           "error default country without rate",
+			rates:          []Rate{{Country: "ID", Rate: percent.MustParse("11")}},
+			defaultCountry: "SG",
+			wantErr:        `default tax country "SG" has no tax rate`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.rates, tt.defaultCountry)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCalculator_Calculate(t *testing.T) {
+	calculator, err := New([]Rate{
+		{Country: "ID", Rate: percent.MustParse("11"), Inclusive: true},
+		{Country: "CA", Rate: percent.MustParse("5")},
+		{Country: "DE", Rate: percent.MustParse("19"), Inclusive: true, Reduced: []ReducedRate{{Rate: percent.MustParse("7"), BookIDs: []int64{3}}}},
+		{Country: "GB", Rate: percent.MustParse("20"), Inclusive: true, Reduced: []ReducedRate{{Rate: 0, BookIDs: []int64{3, 9}}}},
+	}, "ID")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		calculator *Calculator
+		country    string
+		lines      []Line
+		want       Result
+		wantErr    string
+	}{
+		{
+			name:    "exclusive adds the tax on top",
+			country: "CA",
+			lines:   []Line{{BookID: 3, Amount: money.MustParse("19.98")}, {BookID: 9, Amount: money.MustParse("8.99")}},
+			want: Result{
+				Country: "CA",
+				Lines: []LineTax{
+					{Rate: percent.MustParse("5"), Net: money.MustParse("19.98"), Tax: money.MustParse("1.00"), Gross: money.MustParse("20.98")},
+					{Rate: percent.MustParse("5"), Net: money.MustParse("8.99"), Tax: money.MustParse("0.45"), Gross: money.MustParse("9.44")},
+				},
+				Net:   money.MustParse("28.97"),
+				Tax:   money.MustParse("1.45"),
+				Gross: money.MustParse("30.42"),
+			},
+		},
+		{
+			name:    "inclusive works the tax out of the price",
+			country: "id",
+			lines:   []Line{{BookID: 3, Amount: money.MustParse("19.98")}, {BookID: 9, Amount: money.MustParse("8.99")}},
+			want: Result{
+				Country:   "ID",
+				Inclusive: true,
+				Lines: []LineTax{
+					{Rate: percent.MustParse("11"), Net: money.MustParse("18.00"), Tax: money.MustParse("1.98"), Gross: money.MustParse("19.98")},
+					{Rate: percent.MustParse("11"), Net: money.MustParse("8.10"), Tax: money.MustParse("0.89"), Gross: money.MustParse("8.99")},
+				},
+				Net:   money.MustParse("26.10"),
+				Tax:   money.MustParse("2.87"),
+				Gross: money.MustParse("28.97"),
+			},
+		},
+		{
+			name:    "reduced rate of a book",
+			country: "DE",
+			lines:   []Line{{BookID: 3, Amount: money.MustParse("10.70")}, {BookID: 5, Amount: money.MustParse("11.90")}},
+			want: Result{
+				Country:   "DE",
+				Inclusive: true,
+				Lines: []LineTax{
+					{Rate: percent.MustParse("7"), Net: money.MustParse("10.00"), Tax: money.MustParse("0.70"), Gross: money.MustParse("10.70")},
+					{Rate: percent.MustParse("19"), Net: money.MustParse("10.00"), Tax: money.MustParse("1.90"), Gross: money.MustParse("11.90")},
+				},
+				Net:   money.MustParse("20.00"),
+				Tax:   money.MustParse("2.60"),
+				Gross: money.MustParse("22.60"),
+			},
+		},
+		{
+			name:    "zero rated books",
+			country: "GB",
+			lines:   []Line{{BookID: 9, Amount: money.MustParse("8.99")}},
+			want: Result{
+				Country:   "GB",
+				Inclusive: true,
+				Lines:     []LineTax{{Rate: 0, Net: money.MustParse("8.99"), Tax: 0, Gross: money.MustParse("8.99")}},
+				Net:       money.MustParse("8.99"),
+				Gross:     money.MustParse("8.99"),
+			},
+		},
+		{
+			name:    "fully discounted line",
+			country: "CA",
+			lines:   []Line{{BookID: 3, Amount: 0}},
+			want: Result{
+				Country: "CA",
+				Lines:   []LineTax{{Rate: percent.MustParse("5")}},
+			},
+		},
+		{
+			name:  "default country without billing country",
+			lines: []Line{{BookID: 3, Amount: money.MustParse("11.10")}},
+			want: Result{
+				Country:   "ID",
+				Inclusive: true,
+				Lines:     []LineTax{{Rate: percent.MustParse("11"), Net: money.MustParse("10.00"), Tax: money.MustParse("1.10"), Gross: money.MustParse("11.10")}},
+				Net:       money.MustParse("10.00"),
+				Tax:       money.MustParse("1.10"),
+				Gross:     money.MustParse("11.10"),
+			},
+		},
+		{
+			name:    "error country without rate",
+			country: "US",
+			lines:   []Line{{BookID: 3, Amount: money.MustParse("9.99")}},
+			wantErr: "billing country US is not supported",
+		},
+		{
+			name:       "no tax without rates",
+			calculator: &Calculator{},
+			country:    "US",
+			lines:      []Line{{BookID: 3, Amount: money.MustParse("9.99")}},
+			want: Result{
+				Country:   "US",
+				Inclusive: true,
+				Lines:     []LineTax{{Net: money.MustParse("9.99"), Gross: money.MustParse("9.99")}},
+				Net:       money.MustParse("9.99"),
+				Gross:     money.MustParse("9.99"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := calculator
+			if tt.calculator != nil {
+				c = tt.calculator
+			}
+			got, err := c.Calculate(tt.country, tt.lines)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"errors"
 	"github.com/labstack/echo/v4"
+	"time"
 )
 
 func GetUserID(c echo.Context) (int64, error) {
@@ -13,6 +14,22 @@ func GetUserID(c echo.Context) (int64, error) {
 	return userID.(int64), nil
 }
 
+func GetRole(c echo.Context) (string, error) {
+	role := c.Get("role")
+	if role == nil {
+		return "", errors.New("role not found")
+	}
+	return role.(string), nil
+}
+
+func GetSessionID(c echo.Context) (string, error) {
+	sessionID := c.Get("sessionID")
+	if sessionID == nil {
+		return "", errors.New("sessionID not found")
+	}
+	return sessionID.(string), nil
+}
+
 // GetLimitAndOffset calculates and returns the limit and offset based on the provided pageIndex and pageSize.
 func GetLimitAndOffset(pageIndex, pageSize int) (int, int) {
 	// Sanitize request
@@ -29,3 +46,13 @@ func GetLimitAndOffset(pageIndex, pageSize int) (int, int) {
 
 	return limit, offset
 }
+
+// NextUpdatedAt is the updated_at of the next version of a row that is updated optimistically, it has to move forward
+// even when two updates land in the same millisecond
+func NextUpdatedAt(previous int64) int64 {
+	updatedAt := time.Now().UnixMilli()
+	if updatedAt <= previous {
+		updatedAt = previous + 1
+	}
+	return updatedAt
+}
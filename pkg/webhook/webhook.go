@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a delivery, the signature covers the timestamp and the body so a captured request can't be replayed later
+const (
+	HeaderDeliveryID = "X-Webhook-ID"
+	HeaderEventID    = "X-Webhook-Event-ID"
+	HeaderEventType  = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// signatureVersion prefixes the signature so the scheme can change without breaking receivers
+const signatureVersion = "v1"
+
+// maxErrorBody is how much of the body of a failed response is kept in the error
+const maxErrorBody = 256
+
+// Request is a signed delivery of an event to a subscriber
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID int64
+	EventID    int64
+	EventType  string
+	Body       []byte
+}
+
+// Client posts deliveries to the subscribers
+type Client struct {
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func New(timeout time.Duration) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+		now:        time.Now,
+	}
+}
+
+// Send posts the delivery and returns the status code of the response, 0 when no response came back.
+// A delivery without a secret isn't signed. Any response other than 2xx is an error.
+func (c *Client) Send(ctx context.Context, request Request) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.URL, bytes.NewReader(request.Body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := c.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gotu-webhooks/1.0")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(request.DeliveryID, 10))
+	req.Header.Set(HeaderEventID, strconv.FormatInt(request.EventID, 10))
+	req.Header.Set(HeaderEventType, request.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if request.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(request.Secret, timestamp, request.Body))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if len(body) == 0 {
+			return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign is the signature header of a delivery, the hex HMAC-SHA256 of "<timestamp>.<body>" with the secret of the subscription
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify is what a receiver does with a delivery: it checks the signature and that the timestamp is within tolerance of now
+func Verify(secret, signature, timestamp string, body []byte, now time.Time, tolerance time.Duration) error {
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+	age := now.Sub(time.Unix(sentAt, 0))
+	if age > tolerance || age < -tolerance {
+		return errors.New("webhook timestamp is outside of the tolerance")
+	}
+	if !hmac.Equal([]byte(Sign(secret, sentAt, body)), []byte(signature)) {
+		return errors.New("invalid webhook signature")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Send(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCode   int
+		responseBody string
+		wantErr      string
+	}{
+		{name: "success", statusCode: http.StatusNoContent},
+		{name: "error response", statusCode: http.StatusBadGateway, wantErr: "subscriber responded with status 502"},
+		{name: "error response keeps the body", statusCode: http.StatusBadRequest, responseBody: "unknown order\n",
+			wantErr: "subscriber responded with status 400: unknown order"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentAt := time.Unix(1700000000, 0)
+			var verifyErr error
+			var headers http.Header
+			// the receiver checks the request the way a subscriber would
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				headers = r.Header
+				verifyErr = Verify("secret", r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body,
+					sentAt.Add(10*time.Second), 5*time.Minute)
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.responseBody))
+			}))
+			defer server.Close()
+
+			client := New(time.Second)
+			client.now = func() time.Time { return sentAt }
+			statusCode, err := client.Send(context.Background(), Request{
+				URL:        server.URL,
+				Secret:     "secret",
+				DeliveryID: 9,
+				EventID:    7,
+				EventType:  "OrderCreated",
+				Body:       []byte(`{"id":7}`),
+			})
+			assert.Equal(t, tt.statusCode, statusCode)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, verifyErr)
+			assert.Equal(t, "9", headers.Get(HeaderDeliveryID))
+			assert.Equal(t, "7", headers.Get(HeaderEventID))
+			assert.Equal(t, "OrderCreated", headers.Get(HeaderEventType))
+			assert.Equal(t, "1700000000", headers.Get(HeaderTimestamp))
+		})
+	}
+}
+
+func TestClient_Send_unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	statusCode, err := New(time.Second).Send(context.Background(), Request{URL: server.URL, Secret: "secret", Body: []byte(`{}`)})
+	assert.Error(t, err)
+	assert.Equal(t, 0, statusCode)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":7}`)
+	now := time.Unix(1700000000, 0)
+	signature := Sign("secret", now.Unix(), body)
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		wantErr   string
+	}{
+		{name: "valid", secret: "secret", signature: signature, timestamp: "1700000000", body: body},
+		{name: "wrong secret", secret: "other", signature: signature, timestamp: "1700000000", body: body, wantErr: "invalid webhook signature"},
+		{name: "tampered body", secret: "secret", signature: signature, timestamp: "1700000000", body: []byte(`{"id":8}`),
+			wantErr: "invalid webhook signature"},
+		{name: "timestamp changed", secret: "secret", signature: signature, timestamp: "1700000001", body: body,
+			wantErr: "invalid webhook signature"},
+		{name: "replayed too late", secret: "secret", signature: Sign("secret", 1699999000, body), timestamp: "1699999000", body: body,
+			wantErr: "webhook timestamp is outside of the tolerance"},
+		{name: "invalid timestamp", secret: "secret", signature: signature, timestamp: "now", body: body, wantErr: "invalid webhook timestamp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.signature, tt.timestamp, tt.body, now, 5*time.Minute)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_books_search_vector;
+DROP TRIGGER IF EXISTS trg_books_search_vector ON books;
+DROP FUNCTION IF EXISTS books_search_vector_update();
+ALTER TABLE books DROP COLUMN IF EXISTS search_vector;
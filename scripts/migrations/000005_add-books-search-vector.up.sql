@@ -0,0 +1,25 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Title weighs the most, followed by author and isbn. The 'simple' config is used since author names and ISBNs shouldn't be stemmed
+CREATE OR REPLACE FUNCTION books_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.author, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.isbn, '')), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_books_search_vector ON books;
+CREATE TRIGGER trg_books_search_vector
+    BEFORE INSERT OR UPDATE OF title, author, isbn ON books
+    FOR EACH ROW EXECUTE FUNCTION books_search_vector_update();
+
+-- Backfill the existing books
+UPDATE books SET search_vector =
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(author, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(isbn, '')), 'C');
+
+CREATE INDEX IF NOT EXISTS idx_books_search_vector ON books USING GIN(search_vector);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_books_price;
+DROP INDEX IF EXISTS idx_books_lower_author;
@@ -0,0 +1,5 @@
+-- Index for the case-insensitive author filter
+CREATE INDEX IF NOT EXISTS idx_books_lower_author ON books(lower(author));
+
+-- Index for the price range filter and price sort
+CREATE INDEX IF NOT EXISTS idx_books_price ON books(price, id);
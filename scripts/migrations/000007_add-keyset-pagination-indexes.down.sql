@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_books_created_at;
+DROP INDEX IF EXISTS idx_orders_user_id_created_at;
@@ -0,0 +1,5 @@
+-- Index for the keyset pagination of the order history, newest first per user
+CREATE INDEX IF NOT EXISTS idx_orders_user_id_created_at ON orders(user_id, created_at, id);
+
+-- Index for the newest sort of the book list
+CREATE INDEX IF NOT EXISTS idx_books_created_at ON books(created_at, id);
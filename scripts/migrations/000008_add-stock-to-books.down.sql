@@ -0,0 +1,3 @@
+ALTER TABLE books DROP CONSTRAINT IF EXISTS chk_books_stock;
+
+ALTER TABLE books DROP COLUMN IF EXISTS stock;
@@ -0,0 +1,7 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS stock INT NOT NULL DEFAULT 0;
+
+-- The books that exist before the stock is tracked are backfilled with a stock of 0, so nothing is oversold on a made up
+-- number. Set their real stock through PATCH /books/:id after migrating, books created afterwards send their own stock
+UPDATE books SET stock = 0;
+
+ALTER TABLE books ADD CONSTRAINT chk_books_stock CHECK (stock >= 0);
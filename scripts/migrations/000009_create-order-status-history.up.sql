@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id SERIAL NOT NULL PRIMARY KEY,
+    order_id INT NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    actor_id INT,
+    actor_role VARCHAR(20) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id)
+);
+
+-- Index for reading the history of an order
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id, id);
+
+-- Existing orders get the creation of the order as their first transition
+INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, actor_role, created_at)
+SELECT id, NULL, status, user_id, 'customer', created_at FROM orders;
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+    CHECK (status IN ('NEW', 'AWAITING_PAYMENT', 'PAID', 'SHIPPED', 'DELIVERED', 'CANCELLED', 'REFUNDED'));
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL NOT NULL PRIMARY KEY,
+    order_id INT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    reference VARCHAR(255) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    failure_reason TEXT NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id),
+    CONSTRAINT chk_payments_status CHECK (status IN ('AUTHORIZED', 'CAPTURED', 'FAILED', 'VOIDED', 'REFUNDED'))
+);
+
+-- References are only unique per provider, webhooks look the payment up by them
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_provider_reference ON payments(provider, reference);
+
+-- Index for reading the payments of an order
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments(order_id);
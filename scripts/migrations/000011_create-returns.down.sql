@@ -0,0 +1,13 @@
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+    CHECK (status IN ('NEW', 'AWAITING_PAYMENT', 'PAID', 'SHIPPED', 'DELIVERED', 'CANCELLED', 'REFUNDED'));
+
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS chk_order_items_returned_quantity,
+    DROP COLUMN IF EXISTS returned_quantity,
+    DROP COLUMN IF EXISTS refunded_amount;
+
+DROP TABLE IF EXISTS return_items;
+DROP TABLE IF EXISTS returns;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS returns (
+    id SERIAL NOT NULL PRIMARY KEY,
+    order_id INT NOT NULL,
+    user_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    refund_amount DECIMAL(10, 2) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT chk_returns_status CHECK (status IN ('REQUESTED', 'APPROVED', 'REFUNDED', 'REJECTED'))
+);
+
+-- An order has at most one return that is still being handled
+CREATE UNIQUE INDEX IF NOT EXISTS idx_returns_open_order_id ON returns(order_id) WHERE status IN ('REQUESTED', 'APPROVED');
+
+-- Index for reading the returns of an order
+CREATE INDEX IF NOT EXISTS idx_returns_order_id ON returns(order_id);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id SERIAL NOT NULL PRIMARY KEY,
+    return_id INT NOT NULL,
+    order_item_id INT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    reason TEXT NOT NULL,
+    refund_amount DECIMAL(10, 2) NOT NULL,
+    FOREIGN KEY (return_id) REFERENCES returns(id),
+    FOREIGN KEY (order_item_id) REFERENCES order_items(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_items_return_id ON return_items(return_id);
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS returned_quantity INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_order_items_returned_quantity CHECK (returned_quantity <= quantity);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+    CHECK (status IN ('NEW', 'AWAITING_PAYMENT', 'PAID', 'SHIPPED', 'DELIVERED', 'CANCELLED', 'REFUNDED', 'PARTIALLY_REFUNDED'));
@@ -0,0 +1,9 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS discount_amount;
+
+DROP INDEX IF EXISTS idx_orders_promotion_id_user_id;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS promotion_id;
+
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL NOT NULL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(20) NOT NULL,
+    -- a percentage for PERCENTAGE promotions and an amount for FIXED ones
+    discount_value DECIMAL(10, 2) NOT NULL,
+    -- caps the discount of a percentage promotion, 0 means no cap
+    max_discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    min_spend DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    -- the promotion only applies to these books or authors, it applies to every book when both are empty
+    book_ids INT[] NOT NULL DEFAULT '{}',
+    authors TEXT[] NOT NULL DEFAULT '{}',
+    starts_at BIGINT NOT NULL,
+    -- 0 means the promotion doesn't end
+    ends_at BIGINT NOT NULL DEFAULT 0,
+    -- 0 means unlimited
+    usage_limit INT NOT NULL DEFAULT 0,
+    per_user_limit INT NOT NULL DEFAULT 0,
+    used_count INT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    CONSTRAINT chk_promotions_discount_type CHECK (discount_type IN ('PERCENTAGE', 'FIXED')),
+    CONSTRAINT chk_promotions_discount_value CHECK (discount_value > 0 AND (discount_type <> 'PERCENTAGE' OR discount_value <= 100)),
+    CONSTRAINT chk_promotions_used_count CHECK (used_count >= 0)
+);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS promotion_id INT REFERENCES promotions(id),
+    ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Index for counting how many times a user redeemed a promotion
+CREATE INDEX IF NOT EXISTS idx_orders_promotion_id_user_id ON orders(promotion_id, user_id) WHERE promotion_id IS NOT NULL;
+
+-- The share of the order discount taken off the item, refunds of the item are based on what was left to pay
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS tax_rate;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS billing_country,
+    DROP COLUMN IF EXISTS tax_inclusive,
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS subtotal_amount;
+
+ALTER TABLE users DROP COLUMN IF EXISTS billing_country;
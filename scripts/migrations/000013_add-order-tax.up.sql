@@ -0,0 +1,17 @@
+-- The country the customer is billed in, which picks the tax rate. Empty means the default country of the config
+ALTER TABLE users ADD COLUMN IF NOT EXISTS billing_country VARCHAR(2) NOT NULL DEFAULT '';
+
+-- total_amount stays what is paid: subtotal_amount - discount_amount, plus tax_amount when the tax isn't included in the prices
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS subtotal_amount DECIMAL(10, 2),
+    ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS billing_country VARCHAR(2) NOT NULL DEFAULT '';
+
+-- Orders placed before taxes were recorded are untaxed
+UPDATE orders SET subtotal_amount = total_amount + discount_amount WHERE subtotal_amount IS NULL;
+ALTER TABLE orders ALTER COLUMN subtotal_amount SET NOT NULL;
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS tax_rate DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
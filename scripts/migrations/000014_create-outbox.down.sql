@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_aggregate;
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,25 @@
+-- Events written within the transaction of the change they describe, the dispatcher delivers them to the sinks afterwards
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    -- the sinks that already received the event, a retry only goes to the others
+    delivered_to TEXT[] NOT NULL DEFAULT '{}',
+    last_error TEXT NOT NULL DEFAULT '',
+    -- a pending event is picked up once this time has passed, it is pushed back while the event is being delivered
+    next_attempt_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    -- 0 until every sink received the event
+    delivered_at BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT chk_outbox_status CHECK (status IN ('PENDING', 'DELIVERED', 'DEAD'))
+);
+
+-- Index for picking up the pending events that are due
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(next_attempt_at, id) WHERE status = 'PENDING';
+
+-- Index for reading the events of an order
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox(aggregate_type, aggregate_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_subscription_id;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_pending;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,39 @@
+-- Partners subscribed to the events of the outbox, the secret signs every delivery to the url
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id SERIAL NOT NULL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- an inactive subscription doesn't get new events and its pending deliveries wait until it is active again
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+-- Every event sent to a subscription, kept as the delivery log of the subscription
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    -- the body that is posted to the subscriber
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    -- the status code of the last response, 0 when the subscriber couldn't be reached
+    response_status INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    delivered_at BIGINT NOT NULL DEFAULT 0,
+    -- an event that reaches the subscriptions again is only delivered once to each of them
+    CONSTRAINT uq_webhook_deliveries_subscription_event UNIQUE (subscription_id, event_id),
+    CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED'))
+);
+
+-- Index for picking up the pending deliveries that are due
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at, id) WHERE status = 'PENDING';
+
+-- Index for the delivery log of a subscription, newest first
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries(subscription_id, id);
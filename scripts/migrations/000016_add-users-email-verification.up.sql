@@ -0,0 +1,9 @@
+-- Emails are stored trimmed and lower-cased, so the same address can't register twice with a different case.
+-- Accounts differing only by the case of their email have to be merged before this migration
+UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email));
+
+-- The time the user verified the email, 0 while it isn't verified
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at BIGINT NOT NULL DEFAULT 0;
+
+-- The users registered before the verification existed keep placing orders
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at = 0;
@@ -0,0 +1,3 @@
+-- An order holds at most one payment that took or holds the money, two concurrent payments of the same order can't
+-- both be recorded. Orders paid twice before this migration have to be refunded and voided down to one payment first
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_active_order_id ON payments(order_id) WHERE status IN ('AUTHORIZED', 'CAPTURED');
@@ -0,0 +1,7 @@
+UPDATE returns SET status = 'APPROVED' WHERE status = 'REFUNDING';
+
+DROP INDEX IF EXISTS idx_returns_open_order_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_returns_open_order_id ON returns(order_id) WHERE status IN ('REQUESTED', 'APPROVED');
+
+ALTER TABLE returns DROP CONSTRAINT IF EXISTS chk_returns_status;
+ALTER TABLE returns ADD CONSTRAINT chk_returns_status CHECK (status IN ('REQUESTED', 'APPROVED', 'REFUNDED', 'REJECTED'));
@@ -0,0 +1,7 @@
+-- A return the provider refunded is REFUNDING until the refund is recorded on the order, approving it again records it
+ALTER TABLE returns DROP CONSTRAINT IF EXISTS chk_returns_status;
+ALTER TABLE returns ADD CONSTRAINT chk_returns_status CHECK (status IN ('REQUESTED', 'APPROVED', 'REFUNDING', 'REFUNDED', 'REJECTED'));
+
+-- A return that is being refunded is still open, the order can't have another one
+DROP INDEX IF EXISTS idx_returns_open_order_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_returns_open_order_id ON returns(order_id) WHERE status IN ('REQUESTED', 'APPROVED', 'REFUNDING');
@@ -0,0 +1,17 @@
+-- How many times every user redeemed a promotion. Redeeming upserts the row of the user, which locks it until the order
+-- is placed, so concurrent orders of the same user wait for each other and can't go over per_user_limit
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    promotion_id INT NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id),
+    count INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (promotion_id, user_id),
+    CONSTRAINT chk_promotion_redemptions_count CHECK (count >= 0)
+);
+
+-- Orders placed before this migration count too, cancelled orders gave their redemption back
+INSERT INTO promotion_redemptions (promotion_id, user_id, count)
+SELECT promotion_id, user_id, COUNT(*)
+FROM orders
+WHERE promotion_id IS NOT NULL AND status <> 'CANCELLED'
+GROUP BY promotion_id, user_id
+ON CONFLICT (promotion_id, user_id) DO NOTHING;